@@ -1,17 +1,117 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	JWTSecret  []byte
 	DBPath     = "ark_server.db"
 	ServerPort = "8080"
+
+	// 数据库驱动（sqlite / postgres / mysql），SQLite 使用 DBPath，其他驱动使用 DBDSN
+	DBDriver = "sqlite"
+	DBDSN    = ""
+
+	// 执行数据库迁移前是否自动备份 SQLite 数据库文件
+	MigrationBackup = true
+
+	// 模组元数据配置
+	ModMetadataProvider = "steam"       // 模组元数据来源: steam / fixture
+	ModFixturePath      = ""            // fixture 模式下的本地JSON文件路径
+	SteamAPIKey         = ""            // Steam Web API 密钥（可选）
+	ModMetadataCacheTTL = 6 * time.Hour // 模组元数据缓存有效期
+
+	// 模组更新检测配置
+	ModUpdateCheckInterval = 30 * time.Minute // 检查间隔（0表示关闭定时检查）
+	ModAutoRestart         = false            // 检测到模组更新时是否自动重启服务器
+	ModRestartWarning      = 5 * time.Minute  // 自动重启前提前广播提醒的时长
+
+	// RCON连接地址（为空时使用容器IP）
+	RCONHost = ""
+
+	// 插件上传限制
+	PluginMaxUploadSize    int64 = 50 << 20  // 插件压缩包最大大小（字节）
+	PluginMaxExtractedSize int64 = 200 << 20 // 插件解压后最大总大小（字节）
+
+	// 文件管理上传限制（字节）
+	FileMaxUploadSize int64 = 100 << 20
+
+	// 卷辅助容器空闲多久后删除
+	HelperIdleTimeout = 10 * time.Minute
+
+	// 节点健康检查间隔（0表示只在启动时检查一次）
+	NodeHealthCheckInterval = 30 * time.Second
+
+	// 拉取镜像时的加速规则，匹配的镜像先从加速地址拉取，失败时再从原仓库拉取
+	RegistryMirrors []RegistryMirror
+
+	// 面板实例名称，写入创建的容器和卷的标签；多个面板共用同一Docker主机时必须不同
+	ManagerInstance = "default"
+
+	// 遗留资源清理配置
+	OrphanGracePeriod = 7 * 24 * time.Hour // 服务器删除多久后其容器和卷视为遗留资源（回收站保留期内的除外）
+	OrphanBackupDir   = "backups/orphans"  // 删除遗留卷前的备份目录
+
+	// 回收站：删除的服务器保留多久后自动彻底删除（0表示不自动删除）
+	TrashRetention = 7 * 24 * time.Hour
+
+	// 磁盘占用告警阈值
+	StorageWarnFreePercent       = 10 // 磁盘可用空间低于该百分比时告警（0表示不告警）
+	StorageWarnVolumeSize  int64 = 0  // 服务器卷总大小超过该值时告警（字节，0表示不告警）
+
+	// 登录会话配置
+	AccessTokenTTL  = 15 * time.Minute    // 访问令牌有效期
+	RefreshTokenTTL = 30 * 24 * time.Hour // 刷新令牌（会话）有效期
+
+	// 两步验证中显示的发行方名称
+	TOTPIssuer = "ARK Server Commander"
+
+	// 登录防暴力破解配置
+	LoginMaxFailuresPerUser = 5                   // 同一用户名连续失败多少次后锁定
+	LoginMaxFailuresPerIP   = 20                  // 同一IP连续失败多少次后锁定
+	LoginFailureWindow      = 15 * time.Minute    // 失败次数统计窗口，超过后重新计数
+	LoginLockoutDuration    = 15 * time.Minute    // 锁定时长
+	LoginDelayBase          = time.Second         // 失败后的初始等待时间，每次失败翻倍
+	LoginDelayMax           = 30 * time.Second    // 失败后的最大等待时间
+	LoginAttemptRetention   = 90 * 24 * time.Hour // 登录记录保留时长（0表示不清理）
+
+	// OIDC 单点登录配置
+	OIDCEnabled          bool
+	OIDCIssuer           string                                   // 身份提供方地址（用于发现配置）
+	OIDCClientID         string                                   // 客户端ID
+	OIDCClientSecret     string                                   // 客户端密钥（公开客户端可为空，仅使用 PKCE）
+	OIDCRedirectURL      string                                   // 回调地址，如 https://panel.example.com/api/auth/oidc/callback
+	OIDCScopes           = []string{"openid", "profile", "email"} // 请求的作用域
+	OIDCUsernameClaim    = "preferred_username"                   // 作为用户名的声明
+	OIDCRoleClaim        = "groups"                               // 用于角色映射的声明（字符串或字符串数组）
+	OIDCRoleMapping      map[string]string                        // 声明值到角色的映射
+	OIDCDefaultRole      = "viewer"                               // 未匹配映射时的角色，为空时拒绝登录
+	OIDCAutoProvision    = true                                   // 首次登录时自动创建用户
+	OIDCFrontendRedirect = "/"                                    // 登录完成后跳转的前端地址
+
+	// 数据库敏感字段加密密钥，第一个为当前密钥，其余仅用于解密旧数据
+	EncryptionKeys []EncryptionKey
 )
 
+// EncryptionKey 数据加密密钥（AES-256）
+type EncryptionKey struct {
+	ID  string // 密钥标识，随密文保存，用于轮换时找到对应的密钥
+	Key []byte // 32字节密钥
+}
+
+// RegistryMirror 镜像加速规则
+type RegistryMirror struct {
+	Source string // 原仓库地址前缀，如 docker.io 或 docker.io/tbro98
+	Mirror string // 替换后的地址前缀，如 mirror.example.com/dockerhub
+}
+
 // 弱密钥黑名单
 var weakSecrets = []string{
 	"ark-server-commander-secret-key",
@@ -49,10 +149,379 @@ func InitConfig() error {
 	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
 		DBPath = dbPath
 	}
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		DBDriver = driver
+	}
+	DBDSN = os.Getenv("DB_DSN")
+	if err := ValidateDBDriver(DBDriver, DBDSN); err != nil {
+		return err
+	}
 
 	if port := os.Getenv("SERVER_PORT"); port != "" {
 		ServerPort = port
 	}
 
+	var err error
+	if MigrationBackup, err = getBoolEnv("MIGRATION_BACKUP", MigrationBackup); err != nil {
+		return err
+	}
+
+	// 模组元数据配置
+	if provider := os.Getenv("MOD_METADATA_PROVIDER"); provider != "" {
+		if provider != "steam" && provider != "fixture" {
+			return fmt.Errorf("MOD_METADATA_PROVIDER must be 'steam' or 'fixture' (current: %s)", provider)
+		}
+		ModMetadataProvider = provider
+	}
+	ModFixturePath = os.Getenv("MOD_FIXTURE_PATH")
+	if ModMetadataProvider == "fixture" && ModFixturePath == "" {
+		return fmt.Errorf("MOD_FIXTURE_PATH is required when MOD_METADATA_PROVIDER is 'fixture'")
+	}
+	SteamAPIKey = os.Getenv("STEAM_API_KEY")
+
+	if ModMetadataCacheTTL, err = getDurationEnv("MOD_METADATA_CACHE_TTL", ModMetadataCacheTTL); err != nil {
+		return err
+	}
+
+	// 模组更新检测配置
+	if ModUpdateCheckInterval, err = getDurationEnv("MOD_UPDATE_CHECK_INTERVAL", ModUpdateCheckInterval); err != nil {
+		return err
+	}
+	if ModAutoRestart, err = getBoolEnv("MOD_AUTO_RESTART", ModAutoRestart); err != nil {
+		return err
+	}
+	if ModRestartWarning, err = getDurationEnv("MOD_RESTART_WARNING", ModRestartWarning); err != nil {
+		return err
+	}
+
+	RCONHost = os.Getenv("RCON_HOST")
+
+	// 插件上传限制
+	if PluginMaxUploadSize, err = getSizeMBEnv("PLUGIN_MAX_UPLOAD_MB", PluginMaxUploadSize); err != nil {
+		return err
+	}
+	if PluginMaxExtractedSize, err = getSizeMBEnv("PLUGIN_MAX_EXTRACTED_MB", PluginMaxExtractedSize); err != nil {
+		return err
+	}
+
+	// 文件管理上传限制
+	if FileMaxUploadSize, err = getSizeMBEnv("FILE_MAX_UPLOAD_MB", FileMaxUploadSize); err != nil {
+		return err
+	}
+
+	// 卷辅助容器空闲超时
+	if HelperIdleTimeout, err = getDurationEnv("HELPER_IDLE_TIMEOUT", HelperIdleTimeout); err != nil {
+		return err
+	}
+	if HelperIdleTimeout <= 0 {
+		return fmt.Errorf("HELPER_IDLE_TIMEOUT must be positive (current: %s)", HelperIdleTimeout)
+	}
+
+	// 节点健康检查间隔
+	if NodeHealthCheckInterval, err = getDurationEnv("NODE_HEALTH_CHECK_INTERVAL", NodeHealthCheckInterval); err != nil {
+		return err
+	}
+
+	// 镜像加速规则
+	if RegistryMirrors, err = parseRegistryMirrors(os.Getenv("REGISTRY_MIRRORS")); err != nil {
+		return err
+	}
+
+	// 面板实例名称
+	if value := os.Getenv("MANAGER_INSTANCE"); value != "" {
+		ManagerInstance = value
+	}
+	if !validInstanceName(ManagerInstance) {
+		return fmt.Errorf("MANAGER_INSTANCE must be 1-64 letters, digits, '.', '_' or '-' (current: %s)", ManagerInstance)
+	}
+
+	// 遗留资源清理配置
+	if OrphanGracePeriod, err = getDurationEnv("ORPHAN_GRACE_PERIOD", OrphanGracePeriod); err != nil {
+		return err
+	}
+	if value := os.Getenv("ORPHAN_BACKUP_DIR"); value != "" {
+		OrphanBackupDir = value
+	}
+
+	// 回收站保留期
+	if TrashRetention, err = getDurationEnv("TRASH_RETENTION", TrashRetention); err != nil {
+		return err
+	}
+
+	// 磁盘占用告警阈值
+	if value := os.Getenv("STORAGE_WARN_FREE_PERCENT"); value != "" {
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("STORAGE_WARN_FREE_PERCENT must be between 0 and 100 (current: %s)", value)
+		}
+		StorageWarnFreePercent = percent
+	}
+	if StorageWarnVolumeSize, err = getSizeMBEnv("STORAGE_WARN_VOLUME_MB", StorageWarnVolumeSize); err != nil {
+		return err
+	}
+
+	// 登录会话配置
+	if AccessTokenTTL, err = getDurationEnv("ACCESS_TOKEN_TTL", AccessTokenTTL); err != nil {
+		return err
+	}
+	if RefreshTokenTTL, err = getDurationEnv("REFRESH_TOKEN_TTL", RefreshTokenTTL); err != nil {
+		return err
+	}
+	if AccessTokenTTL <= 0 || RefreshTokenTTL <= AccessTokenTTL {
+		return fmt.Errorf("ACCESS_TOKEN_TTL must be positive and shorter than REFRESH_TOKEN_TTL")
+	}
+
+	// 两步验证发行方名称
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		TOTPIssuer = issuer
+	}
+
+	// 登录防暴力破解配置
+	if LoginMaxFailuresPerUser, err = getPositiveIntEnv("LOGIN_MAX_FAILURES_PER_USER", LoginMaxFailuresPerUser); err != nil {
+		return err
+	}
+	if LoginMaxFailuresPerIP, err = getPositiveIntEnv("LOGIN_MAX_FAILURES_PER_IP", LoginMaxFailuresPerIP); err != nil {
+		return err
+	}
+	if LoginFailureWindow, err = getDurationEnv("LOGIN_FAILURE_WINDOW", LoginFailureWindow); err != nil {
+		return err
+	}
+	if LoginLockoutDuration, err = getDurationEnv("LOGIN_LOCKOUT_DURATION", LoginLockoutDuration); err != nil {
+		return err
+	}
+	if LoginDelayBase, err = getDurationEnv("LOGIN_DELAY_BASE", LoginDelayBase); err != nil {
+		return err
+	}
+	if LoginDelayMax, err = getDurationEnv("LOGIN_DELAY_MAX", LoginDelayMax); err != nil {
+		return err
+	}
+	if LoginAttemptRetention, err = getDurationEnv("LOGIN_ATTEMPT_RETENTION", LoginAttemptRetention); err != nil {
+		return err
+	}
+	if LoginFailureWindow <= 0 || LoginLockoutDuration <= 0 {
+		return fmt.Errorf("LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT_DURATION must be positive")
+	}
+	if LoginDelayMax < LoginDelayBase {
+		return fmt.Errorf("LOGIN_DELAY_MAX must not be shorter than LOGIN_DELAY_BASE")
+	}
+
+	// OIDC 单点登录配置
+	if err := initOIDCConfig(); err != nil {
+		return err
+	}
+
+	// 数据加密密钥
+	if EncryptionKeys, err = parseEncryptionKeys(os.Getenv("ENCRYPTION_KEYS")); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// initOIDCConfig 读取 OIDC 单点登录配置
+func initOIDCConfig() error {
+	var err error
+	if OIDCEnabled, err = getBoolEnv("OIDC_ENABLED", false); err != nil {
+		return err
+	}
+	if !OIDCEnabled {
+		return nil
+	}
+
+	OIDCIssuer = strings.TrimRight(os.Getenv("OIDC_ISSUER"), "/")
+	OIDCClientID = os.Getenv("OIDC_CLIENT_ID")
+	OIDCClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+	OIDCRedirectURL = os.Getenv("OIDC_REDIRECT_URL")
+	if OIDCIssuer == "" || OIDCClientID == "" || OIDCRedirectURL == "" {
+		return fmt.Errorf("OIDC_ISSUER, OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ENABLED is true")
+	}
+
+	if scopes := os.Getenv("OIDC_SCOPES"); scopes != "" {
+		OIDCScopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	}
+	if claim := os.Getenv("OIDC_USERNAME_CLAIM"); claim != "" {
+		OIDCUsernameClaim = claim
+	}
+	if claim := os.Getenv("OIDC_ROLE_CLAIM"); claim != "" {
+		OIDCRoleClaim = claim
+	}
+	if OIDCRoleMapping, err = parseRoleMapping(os.Getenv("OIDC_ROLE_MAPPING")); err != nil {
+		return err
+	}
+	if role, ok := os.LookupEnv("OIDC_DEFAULT_ROLE"); ok {
+		OIDCDefaultRole = role
+	}
+	if OIDCDefaultRole != "" && !validRoles[OIDCDefaultRole] {
+		return fmt.Errorf("OIDC_DEFAULT_ROLE must be one of owner, admin, operator, viewer or empty (current: %s)", OIDCDefaultRole)
+	}
+	if OIDCAutoProvision, err = getBoolEnv("OIDC_AUTO_PROVISION", OIDCAutoProvision); err != nil {
+		return err
+	}
+	if redirect := os.Getenv("OIDC_FRONTEND_REDIRECT"); redirect != "" {
+		OIDCFrontendRedirect = redirect
+	}
+
 	return nil
 }
+
+// validRoles 可在配置中使用的角色
+var validRoles = map[string]bool{"owner": true, "admin": true, "operator": true, "viewer": true}
+
+// parseRoleMapping 解析角色映射，格式: 声明值=角色,声明值=角色
+func parseRoleMapping(value string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		claimValue, role, ok := strings.Cut(item, "=")
+		claimValue, role = strings.TrimSpace(claimValue), strings.TrimSpace(role)
+		if !ok || claimValue == "" || !validRoles[role] {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING must look like 'group=role,group=role' with roles owner/admin/operator/viewer (invalid: %s)", item)
+		}
+		mapping[claimValue] = role
+	}
+	return mapping, nil
+}
+
+// parseRegistryMirrors 解析镜像加速规则，格式: 原仓库前缀=加速地址前缀,原仓库前缀=加速地址前缀
+// 同一镜像匹配多条规则时按配置顺序依次尝试
+func parseRegistryMirrors(value string) ([]RegistryMirror, error) {
+	var mirrors []RegistryMirror
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		source, mirror, ok := strings.Cut(item, "=")
+		source, mirror = strings.Trim(strings.TrimSpace(source), "/"), strings.Trim(strings.TrimSpace(mirror), "/")
+		if !ok || source == "" || mirror == "" || strings.Contains(source, "://") || strings.Contains(mirror, "://") {
+			return nil, fmt.Errorf("REGISTRY_MIRRORS must look like 'docker.io=mirror.example.com,docker.io/org=registry.local/org' without schemes (invalid: %s)", item)
+		}
+		mirrors = append(mirrors, RegistryMirror{Source: source, Mirror: mirror})
+	}
+	return mirrors, nil
+}
+
+// validInstanceName 检查面板实例名称是否可以用作标签值
+func validInstanceName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateDBDriver 检查数据库驱动和连接串
+func ValidateDBDriver(driver, dsn string) error {
+	switch driver {
+	case "sqlite":
+		return nil
+	case "postgres", "mysql":
+		if dsn == "" {
+			return fmt.Errorf("DB_DSN is required when DB_DRIVER is '%s'", driver)
+		}
+		return nil
+	}
+	return fmt.Errorf("DB_DRIVER must be 'sqlite', 'postgres' or 'mysql' (current: %s)", driver)
+}
+
+// jwtDerivedKeyID 未配置 ENCRYPTION_KEYS 时由 JWT_SECRET 派生的密钥标识
+const jwtDerivedKeyID = "jwt"
+
+// parseEncryptionKeys 解析加密密钥，格式: 标识:Base64密钥,标识:Base64密钥
+// 未配置时由 JWT_SECRET 派生密钥；配置后派生密钥仍可用于解密旧数据
+func parseEncryptionKeys(value string) ([]EncryptionKey, error) {
+	derived := sha256.Sum256(append([]byte("ark-server-commander/encryption:"), JWTSecret...))
+	fallback := EncryptionKey{ID: jwtDerivedKeyID, Key: derived[:]}
+
+	var keys []EncryptionKey
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(item, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || id == jwtDerivedKeyID || seen[id] {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS must look like 'id:base64key,id:base64key' with unique ids other than '%s' (invalid: %s)", jwtDerivedKeyID, id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS key '%s' must be 32 bytes encoded as base64 (generate with: openssl rand -base64 32)", id)
+		}
+		seen[id] = true
+		keys = append(keys, EncryptionKey{ID: id, Key: key})
+	}
+
+	return append(keys, fallback), nil
+}
+
+// getPositiveIntEnv 读取正整数类型的环境变量，未设置时返回默认值
+func getPositiveIntEnv(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer (current: %s)", key, value)
+	}
+
+	return parsed, nil
+}
+
+// getSizeMBEnv 读取以MB为单位的大小环境变量，返回字节数，未设置时返回默认值
+func getSizeMBEnv(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	megabytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || megabytes <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of megabytes (current: %s)", key, value)
+	}
+
+	return megabytes << 20, nil
+}
+
+// getBoolEnv 读取布尔类型的环境变量（true/false/1/0），未设置时返回默认值
+func getBoolEnv(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be 'true' or 'false' (current: %s)", key, value)
+	}
+
+	return parsed, nil
+}
+
+// getDurationEnv 读取时长类型的环境变量（如 "30m"、"6h"），未设置时返回默认值
+func getDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a valid duration such as '30m' or '6h' (current: %s)", key, value)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s must not be negative (current: %s)", key, value)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,105 @@
+package audits
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/audit"
+	"ark-server-commander/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var auditService = audit.NewAuditService()
+
+// GetAuditLog 获取审计日志
+// @Summary 获取审计日志
+// @Description 分页查询审计日志（按时间倒序），可按操作者、操作名称前缀、服务器、结果和时间范围过滤（需要所有者或管理员角色）
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param actor_id query int false "操作者用户ID（0 表示系统）"
+// @Param action query string false "操作名称或前缀，如 servers 或 servers.start"
+// @Param server_id query int false "服务器ID"
+// @Param result query string false "结果: success 或 failure"
+// @Param from query string false "开始时间（RFC3339）"
+// @Param to query string false "结束时间（RFC3339）"
+// @Param page query int false "页码（默认1）"
+// @Param page_size query int false "每页数量（默认50，最大500）"
+// @Success 200 {object} map[string]interface{} "审计日志和总数"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /audit [get]
+func GetAuditLog(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, total, err := auditService.List(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+		"total":   total,
+	})
+}
+
+// ExportAuditLog 导出审计日志
+// @Summary 导出审计日志
+// @Description 按查询条件导出审计日志为 CSV 或 JSON 文件（单次最多10万条，需要所有者或管理员角色）
+// @Tags 审计日志
+// @Produce text/csv
+// @Produce json
+// @Security Bearer
+// @Param format query string false "导出格式: csv（默认）或 json"
+// @Param actor_id query int false "操作者用户ID（0 表示系统）"
+// @Param action query string false "操作名称或前缀"
+// @Param server_id query int false "服务器ID"
+// @Param result query string false "结果: success 或 failure"
+// @Param from query string false "开始时间（RFC3339）"
+// @Param to query string false "结束时间（RFC3339）"
+// @Success 200 {file} file "审计日志文件"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Router /audit/export [get]
+func ExportAuditLog(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	format := c.DefaultQuery("format", audit.ExportFormatCSV)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case audit.ExportFormatCSV:
+	case audit.ExportFormatJSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的导出格式: %s", format)})
+		return
+	}
+
+	fileName := fmt.Sprintf("audit-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，导出中途失败只能记录日志
+	if err := auditService.Export(query, format, c.Writer); err != nil {
+		utils.Error("导出审计日志失败", zap.Error(err))
+	}
+}
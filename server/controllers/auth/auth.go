@@ -1,15 +1,28 @@
 package auth
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
 	"ark-server-commander/database"
 	"ark-server-commander/models"
+	"ark-server-commander/service/loginguard"
+	"ark-server-commander/service/session"
+	"ark-server-commander/service/twofactor"
+	"ark-server-commander/service/user"
 	"ark-server-commander/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	loginGuard       = loginguard.NewLoginGuardService()
+	sessionService   = session.NewSessionService()
+	twoFactorService = twofactor.NewTwoFactorService()
+	userService      = user.NewUserService()
+)
+
 // CheckInit 检查是否已初始化用户
 // @Summary 检查系统初始化状态
 // @Description 检查系统是否已经初始化过用户
@@ -60,37 +73,37 @@ func InitUser(c *gin.Context) {
 		return
 	}
 
-	// 创建用户
-	user := models.User{
+	// 创建用户（第一个用户为所有者）
+	newUser := models.User{
 		Username: req.Username,
 		Password: hashedPassword,
+		Role:     models.RoleOwner,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := database.DB.Create(&newUser).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户创建失败"})
 		return
 	}
 
-	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	// 创建登录会话
+	pair, err := sessionService.CreateSession(&newUser, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "初始化成功",
-		"token":   token,
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-		},
+		"message":       "初始化成功",
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          user.ToUserResponse(newUser),
 	})
 }
 
 // Login 用户登录
 // @Summary 用户登录
-// @Description 使用用户名和密码登录系统
+// @Description 使用用户名和密码登录系统。已启用两步验证时返回 two_factor_required 和 challenge_token，需调用 /auth/login/2fa 完成登录
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -98,6 +111,7 @@ func InitUser(c *gin.Context) {
 // @Success 200 {object} map[string]interface{} "登录成功"
 // @Failure 400 {object} map[string]string "请求错误"
 // @Failure 401 {object} map[string]string "认证失败"
+// @Failure 429 {object} map[string]string "登录尝试次数过多"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /auth/login [post]
 func Login(c *gin.Context) {
@@ -107,33 +121,177 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 检查登录频率限制和锁定状态
+	if !checkLoginGuard(c, req.Username) {
+		return
+	}
+
 	// 查找用户
-	var user models.User
-	if err := database.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
+	var account models.User
+	if err := database.DB.Where("username = ?", req.Username).First(&account).Error; err != nil {
+		loginGuard.RecordFailure(req.Username, c.ClientIP(), c.Request.UserAgent(), models.LoginFailureUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
 		return
 	}
 
 	// 验证密码
-	if !utils.CheckPassword(req.Password, user.Password) {
+	if !utils.CheckPassword(req.Password, account.Password) {
+		loginGuard.RecordFailure(req.Username, c.ClientIP(), c.Request.UserAgent(), models.LoginFailureBadPassword)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
 		return
 	}
 
-	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	// 检查账号是否被禁用
+	if account.Disabled {
+		loginGuard.RecordFailure(req.Username, c.ClientIP(), c.Request.UserAgent(), models.LoginFailureDisabled)
+		c.JSON(http.StatusForbidden, gin.H{"error": "账号已被禁用"})
+		return
+	}
+
+	// 已启用两步验证时需要再提交验证码
+	if account.TOTPEnabled {
+		challenge, err := twoFactorService.CreateChallenge(account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "请输入两步验证码",
+			"two_factor_required": challenge.TwoFactorRequired,
+			"challenge_token":     challenge.ChallengeToken,
+			"expires_in":          challenge.ExpiresIn,
+		})
+		return
+	}
+
+	respondLoginSuccess(c, &account)
+}
+
+// checkLoginGuard 检查登录频率限制，被限制时写入响应并返回 false
+func checkLoginGuard(c *gin.Context, username string) bool {
+	wait, err := loginGuard.Check(username, c.ClientIP(), c.Request.UserAgent())
+	if err == nil {
+		return true
+	}
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	return false
+}
+
+// respondLoginSuccess 创建登录会话并返回令牌
+func respondLoginSuccess(c *gin.Context, account *models.User) {
+	pair, err := sessionService.CreateSession(account, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
+		return
+	}
+	loginGuard.RecordSuccess(account.Username, c.ClientIP(), c.Request.UserAgent())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "登录成功",
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          user.ToUserResponse(*account),
+		// 管理员要求启用两步验证但用户尚未启用，前端应引导用户完成绑定
+		"two_factor_setup_required": account.TOTPRequired && !account.TOTPEnabled,
+	})
+}
+
+// Refresh 刷新访问令牌
+// @Summary 刷新访问令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌（旧的刷新令牌立即失效，重复使用会撤销整个会话）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param refresh body models.RefreshRequest true "刷新令牌"
+// @Success 200 {object} models.TokenPair "新的令牌"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "刷新令牌无效或会话已失效"
+// @Router /auth/refresh [post]
+func Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	pair, err := sessionService.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 撤销当前会话，会话中的访问令牌和刷新令牌立即失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]string "退出成功"
+// @Failure 401 {object} map[string]string "未授权"
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	sessionID := c.GetUint("session_id")
+	if err := sessionService.RevokeSession(c.GetUint("user_id"), strconv.FormatUint(uint64(sessionID), 10), models.SessionRevokeLogout); err != nil && err.Error() != "会话不存在" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// ChangePassword 修改密码
+// @Summary 修改当前用户密码
+// @Description 验证旧密码后修改密码，所有会话都会失效，响应中返回当前设备的新令牌
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param password body models.PasswordChangeRequest true "旧密码和新密码"
+// @Success 200 {object} map[string]interface{} "修改成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "旧密码错误"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/password [post]
+func ChangePassword(c *gin.Context) {
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	account, err := userService.ChangePassword(c.GetUint("user_id"), req.OldPassword, req.NewPassword)
+	if err != nil {
+		if err.Error() == "旧密码错误" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 所有会话已撤销，为当前设备创建新会话
+	pair, err := sessionService.CreateSession(account, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "令牌生成失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "登录成功",
-		"token":   token,
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-		},
+		"message":       "密码修改成功，其他设备需要重新登录",
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
 	})
 }
 
@@ -149,12 +307,14 @@ func Login(c *gin.Context) {
 // @Router /profile [get]
 func GetProfile(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	username := c.GetString("username")
+
+	var account models.User
+	if err := database.DB.First(&account, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user": models.UserResponse{
-			ID:       userID,
-			Username: username,
-		},
+		"user": user.ToUserResponse(account),
 	})
 }
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/models"
+	"ark-server-commander/service/oidc"
+	"ark-server-commander/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var oidcService = oidc.NewOIDCService()
+
+// oidcBindingCookie 保存登录请求浏览器绑定值的 Cookie
+const oidcBindingCookie = "oidc_binding"
+
+// GetOIDCConfig 获取单点登录配置
+// @Summary 获取单点登录配置
+// @Description 返回是否启用了OIDC单点登录，前端据此显示登录按钮
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} models.OIDCConfigResponse "单点登录配置"
+// @Router /auth/oidc/config [get]
+func GetOIDCConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, models.OIDCConfigResponse{Enabled: oidcService.Enabled()})
+}
+
+// OIDCLogin 跳转到身份提供方登录
+// @Summary 单点登录
+// @Description 使用授权码 + PKCE 流程跳转到身份提供方，登录完成后回调 /auth/oidc/callback。同时设置绑定当前浏览器的 Cookie，其他浏览器完成的回调会被拒绝
+// @Tags 认证
+// @Success 302 "跳转到身份提供方"
+// @Failure 404 {object} map[string]string "未启用OIDC登录"
+// @Failure 502 {object} map[string]string "身份提供方不可用"
+// @Router /auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	authURL, binding, err := oidcService.BeginLogin(c.Request.Context(), 0)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCBindingCookie(c, binding, int(oidc.AuthRequestTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 身份提供方回调
+// @Summary 单点登录回调
+// @Description 身份提供方登录完成后的回调地址。成功时跳转到前端并携带一次性兑换码 oidc_code，失败时携带 oidc_error，绑定外部账号成功时携带 oidc_linked=1
+// @Tags 认证
+// @Param code query string false "授权码"
+// @Param state query string true "登录请求标识"
+// @Success 302 "跳转到前端"
+// @Router /auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		message := "身份提供方返回错误: " + providerError
+		if description := c.Query("error_description"); description != "" {
+			message += " " + description
+		}
+		redirectToFrontend(c, url.Values{"oidc_error": {message}})
+		return
+	}
+
+	binding, _ := c.Cookie(oidcBindingCookie)
+	setOIDCBindingCookie(c, "", -1)
+	result, err := oidcService.HandleCallback(c.Request.Context(), c.Query("code"), c.Query("state"), binding)
+	if err != nil {
+		utils.Warn("OIDC登录失败", zap.String("ip", c.ClientIP()), zap.Error(err))
+		redirectToFrontend(c, url.Values{"oidc_error": {err.Error()}})
+		return
+	}
+	if result.Linked {
+		redirectToFrontend(c, url.Values{"oidc_linked": {"1"}})
+		return
+	}
+	redirectToFrontend(c, url.Values{"oidc_code": {result.ExchangeCode}})
+}
+
+// OIDCExchange 兑换单点登录令牌
+// @Summary 兑换单点登录令牌
+// @Description 使用回调跳转时携带的一次性兑换码换取访问令牌和刷新令牌（兑换码1分钟内有效，只能使用一次）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param exchange body models.OIDCExchangeRequest true "兑换码"
+// @Success 200 {object} map[string]interface{} "登录成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "兑换码无效"
+// @Failure 403 {object} map[string]string "账号已被禁用"
+// @Router /auth/oidc/exchange [post]
+func OIDCExchange(c *gin.Context) {
+	var req models.OIDCExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	account, err := oidcService.Exchange(req.Code)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	respondLoginSuccess(c, account)
+}
+
+// LinkOIDCIdentity 绑定外部账号
+// @Summary 绑定外部账号
+// @Description 返回身份提供方的授权地址并设置绑定当前浏览器的 Cookie，前端在当前浏览器中跳转到该地址登录后，外部账号会绑定到当前用户
+// @Tags 认证
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]string "授权地址"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 404 {object} map[string]string "未启用OIDC登录"
+// @Failure 502 {object} map[string]string "身份提供方不可用"
+// @Router /auth/oidc/link [post]
+func LinkOIDCIdentity(c *gin.Context) {
+	authURL, binding, err := oidcService.BeginLogin(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCBindingCookie(c, binding, int(oidc.AuthRequestTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "请跳转到身份提供方完成绑定",
+		"url":     authURL,
+	})
+}
+
+// GetOIDCIdentities 获取已绑定的外部账号
+// @Summary 获取已绑定的外部账号
+// @Description 获取当前用户绑定的OIDC外部账号
+// @Tags 认证
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.UserIdentityResponse "外部账号列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/oidc/identities [get]
+func GetOIDCIdentities(c *gin.Context) {
+	data, err := oidcService.ListIdentities(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// DeleteOIDCIdentity 解除外部账号绑定
+// @Summary 解除外部账号绑定
+// @Description 解除当前用户绑定的OIDC外部账号
+// @Tags 认证
+// @Produce json
+// @Security Bearer
+// @Param id path int true "外部账号ID"
+// @Success 200 {object} map[string]string "解除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 404 {object} map[string]string "外部账号不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/oidc/identities/{id} [delete]
+func DeleteOIDCIdentity(c *gin.Context) {
+	if err := oidcService.Unlink(c.GetUint("user_id"), c.Param("id")); err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除绑定"})
+}
+
+// setOIDCBindingCookie 设置（maxAge < 0 时删除）登录请求的浏览器绑定 Cookie
+// 只发送到回调地址；身份提供方跳转回来是顶层导航，SameSite=Lax 的 Cookie 会随回调请求发送
+func setOIDCBindingCookie(c *gin.Context, value string, maxAge int) {
+	path := "/"
+	if callback, err := url.Parse(config.OIDCRedirectURL); err == nil && callback.Path != "" {
+		path = callback.Path
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(config.OIDCRedirectURL, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// redirectToFrontend 跳转到前端地址并附加查询参数
+func redirectToFrontend(c *gin.Context, params url.Values) {
+	target, err := url.Parse(config.OIDCFrontendRedirect)
+	if err != nil {
+		target = &url.URL{Path: "/"}
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+// respondOIDCError 将单点登录错误映射为HTTP响应
+func respondOIDCError(c *gin.Context, err error) {
+	message := err.Error()
+	switch message {
+	case "未启用OIDC登录", "外部账号不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case "登录请求已失效，请重新登录", "用户不存在":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	case "账号已被禁用":
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+	case "无效的外部账号ID":
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": message})
+	}
+}
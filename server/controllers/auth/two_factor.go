@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"net/http"
+
+	"ark-server-commander/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginTwoFactor 登录第二步：提交两步验证码
+// @Summary 提交两步验证码完成登录
+// @Description 使用登录返回的 challenge_token 和验证器中的验证码（或一次性恢复码）完成登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param verification body models.TwoFactorLoginRequest true "登录挑战和验证码"
+// @Success 200 {object} map[string]interface{} "登录成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "验证码错误或登录验证已失效"
+// @Failure 403 {object} map[string]string "账号已被禁用"
+// @Failure 429 {object} map[string]string "登录尝试次数过多"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 验证码错误也计入用户名的失败次数，防止通过反复登录绕过单次挑战的尝试限制
+	challengeUser, err := twoFactorService.ChallengeUser(req.ChallengeToken)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	if !checkLoginGuard(c, challengeUser.Username) {
+		return
+	}
+
+	account, err := twoFactorService.VerifyChallenge(req.ChallengeToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		if err.Error() == "验证码错误" {
+			loginGuard.RecordFailure(challengeUser.Username, c.ClientIP(), c.Request.UserAgent(), models.LoginFailureTwoFactor)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	respondLoginSuccess(c, account)
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 获取当前用户是否已启用两步验证、是否被要求启用以及剩余恢复码数量
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]models.TwoFactorStatusResponse "两步验证状态"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	data, err := twoFactorService.Status(c.GetUint("user_id"))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// SetupTwoFactor 开始绑定验证器
+// @Summary 开始绑定验证器
+// @Description 验证密码后生成新的TOTP密钥，返回密钥和 otpauth:// 地址（用于生成二维码），需调用确认接口后生效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param setup body models.TwoFactorSetupRequest true "当前密码"
+// @Success 200 {object} map[string]models.TwoFactorSetupResponse "密钥和绑定地址"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "密码错误"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := twoFactorService.BeginSetup(c.GetUint("user_id"), req.Password)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "请使用验证器应用扫描二维码，并提交验证码完成绑定",
+		"data":    data,
+	})
+}
+
+// ConfirmTwoFactor 确认绑定验证器
+// @Summary 确认绑定验证器
+// @Description 提交验证器生成的验证码，验证通过后启用两步验证并返回一次性恢复码（只显示一次）
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param code body models.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} map[string][]string "恢复码"
+// @Failure 400 {object} map[string]string "请求错误或验证码错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	codes, err := twoFactorService.ConfirmSetup(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "两步验证已启用，请妥善保存恢复码，恢复码只显示一次",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 验证密码和当前验证码后关闭两步验证（管理员强制启用时不能关闭）
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param disable body models.TwoFactorDisableRequest true "密码和验证码"
+// @Success 200 {object} map[string]string "关闭成功"
+// @Failure 400 {object} map[string]string "请求错误或验证码错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "密码错误或不允许关闭"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := twoFactorService.Disable(c.GetUint("user_id"), req.Password, req.Code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 验证当前验证码后生成新的一组恢复码，旧恢复码全部失效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param code body models.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} map[string][]string "新的恢复码"
+// @Failure 400 {object} map[string]string "请求错误或验证码错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	codes, err := twoFactorService.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "恢复码已重新生成，旧恢复码已失效",
+		"recovery_codes": codes,
+	})
+}
+
+// respondTwoFactorError 将两步验证错误映射为HTTP响应
+func respondTwoFactorError(c *gin.Context, err error) {
+	message := err.Error()
+	switch message {
+	case "登录验证已失效，请重新登录":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	case "密码错误", "账号已被禁用", "管理员要求启用两步验证，不能关闭":
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+	case "用户不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case "验证码错误", "请输入验证码或恢复码", "两步验证已启用", "两步验证未启用", "请先生成两步验证密钥":
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
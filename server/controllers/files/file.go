@@ -0,0 +1,291 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/models"
+	"ark-server-commander/service/file_manager"
+
+	"github.com/gin-gonic/gin"
+)
+
+var fileService = file_manager.NewFileService()
+
+// multipartOverhead 上传请求中除文件以外的表单数据余量
+const multipartOverhead = 1 << 20
+
+// ListFiles 列出目录内容
+// @Summary 列出服务器卷目录
+// @Description 列出 Saved 卷或插件卷中指定目录的文件和子目录
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param path query string false "相对于卷根目录的路径"
+// @Success 200 {object} map[string][]models.FileInfo "文件列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或路径不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume} [get]
+func ListFiles(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	data, err := fileService.ListDir(userID, c.Param("id"), c.Param("volume"), c.Query("path"))
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// StatFile 获取文件信息
+// @Summary 获取文件信息
+// @Description 获取 Saved 卷或插件卷中文件或目录的信息
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param path query string false "相对于卷根目录的路径"
+// @Success 200 {object} map[string]models.FileInfo "文件信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或路径不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume}/stat [get]
+func StatFile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	data, err := fileService.Stat(userID, c.Param("id"), c.Param("volume"), c.Query("path"))
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// DownloadFile 下载文件
+// @Summary 下载文件
+// @Description 下载 Saved 卷或插件卷中的文件
+// @Tags 文件管理
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param path query string true "相对于卷根目录的文件路径"
+// @Success 200 {file} file "文件内容"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或文件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume}/download [get]
+func DownloadFile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	written := false
+	err := fileService.DownloadFile(userID, c.Param("id"), c.Param("volume"), c.Query("path"), func(name string, size int64, content io.Reader) error {
+		written = true
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Length", strconv.FormatInt(size, 10))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(name)))
+		c.Status(http.StatusOK)
+		_, err := io.Copy(c.Writer, content)
+		return err
+	})
+	if err != nil && !written {
+		respondFileError(c, err)
+	}
+}
+
+// UploadFile 上传文件
+// @Summary 上传文件
+// @Description 上传文件到 Saved 卷或插件卷中的指定目录（目录不存在时自动创建）
+// @Tags 文件管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param path formData string false "目标目录"
+// @Param file formData file true "文件"
+// @Param overwrite formData bool false "文件已存在时是否覆盖"
+// @Success 200 {object} map[string]models.FileInfo "文件信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 409 {object} map[string]string "目标已存在"
+// @Failure 413 {object} map[string]string "文件过大"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume}/upload [post]
+func UploadFile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.FileMaxUploadSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件大小超过限制"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择要上传的文件"})
+		return
+	}
+	if fileHeader.Size > config.FileMaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件大小超过限制"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+	defer file.Close()
+
+	overwrite := c.PostForm("overwrite") == "true"
+	data, err := fileService.UploadFile(userID, c.Param("id"), c.Param("volume"), c.PostForm("path"),
+		fileHeader.Filename, fileHeader.Size, file, overwrite)
+	if err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "文件上传成功",
+		"data":    data,
+	})
+}
+
+// RenameFile 重命名或移动文件
+// @Summary 重命名或移动文件
+// @Description 在同一个卷内重命名或移动文件/目录，目标已存在时失败
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param rename body models.FileRenameRequest true "原路径和新路径"
+// @Success 200 {object} map[string]string "重命名成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或文件不存在"
+// @Failure 409 {object} map[string]string "目标已存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume}/rename [post]
+func RenameFile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req models.FileRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := fileService.Rename(userID, c.Param("id"), c.Param("volume"), req.From, req.To); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "重命名成功"})
+}
+
+// MakeDirectory 创建目录
+// @Summary 创建目录
+// @Description 在 Saved 卷或插件卷中创建目录（包括不存在的父目录）
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param mkdir body models.FileMkdirRequest true "目录路径"
+// @Success 200 {object} map[string]string "创建成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 409 {object} map[string]string "目标已存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume}/mkdir [post]
+func MakeDirectory(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req models.FileMkdirRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := fileService.Mkdir(userID, c.Param("id"), c.Param("volume"), req.Path); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "目录创建成功"})
+}
+
+// DeleteFile 删除文件或目录
+// @Summary 删除文件或目录
+// @Description 删除 Saved 卷或插件卷中的文件，目录会递归删除
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param volume path string true "卷名称" Enums(saved, plugins)
+// @Param path query string true "相对于卷根目录的路径"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或文件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/files/{volume} [delete]
+func DeleteFile(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	if err := fileService.Delete(userID, c.Param("id"), c.Param("volume"), c.Query("path")); err != nil {
+		respondFileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// respondFileError 将文件操作错误映射为HTTP响应
+func respondFileError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "服务器不存在" || message == "文件不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "目标已存在":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "无效的服务器ID" || message == "路径不是目录" || message == "路径不是文件" ||
+		message == "不能操作卷根目录" || message == "不能移动到自身或子目录中" || message == "路径超出卷范围" ||
+		message == "路径不能包含 .." || message == "路径包含非法字符" ||
+		strings.HasPrefix(message, "无效的卷") || strings.HasPrefix(message, "无效的名称"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,195 @@
+package images
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRegistryCredentials 获取仓库凭据列表
+// @Summary 获取仓库凭据列表
+// @Description 获取拉取镜像时使用的仓库凭据（不返回密码）
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.RegistryCredentialResponse "仓库凭据列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/credentials [get]
+func GetRegistryCredentials(c *gin.Context) {
+	data, err := imageService.ListCredentials()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CreateRegistryCredential 登记仓库凭据
+// @Summary 登记仓库凭据
+// @Description 登记私有仓库或加速地址的登录凭据（密码加密存储），拉取该仓库的镜像时自动使用。Docker Hub 的仓库地址为 docker.io
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param credential body models.RegistryCredentialRequest true "仓库凭据"
+// @Success 200 {object} map[string]models.RegistryCredentialResponse "登记的仓库凭据"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 409 {object} map[string]string "仓库凭据已存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/credentials [post]
+func CreateRegistryCredential(c *gin.Context) {
+	var req models.RegistryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := imageService.CreateCredential(req)
+	if err != nil {
+		respondCredentialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "仓库凭据登记成功",
+		"data":    data,
+	})
+}
+
+// UpdateRegistryCredential 修改仓库凭据
+// @Summary 修改仓库凭据
+// @Description 修改仓库凭据的用户名或密码（密码为空时保留原密码，仓库地址不能修改）
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "仓库凭据ID"
+// @Param credential body models.RegistryCredentialUpdateRequest true "仓库凭据"
+// @Success 200 {object} map[string]models.RegistryCredentialResponse "修改后的仓库凭据"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "仓库凭据不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/credentials/{id} [put]
+func UpdateRegistryCredential(c *gin.Context) {
+	credentialID, ok := parseCredentialID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RegistryCredentialUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := imageService.UpdateCredential(credentialID, req)
+	if err != nil {
+		respondCredentialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "仓库凭据修改成功",
+		"data":    data,
+	})
+}
+
+// DeleteRegistryCredential 删除仓库凭据
+// @Summary 删除仓库凭据
+// @Description 删除仓库凭据，之后拉取该仓库的镜像时不再登录
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "仓库凭据ID"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "仓库凭据不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/credentials/{id} [delete]
+func DeleteRegistryCredential(c *gin.Context) {
+	credentialID, ok := parseCredentialID(c)
+	if !ok {
+		return
+	}
+
+	if err := imageService.DeleteCredential(credentialID); err != nil {
+		respondCredentialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "仓库凭据已删除"})
+}
+
+// TestRegistryCredential 测试仓库凭据
+// @Summary 测试仓库凭据
+// @Description 使用登记的凭据登录仓库（Docker Registry HTTP API V2），检查凭据是否有效
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "仓库凭据ID"
+// @Success 200 {object} map[string]string "凭据有效"
+// @Failure 400 {object} map[string]string "凭据无效"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "仓库凭据不存在"
+// @Failure 502 {object} map[string]string "无法连接仓库"
+// @Router /images/credentials/{id}/test [post]
+func TestRegistryCredential(c *gin.Context) {
+	credentialID, ok := parseCredentialID(c)
+	if !ok {
+		return
+	}
+
+	if err := imageService.TestCredential(credentialID); err != nil {
+		respondCredentialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "仓库凭据有效"})
+}
+
+// parseCredentialID 解析路径中的仓库凭据ID，失败时直接返回 400
+func parseCredentialID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的仓库凭据ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondCredentialError 根据错误信息返回对应的状态码
+func respondCredentialError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "仓库凭据不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "仓库凭据已存在":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "仓库凭据无效" || message == "仓库地址格式错误":
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	case strings.HasPrefix(message, "无法连接仓库"):
+		c.JSON(http.StatusBadGateway, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -2,6 +2,8 @@ package images
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"ark-server-commander/service/server"
 
@@ -10,14 +12,27 @@ import (
 
 var serverService = server.NewServerService()
 
+// queryNodeID 解析可选的 node_id 查询参数（未提供时为 0，表示面板所在主机）
+func queryNodeID(c *gin.Context) (uint, bool) {
+	value := c.Query("node_id")
+	if value == "" {
+		return 0, true
+	}
+	nodeID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(nodeID), true
+}
+
 // PullImage 手动拉取镜像
 // @Summary 手动拉取Docker镜像
-// @Description 用户主动触发镜像下载操作
+// @Description 用户主动触发镜像下载操作，返回的拉取任务可通过 /images/pulls/{id}/events 获取实时进度
 // @Tags 镜像管理
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param request body map[string]string true "镜像信息 {\"image_name\": \"tbro98/ase-server:latest\"}"
+// @Param request body map[string]interface{} true "镜像信息 {\"image_name\": \"tbro98/ase-server:latest\", \"node_id\": 0}"
 // @Success 200 {object} map[string]interface{} "拉取状态"
 // @Failure 400 {object} map[string]string "请求错误"
 // @Failure 401 {object} map[string]string "未授权"
@@ -26,13 +41,14 @@ var serverService = server.NewServerService()
 func PullImage(c *gin.Context) {
 	var req struct {
 		ImageName string `json:"image_name" binding:"required"`
+		NodeID    uint   `json:"node_id"` // 0 表示面板所在主机
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
 		return
 	}
 
-	err := serverService.PullImage(req.ImageName)
+	job, err := serverService.PullImage(req.ImageName, req.NodeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,24 +58,33 @@ func PullImage(c *gin.Context) {
 		"message": "镜像拉取已开始",
 		"data": map[string]interface{}{
 			"image_name": req.ImageName,
+			"node_id":    req.NodeID,
 			"status":     "pulling",
+			"job":        job,
 		},
 	})
 }
 
 // CheckImageUpdates 检查镜像更新
 // @Summary 检查镜像是否有更新
-// @Description 检查所有管理的镜像是否有新版本
+// @Description 检查所有登记的服务器镜像和辅助容器镜像是否有新版本
 // @Tags 镜像管理
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param node_id query int false "节点ID（默认为面板所在主机）"
 // @Success 200 {object} map[string]bool "镜像更新状态映射"
 // @Failure 401 {object} map[string]string "未授权"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /images/check-updates [get]
 func CheckImageUpdates(c *gin.Context) {
-	data, err := serverService.CheckImageUpdates()
+	nodeID, ok := queryNodeID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的节点ID"})
+		return
+	}
+
+	data, err := serverService.CheckImageUpdates(nodeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -78,7 +103,7 @@ func CheckImageUpdates(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param request body map[string]string true "镜像信息 {\"image_name\": \"tbro98/ase-server:latest\"}"
+// @Param request body map[string]interface{} true "镜像信息 {\"image_name\": \"tbro98/ase-server:latest\", \"node_id\": 0}"
 // @Success 200 {object} map[string]interface{} "更新状态"
 // @Failure 400 {object} map[string]string "请求错误"
 // @Failure 401 {object} map[string]string "未授权"
@@ -89,13 +114,14 @@ func UpdateImage(c *gin.Context) {
 
 	var req struct {
 		ImageName string `json:"image_name" binding:"required"`
+		NodeID    uint   `json:"node_id"` // 0 表示面板所在主机
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
 		return
 	}
 
-	affectedServers, err := serverService.UpdateImage(req.ImageName, userID)
+	affectedServers, job, err := serverService.UpdateImage(req.ImageName, userID, req.NodeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -107,13 +133,14 @@ func UpdateImage(c *gin.Context) {
 			"image_name":       req.ImageName,
 			"affected_servers": affectedServers,
 			"status":           "updating",
+			"job":              job,
 		},
 	})
 }
 
 // GetAffectedServers 获取使用指定镜像的服务器列表
 // @Summary 获取影响的服务器列表
-// @Description 获取使用指定镜像的服务器列表
+// @Description 获取使用指定镜像的服务器列表（镜像名称会规范化，如 tbro98/ase-server 等同于 tbro98/ase-server:latest）
 // @Tags 镜像管理
 // @Accept json
 // @Produce json
@@ -135,6 +162,10 @@ func GetAffectedServers(c *gin.Context) {
 
 	servers, err := serverService.GetAffectedServers(imageName, userID)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "镜像名称格式错误") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -155,12 +186,19 @@ func GetAffectedServers(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param node_id query int false "节点ID（默认为面板所在主机）"
 // @Success 200 {object} map[string]interface{} "镜像状态信息"
 // @Failure 401 {object} map[string]string "未授权"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /images/status [get]
 func GetImageStatus(c *gin.Context) {
-	data, err := serverService.GetImageStatus()
+	nodeID, ok := queryNodeID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的节点ID"})
+		return
+	}
+
+	data, err := serverService.GetImageStatus(nodeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
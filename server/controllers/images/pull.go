@@ -0,0 +1,137 @@
+package images
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pullHeartbeatInterval 拉取进度流的心跳间隔，避免代理断开空闲连接
+const pullHeartbeatInterval = 15 * time.Second
+
+// GetPullJobs 获取镜像拉取任务列表
+// @Summary 获取镜像拉取任务列表
+// @Description 获取正在进行和最近一小时内结束的镜像拉取任务，包括结果、耗时和镜像摘要
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]docker_manager.PullJobInfo "拉取任务列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Router /images/pulls [get]
+func GetPullJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    serverService.GetPullJobs(),
+	})
+}
+
+// GetPullJob 获取镜像拉取任务
+// @Summary 获取镜像拉取任务
+// @Description 获取镜像拉取任务的进度或最终结果
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "拉取任务ID"
+// @Success 200 {object} map[string]docker_manager.PullJobInfo "拉取任务"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "拉取任务不存在"
+// @Router /images/pulls/{id} [get]
+func GetPullJob(c *gin.Context) {
+	job, err := serverService.GetPullJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    job.Info(),
+	})
+}
+
+// StreamPullJob 实时推送镜像拉取进度
+// @Summary 实时推送镜像拉取进度
+// @Description 以 Server-Sent Events 推送拉取进度：连接后先发送 status 事件（当前任务信息），之后发送 layer 事件（层级进度）和 status 事件（切换拉取地址），任务结束时发送 done 事件（最终结果）并关闭连接
+// @Tags 镜像管理
+// @Produce text/event-stream
+// @Security Bearer
+// @Param id path string true "拉取任务ID"
+// @Success 200 {string} string "事件流"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "拉取任务不存在"
+// @Router /images/pulls/{id}/events [get]
+func StreamPullJob(c *gin.Context) {
+	job, err := serverService.GetPullJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("status", info)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(pullHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				c.SSEvent("done", job.Info())
+				return false
+			}
+			if event.Layer != nil {
+				c.SSEvent(event.Type, event.Layer)
+			} else {
+				c.SSEvent(event.Type, event.Job)
+			}
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// CancelPullJob 取消镜像拉取
+// @Summary 取消镜像拉取
+// @Description 取消正在进行的镜像拉取任务
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "拉取任务ID"
+// @Success 200 {object} map[string]string "已取消"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "拉取任务不存在"
+// @Failure 409 {object} map[string]string "拉取任务已结束"
+// @Router /images/pulls/{id}/cancel [post]
+func CancelPullJob(c *gin.Context) {
+	if err := serverService.CancelPullJob(c.Param("id")); err != nil {
+		status := http.StatusConflict
+		if err.Error() == "拉取任务不存在" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "镜像拉取已取消"})
+}
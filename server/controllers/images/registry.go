@@ -0,0 +1,168 @@
+package images
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/image"
+
+	"github.com/gin-gonic/gin"
+)
+
+var imageService = image.NewImageService()
+
+// GetRegistryImages 获取登记的镜像列表
+// @Summary 获取登记的镜像列表
+// @Description 获取允许服务器使用的游戏服务器镜像及使用每个镜像的服务器数量
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.ServerImageResponse "镜像列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/registry [get]
+func GetRegistryImages(c *gin.Context) {
+	data, err := imageService.ListImages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CreateRegistryImage 登记镜像
+// @Summary 登记镜像
+// @Description 登记允许服务器使用的镜像，可以使用标签（tbro98/ase-server:v2）或摘要（tbro98/ase-server@sha256:...）固定版本
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param image body models.ServerImageRequest true "镜像信息"
+// @Success 200 {object} map[string]models.ServerImageResponse "登记的镜像"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 409 {object} map[string]string "镜像已登记"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/registry [post]
+func CreateRegistryImage(c *gin.Context) {
+	var req models.ServerImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := imageService.CreateImage(req)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "镜像登记成功",
+		"data":    data,
+	})
+}
+
+// UpdateRegistryImage 修改登记的镜像
+// @Summary 修改登记的镜像
+// @Description 修改镜像说明或将镜像设为新建服务器的默认镜像（镜像引用不能修改）
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "镜像ID"
+// @Param image body models.ServerImageUpdateRequest true "镜像信息"
+// @Success 200 {object} map[string]models.ServerImageResponse "修改后的镜像"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "镜像不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/registry/{id} [put]
+func UpdateRegistryImage(c *gin.Context) {
+	imageID, ok := parseImageID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ServerImageUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := imageService.UpdateImage(imageID, req)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "镜像修改成功",
+		"data":    data,
+	})
+}
+
+// DeleteRegistryImage 删除登记的镜像
+// @Summary 删除登记的镜像
+// @Description 删除没有服务器使用的非默认镜像（不会删除节点上已下载的镜像）
+// @Tags 镜像管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "镜像ID"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "镜像不存在"
+// @Failure 409 {object} map[string]string "镜像正在使用"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /images/registry/{id} [delete]
+func DeleteRegistryImage(c *gin.Context) {
+	imageID, ok := parseImageID(c)
+	if !ok {
+		return
+	}
+
+	if err := imageService.DeleteImage(imageID); err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "镜像已删除"})
+}
+
+// parseImageID 解析路径中的镜像ID，失败时直接返回 400
+func parseImageID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的镜像ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondImageError 根据错误信息返回对应的状态码
+func respondImageError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "镜像不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "镜像已登记" || message == "不能删除默认镜像" || message == "镜像正在被服务器使用，不能删除":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case strings.HasPrefix(message, "镜像名称格式错误") || strings.HasPrefix(message, "不能"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
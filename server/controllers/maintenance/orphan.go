@@ -0,0 +1,74 @@
+package maintenance
+
+import (
+	"net/http"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/maintenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+var maintenanceService = maintenance.NewMaintenanceService()
+
+// GetOrphans 获取遗留资源
+// @Summary 获取遗留的容器和卷
+// @Description 扫描所有节点上由该面板实例创建、但所属服务器不存在或已删除超过保留期的容器和卷（需要所有者或管理员角色）
+// @Tags 维护
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]models.OrphanReport "遗留资源列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /maintenance/orphans [get]
+func GetOrphans(c *gin.Context) {
+	data, err := maintenanceService.FindOrphans()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CleanupOrphans 清理遗留资源
+// @Summary 清理遗留的容器和卷
+// @Description 重新扫描并删除遗留的容器和卷，dry_run 为 true 时只返回将要删除的资源；backup 为 true 时删除卷之前先备份（需要所有者或管理员角色）
+// @Tags 维护
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body models.OrphanCleanupRequest true "清理选项"
+// @Success 200 {object} map[string]models.OrphanCleanupResult "清理结果"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /maintenance/orphans [post]
+func CleanupOrphans(c *gin.Context) {
+	var req models.OrphanCleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := maintenanceService.CleanupOrphans(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "清理完成"
+	if req.DryRun {
+		message = "预览完成，未删除任何资源"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
@@ -0,0 +1,69 @@
+package maintenance
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetServerStorage 获取服务器的磁盘占用
+// @Summary 获取服务器的磁盘占用
+// @Description 获取服务器 Saved 卷和插件卷的大小及其顶层目录的大小、面板保存的卷备份和卷所在磁盘的空间；超过告警阈值时在 warnings 中返回告警
+// @Tags 维护
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string]models.ServerStorage "磁盘占用"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/storage [get]
+func GetServerStorage(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	data, err := maintenanceService.GetServerStorage(userID, c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "无效的服务器ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "服务器不存在":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// GetStorageReport 获取所有节点的磁盘占用
+// @Summary 获取所有节点的磁盘占用
+// @Description 获取各节点的磁盘空间、Docker 镜像/容器/卷/构建缓存的占用、各服务器卷的大小，以及面板保存的卷备份和数据库备份；无法访问的节点在 error 中返回原因（需要所有者或管理员角色）
+// @Tags 维护
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]models.StorageReport "磁盘占用"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /storage [get]
+func GetStorageReport(c *gin.Context) {
+	data, err := maintenanceService.GetStorageReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
@@ -0,0 +1,261 @@
+package mods
+
+import (
+	"net/http"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/mod"
+
+	"github.com/gin-gonic/gin"
+)
+
+var modService = mod.NewModService()
+
+// GetMods 获取模组目录
+// @Summary 获取模组目录
+// @Description 获取已缓存的创意工坊模组元数据列表
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.ModResponse "模组列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /mods [get]
+func GetMods(c *gin.Context) {
+	data, err := modService.ListMods()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// GetMod 获取模组元数据
+// @Summary 获取模组元数据
+// @Description 根据创意工坊ID获取模组名称、大小和最后更新时间（结果会缓存到数据库）
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param workshop_id path string true "创意工坊模组ID"
+// @Param refresh query bool false "是否忽略缓存重新获取"
+// @Success 200 {object} map[string]models.ModResponse "模组信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "模组不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /mods/{workshop_id} [get]
+func GetMod(c *gin.Context) {
+	workshopID := c.Param("workshop_id")
+	refresh := c.Query("refresh") == "true"
+
+	data, err := modService.GetMod(workshopID, refresh)
+	if err != nil {
+		if err.Error() == "模组不存在" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "模组ID不能为空" || strings.HasPrefix(err.Error(), "无效的模组ID") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// GetServerMods 获取服务器模组列表
+// @Summary 获取服务器模组列表
+// @Description 获取指定服务器按加载顺序排列的模组列表
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string][]models.ServerModResponse "模组列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/mods [get]
+func GetServerMods(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	data, err := modService.GetServerMods(userID, serverID)
+	if err != nil {
+		respondServerModError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// AddServerMod 添加服务器模组
+// @Summary 添加服务器模组
+// @Description 将创意工坊模组添加到服务器模组列表末尾（需重启服务器生效）
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param mod body models.ModAddRequest true "模组信息"
+// @Success 200 {object} map[string][]models.ServerModResponse "更新后的模组列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或模组不存在"
+// @Failure 409 {object} map[string]string "模组已存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/mods [post]
+func AddServerMod(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	var req models.ModAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := modService.AddServerMod(userID, serverID, req.WorkshopID)
+	if err != nil {
+		respondServerModError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "模组添加成功",
+		"data":    data,
+	})
+}
+
+// RemoveServerMod 移除服务器模组
+// @Summary 移除服务器模组
+// @Description 从服务器模组列表中移除指定模组（需重启服务器生效）
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param workshop_id path string true "创意工坊模组ID"
+// @Success 200 {object} map[string][]models.ServerModResponse "更新后的模组列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在或模组不在列表中"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/mods/{workshop_id} [delete]
+func RemoveServerMod(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	workshopID := c.Param("workshop_id")
+
+	data, err := modService.RemoveServerMod(userID, serverID, workshopID)
+	if err != nil {
+		respondServerModError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "模组移除成功",
+		"data":    data,
+	})
+}
+
+// ReorderServerMods 调整服务器模组顺序
+// @Summary 调整服务器模组加载顺序
+// @Description 按给定顺序重新排列服务器模组列表，列表必须包含当前所有模组且不能重复
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param order body models.ModReorderRequest true "新的模组顺序"
+// @Success 200 {object} map[string][]models.ServerModResponse "更新后的模组列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/mods/order [put]
+func ReorderServerMods(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	var req models.ModReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := modService.ReorderServerMods(userID, serverID, req.WorkshopIDs)
+	if err != nil {
+		respondServerModError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "模组顺序更新成功",
+		"data":    data,
+	})
+}
+
+// GetServerModStatus 获取服务器模组更新状态
+// @Summary 获取服务器模组更新状态
+// @Description 比较服务器卷中已安装的模组版本与创意工坊最新版本，列出过期的模组
+// @Tags 模组管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param refresh query bool false "是否忽略缓存重新获取最新版本信息"
+// @Success 200 {object} map[string]models.ServerModStatusResponse "模组更新状态"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/mods/status [get]
+func GetServerModStatus(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	refresh := c.Query("refresh") == "true"
+
+	data, err := modService.GetServerModStatus(userID, serverID, refresh)
+	if err != nil {
+		respondServerModError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// respondServerModError 将服务器模组操作错误映射为HTTP响应
+func respondServerModError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "服务器不存在" || message == "模组不存在" || message == "模组不在列表中":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "模组已存在":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "无效的服务器ID" || message == "排序列表与当前模组列表不一致" ||
+		message == "模组ID不能为空" || strings.HasPrefix(message, "无效的模组ID") || strings.HasPrefix(message, "模组ID重复"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,236 @@
+package nodes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+var nodeService = node.NewNodeService()
+
+// GetNodes 获取节点列表
+// @Summary 获取节点列表
+// @Description 获取所有Docker节点及其状态和服务器数量（需要所有者或管理员角色）
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.NodeResponse "节点列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes [get]
+func GetNodes(c *gin.Context) {
+	data, err := nodeService.ListNodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// GetNode 获取节点详情
+// @Summary 获取节点详情
+// @Description 根据ID获取节点信息（不包含证书和私钥）
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} map[string]models.NodeResponse "节点信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "节点不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes/{id} [get]
+func GetNode(c *gin.Context) {
+	nodeID, ok := parseNodeID(c)
+	if !ok {
+		return
+	}
+
+	data, err := nodeService.GetNode(nodeID)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CreateNode 添加节点
+// @Summary 添加节点
+// @Description 添加运行ARK服务器的Docker主机。endpoint 为空表示面板所在主机，tcp://host:2376 需要配置TLS证书，ssh://user@host 需要配置SSH私钥和主机公钥
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param node body models.NodeRequest true "节点信息"
+// @Success 200 {object} map[string]models.NodeResponse "添加的节点"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 409 {object} map[string]string "节点已存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes [post]
+func CreateNode(c *gin.Context) {
+	var req models.NodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := nodeService.CreateNode(req)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "节点添加成功",
+		"data":    data,
+	})
+}
+
+// UpdateNode 修改节点
+// @Summary 修改节点
+// @Description 修改节点的连接参数、标签和调度设置（私钥为空时保留原值），修改后会重新连接
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param node body models.NodeUpdateRequest true "节点信息"
+// @Success 200 {object} map[string]models.NodeResponse "修改后的节点"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "节点不存在"
+// @Failure 409 {object} map[string]string "节点已存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes/{id} [put]
+func UpdateNode(c *gin.Context) {
+	nodeID, ok := parseNodeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.NodeUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := nodeService.UpdateNode(nodeID, req)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "节点修改成功",
+		"data":    data,
+	})
+}
+
+// DeleteNode 删除节点
+// @Summary 删除节点
+// @Description 删除没有服务器的节点
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "节点不存在"
+// @Failure 409 {object} map[string]string "节点上还有服务器"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes/{id} [delete]
+func DeleteNode(c *gin.Context) {
+	nodeID, ok := parseNodeID(c)
+	if !ok {
+		return
+	}
+
+	if err := nodeService.DeleteNode(nodeID); err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "节点已删除"})
+}
+
+// CheckNode 立即检查节点
+// @Summary 检查节点连接
+// @Description 立即连接节点的Docker服务并更新状态和主机信息
+// @Tags 节点管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} map[string]models.NodeResponse "检查后的节点"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "节点不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /nodes/{id}/check [post]
+func CheckNode(c *gin.Context) {
+	nodeID, ok := parseNodeID(c)
+	if !ok {
+		return
+	}
+
+	data, err := nodeService.CheckNodeByID(nodeID)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "检查完成",
+		"data":    data,
+	})
+}
+
+// parseNodeID 解析路径中的节点ID，失败时直接返回 400
+func parseNodeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的节点ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondNodeError 根据错误信息返回对应的状态码
+func respondNodeError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "节点不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "节点名称已存在" || message == "面板所在主机的节点已存在" || message == "节点上还有服务器，不能删除":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "节点名称不能为空" || message == "服务器数量上限不能为负数" ||
+		strings.HasPrefix(message, "节点配置错误") || strings.HasPrefix(message, "节点标签"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,117 @@
+package permissions
+
+import (
+	"net/http"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/permission"
+
+	"github.com/gin-gonic/gin"
+)
+
+var permissionService = permission.NewPermissionService()
+
+// GetServerPermissions 获取服务器授权列表
+// @Summary 获取服务器授权列表
+// @Description 获取被授权操作该服务器的用户及其权限（需要服务器创建者或管理员）
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string][]models.ServerPermissionResponse "授权列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/permissions [get]
+func GetServerPermissions(c *gin.Context) {
+	data, err := permissionService.ListServerPermissions(c.Param("id"))
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// SetServerPermissions 设置用户的服务器权限
+// @Summary 设置用户的服务器权限
+// @Description 授予用户对服务器的权限（覆盖原有授权），可选权限: start_stop, edit_config, rcon, backups, delete
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param user_id path int true "用户ID"
+// @Param permissions body models.ServerPermissionRequest true "权限列表"
+// @Success 200 {object} map[string]models.ServerPermissionResponse "授权信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "服务器或用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/permissions/{user_id} [put]
+func SetServerPermissions(c *gin.Context) {
+	var req models.ServerPermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := permissionService.SetServerPermissions(c.Param("id"), c.Param("user_id"), req.Permissions)
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "授权更新成功",
+		"data":    data,
+	})
+}
+
+// DeleteServerPermissions 撤销用户的服务器权限
+// @Summary 撤销用户的服务器权限
+// @Description 撤销用户对服务器的全部授权
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} map[string]string "撤销成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "授权不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/permissions/{user_id} [delete]
+func DeleteServerPermissions(c *gin.Context) {
+	if err := permissionService.RemoveServerPermissions(c.Param("id"), c.Param("user_id")); err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "授权已撤销"})
+}
+
+// respondPermissionError 将授权管理错误映射为HTTP响应
+func respondPermissionError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "服务器不存在" || message == "用户不存在" || message == "授权不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "无效的服务器ID" || message == "无效的用户ID" || message == "服务器创建者已拥有全部权限" ||
+		message == "查看者只能授予查看权限" ||
+		strings.HasPrefix(message, "无效的权限"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
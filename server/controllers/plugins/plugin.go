@@ -0,0 +1,276 @@
+package plugins
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/models"
+	"ark-server-commander/service/plugin"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pluginService = plugin.NewPluginService()
+
+// multipartOverhead 上传请求中除压缩包以外的表单数据余量
+const multipartOverhead = 1 << 20
+
+// GetPlugins 获取服务器插件列表
+// @Summary 获取服务器插件列表
+// @Description 列出插件卷中已安装的 ArkApi 插件（包括已禁用的插件）及 PluginInfo.json 中的版本信息
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string][]models.PluginResponse "插件列表"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins [get]
+func GetPlugins(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	data, err := pluginService.ListPlugins(userID, serverID)
+	if err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// UploadPlugin 上传插件
+// @Summary 上传插件
+// @Description 上传插件zip压缩包并解压到插件卷中独立的目录（需重启服务器生效）
+// @Tags 插件管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param file formData file true "插件zip压缩包"
+// @Param overwrite formData bool false "插件已存在时是否覆盖"
+// @Success 200 {object} map[string]models.PluginResponse "插件信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 409 {object} map[string]string "插件已存在"
+// @Failure 413 {object} map[string]string "压缩包过大"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins [post]
+func UploadPlugin(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.PluginMaxUploadSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "插件压缩包大小超过限制"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传插件压缩包"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+	defer file.Close()
+
+	overwrite := c.PostForm("overwrite") == "true"
+	data, err := pluginService.InstallPlugin(userID, serverID, fileHeader.Filename, file, fileHeader.Size, overwrite)
+	if err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "插件安装成功",
+		"data":    data,
+	})
+}
+
+// EnablePlugin 启用插件
+// @Summary 启用插件
+// @Description 将插件从禁用目录移回插件目录（需重启服务器生效）
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param name path string true "插件名称"
+// @Success 200 {object} map[string]models.PluginResponse "插件信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或插件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins/{name}/enable [post]
+func EnablePlugin(c *gin.Context) {
+	setPluginEnabled(c, true, "插件已启用")
+}
+
+// DisablePlugin 禁用插件
+// @Summary 禁用插件
+// @Description 将插件移动到禁用目录，ArkApi 不会加载该插件（需重启服务器生效）
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param name path string true "插件名称"
+// @Success 200 {object} map[string]models.PluginResponse "插件信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或插件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins/{name}/disable [post]
+func DisablePlugin(c *gin.Context) {
+	setPluginEnabled(c, false, "插件已禁用")
+}
+
+// setPluginEnabled 启用或禁用插件
+func setPluginEnabled(c *gin.Context, enabled bool, message string) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	name := c.Param("name")
+
+	data, err := pluginService.SetPluginEnabled(userID, serverID, name, enabled)
+	if err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// GetPluginConfig 获取插件配置
+// @Summary 获取插件配置
+// @Description 读取插件目录中的 config.json
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param name path string true "插件名称"
+// @Success 200 {object} map[string]string "配置内容"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器、插件或配置文件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins/{name}/config [get]
+func GetPluginConfig(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	name := c.Param("name")
+
+	content, err := pluginService.GetPluginConfig(userID, serverID, name)
+	if err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data": gin.H{
+			"content": content,
+		},
+	})
+}
+
+// UpdatePluginConfig 更新插件配置
+// @Summary 更新插件配置
+// @Description 写入插件目录中的 config.json，内容必须是合法的JSON（需重启服务器或重新加载插件生效）
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param name path string true "插件名称"
+// @Param config body models.PluginConfigRequest true "配置内容"
+// @Success 200 {object} map[string]string "更新成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或插件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins/{name}/config [put]
+func UpdatePluginConfig(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	name := c.Param("name")
+
+	var req models.PluginConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := pluginService.UpdatePluginConfig(userID, serverID, name, req.Content); err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "插件配置更新成功"})
+}
+
+// DeletePlugin 删除插件
+// @Summary 删除插件
+// @Description 从插件卷中删除插件目录（需重启服务器生效）
+// @Tags 插件管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Param name path string true "插件名称"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器或插件不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/plugins/{name} [delete]
+func DeletePlugin(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+	name := c.Param("name")
+
+	if err := pluginService.RemovePlugin(userID, serverID, name); err != nil {
+		respondPluginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "插件删除成功"})
+}
+
+// respondPluginError 将插件操作错误映射为HTTP响应
+func respondPluginError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "服务器不存在" || message == "插件不存在" || message == "插件配置文件不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "插件已存在":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "插件压缩包大小超过限制" || message == "插件解压后大小超过限制":
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": message})
+	case message == "无效的服务器ID" || message == "插件必须是zip压缩包" || message == "插件配置不是有效的JSON" ||
+		message == "压缩包中没有文件" || strings.HasPrefix(message, "无效的插件名称") ||
+		strings.HasPrefix(message, "无效的zip压缩包") || strings.HasPrefix(message, "压缩包中包含") ||
+		strings.HasPrefix(message, "压缩包文件数量超过限制"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
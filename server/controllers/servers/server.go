@@ -2,6 +2,7 @@ package servers
 
 import (
 	"net/http"
+	"strings"
 
 	"ark-server-commander/models"
 	"ark-server-commander/service/server"
@@ -61,7 +62,14 @@ func CreateServer(c *gin.Context) {
 
 	response, err := serverService.CreateServer(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		message := err.Error()
+		// 没有满足条件的节点、资源限制或网络配置错误、镜像未登记
+		if message == "节点不存在" || strings.HasPrefix(message, "节点 ") || strings.HasPrefix(message, "没有可用的节点") ||
+			strings.HasPrefix(message, "资源限制") || strings.HasPrefix(message, "网络配置") || strings.HasPrefix(message, "镜像") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
 		return
 	}
 
@@ -145,6 +153,42 @@ func GetServerRCON(c *gin.Context) {
 	})
 }
 
+// RevealAdminPassword 查看服务器管理员密码
+// @Summary 查看管理员密码
+// @Description 服务器列表和详情不返回管理员密码，需要时通过此接口查看，每次查看都会记录审计日志
+// @Tags 服务器管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string]string "管理员密码"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 401 {object} map[string]string "未授权"
+// @Router /servers/{id}/admin-password [get]
+func RevealAdminPassword(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	serverID := c.Param("id")
+
+	password, err := serverService.RevealAdminPassword(userID, serverID)
+	if err != nil {
+		if err.Error() == "无效的服务器ID" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "服务器不存在" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    gin.H{"admin_password": password},
+	})
+}
+
 // UpdateServer 更新服务器
 // @Summary 更新服务器配置
 // @Description 更新指定服务器的配置信息（包括配置文件）
@@ -157,6 +201,7 @@ func GetServerRCON(c *gin.Context) {
 // @Success 200 {object} map[string]models.ServerResponse "更新成功"
 // @Failure 400 {object} map[string]string "请求错误"
 // @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 409 {object} map[string]string "端口已被占用"
 // @Failure 401 {object} map[string]string "未授权"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /servers/{id} [put]
@@ -180,10 +225,14 @@ func UpdateServer(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err.Error() == "服务器标识已存在" {
+		if err.Error() == "服务器标识已存在" || strings.HasPrefix(err.Error(), "资源限制") || strings.HasPrefix(err.Error(), "网络配置") || strings.HasPrefix(err.Error(), "镜像") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "端口 ") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -191,7 +240,7 @@ func UpdateServer(c *gin.Context) {
 	// 构建响应消息
 	message := "服务器更新成功"
 	if argsChanged && response.Status == "running" {
-		message = "服务器更新成功，启动参数已修改。由于服务器正在运行，需要重启服务器以应用新的启动参数。"
+		message = "服务器更新成功，启动参数、资源限制、镜像或网络已修改。由于服务器正在运行，需要重启服务器以应用新的配置。"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -203,23 +252,32 @@ func UpdateServer(c *gin.Context) {
 
 // DeleteServer 删除服务器
 // @Summary 删除服务器
-// @Description 删除指定的服务器配置（仅允许删除已停止的服务器）
+// @Description 将已停止的服务器移入回收站（保留数据卷，可以恢复）；purge=true 时彻底删除服务器及其数据卷，包括回收站中的服务器（需要所有者或管理员角色，confirm 需与服务器标识一致）
 // @Tags 服务器管理
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "服务器ID"
+// @Param purge query bool false "彻底删除"
+// @Param confirm query string false "彻底删除时输入服务器标识确认"
 // @Success 200 {object} map[string]string "删除成功"
 // @Failure 400 {object} map[string]string "请求错误"
 // @Failure 404 {object} map[string]string "服务器不存在"
 // @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /servers/{id} [delete]
 func DeleteServer(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	serverID := c.Param("id")
 
-	err := serverService.DeleteServer(userID, serverID)
+	purge := c.Query("purge") == "true"
+	var err error
+	if purge {
+		err = serverService.PurgeServer(serverID, c.Query("confirm"))
+	} else {
+		err = serverService.DeleteServer(userID, serverID)
+	}
 	if err != nil {
 		if err.Error() == "无效的服务器ID" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -229,7 +287,7 @@ func DeleteServer(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err.Error() == "无法删除正在运行的服务器，请先停止服务器" {
+		if err.Error() == "无法删除正在运行的服务器，请先停止服务器" || err.Error() == "确认信息与服务器标识不一致" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -237,8 +295,74 @@ func DeleteServer(c *gin.Context) {
 		return
 	}
 
+	message := "服务器已移入回收站"
+	if purge {
+		message = "服务器已彻底删除"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// GetDeletedServers 获取回收站
+// @Summary 获取回收站中的服务器
+// @Description 获取已删除但尚未彻底删除的服务器，purge_at 为自动彻底删除的时间（需要所有者或管理员角色）
+// @Tags 服务器管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.DeletedServerResponse "回收站中的服务器"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/trash [get]
+func GetDeletedServers(c *gin.Context) {
+	data, err := serverService.GetDeletedServers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// RestoreServer 从回收站恢复服务器
+// @Summary 恢复已删除的服务器
+// @Description 从回收站恢复服务器并重新创建容器，需要数据卷仍然存在且标识和端口未被占用（需要所有者或管理员角色）
+// @Tags 服务器管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "服务器ID"
+// @Success 200 {object} map[string]string "恢复成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "服务器不存在"
+// @Failure 409 {object} map[string]string "标识或端口已被占用"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /servers/{id}/restore [post]
+func RestoreServer(c *gin.Context) {
+	err := serverService.RestoreServer(c.Param("id"))
+	if err != nil {
+		switch {
+		case err.Error() == "无效的服务器ID" || err.Error() == "服务器未被删除" || err.Error() == "服务器数据卷已被删除，无法恢复":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "服务器不存在":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "服务器标识已存在" || strings.HasPrefix(err.Error(), "端口 "):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "服务器删除成功",
+		"message": "服务器已恢复",
 	})
 }
 
@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"net/http"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+var sessionService = session.NewSessionService()
+
+// GetSessions 获取登录会话列表
+// @Summary 获取登录会话列表
+// @Description 获取当前用户所有有效的登录会话，current 标记当前请求所属的会话
+// @Tags 会话
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.SessionResponse "会话列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "不能使用API令牌访问"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /sessions [get]
+func GetSessions(c *gin.Context) {
+	data, err := sessionService.ListSessions(c.GetUint("user_id"), c.GetUint("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// RevokeSession 撤销登录会话
+// @Summary 撤销登录会话
+// @Description 撤销当前用户的某个登录会话，该会话的访问令牌和刷新令牌立即失效
+// @Tags 会话
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Success 200 {object} map[string]string "撤销成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "不能使用API令牌访问"
+// @Failure 404 {object} map[string]string "会话不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	if err := sessionService.RevokeSession(c.GetUint("user_id"), c.Param("id"), models.SessionRevokeManual); err != nil {
+		switch err.Error() {
+		case "无效的会话ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "会话不存在":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "会话已撤销"})
+}
@@ -0,0 +1,107 @@
+package tokens
+
+import (
+	"net/http"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+var tokenService = token.NewTokenService()
+
+// GetTokens 获取API令牌列表
+// @Summary 获取API令牌列表
+// @Description 获取当前用户未撤销的个人访问令牌（不包含令牌明文）
+// @Tags API令牌
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.APITokenResponse "令牌列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "不能使用API令牌访问"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /tokens [get]
+func GetTokens(c *gin.Context) {
+	data, err := tokenService.ListTokens(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CreateToken 创建API令牌
+// @Summary 创建API令牌
+// @Description 创建个人访问令牌，令牌明文只在响应中返回一次。作用域: read, lifecycle, config, rcon
+// @Tags API令牌
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param token body models.APITokenCreateRequest true "令牌信息"
+// @Success 200 {object} map[string]models.APITokenResponse "创建的令牌（包含明文）"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "不能使用API令牌访问"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /tokens [post]
+func CreateToken(c *gin.Context) {
+	var req models.APITokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := tokenService.CreateToken(c.GetUint("user_id"), req)
+	if err != nil {
+		message := err.Error()
+		if message == "令牌名称不能为空" || message == "至少需要一个作用域" || strings.HasPrefix(message, "无效的作用域") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "令牌创建成功，请妥善保存，令牌只显示一次",
+		"data":    data,
+	})
+}
+
+// RevokeToken 撤销API令牌
+// @Summary 撤销API令牌
+// @Description 撤销当前用户的个人访问令牌，撤销后立即失效
+// @Tags API令牌
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "令牌ID"
+// @Success 200 {object} map[string]string "撤销成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "不能使用API令牌访问"
+// @Failure 404 {object} map[string]string "令牌不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /tokens/{id} [delete]
+func RevokeToken(c *gin.Context) {
+	if err := tokenService.RevokeToken(c.GetUint("user_id"), c.Param("id")); err != nil {
+		switch err.Error() {
+		case "无效的令牌ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "令牌不存在":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "令牌已撤销"})
+}
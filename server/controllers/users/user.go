@@ -0,0 +1,341 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/loginguard"
+	"ark-server-commander/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	loginGuard  = loginguard.NewLoginGuardService()
+	userService = user.NewUserService()
+)
+
+// GetUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 获取所有用户及其角色和状态（需要所有者或管理员角色）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string][]models.UserResponse "用户列表"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users [get]
+func GetUsers(c *gin.Context) {
+	data, err := userService.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// CreateUser 创建用户
+// @Summary 创建用户
+// @Description 创建新用户并指定角色（管理员只能创建操作员和观察者）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param user body models.UserCreateRequest true "用户信息"
+// @Success 200 {object} map[string]models.UserResponse "创建的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 409 {object} map[string]string "用户名已存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users [post]
+func CreateUser(c *gin.Context) {
+	var req models.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := userService.CreateUser(c.GetUint("user_id"), req)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "用户创建成功",
+		"data":    data,
+	})
+}
+
+// UpdateUserRole 修改用户角色
+// @Summary 修改用户角色
+// @Description 修改用户角色（不能修改自己的角色，至少保留一个可用的所有者）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param role body models.UserRoleRequest true "新角色"
+// @Success 200 {object} map[string]models.UserResponse "更新后的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/role [put]
+func UpdateUserRole(c *gin.Context) {
+	var req models.UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := userService.UpdateUserRole(c.GetUint("user_id"), c.Param("id"), req.Role)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "用户角色更新成功",
+		"data":    data,
+	})
+}
+
+// DisableUser 禁用用户
+// @Summary 禁用用户
+// @Description 禁用用户，被禁用的用户无法登录，已签发的令牌立即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} map[string]models.UserResponse "更新后的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/disable [post]
+func DisableUser(c *gin.Context) {
+	setUserDisabled(c, true, "用户已禁用")
+}
+
+// EnableUser 启用用户
+// @Summary 启用用户
+// @Description 重新启用被禁用的用户
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} map[string]models.UserResponse "更新后的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/enable [post]
+func EnableUser(c *gin.Context) {
+	setUserDisabled(c, false, "用户已启用")
+}
+
+// setUserDisabled 禁用或启用用户
+func setUserDisabled(c *gin.Context, disabled bool, message string) {
+	data, err := userService.SetUserDisabled(c.GetUint("user_id"), c.Param("id"), disabled)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// ResetUserPassword 重置用户密码
+// @Summary 重置用户密码
+// @Description 为用户设置新密码
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param password body models.UserPasswordResetRequest true "新密码"
+// @Success 200 {object} map[string]string "重置成功"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/reset-password [post]
+func ResetUserPassword(c *gin.Context) {
+	var req models.UserPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := userService.ResetPassword(c.GetUint("user_id"), c.Param("id"), req.Password); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "密码重置成功"})
+}
+
+// SetUserTwoFactorRequired 设置是否强制启用两步验证
+// @Summary 设置是否强制启用两步验证
+// @Description 强制用户启用两步验证，用户启用前只能访问个人账号和两步验证相关接口
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param required body models.UserTwoFactorRequiredRequest true "是否强制"
+// @Success 200 {object} map[string]models.UserResponse "更新后的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/2fa [put]
+func SetUserTwoFactorRequired(c *gin.Context) {
+	var req models.UserTwoFactorRequiredRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := userService.SetTwoFactorRequired(c.GetUint("user_id"), c.Param("id"), req.Required)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "两步验证要求已更新",
+		"data":    data,
+	})
+}
+
+// ResetUserTwoFactor 重置用户的两步验证
+// @Summary 重置用户的两步验证
+// @Description 清除用户的验证器绑定和恢复码（用户丢失验证器时使用），用户的所有会话同时失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} map[string]models.UserResponse "更新后的用户"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/2fa [delete]
+func ResetUserTwoFactor(c *gin.Context) {
+	data, err := userService.ResetTwoFactor(c.GetUint("user_id"), c.Param("id"))
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "两步验证已重置",
+		"data":    data,
+	})
+}
+
+// UnlockUser 解除用户登录锁定
+// @Summary 解除用户登录锁定
+// @Description 清除用户名的登录失败计数和锁定状态（IP维度的限制不受影响）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} map[string]models.UserResponse "用户信息"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 404 {object} map[string]string "用户不存在"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	data, err := userService.UnlockUser(c.GetUint("user_id"), c.Param("id"))
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "用户登录锁定已解除",
+		"data":    data,
+	})
+}
+
+// GetLoginAttempts 获取登录记录
+// @Summary 获取登录记录
+// @Description 按时间倒序获取登录尝试记录，可按用户名、IP和是否成功过滤（需要所有者或管理员角色）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param username query string false "用户名"
+// @Param ip query string false "IP地址"
+// @Param success query bool false "是否成功"
+// @Param limit query int false "返回数量（默认100，最大1000）"
+// @Success 200 {object} map[string][]models.LoginAttempt "登录记录"
+// @Failure 400 {object} map[string]string "请求错误"
+// @Failure 401 {object} map[string]string "未授权"
+// @Failure 403 {object} map[string]string "权限不足"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /login-attempts [get]
+func GetLoginAttempts(c *gin.Context) {
+	var query models.LoginAttemptQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	data, err := loginGuard.ListAttempts(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取成功",
+		"data":    data,
+	})
+}
+
+// respondUserError 将用户管理错误映射为HTTP响应
+func respondUserError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "用户不存在":
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+	case message == "用户名已存在":
+		c.JSON(http.StatusConflict, gin.H{"error": message})
+	case message == "权限不足":
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+	case message == "无效的用户ID" || message == "用户名不能为空" || message == "不能禁用或启用自己" ||
+		message == "不能修改自己的角色" || message == "至少需要保留一个可用的所有者" ||
+		message == "不能重置自己的两步验证" ||
+		strings.HasPrefix(message, "无效的角色"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 基线迁移（版本1）使用的表结构快照
+// 即引入版本化迁移前由 AutoMigrate 维护的表结构，字符串列的长度与支持 PostgreSQL/MySQL 时的定义一致
+// 快照不能随模型修改，之后新增的表和列由后续迁移添加
+
+type baselineUser struct {
+	ID                uint   `gorm:"primarykey"`
+	Username          string `gorm:"unique;not null"`
+	Password          string `gorm:"not null"`
+	Role              string `gorm:"not null;default:'viewer'"`
+	Disabled          bool   `gorm:"not null;default:false"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	TOTPSecret        string
+	TOTPPendingSecret string
+	TOTPEnabled       bool `gorm:"not null;default:false"`
+	TOTPRequired      bool `gorm:"not null;default:false"`
+	TOTPLastStep      int64
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineServer struct {
+	ID             uint         `gorm:"primarykey"`
+	Identifier     string       `gorm:"not null"`
+	SessionName    string       `gorm:"default:'ARK Server'"`
+	ClusterID      string       `gorm:"default:''"`
+	Port           int          `gorm:"not null;default:7777"`
+	QueryPort      int          `gorm:"not null;default:27015"`
+	RCONPort       int          `gorm:"not null;default:32330"`
+	AdminPassword  string       `gorm:"size:512;not null;default:password"`
+	Map            string       `gorm:"default:'TheIsland'"`
+	MaxPlayers     int          `gorm:"not null;default:70"`
+	GameModIds     string       `gorm:"size:2048;default:''"`
+	Status         string       `gorm:"default:'stopped'"`
+	AutoRestart    bool         `gorm:"default:true"`
+	UserID         uint         `gorm:"not null"`
+	User           baselineUser `gorm:"foreignKey:UserID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	ServerArgsJSON string         `gorm:"size:4096;default:'{}'"`
+}
+
+func (baselineServer) TableName() string { return "servers" }
+
+type baselineMod struct {
+	WorkshopID  string `gorm:"primarykey"`
+	Name        string `gorm:"default:''"`
+	Size        int64  `gorm:"default:0"`
+	LastUpdated time.Time
+	FetchedAt   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (baselineMod) TableName() string { return "mods" }
+
+type baselineServerMod struct {
+	ID         uint   `gorm:"primarykey"`
+	ServerID   uint   `gorm:"not null;uniqueIndex:idx_server_mod"`
+	WorkshopID string `gorm:"size:64;not null;uniqueIndex:idx_server_mod"`
+	Position   int    `gorm:"not null;default:0"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (baselineServerMod) TableName() string { return "server_mods" }
+
+type baselineServerPermission struct {
+	ID          uint   `gorm:"primarykey"`
+	ServerID    uint   `gorm:"not null;uniqueIndex:idx_server_permission_user"`
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_server_permission_user"`
+	Permissions string `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (baselineServerPermission) TableName() string { return "server_permissions" }
+
+type baselineAPIToken struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	TokenHash  string `gorm:"size:64;not null;uniqueIndex"`
+	Scopes     string `gorm:"not null;default:''"`
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"not null;default:''"`
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+func (baselineAPIToken) TableName() string { return "api_tokens" }
+
+type baselineSession struct {
+	ID           uint   `gorm:"primarykey"`
+	UserID       uint   `gorm:"not null;index"`
+	UserAgent    string `gorm:"not null;default:''"`
+	IP           string `gorm:"not null;default:''"`
+	LastSeenAt   time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time `gorm:"index"`
+	RevokeReason string     `gorm:"not null;default:''"`
+	CreatedAt    time.Time
+}
+
+func (baselineSession) TableName() string { return "sessions" }
+
+type baselineRefreshToken struct {
+	ID        uint   `gorm:"primarykey"`
+	SessionID uint   `gorm:"not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (baselineRefreshToken) TableName() string { return "refresh_tokens" }
+
+type baselineRecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineRecoveryCode) TableName() string { return "recovery_codes" }
+
+type baselineLoginChallenge struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	Attempts  int    `gorm:"not null;default:0"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineLoginChallenge) TableName() string { return "login_challenges" }
+
+type baselineLoginAttempt struct {
+	ID        uint   `gorm:"primarykey"`
+	Username  string `gorm:"index"`
+	IP        string `gorm:"index"`
+	UserAgent string
+	Success   bool
+	Reason    string
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (baselineLoginAttempt) TableName() string { return "login_attempts" }
+
+type baselineLoginThrottle struct {
+	ID            uint   `gorm:"primarykey"`
+	Kind          string `gorm:"size:16;not null;uniqueIndex:idx_login_throttle_subject"`
+	Value         string `gorm:"size:191;not null;uniqueIndex:idx_login_throttle_subject"`
+	Failures      int    `gorm:"not null;default:0"`
+	LastFailureAt time.Time
+	NextAttemptAt time.Time
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
+
+func (baselineLoginThrottle) TableName() string { return "login_throttles" }
+
+type baselineAuditEntry struct {
+	ID        uint `gorm:"primarykey"`
+	ActorID   uint `gorm:"index"`
+	ActorName string
+	TokenID   uint
+	Action    string `gorm:"index"`
+	Method    string
+	Path      string
+	ServerID  *uint `gorm:"index"`
+	Params    string
+	Result    string `gorm:"index"`
+	Status    int
+	Error     string
+	IP        string
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (baselineAuditEntry) TableName() string { return "audit_entries" }
+
+type baselineUserIdentity struct {
+	ID          uint   `gorm:"primarykey"`
+	UserID      uint   `gorm:"not null;index"`
+	Issuer      string `gorm:"size:191;not null;uniqueIndex:idx_user_identity_subject"`
+	Subject     string `gorm:"size:191;not null;uniqueIndex:idx_user_identity_subject"`
+	Email       string
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+func (baselineUserIdentity) TableName() string { return "user_identities" }
+
+// baselineOIDCAuthRequest 引入版本化迁移时 OIDC 登录请求使用默认命名的表名，版本3删除该表
+type baselineOIDCAuthRequest struct {
+	ID           uint   `gorm:"primarykey"`
+	StateHash    string `gorm:"size:128;not null;uniqueIndex"`
+	CodeVerifier string `gorm:"not null"`
+	Nonce        string `gorm:"not null"`
+	LinkUserID   uint
+	UserID       uint
+	ExchangeHash string `gorm:"size:64;index"`
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+func (baselineOIDCAuthRequest) TableName() string { return "o_id_c_auth_requests" }
+
+// baselineModels 基线迁移创建的表
+var baselineModels = []interface{}{
+	&baselineUser{}, &baselineServer{}, &baselineMod{}, &baselineServerMod{}, &baselineServerPermission{},
+	&baselineAPIToken{}, &baselineSession{}, &baselineRefreshToken{}, &baselineRecoveryCode{}, &baselineLoginChallenge{},
+	&baselineLoginAttempt{}, &baselineLoginThrottle{}, &baselineAuditEntry{}, &baselineUserIdentity{}, &baselineOIDCAuthRequest{},
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ark-server-commander/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 复制数据时每批读取的行数
+const copyBatchSize = 500
+
+// copyModels 复制数据的表，被引用的表在前（新增数据表时需要在这里登记）
+var copyModels = []interface{}{
+	&models.User{},
+	&models.Node{},
+	&models.ServerImage{},
+	&models.RegistryCredential{},
+	&models.Server{},
+	&models.Mod{},
+	&models.ServerMod{},
+	&models.ServerPermission{},
+	&models.APIToken{},
+	&models.Session{},
+	&models.RefreshToken{},
+	&models.RecoveryCode{},
+	&models.LoginChallenge{},
+	&models.LoginAttempt{},
+	&models.LoginThrottle{},
+	&models.AuditEntry{},
+	&models.UserIdentity{},
+	&models.OIDCAuthRequest{},
+}
+
+// CopyDatabase 将源数据库的全部数据复制到目标数据库（如从 SQLite 迁移到 PostgreSQL）
+// 目标数据库会先执行迁移建表，且所有数据表必须为空；加密字段按密文原样复制
+// progress: 每复制完一张表回调一次，可为 nil
+func CopyDatabase(source, target *gorm.DB, progress func(table string, rows int)) error {
+	statuses, err := newMigrator(source).status()
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			return fmt.Errorf("源数据库还有未执行的迁移 %d_%s，请先执行 migrate up", status.Version, status.Name)
+		}
+	}
+
+	if _, err := MigrateUp(target, 0); err != nil {
+		return fmt.Errorf("目标数据库迁移失败: %w", err)
+	}
+
+	tables := make([]*schema.Schema, 0, len(copyModels))
+	for _, model := range copyModels {
+		statement := &gorm.Statement{DB: target}
+		if err := statement.Parse(model); err != nil {
+			return fmt.Errorf("解析数据表结构失败: %w", err)
+		}
+		var count int64
+		if err := target.Model(model).Unscoped().Count(&count).Error; err != nil {
+			return fmt.Errorf("读取目标数据表 %s 失败: %w", statement.Schema.Table, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("目标数据库的 %s 表不为空", statement.Schema.Table)
+		}
+		tables = append(tables, statement.Schema)
+	}
+
+	return target.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			rows, err := copyTable(source, tx, table)
+			if err != nil {
+				return fmt.Errorf("复制数据表 %s 失败: %w", table.Table, err)
+			}
+			if err := resetSequence(tx, table); err != nil {
+				return fmt.Errorf("重置 %s 自增序列失败: %w", table.Table, err)
+			}
+			if progress != nil {
+				progress(table.Table, rows)
+			}
+		}
+		return nil
+	})
+}
+
+// copyTable 按主键顺序分批复制一张表，返回复制的行数
+// 按原始列值复制而不经过模型，避免默认值覆盖零值（如 auto_restart=false）和重复加密
+func copyTable(source, target *gorm.DB, table *schema.Schema) (int, error) {
+	orderColumns := make([]string, 0, len(table.PrimaryFieldDBNames))
+	for _, name := range table.PrimaryFieldDBNames {
+		orderColumns = append(orderColumns, source.Statement.Quote(name))
+	}
+
+	copied := 0
+	for offset := 0; ; offset += copyBatchSize {
+		var rows []map[string]interface{}
+		if err := source.Table(table.Table).Order(strings.Join(orderColumns, ", ")).
+			Limit(copyBatchSize).Offset(offset).Find(&rows).Error; err != nil {
+			return copied, err
+		}
+		if len(rows) == 0 {
+			return copied, nil
+		}
+
+		for i, row := range rows {
+			normalized, err := normalizeRow(table, row)
+			if err != nil {
+				return copied, err
+			}
+			rows[i] = normalized
+		}
+		if err := target.Table(table.Table).Create(&rows).Error; err != nil {
+			return copied, err
+		}
+		copied += len(rows)
+	}
+}
+
+// normalizeRow 将源数据库的列值转换为目标数据库可接受的类型
+// SQLite 的布尔值读出为整数，时间可能读出为字符串；源表中已不在模型里的列会被丢弃
+func normalizeRow(table *schema.Schema, row map[string]interface{}) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		field := table.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			continue
+		}
+
+		switch field.DataType {
+		case schema.Bool:
+			switch v := value.(type) {
+			case int64:
+				value = v != 0
+			case string:
+				value = v == "1" || strings.EqualFold(v, "true")
+			}
+		case schema.Time:
+			if text, ok := value.(string); ok {
+				parsed, err := parseCopiedTime(text)
+				if err != nil {
+					return nil, fmt.Errorf("列 %s 的时间格式无法识别: %s", column, text)
+				}
+				value = parsed
+			}
+		case schema.String:
+			if raw, ok := value.([]byte); ok {
+				value = string(raw)
+			}
+		}
+		normalized[field.DBName] = value
+	}
+	return normalized, nil
+}
+
+// copiedTimeLayouts SQLite 中可能出现的时间格式
+var copiedTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// parseCopiedTime 解析字符串形式的时间
+func parseCopiedTime(text string) (time.Time, error) {
+	for _, layout := range copiedTimeLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", text)
+}
+
+// resetSequence 按已复制的最大ID重置 PostgreSQL 的自增序列（MySQL 和 SQLite 会自动调整）
+func resetSequence(tx *gorm.DB, table *schema.Schema) error {
+	if tx.Dialector.Name() != "postgres" || table.PrioritizedPrimaryField == nil || !table.PrioritizedPrimaryField.AutoIncrement {
+		return nil
+	}
+
+	column := table.PrioritizedPrimaryField.DBName
+	return tx.Exec(
+		fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, ?), (SELECT COALESCE(MAX(%s), 0) + 1 FROM %s), false)",
+			tx.Statement.Quote(column), tx.Statement.Quote(table.Table)),
+		table.Table, column,
+	).Error
+}
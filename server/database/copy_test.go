@@ -0,0 +1,201 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/models"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+}
+
+// migratedTestDB 创建已执行全部迁移的 SQLite 数据库文件
+func migratedTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+
+	db := openTestDB(t, filepath.Join(t.TempDir(), name))
+	m := &migrator{db: db, now: time.Now, migrations: migrations}
+	if _, err := m.up(0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
+
+// seedCopySource 写入需要特别注意的数据：与默认值不同的零值、加密字段、软删除行和字符串主键
+func seedCopySource(t *testing.T, db *gorm.DB) models.Server {
+	t.Helper()
+
+	user := models.User{Username: "alice", Password: "x", Role: models.RoleOwner, TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPEnabled: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	server := models.Server{Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 32330, AdminPassword: "hunter2", UserID: user.ID}
+	if err := db.Create(&server).Error; err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	db.Model(&server).Update("auto_restart", false)
+
+	deleted := models.Server{Identifier: "deleted", Port: 7787, QueryPort: 27025, RCONPort: 32340, AdminPassword: "x", UserID: user.ID}
+	db.Create(&deleted)
+	db.Delete(&deleted)
+
+	db.Create(&models.Mod{WorkshopID: "731604991", Name: "Structures Plus", FetchedAt: time.Now()})
+	db.Create(&models.AuditEntry{Action: "servers.start", ActorID: user.ID, Params: `{"a":1}`, Result: models.AuditResultSuccess})
+	return server
+}
+
+func TestCopyModelsCoverAllTables(t *testing.T) {
+	db := migratedTestDB(t, "ark.db")
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatalf("读取数据表失败: %v", err)
+	}
+
+	registered := map[string]bool{"schema_migrations": true}
+	for _, model := range copyModels {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(model); err != nil {
+			t.Fatalf("解析模型失败: %v", err)
+		}
+		registered[statement.Schema.Table] = true
+	}
+	for _, table := range tables {
+		if !registered[table] && !strings.HasPrefix(table, "sqlite_") {
+			t.Errorf("数据表 %s 没有登记到 copyModels，db copy 会漏掉它", table)
+		}
+	}
+}
+
+func TestCopyDatabase(t *testing.T) {
+	source := migratedTestDB(t, "source.db")
+	original := seedCopySource(t, source)
+	target := openTestDB(t, filepath.Join(t.TempDir(), "target.db"))
+
+	copied := map[string]int{}
+	if err := CopyDatabase(source, target, func(table string, rows int) { copied[table] = rows }); err != nil {
+		t.Fatalf("复制数据库失败: %v", err)
+	}
+	if copied["servers"] != 2 || copied["users"] != 1 || copied["mods"] != 1 {
+		t.Fatalf("复制的行数不正确: %v", copied)
+	}
+	assertCopied(t, target, original)
+
+	// 目标数据库已有数据时拒绝复制
+	if err := CopyDatabase(source, target, nil); err == nil {
+		t.Fatal("目标数据库不为空时应拒绝复制")
+	}
+}
+
+// assertCopied 检查复制后的数据与源数据一致
+func assertCopied(t *testing.T, target *gorm.DB, original models.Server) {
+	t.Helper()
+
+	var server models.Server
+	if err := target.First(&server, original.ID).Error; err != nil {
+		t.Fatalf("读取复制的服务器失败: %v", err)
+	}
+	if server.AutoRestart || server.AdminPassword != "hunter2" || server.Identifier != "island" {
+		t.Fatalf("服务器数据不一致: auto_restart=%v identifier=%s", server.AutoRestart, server.Identifier)
+	}
+
+	var user models.User
+	target.First(&user)
+	if !user.TOTPEnabled || user.TOTPSecret != "JBSWY3DPEHPK3PXP" {
+		t.Fatal("用户的两步验证数据不一致")
+	}
+
+	var total int64
+	target.Unscoped().Model(&models.Server{}).Count(&total)
+	if total != 2 {
+		t.Fatalf("软删除的行也应复制，实际 %d 行", total)
+	}
+
+	// 复制后新增的数据不会与已复制的ID冲突
+	next := models.Server{Identifier: "new", Port: 7797, QueryPort: 27035, RCONPort: 32350, AdminPassword: "x", UserID: user.ID}
+	if err := target.Create(&next).Error; err != nil || next.ID <= original.ID+1 {
+		t.Fatalf("复制后新增数据失败: id=%d (%v)", next.ID, err)
+	}
+}
+
+func TestCopyDatabaseToPostgres(t *testing.T) {
+	// 需要本地 PostgreSQL，例如:
+	// docker run --rm -e POSTGRES_PASSWORD=test -p 5432:5432 postgres:16
+	// TEST_POSTGRES_DSN="host=127.0.0.1 user=postgres password=test dbname=postgres sslmode=disable" go test ./database
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("未设置 TEST_POSTGRES_DSN，跳过 PostgreSQL 测试")
+	}
+
+	admin, err := Connect("postgres", dsn, logger.Silent)
+	if err != nil {
+		t.Fatalf("连接 PostgreSQL 失败: %v", err)
+	}
+	schemaName := fmt.Sprintf("ark_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schemaName).Error; err != nil {
+		t.Fatalf("创建测试 schema 失败: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schemaName + " CASCADE") })
+
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "search_path=" + schemaName
+	} else {
+		dsn += " search_path=" + schemaName
+	}
+	target, err := Connect("postgres", dsn, logger.Silent)
+	if err != nil {
+		t.Fatalf("连接测试 schema 失败: %v", err)
+	}
+
+	source := migratedTestDB(t, "source.db")
+	original := seedCopySource(t, source)
+	if err := CopyDatabase(source, target, nil); err != nil {
+		t.Fatalf("复制到 PostgreSQL 失败: %v", err)
+	}
+	assertCopied(t, target, original)
+}
+
+func TestIndexedStringColumnsFitMySQL(t *testing.T) {
+	// MySQL 不能直接为 TEXT 列建索引，带索引的字符串字段需要指定 size
+	dialector := mysql.Dialector{Config: &mysql.Config{}}
+	cache := &sync.Map{}
+	var problems []string
+	for _, model := range copyModels {
+		parsed, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("解析模型失败: %v", err)
+		}
+		for _, index := range parsed.ParseIndexes() {
+			for _, option := range index.Fields {
+				if option.Field.DataType != schema.String {
+					continue
+				}
+				if dataType := dialector.DataTypeOf(option.Field); strings.Contains(dataType, "text") {
+					problems = append(problems, parsed.Table+"."+option.Field.DBName)
+				}
+			}
+		}
+	}
+	sort.Strings(problems)
+	if len(problems) > 0 {
+		t.Fatalf("以下带索引的字符串列在 MySQL 中会是 TEXT 类型: %v", problems)
+	}
+}
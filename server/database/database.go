@@ -1,12 +1,16 @@
 package database
 
 import (
+	"fmt"
+
 	"ark-server-commander/config"
-	"ark-server-commander/models"
 	"ark-server-commander/utils"
 
 	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -14,24 +18,89 @@ import (
 var DB *gorm.DB
 
 func InitDB() {
-	var err error
+	if err := Open(); err != nil {
+		utils.Fatal("数据库连接失败", zap.Error(err))
+	}
 
-	// 连接SQLite数据库
-	DB, err = gorm.Open(sqlite.Open(config.DBPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	// 执行版本化迁移
+	applied, err := MigrateUp(DB, 0)
+	if err != nil {
+		utils.Fatal("数据库迁移失败", zap.Error(err))
+	}
+	if applied > 0 {
+		utils.Info("数据库迁移完成", zap.Int("applied", applied))
+	}
+
+	// 加密明文存储的敏感字段，并将旧密钥加密的数据轮换到当前密钥
+	if err := encryptSecrets(DB); err != nil {
+		utils.Fatal("加密敏感字段失败", zap.Error(err))
+	}
+
+	utils.Info("数据库初始化成功", zap.String("driver", config.DBDriver))
+}
+
+// Open 按配置连接数据库（不执行迁移）
+func Open() error {
+	dsn := config.DBDSN
+	if config.DBDriver == "sqlite" {
+		dsn = config.DBPath
+	}
 
+	db, err := Connect(config.DBDriver, dsn, logger.Info)
 	if err != nil {
-		utils.Fatal("数据库连接失败", zap.Error(err))
+		return err
+	}
+	DB = db
+	return nil
+}
+
+// Connect 连接指定驱动的数据库
+// driver: sqlite / postgres / mysql
+// dsn: SQLite 为数据库文件路径，其他驱动为连接串
+func Connect(driver, dsn string, logLevel logger.LogLevel) (*gorm.DB, error) {
+	if err := config.ValidateDBDriver(driver, dsn); err != nil {
+		return nil, err
 	}
 
-	// 自动迁移数据库结构
-	err = DB.AutoMigrate(&models.User{}, &models.Server{})
+	var dialector gorm.Dialector
+	switch driver {
+	case "sqlite":
+		dialector = sqlite.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	case "mysql":
+		normalized, err := normalizeMySQLDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		dialector = mysql.Open(normalized)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logLevel),
+	})
 	if err != nil {
-		utils.Fatal("数据库迁移失败", zap.Error(err))
+		return nil, fmt.Errorf("连接 %s 数据库失败: %w", driver, err)
 	}
+	return db, nil
+}
 
-	utils.Info("数据库初始化成功", zap.String("db_path", config.DBPath))
+// normalizeMySQLDSN 补充程序依赖的 MySQL 连接参数
+// parseTime: 时间列读取为 time.Time；clientFoundRows: 更新时返回匹配的行数（与 SQLite/PostgreSQL 一致）
+func normalizeMySQLDSN(dsn string) (string, error) {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("无效的 MySQL 连接串: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.ClientFoundRows = true
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	if _, ok := cfg.Params["charset"]; !ok {
+		cfg.Params["charset"] = "utf8mb4"
+	}
+	return cfg.FormatDSN(), nil
 }
 
 func GetDB() *gorm.DB {
@@ -0,0 +1,30 @@
+// Package dbtest 提供测试使用的数据库
+package dbtest
+
+import (
+	"fmt"
+	"testing"
+
+	"ark-server-commander/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open 为当前测试创建独立的内存数据库，执行全部版本化迁移（与生产环境相同的表结构）并设为 database.DB
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+	database.DB = db
+	return db
+}
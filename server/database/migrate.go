@@ -0,0 +1,290 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 迁移前备份保留的数量
+const migrationBackupKeep = 5
+
+// Migration 一个版本化的数据库迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为 nil 表示不能回滚
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 迁移执行状态
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied    bool
+	AppliedAt  *time.Time
+	Reversible bool
+}
+
+// migrator 执行迁移（测试中可替换迁移列表）
+type migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	backupPath string // 需要备份的 SQLite 数据库文件，为空时不备份
+	now        func() time.Time
+}
+
+// newMigrator 创建使用已登记迁移的执行器
+func newMigrator(db *gorm.DB) *migrator {
+	m := &migrator{db: db, migrations: migrations, now: time.Now}
+	if config.MigrationBackup && db.Dialector.Name() == "sqlite" && config.DBPath != ":memory:" {
+		m.backupPath = config.DBPath
+	}
+	return m
+}
+
+// MigrateUp 执行未执行的迁移直到目标版本（0 表示最新版本），返回执行的数量
+func MigrateUp(db *gorm.DB, target int) (int, error) {
+	return newMigrator(db).up(target)
+}
+
+// MigrateDown 按执行顺序倒序回滚最近的若干个迁移，返回回滚的数量
+func MigrateDown(db *gorm.DB, steps int) (int, error) {
+	return newMigrator(db).down(steps)
+}
+
+// GetMigrationStatus 获取所有迁移的执行状态
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	return newMigrator(db).status()
+}
+
+// status 获取所有迁移的执行状态（包含数据库中有记录但程序不认识的版本）
+func (m *migrator) status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Reversible: migration.Down != nil}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: record.Version, Name: record.Name, Applied: true, AppliedAt: &appliedAt})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// up 执行未执行的迁移直到目标版本
+func (m *migrator) up(target int) (int, error) {
+	if err := m.validate(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	latest := m.migrations[len(m.migrations)-1].Version
+	for version := range applied {
+		if version > latest {
+			return 0, fmt.Errorf("数据库版本 %d 高于程序支持的最新版本 %d，请升级程序或从备份恢复数据库", version, latest)
+		}
+	}
+	if target == 0 {
+		target = latest
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= target {
+			pending = append(pending, migration)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	// 数据库已有数据时先备份，迁移失败可以从备份恢复
+	if len(applied) > 0 || m.hasUserTables() {
+		if _, err := m.backup(fmt.Sprintf("v%d", pending[0].Version)); err != nil {
+			return 0, err
+		}
+		if m.db.Dialector.Name() != "sqlite" {
+			utils.Warn("PostgreSQL/MySQL 数据库不会在迁移前自动备份，请自行备份", zap.Int("pending", len(pending)))
+		}
+	}
+
+	for i, migration := range pending {
+		utils.Info("执行数据库迁移", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: m.now()}).Error
+		})
+		if err != nil {
+			return i, fmt.Errorf("数据库迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return len(pending), nil
+}
+
+// down 倒序回滚最近执行的若干个迁移
+func (m *migrator) down(steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("回滚数量必须大于0")
+	}
+	if err := m.validate(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if len(versions) > steps {
+		versions = versions[:steps]
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+
+	// 先检查所有要回滚的迁移，避免回滚到一半才发现不能继续
+	for _, version := range versions {
+		migration, ok := known[version]
+		if !ok {
+			return 0, fmt.Errorf("数据库迁移 %d 不在当前程序中，无法回滚", version)
+		}
+		if migration.Down == nil {
+			return 0, fmt.Errorf("数据库迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+		}
+	}
+
+	if _, err := m.backup(fmt.Sprintf("down-v%d", versions[0])); err != nil {
+		return 0, err
+	}
+
+	for i, version := range versions {
+		migration := known[version]
+		utils.Info("回滚数据库迁移", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return i, fmt.Errorf("回滚数据库迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return len(versions), nil
+}
+
+// validate 检查迁移列表的版本号唯一且递增
+func (m *migrator) validate() error {
+	if len(m.migrations) == 0 {
+		return fmt.Errorf("没有登记数据库迁移")
+	}
+	for i, migration := range m.migrations {
+		if migration.Version <= 0 || migration.Up == nil {
+			return fmt.Errorf("无效的数据库迁移: %d_%s", migration.Version, migration.Name)
+		}
+		if i > 0 && migration.Version <= m.migrations[i-1].Version {
+			return fmt.Errorf("数据库迁移的版本号必须递增: %d", migration.Version)
+		}
+	}
+	return nil
+}
+
+// applied 读取已执行的迁移（迁移记录表不存在时自动创建）
+func (m *migrator) applied() (map[int]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var records []SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// hasUserTables 数据库中是否已有业务数据表（升级前由 AutoMigrate 创建的数据库没有迁移记录）
+func (m *migrator) hasUserTables() bool {
+	return m.db.Migrator().HasTable("users")
+}
+
+// backup 备份 SQLite 数据库文件，并删除较早的备份
+// 返回: 备份文件路径，未启用备份时为空
+func (m *migrator) backup(reason string) (string, error) {
+	if m.backupPath == "" {
+		return "", nil
+	}
+	if _, err := os.Stat(m.backupPath); err != nil {
+		return "", nil
+	}
+
+	target := fmt.Sprintf("%s.pre-migrate-%s-%s.bak", m.backupPath, m.now().Format("20060102-150405"), reason)
+	// VACUUM INTO 生成一致的数据库副本，不受 WAL 和其他连接影响
+	if err := m.db.Exec("VACUUM INTO ?", target).Error; err != nil {
+		return "", fmt.Errorf("迁移前备份数据库失败: %w", err)
+	}
+	utils.Info("迁移前已备份数据库", zap.String("path", target))
+
+	backups, err := filepath.Glob(m.backupPath + ".pre-migrate-*.bak")
+	if err == nil && len(backups) > migrationBackupKeep {
+		// 文件名中的时间戳保证按名称排序即按时间排序
+		sort.Strings(backups)
+		for _, old := range backups[:len(backups)-migrationBackupKeep] {
+			if err := os.Remove(old); err != nil {
+				utils.Warn("删除旧的数据库备份失败", zap.String("path", old), zap.Error(err))
+			}
+		}
+	}
+	return target, nil
+}
@@ -0,0 +1,318 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// openTestDB 打开测试数据库，dsn 为空时使用独立的内存数据库
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	if dsn == "" {
+		dsn = fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	}
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	return db
+}
+
+// tableMigration 创建/删除一张表的测试迁移
+func tableMigration(version int, table string) Migration {
+	return Migration{
+		Version: version,
+		Name:    "create_" + table,
+		Up:      func(tx *gorm.DB) error { return tx.Exec("CREATE TABLE " + table + " (id INTEGER PRIMARY KEY)").Error },
+		Down:    func(tx *gorm.DB) error { return tx.Exec("DROP TABLE " + table).Error },
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db := openTestDB(t, "")
+	m := &migrator{db: db, now: time.Now, migrations: []Migration{
+		tableMigration(1, "alpha"),
+		tableMigration(2, "beta"),
+		tableMigration(3, "gamma"),
+	}}
+
+	// 迁移到指定版本
+	if applied, err := m.up(2); err != nil || applied != 2 {
+		t.Fatalf("期望执行 2 个迁移，实际 %d (%v)", applied, err)
+	}
+	if db.Migrator().HasTable("gamma") {
+		t.Fatal("不应执行目标版本之后的迁移")
+	}
+
+	if applied, err := m.up(0); err != nil || applied != 1 {
+		t.Fatalf("期望执行剩余的 1 个迁移，实际 %d (%v)", applied, err)
+	}
+	if applied, err := m.up(0); err != nil || applied != 0 {
+		t.Fatalf("重复执行不应有迁移，实际 %d (%v)", applied, err)
+	}
+
+	if reverted, err := m.down(2); err != nil || reverted != 2 {
+		t.Fatalf("期望回滚 2 个迁移，实际 %d (%v)", reverted, err)
+	}
+	if !db.Migrator().HasTable("alpha") || db.Migrator().HasTable("beta") || db.Migrator().HasTable("gamma") {
+		t.Fatal("回滚后的表结构不正确")
+	}
+
+	statuses, err := m.status()
+	if err != nil || len(statuses) != 3 {
+		t.Fatalf("读取迁移状态失败: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied || statuses[2].Applied {
+		t.Fatalf("迁移状态不正确: %+v", statuses)
+	}
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	db := openTestDB(t, "")
+	failing := Migration{
+		Version: 2,
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE half_done (id INTEGER PRIMARY KEY)").Error; err != nil {
+				return err
+			}
+			return fmt.Errorf("boom")
+		},
+	}
+	m := &migrator{db: db, now: time.Now, migrations: []Migration{tableMigration(1, "alpha"), failing}}
+
+	applied, err := m.up(0)
+	if err == nil || applied != 1 {
+		t.Fatalf("期望第二个迁移失败，实际执行 %d (%v)", applied, err)
+	}
+	if db.Migrator().HasTable("half_done") {
+		t.Fatal("失败的迁移应整体回滚")
+	}
+
+	statuses, _ := m.status()
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("失败的迁移不应记录为已执行: %+v", statuses)
+	}
+
+	// 不可回滚的迁移拒绝回滚
+	db.Create(&SchemaMigration{Version: 2, Name: "broken", AppliedAt: time.Now()})
+	if _, err := m.down(1); err == nil {
+		t.Fatal("没有 Down 的迁移不应被回滚")
+	}
+}
+
+func TestMigrateRejectsNewerDatabase(t *testing.T) {
+	db := openTestDB(t, "")
+	m := &migrator{db: db, now: time.Now, migrations: []Migration{tableMigration(1, "alpha")}}
+	if _, err := m.up(0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	db.Create(&SchemaMigration{Version: 5, Name: "from_newer_release", AppliedAt: time.Now()})
+	if _, err := m.up(0); err == nil {
+		t.Fatal("数据库版本高于程序时应拒绝启动")
+	}
+}
+
+func TestMigrateBacksUpExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ark.db")
+	db := openTestDB(t, path)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var list []Migration
+	for version := 1; version <= migrationBackupKeep+2; version++ {
+		list = append(list, tableMigration(version, fmt.Sprintf("t%d", version)))
+	}
+	m := &migrator{db: db, backupPath: path, now: func() time.Time { return clock }}
+
+	// 新数据库第一次迁移不需要备份
+	m.migrations = list[:1]
+	if _, err := m.up(0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	if backups, _ := filepath.Glob(path + ".pre-migrate-*.bak"); len(backups) != 0 {
+		t.Fatalf("新数据库不应备份，实际 %d 个备份", len(backups))
+	}
+
+	// 之后每次迁移前备份，只保留最近的几个
+	for i := 2; i <= len(list); i++ {
+		clock = clock.Add(time.Minute)
+		m.migrations = list[:i]
+		if _, err := m.up(0); err != nil {
+			t.Fatalf("执行迁移失败: %v", err)
+		}
+	}
+	backups, _ := filepath.Glob(path + ".pre-migrate-*.bak")
+	if len(backups) != migrationBackupKeep {
+		t.Fatalf("期望保留 %d 个备份，实际 %d", migrationBackupKeep, len(backups))
+	}
+
+	// 备份是可以打开的完整数据库
+	backup := openTestDB(t, backups[len(backups)-1])
+	var count int64
+	if err := backup.Model(&SchemaMigration{}).Count(&count).Error; err != nil || count != int64(len(list)-1) {
+		t.Fatalf("备份中应有 %d 条迁移记录，实际 %d (%v)", len(list)-1, count, err)
+	}
+}
+
+func TestBaselineMigratesLegacyDatabase(t *testing.T) {
+	db := openTestDB(t, "")
+
+	// 升级前由 AutoMigrate 创建的数据库，已有没有角色的用户和服务器
+	if err := db.AutoMigrate(&baselineUser{}, &baselineServer{}); err != nil {
+		t.Fatalf("创建旧表结构失败: %v", err)
+	}
+	db.Exec("INSERT INTO users (username, password, role, created_at, updated_at) VALUES ('alice', 'x', '', ?, ?)", time.Now(), time.Now())
+	db.Exec("INSERT INTO servers (identifier, user_id, created_at, updated_at) VALUES ('ark', 1, ?, ?)", time.Now(), time.Now())
+
+	m := &migrator{db: db, now: time.Now, migrations: migrations}
+	if _, err := m.up(0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	var user models.User
+	db.First(&user)
+	if user.Role != models.RoleOwner {
+		t.Fatalf("最早的用户应被设为所有者，实际为 %q", user.Role)
+	}
+	if !db.Migrator().HasTable(&models.AuditEntry{}) {
+		t.Fatal("基线迁移应补齐缺少的表")
+	}
+
+	// 已有的服务器归属到 local 节点
+	var local models.Node
+	if err := db.Where("name = ?", "local").First(&local).Error; err != nil || !local.IsLocal() {
+		t.Fatalf("应创建代表面板所在主机的 local 节点: %v", err)
+	}
+	var server models.Server
+	db.First(&server)
+	if server.NodeID != local.ID {
+		t.Fatalf("已有服务器应归属到 local 节点，实际为 %d", server.NodeID)
+	}
+
+	// 已有的服务器使用原来固定的镜像，并登记为默认镜像
+	if server.Image != models.DefaultServerImage {
+		t.Fatalf("已有服务器应使用默认镜像，实际为 %q", server.Image)
+	}
+	var image models.ServerImage
+	if err := db.Where("image = ?", models.DefaultServerImage).First(&image).Error; err != nil || !image.IsDefault {
+		t.Fatalf("应登记默认镜像: %v", err)
+	}
+}
+
+func TestMigrationsCreateCurrentSchema(t *testing.T) {
+	db := openTestDB(t, "")
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	// 新建的数据库执行全部迁移后，每个模型的表和列都应存在
+	for _, model := range copyModels {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(model); err != nil {
+			t.Fatalf("解析模型失败: %v", err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("缺少表 %s", statement.Schema.Table)
+			continue
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("表 %s 缺少列 %s", statement.Schema.Table, field.DBName)
+			}
+		}
+	}
+	if db.Migrator().HasTable("o_id_c_auth_requests") {
+		t.Error("旧的 OIDC 登录请求表应已删除")
+	}
+}
+
+func TestMigrationsRollBackToBaseline(t *testing.T) {
+	db := openTestDB(t, "")
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	// 回滚到版本3，版本4之后添加的表和列都应删除
+	if _, err := MigrateDown(db, len(migrations)-3); err != nil {
+		t.Fatalf("回滚迁移失败: %v", err)
+	}
+	for _, table := range []string{"nodes", "server_images", "registry_credentials"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("回滚后不应存在表 %s", table)
+		}
+	}
+	for _, column := range []string{"node_id", "memory_limit_mb", "image", "network_mode"} {
+		if db.Migrator().HasColumn("servers", column) {
+			t.Errorf("回滚后不应存在列 servers.%s", column)
+		}
+	}
+
+	// 回滚后可以重新迁移
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("重新执行迁移失败: %v", err)
+	}
+	if !db.Migrator().HasTable(&models.Node{}) || !db.Migrator().HasColumn(&models.Server{}, "bind_ip") {
+		t.Fatal("重新迁移后的表结构不正确")
+	}
+}
+
+func TestMigrationsDropAdminPasswordDefault(t *testing.T) {
+	db := openTestDB(t, "")
+	if _, err := MigrateUp(db, 9); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	db.Exec("INSERT INTO servers (identifier, user_id, created_at, updated_at) VALUES ('ark', 1, ?, ?)", time.Now(), time.Now())
+
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	var columns []struct {
+		Name      string
+		DfltValue *string
+	}
+	db.Raw("SELECT name, dflt_value FROM pragma_table_info('servers')").Scan(&columns)
+	for _, column := range columns {
+		if column.Name == "admin_password" && column.DfltValue != nil {
+			t.Fatalf("admin_password 列不应有默认值，实际为 %s", *column.DfltValue)
+		}
+	}
+
+	// 重建表后保留已有数据、索引和其他列的默认值
+	var server models.Server
+	if err := db.First(&server).Error; err != nil || server.Identifier != "ark" || server.AdminPassword != "password" {
+		t.Fatalf("重建表后应保留已有的服务器: %+v (%v)", server, err)
+	}
+	if !db.Migrator().HasIndex(&models.Server{}, "idx_servers_deleted_at") {
+		t.Fatal("重建表后应保留索引")
+	}
+	if err := db.Exec("INSERT INTO servers (identifier, user_id) VALUES ('no-password', 1)").Error; err == nil {
+		t.Fatal("未设置 admin_password 时应插入失败")
+	}
+	if err := db.Exec("INSERT INTO servers (identifier, user_id, admin_password) VALUES ('ark2', 1, 'x')").Error; err != nil {
+		t.Fatalf("插入服务器失败: %v", err)
+	}
+	var created models.Server
+	db.Where("identifier = ?", "ark2").First(&created)
+	if created.Port != 7777 || created.ServerArgsJSON != "{}" {
+		t.Fatalf("其他列的默认值不应改变: %+v", created)
+	}
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrations 所有数据库迁移，按版本号递增排列
+// 已发布的迁移不能修改或删除，表结构和数据的变化需要追加新的迁移
+// 迁移使用固定的表结构快照（baseline.go、snapshots.go）建表和加列，不引用会继续变化的模型
+var migrations = []Migration{
+	{
+		// 引入版本化迁移前由 AutoMigrate 维护的表结构，对已有数据库只会补齐缺少的表和列
+		Version: 1,
+		Name:    "baseline_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineModels...)
+		},
+	},
+	{
+		// 升级前创建的用户没有角色，将最早的用户设为所有者
+		Version: 2,
+		Name:    "assign_owner_role",
+		Up:      ensureOwner,
+		Down:    func(tx *gorm.DB) error { return nil },
+	},
+	{
+		// OIDCAuthRequest 改用 oidc_auth_requests 表名，登录请求只保存几分钟，直接删除旧表
+		Version: 3,
+		Name:    "drop_o_id_c_auth_requests",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("o_id_c_auth_requests")
+		},
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		// 多节点支持：新增节点表，已有的服务器归属到代表面板所在主机的 local 节点
+		Version: 4,
+		Name:    "add_nodes",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v4Node{}); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &v4Server{}, "NodeID"); err != nil {
+				return err
+			}
+			return ensureLocalNode(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &v4Server{}, "NodeID"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&v4Node{})
+		},
+	},
+	{
+		// 服务器容器资源限制
+		Version: 5,
+		Name:    "add_server_resources",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &v5Server{}, serverResourceFields...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &v5Server{}, serverResourceFields...)
+		},
+	},
+	{
+		// 镜像登记表和服务器使用的镜像，已有的服务器继续使用原来固定的镜像
+		Version: 6,
+		Name:    "add_server_images",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v6ServerImage{}); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &v6Server{}, "Image"); err != nil {
+				return err
+			}
+			return ensureDefaultServerImage(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &v6Server{}, "Image"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&v6ServerImage{})
+		},
+	},
+	{
+		// 镜像仓库登录凭据
+		Version: 7,
+		Name:    "add_registry_credentials",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v7RegistryCredential{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v7RegistryCredential{})
+		},
+	},
+	{
+		// 服务器容器网络模式和绑定IP，已有的服务器继续使用默认的桥接网络
+		Version: 8,
+		Name:    "add_server_network",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &v8Server{}, serverNetworkFields...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &v8Server{}, serverNetworkFields...)
+		},
+	},
+	{
+		// 版本3删除旧表后创建 oidc_auth_requests 表（此前由基线迁移按当前模型创建，从版本2升级的数据库缺少该表）
+		Version: 9,
+		Name:    "add_oidc_auth_requests",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v9OIDCAuthRequest{})
+		},
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		// 基线的服务器表 admin_password 列带有默认值 'password'，未设置RCON密码的服务器会使用该弱密码，删除默认值
+		// 回滚时不恢复该默认值
+		Version: 10,
+		Name:    "drop_admin_password_default",
+		Up: func(tx *gorm.DB) error {
+			return dropColumnDefault(tx, "servers", "admin_password")
+		},
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		// OIDC 登录请求绑定发起登录的浏览器，升级前创建的登录请求没有绑定值，回调时会被拒绝
+		Version: 11,
+		Name:    "add_oidc_binding_hash",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &v11OIDCAuthRequest{}, "BindingHash")
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, &v11OIDCAuthRequest{}, "BindingHash")
+		},
+	},
+}
+
+// serverResourceFields 版本5新增的服务器资源限制字段
+var serverResourceFields = []string{
+	"MemoryLimitMB", "MemoryReservationMB", "CPUShares", "CPULimit", "PidsLimit", "Ulimits", "RestartMaxRetries",
+}
+
+// serverNetworkFields 版本8新增的服务器网络字段
+var serverNetworkFields = []string{"NetworkMode", "NetworkName", "BindIP"}
+
+// ensureOwner 存在用户但没有所有者时，将最早创建的用户设为所有者
+func ensureOwner(db *gorm.DB) error {
+	var ownerCount int64
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleOwner).Count(&ownerCount).Error; err != nil {
+		return err
+	}
+	if ownerCount > 0 {
+		return nil
+	}
+
+	var first models.User
+	if err := db.Order("id").Limit(1).Find(&first).Error; err != nil || first.ID == 0 {
+		return err
+	}
+
+	utils.Info("已将最早创建的用户设为所有者", zap.String("username", first.Username))
+	return db.Model(&first).Updates(map[string]interface{}{"role": models.RoleOwner, "disabled": false}).Error
+}
+
+// ensureLocalNode 存在未分配节点的服务器时，创建 local 节点并将这些服务器归属到该节点
+// 新建的数据库不创建节点，未配置任何节点时服务器运行在面板所在主机上
+func ensureLocalNode(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&v4Server{}).Where("node_id = ?", 0).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	local := v4Node{Name: "local", Labels: "{}", Schedulable: true, Status: models.NodeStatusUnknown}
+	if err := tx.Create(&local).Error; err != nil {
+		return fmt.Errorf("创建 local 节点失败: %w", err)
+	}
+	return tx.Model(&v4Server{}).Where("node_id = ?", 0).UpdateColumn("node_id", local.ID).Error
+}
+
+// ensureDefaultServerImage 存在服务器时，登记原来固定使用的镜像并设为默认，未设置镜像的服务器使用该镜像
+// 新建的数据库不登记镜像，未设置默认镜像时使用 models.DefaultServerImage
+func ensureDefaultServerImage(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&v6Server{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var image v6ServerImage
+	if err := tx.Where("image = ?", models.DefaultServerImage).FirstOrCreate(&image, v6ServerImage{
+		Image: models.DefaultServerImage, Description: "ARK: Survival Evolved 服务器", IsDefault: true,
+	}).Error; err != nil {
+		return fmt.Errorf("登记默认镜像失败: %w", err)
+	}
+	return tx.Model(&v6Server{}).Where("image = ?", "").UpdateColumn("image", models.DefaultServerImage).Error
+}
+
+// addColumns 为已有的表添加列，用于追加字段的迁移
+func addColumns(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if err := tx.Migrator().AddColumn(model, field); err != nil {
+			return fmt.Errorf("添加列 %s 失败: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// dropColumns 删除列（列不存在时跳过），用于回滚追加字段的迁移
+func dropColumns(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if !tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().DropColumn(model, field); err != nil {
+			return fmt.Errorf("删除列 %s 失败: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// dropColumnDefault 删除列的默认值
+// SQLite 不支持修改列定义，需要按修改后的建表语句重建表
+func dropColumnDefault(tx *gorm.DB, table, column string) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return tx.Exec("ALTER TABLE ? ALTER COLUMN ? DROP DEFAULT", clause.Table{Name: table}, clause.Column{Name: column}).Error
+	}
+
+	columnDefault := regexp.MustCompile("(`" + regexp.QuoteMeta(column) + "`[^,]*?) DEFAULT (?:\"[^\"]*\"|'[^']*'|[^\\s,)]+)")
+	return rebuildSQLiteTable(tx, table, func(createSQL string) string {
+		return columnDefault.ReplaceAllString(createSQL, "$1")
+	})
+}
+
+// rebuildSQLiteTable 按 rewrite 修改后的建表语句重建 SQLite 表，保留数据和索引
+// 按 SQLite 文档的做法先建新表再删除旧表，避免重命名旧表时其他表的外键跟随改名
+func rebuildSQLiteTable(tx *gorm.DB, table string, rewrite func(createSQL string) string) error {
+	var createSQL string
+	if err := tx.Raw("SELECT sql FROM sqlite_master WHERE type = ? AND name = ?", "table", table).Scan(&createSQL).Error; err != nil {
+		return err
+	}
+	var indexSQL []string
+	if err := tx.Raw("SELECT sql FROM sqlite_master WHERE type = ? AND tbl_name = ? AND sql IS NOT NULL", "index", table).Scan(&indexSQL).Error; err != nil {
+		return err
+	}
+
+	// 表名可能带反引号或双引号（SQLite 重命名表后改写为双引号）
+	rebuilt := table + "__rebuild"
+	name := regexp.MustCompile("^CREATE TABLE [`\"]?" + regexp.QuoteMeta(table) + "[`\"]? ")
+	if !name.MatchString(createSQL) {
+		return fmt.Errorf("无法解析表 %s 的建表语句", table)
+	}
+	createSQL = name.ReplaceAllLiteralString(rewrite(createSQL), "CREATE TABLE `"+rebuilt+"` ")
+
+	statements := []string{
+		createSQL,
+		fmt.Sprintf("INSERT INTO `%s` SELECT * FROM `%s`", rebuilt, table),
+		fmt.Sprintf("DROP TABLE `%s`", table),
+		fmt.Sprintf("ALTER TABLE `%s` RENAME TO `%s`", rebuilt, table),
+	}
+	for _, statement := range append(statements, indexSQL...) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("重建表 %s 失败: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"fmt"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// encryptedColumns 加密存储的字段（新增 models.EncryptedString 字段时需要在这里登记）
+var encryptedColumns = []struct {
+	model  interface{}
+	column string
+}{
+	{&models.Server{}, "admin_password"},
+	{&models.User{}, "totp_secret"},
+	{&models.User{}, "totp_pending_secret"},
+	{&models.Node{}, "tls_key"},
+	{&models.Node{}, "ssh_key"},
+	{&models.RegistryCredential{}, "password"},
+}
+
+// encryptSecrets 将明文或使用旧密钥加密的字段用当前密钥重新加密
+// 升级后首次启动会加密已有的明文数据；更换 ENCRYPTION_KEYS 中的当前密钥后重启即完成轮换
+func encryptSecrets(db *gorm.DB) error {
+	for _, target := range encryptedColumns {
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		if err := db.Model(target.model).Unscoped().Select("id, " + target.column + " AS value").Scan(&rows).Error; err != nil {
+			return fmt.Errorf("读取 %s 失败: %w", target.column, err)
+		}
+
+		updated := 0
+		for _, row := range rows {
+			if !utils.SecretNeedsRotation(row.Value) {
+				continue
+			}
+			plaintext, err := utils.DecryptSecret(row.Value)
+			if err != nil {
+				return fmt.Errorf("解密 %s (id=%d) 失败: %w", target.column, row.ID, err)
+			}
+			encrypted, err := utils.EncryptSecret(plaintext)
+			if err != nil {
+				return err
+			}
+			// 直接写入密文，不经过 EncryptedString 避免重复加密，也不更新 updated_at
+			if err := db.Model(target.model).Unscoped().Where("id = ?", row.ID).UpdateColumn(target.column, encrypted).Error; err != nil {
+				return fmt.Errorf("保存 %s (id=%d) 失败: %w", target.column, row.ID, err)
+			}
+			updated++
+		}
+
+		if updated > 0 {
+			utils.Info("已加密敏感字段", zap.String("column", target.column), zap.Int("count", updated))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import "time"
+
+// 基线之后的迁移使用的表结构快照，按迁移版本命名
+// 与基线快照一样不能随模型修改，模型之后的变化由新的迁移和新的快照表示
+
+// v4Node 版本4创建的节点表
+type v4Node struct {
+	ID            uint   `gorm:"primarykey"`
+	Name          string `gorm:"size:64;not null;uniqueIndex"`
+	Endpoint      string `gorm:"size:512;not null;default:''"`
+	PublicHost    string `gorm:"size:255;not null;default:''"`
+	TLSCACert     string `gorm:"type:text"`
+	TLSCert       string `gorm:"type:text"`
+	TLSKey        string `gorm:"type:text"`
+	SSHKey        string `gorm:"type:text"`
+	SSHHostKey    string `gorm:"size:1024;not null;default:''"`
+	Labels        string `gorm:"size:1024;not null;default:'{}'"`
+	MaxServers    int    `gorm:"not null;default:0"`
+	Schedulable   bool   `gorm:"not null"`
+	Status        string `gorm:"size:16;not null;default:'unknown'"`
+	LastError     string `gorm:"size:1024;not null;default:''"`
+	LastSeenAt    *time.Time
+	DockerVersion string `gorm:"size:64;not null;default:''"`
+	CPUs          int    `gorm:"not null;default:0"`
+	MemoryBytes   int64  `gorm:"not null;default:0"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (v4Node) TableName() string { return "nodes" }
+
+// v4Server 版本4为服务器表添加的列
+type v4Server struct {
+	ID     uint `gorm:"primarykey"`
+	NodeID uint `gorm:"not null;default:0;index"`
+}
+
+func (v4Server) TableName() string { return "servers" }
+
+// v5Server 版本5为服务器表添加的资源限制列
+type v5Server struct {
+	ID                  uint    `gorm:"primarykey"`
+	MemoryLimitMB       int64   `gorm:"not null;default:0"`
+	MemoryReservationMB int64   `gorm:"not null;default:0"`
+	CPUShares           int64   `gorm:"not null;default:0"`
+	CPULimit            float64 `gorm:"not null;default:0"`
+	PidsLimit           int64   `gorm:"not null;default:0"`
+	Ulimits             string  `gorm:"size:1024;not null;default:'[]'"`
+	RestartMaxRetries   int     `gorm:"not null;default:0"`
+}
+
+func (v5Server) TableName() string { return "servers" }
+
+// v6ServerImage 版本6创建的镜像登记表
+type v6ServerImage struct {
+	ID          uint   `gorm:"primarykey"`
+	Image       string `gorm:"size:512;not null;uniqueIndex"`
+	Description string `gorm:"size:255;not null;default:''"`
+	IsDefault   bool   `gorm:"not null;default:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v6ServerImage) TableName() string { return "server_images" }
+
+// v6Server 版本6为服务器表添加的列
+type v6Server struct {
+	ID    uint   `gorm:"primarykey"`
+	Image string `gorm:"size:512;not null;default:''"`
+}
+
+func (v6Server) TableName() string { return "servers" }
+
+// v7RegistryCredential 版本7创建的镜像仓库凭据表
+type v7RegistryCredential struct {
+	ID        uint   `gorm:"primarykey"`
+	Registry  string `gorm:"size:255;not null;uniqueIndex"`
+	Username  string `gorm:"size:255;not null"`
+	Password  string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v7RegistryCredential) TableName() string { return "registry_credentials" }
+
+// v8Server 版本8为服务器表添加的网络列
+type v8Server struct {
+	ID          uint   `gorm:"primarykey"`
+	NetworkMode string `gorm:"size:32;not null;default:''"`
+	NetworkName string `gorm:"size:255;not null;default:''"`
+	BindIP      string `gorm:"size:64;not null;default:''"`
+}
+
+func (v8Server) TableName() string { return "servers" }
+
+// v9OIDCAuthRequest 版本9创建的 OIDC 登录请求表
+type v9OIDCAuthRequest struct {
+	ID           uint   `gorm:"primarykey"`
+	StateHash    string `gorm:"size:128;not null;uniqueIndex"`
+	CodeVerifier string `gorm:"not null"`
+	Nonce        string `gorm:"not null"`
+	LinkUserID   uint
+	UserID       uint
+	ExchangeHash string `gorm:"size:64;index"`
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+func (v9OIDCAuthRequest) TableName() string { return "oidc_auth_requests" }
+
+// v11OIDCAuthRequest 版本11为 OIDC 登录请求表添加的列
+type v11OIDCAuthRequest struct {
+	ID          uint   `gorm:"primarykey"`
+	BindingHash string `gorm:"size:64;not null;default:''"`
+}
+
+func (v11OIDCAuthRequest) TableName() string { return "oidc_auth_requests" }
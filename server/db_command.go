@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dbUsage db 子命令的用法说明
+const dbUsage = `用法: ark-server-commander db copy <目标驱动> <目标连接串>
+
+将当前配置的数据库（DB_DRIVER/DB_PATH/DB_DSN）中的全部数据复制到目标数据库，
+目标数据库会自动建表，且数据表必须为空。复制期间请停止面板服务。
+
+示例:
+  ark-server-commander db copy postgres "host=127.0.0.1 user=ark password=secret dbname=ark sslmode=disable"
+  ark-server-commander db copy mysql "ark:secret@tcp(127.0.0.1:3306)/ark"
+
+复制完成后将 DB_DRIVER 和 DB_DSN 改为目标数据库，并保持 ENCRYPTION_KEYS（或 JWT_SECRET）不变
+`
+
+// runDBCommand 执行 db 子命令，返回进程退出码
+func runDBCommand(args []string) int {
+	if len(args) != 3 || args[0] != "copy" {
+		fmt.Fprint(os.Stderr, dbUsage)
+		return 2
+	}
+	targetDriver, targetDSN := args[1], args[2]
+	if targetDriver == config.DBDriver && (targetDSN == config.DBDSN || targetDSN == config.DBPath) {
+		fmt.Fprintln(os.Stderr, "目标数据库不能与源数据库相同")
+		return 2
+	}
+
+	if err := database.Open(); err != nil {
+		fmt.Fprintf(os.Stderr, "连接源数据库失败: %v\n", err)
+		return 1
+	}
+	source := database.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+	target, err := database.Connect(targetDriver, targetDSN, logger.Warn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "连接目标数据库失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("正在从 %s 复制数据到 %s ...\n", config.DBDriver, targetDriver)
+	err = database.CopyDatabase(source, target, func(table string, rows int) {
+		fmt.Printf("  %-24s %d 行\n", table, rows)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "复制失败（目标数据库未写入数据）: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("复制完成")
+	return 0
+}
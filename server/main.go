@@ -3,9 +3,21 @@ package main
 import (
 	"ark-server-commander/config"
 	"ark-server-commander/database"
+	"ark-server-commander/models"
 	"ark-server-commander/routes"
 	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/loginguard"
+	"ark-server-commander/service/mod"
+	"ark-server-commander/service/node"
+	"ark-server-commander/service/server"
 	"ark-server-commander/utils"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -15,6 +27,9 @@ import (
 	_ "ark-server-commander/docs" // 导入生成的docs包
 )
 
+// shutdownTimeout 收到退出信号后等待进行中的请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
 // @title ARK服务器管理器 API
 // @version 1.0
 // @description 基于Gin+Gorm的ARK服务器管理系统API文档
@@ -60,22 +75,73 @@ func main() {
 		utils.Fatal("程序退出")
 	}
 
+	// 数据库子命令：ark-server-commander migrate <status|up|down> / db copy
+	if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "db") {
+		run := runMigrateCommand
+		if os.Args[1] == "db" {
+			run = runDBCommand
+		}
+		code := run(os.Args[2:])
+		utils.Sync()
+		os.Exit(code)
+	}
+
 	// 初始化数据库
 	database.InitDB()
 
-	// 检查Docker环境
-	if err := docker_manager.CheckDockerStatus(); err != nil {
-		utils.Fatal("Docker环境检查失败，请确保Docker已安装并运行", zap.Error(err))
+	// 服务器运行在面板所在主机上时，检查本机Docker环境
+	nodeService := node.NewNodeService()
+	usesLocalHost, err := nodeService.UsesLocalHost()
+	if err != nil {
+		utils.Fatal("读取节点配置失败", zap.Error(err))
 	}
-	utils.Info("Docker环境检查通过")
+	if usesLocalHost {
+		if err := docker_manager.CheckDockerStatus(); err != nil {
+			utils.Fatal("Docker环境检查失败，请确保Docker已安装并运行", zap.Error(err))
+		}
+		utils.Info("Docker环境检查通过")
 
-	// 获取Docker管理器单例实例
-	_, err := docker_manager.GetDockerManager()
-	if err != nil {
-		utils.Fatal("获取Docker管理器失败", zap.Error(err))
+		// 获取Docker管理器单例实例
+		dockerManager, err := docker_manager.GetDockerManager()
+		if err != nil {
+			utils.Fatal("获取Docker管理器失败", zap.Error(err))
+		}
+
+		// 接管遗留的卷辅助容器
+		if err := dockerManager.RecoverHelpers(); err != nil {
+			utils.Warn("回收遗留辅助容器失败", zap.Error(err))
+		}
 	}
 	defer docker_manager.CloseDockerManager()
 
+	// 启动所有节点的辅助容器空闲回收
+	stopHelperReaper := docker_manager.StartHelperReaper(config.HelperIdleTimeout)
+	defer docker_manager.RemoveAllHelpers()
+	defer stopHelperReaper()
+
+	// 启动节点健康检查
+	nodeHealthChecker := node.NewHealthChecker(nodeService)
+	nodeHealthChecker.Start()
+	defer nodeHealthChecker.Stop()
+
+	// 启动模组更新定时检查
+	serverService := server.NewServerService()
+	modUpdateChecker := mod.NewModUpdateChecker(mod.NewModService(), func(ctx context.Context, s models.Server, reason string) error {
+		return serverService.RestartServerWithWarning(ctx, s, config.ModRestartWarning, reason)
+	})
+	modUpdateChecker.Start()
+	defer modUpdateChecker.Stop()
+
+	// 启动回收站自动清理
+	trashPurger := server.NewTrashPurger(serverService)
+	trashPurger.Start()
+	defer trashPurger.Stop()
+
+	// 启动登录记录自动清理
+	loginPruner := loginguard.NewPruner(loginguard.NewLoginGuardService())
+	loginPruner.Start()
+	defer loginPruner.Stop()
+
 	// 创建Gin实例
 	r := gin.Default()
 
@@ -114,7 +180,28 @@ func main() {
 	utils.Info("📋 服务器状态同步完成")
 	utils.Info("=========================================")
 
-	if err := r.Run(":8080"); err != nil {
-		utils.Fatal("服务器启动失败", zap.Error(err))
+	// 收到 SIGINT/SIGTERM 后停止接收新请求，返回后按 defer 顺序停止后台任务并删除辅助容器
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			utils.Fatal("服务器启动失败", zap.Error(err))
+		}
+	case <-ctx.Done():
+		stop()
+		utils.Info("收到退出信号，正在关闭服务器...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			utils.Warn("等待请求完成超时，强制关闭服务器", zap.Error(err))
+		}
 	}
 }
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计日志记录限制
+const (
+	maxAuditBodySize     = 64 << 10 // 记录的请求体最大字节数，超过时只记录截断标记
+	maxAuditResponseSize = 4 << 10  // 失败响应中用于提取错误信息的最大字节数
+)
+
+// 审计相关的上下文键
+const (
+	auditForceKey  = "audit_force"  // 强制记录非修改类请求
+	auditActionKey = "audit_action" // 自定义操作名称
+)
+
+// AuditMiddleware 记录所有修改类请求（POST/PUT/PATCH/DELETE）的审计日志
+// 请求参数中的密码、令牌等敏感字段会被脱敏，需在 AuthMiddleware 之后使用
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body interface{}
+		if isMutatingMethod(c.Request.Method) {
+			body = captureRequestBody(c)
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if c.FullPath() == "" || !(isMutatingMethod(c.Request.Method) || c.GetBool(auditForceKey)) {
+			return
+		}
+
+		action := c.GetString(auditActionKey)
+		if action == "" {
+			action = audit.ActionFromRoute(c.Request.Method, c.FullPath())
+		}
+
+		params := map[string]interface{}{}
+		if len(c.Params) > 0 {
+			pathParams := map[string]string{}
+			for _, param := range c.Params {
+				pathParams[param.Key] = param.Value
+			}
+			params["path"] = pathParams
+		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			params["query"] = query
+		}
+		if body != nil {
+			params["body"] = body
+		}
+
+		status := writer.Status()
+		entry := models.AuditEntry{
+			ActorID:   c.GetUint("user_id"),
+			ActorName: c.GetString("username"),
+			TokenID:   c.GetUint("token_id"),
+			Action:    action,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Params:    audit.EncodeParams(params),
+			Result:    models.AuditResultSuccess,
+			Status:    status,
+			IP:        c.ClientIP(),
+		}
+		if strings.HasPrefix(c.FullPath(), "/api/servers/:id") {
+			if id, err := strconv.ParseUint(c.Param("id"), 10, 32); err == nil {
+				serverID := uint(id)
+				entry.ServerID = &serverID
+			}
+		}
+		if status >= http.StatusBadRequest {
+			entry.Result = models.AuditResultFailure
+			entry.Error = writer.errorMessage()
+		}
+		audit.Record(entry)
+	}
+}
+
+// AuditAccess 强制记录读取类请求的审计日志（用于查看密码等敏感信息的接口）
+// action: 操作名称
+func AuditAccess(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(auditForceKey, true)
+		c.Set(auditActionKey, action)
+		c.Next()
+	}
+}
+
+// isMutatingMethod 判断是否为修改类请求
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// captureRequestBody 读取请求体用于审计，并恢复请求体供后续处理使用
+// 返回: 解析后的JSON、文本，或文件上传、超长时的说明
+func captureRequestBody(c *gin.Context) interface{} {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		return "[multipart]"
+	}
+
+	buffer, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBodySize+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buffer), c.Request.Body))
+	if err != nil || len(buffer) == 0 {
+		return nil
+	}
+	if len(buffer) > maxAuditBodySize {
+		return "[truncated]"
+	}
+
+	var parsed interface{}
+	if json.Unmarshal(buffer, &parsed) == nil {
+		return parsed
+	}
+	return string(buffer)
+}
+
+// auditResponseWriter 在请求失败时保存响应开头部分，用于提取错误信息
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(data string) (int, error) {
+	w.capture([]byte(data))
+	return w.ResponseWriter.WriteString(data)
+}
+
+// capture 只保存失败响应的开头部分
+func (w *auditResponseWriter) capture(data []byte) {
+	if w.ResponseWriter.Status() < http.StatusBadRequest {
+		return
+	}
+	if remaining := maxAuditResponseSize - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
+
+// errorMessage 从失败响应中提取 error 字段
+func (w *auditResponseWriter) errorMessage() string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(w.body.Bytes(), &response) != nil {
+		return ""
+	}
+	return response.Error
+}
@@ -4,11 +4,21 @@ import (
 	"net/http"
 	"strings"
 
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/session"
+	"ark-server-commander/service/token"
 	"ark-server-commander/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	tokenService   = token.NewTokenService()
+	sessionService = session.NewSessionService()
+)
+
+// AuthMiddleware 验证 JWT 或 API 令牌（以 asc_ 开头）
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -26,16 +36,85 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ParseToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的授权令牌"})
+		var userID uint
+		var scopes []string
+		if token.IsAPIToken(parts[1]) {
+			apiToken, err := tokenService.Authenticate(parts[1], c.ClientIP())
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			userID = apiToken.UserID
+			scopes = token.ParseScopes(apiToken.Scopes)
+			c.Set("token_id", apiToken.ID)
+		} else {
+			claims, err := utils.ParseToken(parts[1])
+			if err != nil || claims.SessionID == 0 {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的授权令牌"})
+				c.Abort()
+				return
+			}
+			// 会话被撤销后访问令牌立即失效
+			if err := sessionService.ValidateSession(claims.SessionID, claims.UserID, c.ClientIP()); err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			userID = claims.UserID
+			c.Set("session_id", claims.SessionID)
+		}
+
+		// 检查用户是否存在且未被禁用
+		var user models.User
+		if err := database.DB.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			c.Abort()
+			return
+		}
+		if user.Disabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "账号已被禁用"})
+			c.Abort()
+			return
+		}
+
+		// 管理员要求启用两步验证时，启用前只能访问个人账号相关接口
+		if scopes == nil && user.TOTPRequired && !user.TOTPEnabled && !twoFactorSetupAllowed(c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "请先启用两步验证", "two_factor_setup_required": true})
 			c.Abort()
 			return
 		}
 
 		// 将用户信息存储在上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		c.Set("user_id", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		if scopes != nil {
+			c.Set("token_scopes", scopes)
+		}
+		c.Next()
+	}
+}
+
+// twoFactorSetupAllowed 判断尚未完成两步验证绑定的用户是否可以访问该路由
+func twoFactorSetupAllowed(path string) bool {
+	return path == "/api/profile" || path == "/api/auth/logout" || strings.HasPrefix(path, "/api/auth/2fa")
+}
+
+// isAPITokenRequest 判断当前请求是否使用API令牌认证
+func isAPITokenRequest(c *gin.Context) bool {
+	_, ok := c.Get("token_scopes")
+	return ok
+}
+
+// DenyAPIToken 拒绝使用API令牌访问（用于令牌管理、用户管理等敏感接口）
+func DenyAPIToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAPITokenRequest(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API令牌无权访问此接口"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
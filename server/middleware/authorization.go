@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/permission"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户具有指定角色之一（需在 AuthMiddleware 之后使用）
+// API令牌不能访问按角色限制的接口
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAPITokenRequest(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API令牌无权访问此接口"})
+			c.Abort()
+			return
+		}
+
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+		c.Abort()
+	}
+}
+
+// RequireServerPermission 要求当前用户对路径中的服务器（:id）具有指定权限（需在 AuthMiddleware 之后使用）
+// 使用API令牌时还要求令牌作用域允许该操作
+func RequireServerPermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := permission.CheckServerPermission(c.GetUint("user_id"), c.Param("id"), perm)
+		if err == nil {
+			if scopes := c.GetStringSlice("token_scopes"); isAPITokenRequest(c) && !permission.ScopeAllows(scopes, perm) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API令牌作用域不足"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		switch err.Error() {
+		case "无效的服务器ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "服务器不存在":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		}
+		c.Abort()
+	}
+}
+
+// RequireServerDelete 删除服务器的权限检查（需在 AuthMiddleware 之后使用）
+// purge=true（彻底删除，包括回收站中的服务器）需要所有者或管理员角色，否则需要对服务器的删除权限
+func RequireServerDelete() gin.HandlerFunc {
+	canDelete := RequireServerPermission(models.PermissionDelete)
+	canPurge := RequireRole(models.RoleOwner, models.RoleAdmin)
+	return func(c *gin.Context) {
+		if c.Query("purge") == "true" {
+			c.Set(auditActionKey, "servers.purge")
+			canPurge(c)
+			return
+		}
+		canDelete(c)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"ark-server-commander/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// migrateUsage migrate 子命令的用法说明
+const migrateUsage = `用法: ark-server-commander migrate <命令>
+
+命令:
+  status          查看数据库迁移状态
+  up [版本号]     执行未执行的迁移（默认迁移到最新版本）
+  down [数量]     回滚最近执行的迁移（默认回滚1个）
+
+执行迁移或回滚前会自动备份 SQLite 数据库（MIGRATION_BACKUP=false 可关闭）
+`
+
+// runMigrateCommand 执行 migrate 子命令，返回进程退出码
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	argument := 0
+	if len(args) > 1 {
+		value, err := strconv.Atoi(args[1])
+		if err != nil || value < 0 {
+			fmt.Fprintf(os.Stderr, "无效的参数: %s\n", args[1])
+			return 2
+		}
+		argument = value
+	}
+
+	if err := database.Open(); err != nil {
+		fmt.Fprintf(os.Stderr, "数据库连接失败: %v\n", err)
+		return 1
+	}
+	// 命令行只输出迁移结果，不打印每条SQL
+	database.DB = database.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+
+	switch args[0] {
+	case "status":
+		statuses, err := database.GetMigrationStatus(database.DB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取迁移状态失败: %v\n", err)
+			return 1
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "版本\t名称\t状态\t执行时间\t可回滚")
+		for _, status := range statuses {
+			state, appliedAt := "未执行", "-"
+			if status.Applied {
+				state = "已执行"
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			reversible := "否"
+			if status.Reversible {
+				reversible = "是"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt, reversible)
+		}
+		writer.Flush()
+		return 0
+
+	case "up":
+		applied, err := database.MigrateUp(database.DB, argument)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "数据库迁移失败（已执行 %d 个）: %v\n", applied, err)
+			return 1
+		}
+		fmt.Printf("已执行 %d 个迁移\n", applied)
+		return 0
+
+	case "down":
+		if argument == 0 {
+			argument = 1
+		}
+		reverted, err := database.MigrateDown(database.DB, argument)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "回滚迁移失败（已回滚 %d 个）: %v\n", reverted, err)
+			return 1
+		}
+		fmt.Printf("已回滚 %d 个迁移\n", reverted)
+		return 0
+	}
+
+	fmt.Fprint(os.Stderr, migrateUsage)
+	return 2
+}
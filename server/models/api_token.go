@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// API令牌作用域
+const (
+	TokenScopeRead      = "read"      // 只读：查看服务器、模组、插件等信息
+	TokenScopeLifecycle = "lifecycle" // 启动、停止、重启、重建容器
+	TokenScopeConfig    = "config"    // 修改配置、模组、插件和文件
+	TokenScopeRCON      = "rcon"      // 查看RCON信息和执行RCON命令
+)
+
+// TokenScopes 所有可用的API令牌作用域
+var TokenScopes = []string{
+	TokenScopeRead,
+	TokenScopeLifecycle,
+	TokenScopeConfig,
+	TokenScopeRCON,
+}
+
+// APIToken 个人访问令牌，用于脚本和自动化调用
+// 令牌明文只在创建时返回一次，数据库中只保存 SHA-256 哈希
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`                  // 令牌前缀，用于识别令牌
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`   // 令牌的 SHA-256 哈希
+	Scopes     string     `json:"scopes" gorm:"not null;default:''"`       // 作用域列表，用逗号分隔
+	LastUsedAt *time.Time `json:"last_used_at"`                            // 最后使用时间
+	LastUsedIP string     `json:"last_used_ip" gorm:"not null;default:''"` // 最后使用的IP
+	ExpiresAt  *time.Time `json:"expires_at"`                              // 过期时间，为空表示永不过期
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"index"`                 // 撤销时间
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APITokenCreateRequest 创建API令牌请求
+type APITokenCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"` // 有效天数，0表示永不过期
+}
+
+// APITokenResponse API令牌信息
+type APITokenResponse struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt string   `json:"last_used_at"`
+	LastUsedIP string   `json:"last_used_ip"`
+	ExpiresAt  string   `json:"expires_at"`
+	CreatedAt  string   `json:"created_at"`
+	Token      string   `json:"token,omitempty"` // 令牌明文，仅创建时返回
+}
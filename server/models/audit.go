@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计结果
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditEntry 审计日志（记录谁在什么时候对哪个服务器做了什么操作）
+type AuditEntry struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	ActorID   uint      `json:"actor_id" gorm:"index"` // 操作者用户ID，0 表示系统自动执行
+	ActorName string    `json:"actor_name"`            // 操作者用户名（用户删除后仍可追溯）
+	TokenID   uint      `json:"token_id"`              // 使用API令牌时的令牌ID
+	Action    string    `json:"action" gorm:"index"`   // 操作名称，如 servers.start、rcon.command
+	Method    string    `json:"method"`                // HTTP方法（服务层记录时为空）
+	Path      string    `json:"path"`                  // 请求路径
+	ServerID  *uint     `json:"server_id" gorm:"index"`
+	Params    string    `json:"params"` // 请求参数（JSON，敏感字段已脱敏）
+	Result    string    `json:"result" gorm:"index"`
+	Status    int       `json:"status"` // HTTP状态码
+	Error     string    `json:"error"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// AuditQuery 审计日志查询条件
+type AuditQuery struct {
+	ActorID  *uint     `form:"actor_id"`
+	Action   string    `form:"action"` // 操作名称前缀，如 servers 匹配所有服务器操作
+	ServerID *uint     `form:"server_id"`
+	Result   string    `form:"result"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int       `form:"page"`
+	PageSize int       `form:"page_size"`
+}
+
+// AuditEntryResponse 审计日志响应（参数以JSON对象返回）
+type AuditEntryResponse struct {
+	ID        uint            `json:"id"`
+	ActorID   uint            `json:"actor_id"`
+	ActorName string          `json:"actor_name"`
+	TokenID   uint            `json:"token_id,omitempty"`
+	Action    string          `json:"action"`
+	Method    string          `json:"method,omitempty"`
+	Path      string          `json:"path,omitempty"`
+	ServerID  *uint           `json:"server_id"`
+	Params    json.RawMessage `json:"params,omitempty" swaggertype:"object"`
+	Result    string          `json:"result"`
+	Status    int             `json:"status,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	CreatedAt string          `json:"created_at"`
+}
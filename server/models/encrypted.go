@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"ark-server-commander/utils"
+)
+
+// EncryptedString 加密存储的字符串字段
+// 写入数据库时使用当前密钥加密，读取时自动解密，代码中按普通字符串使用
+type EncryptedString string
+
+// Value 写入数据库前加密
+func (s EncryptedString) Value() (driver.Value, error) {
+	return utils.EncryptSecret(string(s))
+}
+
+// Scan 从数据库读取后解密
+func (s *EncryptedString) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		raw = ""
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("无法读取加密字段: %T", value)
+	}
+
+	plaintext, err := utils.DecryptSecret(raw)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
@@ -0,0 +1,22 @@
+package models
+
+// FileInfo 服务器卷中的文件信息
+type FileInfo struct {
+	Name      string `json:"name"`       // 文件名
+	Path      string `json:"path"`       // 相对于卷根目录的路径
+	IsDir     bool   `json:"is_dir"`     // 是否为目录
+	IsSymlink bool   `json:"is_symlink"` // 是否为符号链接
+	Size      int64  `json:"size"`       // 文件大小（字节）
+	ModTime   string `json:"mod_time"`   // 最后修改时间
+}
+
+// FileRenameRequest 文件重命名/移动请求
+type FileRenameRequest struct {
+	From string `json:"from" binding:"required"` // 原路径
+	To   string `json:"to" binding:"required"`   // 新路径
+}
+
+// FileMkdirRequest 创建目录请求
+type FileMkdirRequest struct {
+	Path string `json:"path" binding:"required"` // 目录路径
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// 登录失败原因
+const (
+	LoginFailureUnknownUser = "unknown_user" // 用户名不存在
+	LoginFailureBadPassword = "bad_password" // 密码错误
+	LoginFailureDisabled    = "disabled"     // 账号已禁用
+	LoginFailureTwoFactor   = "bad_2fa_code" // 两步验证码错误
+	LoginFailureLocked      = "locked"       // 已被锁定或等待时间未到
+)
+
+// 登录限制的统计维度
+const (
+	LoginThrottleKindUser = "user" // 按用户名限制
+	LoginThrottleKindIP   = "ip"   // 按IP限制
+)
+
+// LoginAttempt 登录尝试记录（用于审计）
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Username  string    `json:"username" gorm:"index"`
+	IP        string    `json:"ip" gorm:"index"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"` // 失败原因，成功时为空
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// LoginThrottle 按用户名或IP统计的登录失败状态
+type LoginThrottle struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	Kind          string     `json:"kind" gorm:"size:16;not null;uniqueIndex:idx_login_throttle_subject"`   // user / ip
+	Value         string     `json:"value" gorm:"size:191;not null;uniqueIndex:idx_login_throttle_subject"` // 用户名或IP
+	Failures      int        `json:"failures" gorm:"not null;default:0"`                                    // 统计窗口内的连续失败次数
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"` // 在此之前的登录请求直接拒绝（渐进延迟）
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// LoginAttemptQuery 登录记录查询条件
+type LoginAttemptQuery struct {
+	Username string `form:"username"`
+	IP       string `form:"ip"`
+	Success  *bool  `form:"success"`
+	Limit    int    `form:"limit"`
+}
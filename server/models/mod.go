@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Mod 创意工坊模组元数据（缓存于数据库）
+type Mod struct {
+	WorkshopID  string    `json:"workshop_id" gorm:"primarykey"`
+	Name        string    `json:"name" gorm:"default:''"`
+	Size        int64     `json:"size" gorm:"default:0"` // 文件大小（字节）
+	LastUpdated time.Time `json:"last_updated"`          // 创意工坊最后更新时间
+	FetchedAt   time.Time `json:"fetched_at"`            // 元数据最后拉取时间
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServerMod 服务器模组列表（按 Position 排序，顺序即加载顺序）
+type ServerMod struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	ServerID   uint      `json:"server_id" gorm:"not null;uniqueIndex:idx_server_mod"`
+	WorkshopID string    `json:"workshop_id" gorm:"size:64;not null;uniqueIndex:idx_server_mod"`
+	Position   int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ModAddRequest struct {
+	WorkshopID string `json:"workshop_id" binding:"required"`
+}
+
+type ModReorderRequest struct {
+	WorkshopIDs []string `json:"workshop_ids" binding:"required"`
+}
+
+type ModResponse struct {
+	WorkshopID  string `json:"workshop_id"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	LastUpdated string `json:"last_updated"`
+	FetchedAt   string `json:"fetched_at"`
+}
+
+type ServerModResponse struct {
+	Position    int    `json:"position"`
+	WorkshopID  string `json:"workshop_id"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	LastUpdated string `json:"last_updated"`
+}
+
+// ModUpdateStatus 单个模组的更新状态
+type ModUpdateStatus struct {
+	WorkshopID       string `json:"workshop_id"`
+	Name             string `json:"name"`
+	InstalledUpdated string `json:"installed_updated"` // 已安装版本的更新时间
+	LatestUpdated    string `json:"latest_updated"`    // 创意工坊最新版本的更新时间
+	Status           string `json:"status"`            // up_to_date / outdated / not_installed / unknown
+}
+
+// ServerModStatusResponse 服务器模组更新状态
+type ServerModStatusResponse struct {
+	ServerID        uint              `json:"server_id"`
+	Mods            []ModUpdateStatus `json:"mods"`
+	OutdatedCount   int               `json:"outdated_count"`
+	UpdateAvailable bool              `json:"update_available"`
+	CheckedAt       string            `json:"checked_at"`
+}
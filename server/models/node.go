@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// 节点状态
+const (
+	NodeStatusUnknown = "unknown" // 尚未检查
+	NodeStatusOnline  = "online"
+	NodeStatusOffline = "offline"
+)
+
+// Node 运行ARK服务器的Docker主机
+// Endpoint 为空表示面板所在主机（使用 DOCKER_HOST 等环境变量连接），
+// tcp://host:2376 通过 TLS 连接，ssh://user@host[:port][/docker.sock路径] 通过 SSH 转发 Docker 套接字
+type Node struct {
+	ID            uint            `json:"id" gorm:"primarykey"`
+	Name          string          `json:"name" gorm:"size:64;not null;uniqueIndex"`
+	Endpoint      string          `json:"endpoint" gorm:"size:512;not null;default:''"`
+	PublicHost    string          `json:"public_host" gorm:"size:255;not null;default:''"` // 玩家和RCON连接使用的地址，为空时使用 Endpoint 中的主机名
+	TLSCACert     string          `json:"-" gorm:"type:text"`                              // 校验 Docker 守护进程证书的CA（PEM）
+	TLSCert       string          `json:"-" gorm:"type:text"`                              // 客户端证书（PEM）
+	TLSKey        EncryptedString `json:"-" gorm:"type:text"`                              // 客户端私钥（PEM，加密存储）
+	SSHKey        EncryptedString `json:"-" gorm:"type:text"`                              // SSH 私钥（PEM，加密存储）
+	SSHHostKey    string          `json:"-" gorm:"size:1024;not null;default:''"`          // SSH 主机公钥（authorized_keys 格式），用于校验主机身份
+	Labels        string          `json:"labels" gorm:"size:1024;not null;default:'{}'"`   // 标签（JSON对象），创建服务器时可按标签选择节点
+	MaxServers    int             `json:"max_servers" gorm:"not null;default:0"`           // 最多运行的服务器数量，0表示不限制
+	Schedulable   bool            `json:"schedulable" gorm:"not null"`                     // 是否允许自动分配新服务器
+	Status        string          `json:"status" gorm:"size:16;not null;default:'unknown'"`
+	LastError     string          `json:"last_error" gorm:"size:1024;not null;default:''"`
+	LastSeenAt    *time.Time      `json:"last_seen_at"`
+	DockerVersion string          `json:"docker_version" gorm:"size:64;not null;default:''"`
+	CPUs          int             `json:"cpus" gorm:"not null;default:0"`
+	MemoryBytes   int64           `json:"memory_bytes" gorm:"not null;default:0"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// IsLocal 是否为面板所在主机
+func (n Node) IsLocal() bool {
+	return n.Endpoint == ""
+}
+
+// NodeRequest 创建节点请求
+type NodeRequest struct {
+	Name        string            `json:"name" binding:"required,max=64"`
+	Endpoint    string            `json:"endpoint"` // 为空表示面板所在主机
+	PublicHost  string            `json:"public_host"`
+	TLSCACert   string            `json:"tls_ca_cert"`
+	TLSCert     string            `json:"tls_cert"`
+	TLSKey      string            `json:"tls_key"`
+	SSHKey      string            `json:"ssh_key"`
+	SSHHostKey  string            `json:"ssh_host_key"`
+	Labels      map[string]string `json:"labels"`
+	MaxServers  int               `json:"max_servers" binding:"min=0"`
+	Schedulable *bool             `json:"schedulable"` // 默认为 true
+}
+
+// NodeUpdateRequest 更新节点请求（未提供的字段保持不变，私钥为空时保留原值）
+type NodeUpdateRequest struct {
+	Name        string             `json:"name" binding:"max=64"`
+	Endpoint    *string            `json:"endpoint"`
+	PublicHost  *string            `json:"public_host"`
+	TLSCACert   *string            `json:"tls_ca_cert"`
+	TLSCert     *string            `json:"tls_cert"`
+	TLSKey      string             `json:"tls_key"`
+	SSHKey      string             `json:"ssh_key"`
+	SSHHostKey  *string            `json:"ssh_host_key"`
+	Labels      *map[string]string `json:"labels"`
+	MaxServers  *int               `json:"max_servers" binding:"omitempty,min=0"`
+	Schedulable *bool              `json:"schedulable"`
+}
+
+// NodeResponse 节点信息（不包含证书和私钥）
+type NodeResponse struct {
+	ID            uint              `json:"id"`
+	Name          string            `json:"name"`
+	Endpoint      string            `json:"endpoint"`
+	PublicHost    string            `json:"public_host"`
+	Local         bool              `json:"local"`
+	TLSEnabled    bool              `json:"tls_enabled"`
+	SSHEnabled    bool              `json:"ssh_enabled"`
+	Labels        map[string]string `json:"labels"`
+	MaxServers    int               `json:"max_servers"`
+	Schedulable   bool              `json:"schedulable"`
+	ServerCount   int64             `json:"server_count"`
+	Status        string            `json:"status"`
+	LastError     string            `json:"last_error"`
+	LastSeenAt    string            `json:"last_seen_at"`
+	DockerVersion string            `json:"docker_version"`
+	CPUs          int               `json:"cpus"`
+	MemoryBytes   int64             `json:"memory_bytes"`
+	CreatedAt     string            `json:"created_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// UserIdentity 外部身份（OIDC）与本地用户的绑定
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Issuer      string     `json:"issuer" gorm:"size:191;not null;uniqueIndex:idx_user_identity_subject"`
+	Subject     string     `json:"subject" gorm:"size:191;not null;uniqueIndex:idx_user_identity_subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCAuthRequest 进行中的 OIDC 登录请求（保存 PKCE 校验码和 nonce）
+type OIDCAuthRequest struct {
+	ID           uint       `gorm:"primarykey"`
+	StateHash    string     `gorm:"size:128;not null;uniqueIndex"`
+	CodeVerifier string     `gorm:"not null"`
+	Nonce        string     `gorm:"not null"`
+	BindingHash  string     `gorm:"size:64;not null;default:''"` // 发起登录的浏览器 Cookie 中绑定值的哈希
+	LinkUserID   uint       // 绑定外部账号时的当前用户ID，0 表示登录
+	UserID       uint       // 回调完成后确定的本地用户
+	ExchangeHash string     `gorm:"size:64;index"` // 回调完成后交给前端的一次性兑换码哈希
+	ExpiresAt    time.Time  // 登录请求或兑换码的过期时间
+	UsedAt       *time.Time // 兑换码使用时间
+	CreatedAt    time.Time
+}
+
+// TableName OIDC 登录请求表名（默认命名会拆成 o_id_c_auth_requests）
+func (OIDCAuthRequest) TableName() string {
+	return "oidc_auth_requests"
+}
+
+// OIDCExchangeRequest 使用一次性兑换码换取登录令牌
+type OIDCExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// OIDCConfigResponse 前端显示单点登录按钮所需的配置
+type OIDCConfigResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UserIdentityResponse 已绑定的外部账号
+type UserIdentityResponse struct {
+	ID          uint   `json:"id"`
+	Issuer      string `json:"issuer"`
+	Subject     string `json:"subject"`
+	Email       string `json:"email,omitempty"`
+	LastLoginAt string `json:"last_login_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
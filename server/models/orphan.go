@@ -0,0 +1,54 @@
+package models
+
+// OrphanResource 遗留的容器或卷（所属服务器不存在，或服务器已删除超过保留期）
+type OrphanResource struct {
+	NodeID    uint   `json:"node_id"` // 0 表示面板所在主机
+	NodeName  string `json:"node_name"`
+	Kind      string `json:"kind"` // container 或 volume
+	Name      string `json:"name"`
+	Role      string `json:"role"`      // server, helper, backup, saved, plugins
+	ServerID  uint   `json:"server_id"` // 0 表示无法识别所属服务器
+	Reason    string `json:"reason"`
+	Legacy    bool   `json:"legacy"` // 没有面板标签，按名称识别
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// OrphanScanError 无法扫描的节点
+type OrphanScanError struct {
+	NodeID   uint   `json:"node_id"`
+	NodeName string `json:"node_name"`
+	Error    string `json:"error"`
+}
+
+// OrphanReport 遗留资源扫描结果
+type OrphanReport struct {
+	ManagerInstance string            `json:"manager_instance"` // 只包含该面板实例创建的资源
+	GracePeriod     string            `json:"grace_period"`     // 服务器删除后的保留期
+	Resources       []OrphanResource  `json:"resources"`
+	Errors          []OrphanScanError `json:"errors"`
+}
+
+// OrphanCleanupRequest 清理遗留资源请求
+type OrphanCleanupRequest struct {
+	DryRun bool     `json:"dry_run"` // 只返回将要删除的资源，不实际删除
+	Backup bool     `json:"backup"`  // 删除卷之前备份到 ORPHAN_BACKUP_DIR
+	NodeID *uint    `json:"node_id"` // 只清理该节点（0 表示面板所在主机），为空时清理所有节点
+	Names  []string `json:"names"`   // 只清理这些名称的资源，为空时清理全部遗留资源
+}
+
+// OrphanCleanupItem 单个资源的清理结果
+type OrphanCleanupItem struct {
+	OrphanResource
+	Removed    bool   `json:"removed"`
+	BackupPath string `json:"backup_path,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OrphanCleanupResult 清理遗留资源结果
+type OrphanCleanupResult struct {
+	DryRun  bool                `json:"dry_run"`
+	Items   []OrphanCleanupItem `json:"items"`
+	Removed int                 `json:"removed"`
+	Failed  int                 `json:"failed"`
+	Errors  []OrphanScanError   `json:"errors"` // 无法扫描的节点
+}
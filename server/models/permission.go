@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// 服务器操作权限
+const (
+	PermissionView       = "view"        // 查看服务器（可见即拥有）
+	PermissionStartStop  = "start_stop"  // 启动、停止、重启、重建容器
+	PermissionEditConfig = "edit_config" // 修改配置、模组、插件和文件
+	PermissionRCON       = "rcon"        // 查看RCON信息和执行RCON命令
+	PermissionBackups    = "backups"     // 管理备份
+	PermissionDelete     = "delete"      // 删除服务器
+	PermissionManage     = "manage"      // 管理服务器授权（仅服务器所有者和管理员）
+)
+
+// GrantablePermissions 可授予其他用户的服务器权限
+var GrantablePermissions = []string{
+	PermissionStartStop,
+	PermissionEditConfig,
+	PermissionRCON,
+	PermissionBackups,
+	PermissionDelete,
+}
+
+// ServerPermission 服务器授权，允许非所有者用户操作服务器
+type ServerPermission struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	ServerID    uint      `json:"server_id" gorm:"not null;uniqueIndex:idx_server_permission_user"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_server_permission_user"`
+	Permissions string    `json:"permissions" gorm:"not null;default:''"` // 权限列表，用逗号分隔
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServerPermissionRequest 设置服务器授权请求
+type ServerPermissionRequest struct {
+	Permissions []string `json:"permissions"` // 授予的权限列表，为空表示只读
+}
+
+// ServerPermissionResponse 服务器授权信息
+type ServerPermissionResponse struct {
+	UserID      uint     `json:"user_id"`
+	Username    string   `json:"username"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// PluginResponse ArkApi 插件信息
+type PluginResponse struct {
+	Name          string `json:"name"`            // 插件目录名称
+	Enabled       bool   `json:"enabled"`         // 是否启用
+	FullName      string `json:"full_name"`       // PluginInfo.json 中的 FullName
+	Version       string `json:"version"`         // PluginInfo.json 中的 Version
+	Description   string `json:"description"`     // PluginInfo.json 中的 Description
+	MinApiVersion string `json:"min_api_version"` // PluginInfo.json 中的 MinApiVersion
+	HasConfig     bool   `json:"has_config"`      // 是否有 config.json
+}
+
+// PluginConfigRequest 插件配置更新请求
+type PluginConfigRequest struct {
+	Content string `json:"content" binding:"required"` // config.json 内容
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RegistryCredential 镜像仓库的登录凭据，拉取该仓库（包括加速地址）的镜像时使用
+type RegistryCredential struct {
+	ID        uint            `json:"id" gorm:"primarykey"`
+	Registry  string          `json:"registry" gorm:"size:255;not null;uniqueIndex"` // 仓库地址（主机名[:端口]），Docker Hub 为 docker.io
+	Username  string          `json:"username" gorm:"size:255;not null"`
+	Password  EncryptedString `json:"-" gorm:"type:text"` // 密码或访问令牌（加密存储）
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// RegistryCredentialRequest 登记仓库凭据请求
+type RegistryCredentialRequest struct {
+	Registry string `json:"registry" binding:"required,max=255"`
+	Username string `json:"username" binding:"required,max=255"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RegistryCredentialUpdateRequest 修改仓库凭据请求（密码为空时保留原值）
+type RegistryCredentialUpdateRequest struct {
+	Username string `json:"username" binding:"max=255"`
+	Password string `json:"password"`
+}
+
+// RegistryCredentialResponse 仓库凭据信息（不包含密码）
+type RegistryCredentialResponse struct {
+	ID          uint   `json:"id"`
+	Registry    string `json:"registry"`
+	Username    string `json:"username"`
+	PasswordSet bool   `json:"password_set"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
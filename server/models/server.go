@@ -7,26 +7,48 @@ import (
 )
 
 type Server struct {
-	ID            uint           `json:"id" gorm:"primarykey"`
-	Identifier    string         `json:"identifier" gorm:"not null"`
-	SessionName   string         `json:"session_name" gorm:"default:'ARK Server'"` // 服务器名称
-	ClusterID     string         `json:"cluster_id" gorm:"default:''"`             // 集群ID
-	Port          int            `json:"port" gorm:"not null;default:7777"`
-	QueryPort     int            `json:"query_port" gorm:"not null;default:27015"`
-	RCONPort      int            `json:"rcon_port" gorm:"not null;default:32330"`
-	AdminPassword string         `json:"admin_password" gorm:"not null;default:password"`
-	Map           string         `json:"map" gorm:"default:'TheIsland'"`
-	MaxPlayers    int            `json:"max_players" gorm:"not null;default:70"` // 最大玩家数
-	GameModIds    string         `json:"game_mod_ids" gorm:"default:''"`         // 游戏模组ID列表，用逗号分隔
-	Status        string         `json:"status" gorm:"default:'stopped'"`
-	AutoRestart   bool           `json:"auto_restart" gorm:"default:true"`
-	UserID        uint           `json:"user_id" gorm:"not null"`
-	User          User           `json:"user" gorm:"foreignKey:UserID"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	ID            uint            `json:"id" gorm:"primarykey"`
+	Identifier    string          `json:"identifier" gorm:"not null"`
+	SessionName   string          `json:"session_name" gorm:"default:'ARK Server'"` // 服务器名称
+	ClusterID     string          `json:"cluster_id" gorm:"default:''"`             // 集群ID
+	Port          int             `json:"port" gorm:"not null;default:7777"`
+	QueryPort     int             `json:"query_port" gorm:"not null;default:27015"`
+	RCONPort      int             `json:"rcon_port" gorm:"not null;default:32330"`
+	AdminPassword EncryptedString `json:"admin_password" gorm:"size:512;not null"` // 加密存储
+	Map           string          `json:"map" gorm:"default:'TheIsland'"`
+	MaxPlayers    int             `json:"max_players" gorm:"not null;default:70"`   // 最大玩家数
+	GameModIds    string          `json:"game_mod_ids" gorm:"size:2048;default:''"` // 游戏模组ID列表，用逗号分隔
+	Status        string          `json:"status" gorm:"default:'stopped'"`
+	AutoRestart   bool            `json:"auto_restart" gorm:"default:true"`
+	UserID        uint            `json:"user_id" gorm:"not null"`
+	NodeID        uint            `json:"node_id" gorm:"not null;default:0;index"`   // 所在节点
+	Image         string          `json:"image" gorm:"size:512;not null;default:''"` // 游戏服务器镜像（已登记的镜像）
+	User          User            `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `gorm:"index"`
 	// 启动参数（JSON格式存储）
-	ServerArgsJSON string `json:"server_args_json" gorm:"default:'{}'"` // 启动参数的JSON字符串
+	ServerArgsJSON string `json:"server_args_json" gorm:"size:4096;default:'{}'"` // 启动参数的JSON字符串
+	// 容器资源限制
+	Resources ServerResources `json:"resources" gorm:"embedded"`
+	// 容器网络
+	Network ServerNetwork `json:"network" gorm:"embedded"`
+}
+
+// ImageName 服务器使用的镜像（未设置时为默认镜像）
+func (s Server) ImageName() string {
+	if s.Image == "" {
+		return DefaultServerImage
+	}
+	return s.Image
+}
+
+// HostPorts 服务器在主机上占用的端口（游戏端口、游戏端口+1、查询端口、RCON端口），macvlan 模式下不占用主机端口
+func (s Server) HostPorts() []int {
+	if !s.Network.UsesHostPorts() {
+		return nil
+	}
+	return []int{s.Port, s.Port + 1, s.QueryPort, s.RCONPort}
 }
 
 type ServerRequest struct {
@@ -46,31 +68,47 @@ type ServerRequest struct {
 	GameIni          string `json:"game_ini,omitempty"`           // Game.ini 文件内容
 	// 启动参数（可选）
 	ServerArgs *ServerArgsRequest `json:"server_args,omitempty"`
+	// 运行节点（可选，未指定时自动选择）
+	NodeID     *uint             `json:"node_id"`     // 指定节点
+	NodeLabels map[string]string `json:"node_labels"` // 按标签选择节点，节点需包含全部标签
+	// 游戏服务器镜像（可选，需要已登记，未指定时使用默认镜像）
+	Image string `json:"image"`
+	// 容器资源限制（可选）
+	Resources *ServerResources `json:"resources,omitempty"`
+	// 容器网络（可选，未指定时使用默认的桥接网络）
+	Network *ServerNetwork `json:"network,omitempty"`
 }
 
 type ServerResponse struct {
-	ID            uint   `json:"id"`
-	Identifier    string `json:"identifier"`
-	SessionName   string `json:"session_name"` // 服务器名称
-	ClusterID     string `json:"cluster_id"`   // 集群ID
-	Port          int    `json:"port"`
-	QueryPort     int    `json:"query_port"`
-	RCONPort      int    `json:"rcon_port"`
-	AdminPassword string `json:"admin_password"`
-	Map           string `json:"map"`
-	MaxPlayers    int    `json:"max_players"` // 最大玩家数
-	GameModIds    string `json:"game_mod_ids"`
-	Status        string `json:"status"`
-	AutoRestart   bool   `json:"auto_restart"`
-	UserID        uint   `json:"user_id"`
-	CreatedAt     string `json:"created_at"`
-	UpdatedAt     string `json:"updated_at"`
+	ID          uint   `json:"id"`
+	Identifier  string `json:"identifier"`
+	SessionName string `json:"session_name"` // 服务器名称
+	ClusterID   string `json:"cluster_id"`   // 集群ID
+	Port        int    `json:"port"`
+	QueryPort   int    `json:"query_port"`
+	RCONPort    int    `json:"rcon_port"`
+	Map         string `json:"map"`
+	MaxPlayers  int    `json:"max_players"` // 最大玩家数
+	GameModIds  string `json:"game_mod_ids"`
+	Status      string `json:"status"`
+	AutoRestart bool   `json:"auto_restart"`
+	UserID      uint   `json:"user_id"`
+	NodeID      uint   `json:"node_id"` // 所在节点
+	Image       string `json:"image"`   // 游戏服务器镜像
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	// 管理员密码不在响应中返回，需要时通过 /servers/:id/admin-password 查看（会记录审计日志）
+	PasswordIsSet bool `json:"admin_password_set"`
 	// 配置文件内容
 	GameUserSettings string `json:"game_user_settings,omitempty"` // GameUserSettings.ini 文件内容
 	GameIni          string `json:"game_ini,omitempty"`           // Game.ini 文件内容
 	// 启动参数
 	ServerArgs    *ServerArgs `json:"server_args,omitempty"`    // 启动参数结构
 	GeneratedArgs string      `json:"generated_args,omitempty"` // 生成的完整启动参数字符串
+	// 容器资源限制
+	Resources ServerResources `json:"resources"`
+	// 容器网络
+	Network ServerNetwork `json:"network"`
 }
 
 type ServerUpdateRequest struct {
@@ -90,4 +128,25 @@ type ServerUpdateRequest struct {
 	GameIni          string `json:"game_ini,omitempty"`           // Game.ini 文件内容
 	// 启动参数（可选）
 	ServerArgs *ServerArgsRequest `json:"server_args,omitempty"` // 启动参数结构
+	// 容器资源限制（可选，提供时整体替换）
+	Resources *ServerResources `json:"resources,omitempty"`
+	// 游戏服务器镜像（可选，需要已登记，修改后下次启动时重建容器）
+	Image string `json:"image"`
+	// 容器网络（可选，提供时整体替换，修改后下次启动时重建容器）
+	Network *ServerNetwork `json:"network,omitempty"`
+}
+
+// DeletedServerResponse 回收站中的服务器
+type DeletedServerResponse struct {
+	ID          uint   `json:"id"`
+	Identifier  string `json:"identifier"`
+	SessionName string `json:"session_name"` // 服务器名称
+	Map         string `json:"map"`
+	Port        int    `json:"port"`
+	UserID      uint   `json:"user_id"`
+	NodeID      uint   `json:"node_id"` // 所在节点
+	Image       string `json:"image"`   // 游戏服务器镜像
+	CreatedAt   string `json:"created_at"`
+	DeletedAt   string `json:"deleted_at"`
+	PurgeAt     string `json:"purge_at,omitempty"` // 自动彻底删除的时间（未开启自动删除时为空）
 }
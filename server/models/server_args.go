@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -71,6 +72,7 @@ func FromServer(server Server) *ServerArgs {
 
 // GenerateArgsString 生成完整的启动参数字符串
 // 从服务器基础参数中获取：游戏端口、查询端口、RCON端口、管理员密码、地图、模组ID
+// 从网络配置中获取：游戏绑定的IP（?MultiHome 和 -MULTIHOME）
 // 从启动参数中获取：其他自定义参数
 func (sa *ServerArgs) GenerateArgsString(server Server) string {
 	var queryParams []string
@@ -97,13 +99,23 @@ func (sa *ServerArgs) GenerateArgsString(server Server) string {
 		queryParams = append(queryParams, fmt.Sprintf("?GameModIds=%s", server.GameModIds))
 	}
 
-	// 添加自定义查询参数
-	for key, value := range sa.QueryParams {
+	// 网络配置指定了IP时由面板生成 MultiHome 参数，忽略启动参数中的同名参数
+	multiHomeIP := server.Network.MultiHomeIP()
+	if multiHomeIP != "" {
+		queryParams = append(queryParams, fmt.Sprintf("?MultiHome=%s", multiHomeIP))
+	}
+
+	// 添加自定义查询参数（按键名排序，保证相同配置生成的参数字符串一致）
+	for _, key := range sortedKeys(sa.QueryParams) {
+		value := sa.QueryParams[key]
 		// 跳过基础参数，避免重复
 		if key == "listen" || key == "Port" || key == "QueryPort" || key == "MaxPlayers" ||
 			key == "RCONEnabled" || key == "RCONPort" || key == "ServerAdminPassword" || key == "GameModIds" {
 			continue
 		}
+		if multiHomeIP != "" && strings.EqualFold(key, "MultiHome") {
+			continue
+		}
 
 		// 如果查询参数的值为空或为"False"，则不添加该参数
 		if value == "" || strings.ToLower(value) == "false" {
@@ -114,7 +126,11 @@ func (sa *ServerArgs) GenerateArgsString(server Server) string {
 	}
 
 	// 添加命令行参数
-	for key, value := range sa.CommandLineArgs {
+	for _, key := range sortedKeys(sa.CommandLineArgs) {
+		value := sa.CommandLineArgs[key]
+		if multiHomeIP != "" && strings.EqualFold(key, "MULTIHOME") {
+			continue
+		}
 		switch v := value.(type) {
 		case bool:
 			if v {
@@ -140,6 +156,10 @@ func (sa *ServerArgs) GenerateArgsString(server Server) string {
 		commandLineParams = append(commandLineParams, fmt.Sprintf("-clusterid=%s", server.ClusterID))
 	}
 
+	if multiHomeIP != "" {
+		commandLineParams = append(commandLineParams, "-MULTIHOME")
+	}
+
 	// 添加自定义参数
 	commandLineParams = append(commandLineParams, sa.CustomArgs...)
 
@@ -157,3 +177,13 @@ func (sa *ServerArgs) GenerateArgsString(server Server) string {
 
 	return result
 }
+
+// sortedKeys 返回按字典序排序的键名
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
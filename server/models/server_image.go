@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// DefaultServerImage 未登记其他镜像时ARK服务器使用的镜像
+const DefaultServerImage = "tbro98/ase-server:latest"
+
+// ServerImage 允许服务器使用的游戏服务器镜像
+// Image 为规范化的镜像引用，可以是标签（tbro98/ase-server:v2）或摘要（tbro98/ase-server@sha256:...）
+type ServerImage struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Image       string    `json:"image" gorm:"size:512;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:255;not null;default:''"`
+	IsDefault   bool      `json:"is_default" gorm:"not null;default:false"` // 新建服务器未指定镜像时使用
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServerImageRequest 登记镜像请求
+type ServerImageRequest struct {
+	Image       string `json:"image" binding:"required"`
+	Description string `json:"description" binding:"max=255"`
+	IsDefault   bool   `json:"is_default"`
+}
+
+// ServerImageUpdateRequest 修改镜像请求（镜像引用不能修改，未提供的字段保持不变）
+type ServerImageUpdateRequest struct {
+	Description *string `json:"description" binding:"omitempty,max=255"`
+	IsDefault   *bool   `json:"is_default"` // 只能设为 true，默认镜像通过将其他镜像设为默认来更换
+}
+
+// ServerImageResponse 镜像信息
+type ServerImageResponse struct {
+	ID          uint   `json:"id"`
+	Image       string `json:"image"`
+	Description string `json:"description"`
+	IsDefault   bool   `json:"is_default"`
+	ServerCount int64  `json:"server_count"` // 使用该镜像的服务器数量
+	CreatedAt   string `json:"created_at"`
+}
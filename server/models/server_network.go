@@ -0,0 +1,40 @@
+package models
+
+// 服务器容器的网络模式
+const (
+	NetworkModeBridge  = "bridge"  // 默认桥接网络，端口映射到主机
+	NetworkModeHost    = "host"    // 主机网络，游戏直接监听主机端口
+	NetworkModeNetwork = "network" // 已创建的自定义 Docker 网络，端口映射到主机
+	NetworkModeMacvlan = "macvlan" // macvlan 网络，容器拥有独立的IP，不占用主机端口
+)
+
+// ServerNetwork 服务器容器的网络配置（字段为空表示默认的桥接网络，端口绑定到所有地址）
+type ServerNetwork struct {
+	NetworkMode string `json:"network_mode" gorm:"size:32;not null;default:''"`  // bridge、host、network 或 macvlan
+	NetworkName string `json:"network_name" gorm:"size:255;not null;default:''"` // network 和 macvlan 模式使用的 Docker 网络
+	// bridge 和 network 模式：端口映射绑定的主机IP；host 模式：游戏监听的主机IP；macvlan 模式：容器的IP
+	BindIP string `json:"bind_ip" gorm:"size:64;not null;default:''"`
+}
+
+// Mode 网络模式（未设置时为 bridge）
+func (n ServerNetwork) Mode() string {
+	if n.NetworkMode == "" {
+		return NetworkModeBridge
+	}
+	return n.NetworkMode
+}
+
+// UsesHostPorts 容器是否占用主机端口（macvlan 模式下容器使用独立的IP）
+func (n ServerNetwork) UsesHostPorts() bool {
+	return n.Mode() != NetworkModeMacvlan
+}
+
+// MultiHomeIP 游戏需要通过 MultiHome 绑定的IP
+// 只有 host 和 macvlan 模式下该IP在容器内可用；端口映射模式下容器内没有该IP，由端口映射限制监听地址
+func (n ServerNetwork) MultiHomeIP() string {
+	switch n.Mode() {
+	case NetworkModeHost, NetworkModeMacvlan:
+		return n.BindIP
+	}
+	return ""
+}
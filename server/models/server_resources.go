@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ServerResources 服务器容器的资源限制（数值为 0 表示不限制）
+type ServerResources struct {
+	MemoryLimitMB       int64   `json:"memory_limit_mb" gorm:"not null;default:0"`       // 内存上限（MB）
+	MemoryReservationMB int64   `json:"memory_reservation_mb" gorm:"not null;default:0"` // 内存软限制（MB），主机内存紧张时回收到该值
+	CPUShares           int64   `json:"cpu_shares" gorm:"not null;default:0"`            // CPU 权重（默认 1024）
+	CPULimit            float64 `json:"cpu_limit" gorm:"not null;default:0"`             // 最多使用的CPU核数，换算为 CPU quota
+	PidsLimit           int64   `json:"pids_limit" gorm:"not null;default:0"`            // 最大进程数
+	Ulimits             Ulimits `json:"ulimits" gorm:"size:1024;not null;default:'[]'"`
+	RestartMaxRetries   int     `json:"restart_max_retries" gorm:"not null;default:0"` // 自动重启时最多重试次数，0 表示一直重启
+}
+
+// IsZero 是否没有配置任何资源限制
+func (r ServerResources) IsZero() bool {
+	return r.MemoryLimitMB == 0 && r.MemoryReservationMB == 0 && r.CPUShares == 0 && r.CPULimit == 0 &&
+		r.PidsLimit == 0 && len(r.Ulimits) == 0 && r.RestartMaxRetries == 0
+}
+
+// Ulimit 容器进程的 ulimit 限制
+type Ulimit struct {
+	Name string `json:"name"` // 如 nofile、nproc、memlock
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
+
+// Ulimits 以JSON数组存储的 ulimit 列表
+type Ulimits []Ulimit
+
+// Value 写入数据库前序列化为JSON
+func (u Ulimits) Value() (driver.Value, error) {
+	if u == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 从数据库读取JSON
+func (u *Ulimits) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*u = nil
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("无法读取ulimit字段: %T", value)
+	}
+
+	var ulimits Ulimits
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &ulimits); err != nil {
+			return fmt.Errorf("ulimit字段格式错误: %v", err)
+		}
+	}
+	if len(ulimits) == 0 {
+		ulimits = nil
+	}
+	*u = ulimits
+	return nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Session 登录会话，同一会话中轮换产生的刷新令牌属于同一个令牌族
+type Session struct {
+	ID           uint       `json:"id" gorm:"primarykey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent    string     `json:"user_agent" gorm:"not null;default:''"`    // 登录设备
+	IP           string     `json:"ip" gorm:"not null;default:''"`            // 最后访问IP
+	LastSeenAt   time.Time  `json:"last_seen_at"`                             // 最后活动时间
+	ExpiresAt    time.Time  `json:"expires_at"`                               // 会话过期时间
+	RevokedAt    *time.Time `json:"revoked_at" gorm:"index"`                  // 撤销时间
+	RevokeReason string     `json:"revoke_reason" gorm:"not null;default:''"` // 撤销原因
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RefreshToken 刷新令牌，每次使用后轮换，数据库中只保存 SHA-256 哈希
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	SessionID uint       `json:"session_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at"` // 使用（轮换）时间，再次使用视为令牌泄露
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 会话撤销原因
+const (
+	SessionRevokeLogout         = "logout"          // 用户登出
+	SessionRevokeManual         = "revoked"         // 用户手动撤销
+	SessionRevokePasswordChange = "password_change" // 密码修改
+	SessionRevokeUserDisabled   = "user_disabled"   // 用户被禁用
+	SessionRevokeTokenReuse     = "token_reuse"     // 检测到刷新令牌被重复使用
+	SessionRevokeTwoFactorReset = "2fa_reset"       // 管理员重置两步验证
+)
+
+// TokenPair 登录或刷新后返回的令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`         // 短期访问令牌（JWT）
+	RefreshToken string `json:"refresh_token"` // 刷新令牌
+	ExpiresIn    int64  `json:"expires_in"`    // 访问令牌有效期（秒）
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// PasswordChangeRequest 修改密码请求
+type PasswordChangeRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// SessionResponse 会话信息
+type SessionResponse struct {
+	ID         uint   `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"` // 是否为当前请求所在的会话
+}
@@ -0,0 +1,77 @@
+package models
+
+// DiskSpace 磁盘空间（字节）
+type DiskSpace struct {
+	Total       int64   `json:"total"`
+	Used        int64   `json:"used"`
+	Available   int64   `json:"available"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// DirectoryUsage 卷中顶层目录的占用空间（字节）
+type DirectoryUsage struct {
+	Name string `json:"name"` // 如 SavedArks、Logs
+	Size int64  `json:"size"`
+}
+
+// VolumeUsage 服务器卷的占用空间
+type VolumeUsage struct {
+	Name        string           `json:"name"`
+	Kind        string           `json:"kind"` // saved 或 plugins
+	Size        int64            `json:"size"` // 字节
+	Directories []DirectoryUsage `json:"directories"`
+}
+
+// ArchiveFile 面板保存的归档文件（清理遗留卷时的备份、数据库迁移前的备份）
+type ArchiveFile struct {
+	Path       string `json:"path"`
+	Kind       string `json:"kind"` // volume_backup 或 database_backup
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modified_at"`
+}
+
+// ServerStorage 服务器的磁盘占用
+type ServerStorage struct {
+	ServerID   uint          `json:"server_id"`
+	Identifier string        `json:"identifier"`
+	NodeID     uint          `json:"node_id"`
+	Volumes    []VolumeUsage `json:"volumes"`
+	Total      int64         `json:"total"` // 卷和归档文件的总大小（字节）
+	Archives   []ArchiveFile `json:"archives"`
+	Disk       *DiskSpace    `json:"disk"` // 卷所在磁盘的空间
+	Warnings   []string      `json:"warnings"`
+}
+
+// DockerDiskUsage Docker 的磁盘占用（字节）
+type DockerDiskUsage struct {
+	Images     int64 `json:"images"`
+	Containers int64 `json:"containers"`
+	Volumes    int64 `json:"volumes"`
+	BuildCache int64 `json:"build_cache"`
+}
+
+// ServerVolumeSize 服务器卷的大小（字节，-1 表示Docker无法统计）
+type ServerVolumeSize struct {
+	ServerID   uint   `json:"server_id"`
+	Identifier string `json:"identifier"` // 已删除或不存在的服务器为空
+	Saved      int64  `json:"saved"`
+	Plugins    int64  `json:"plugins"`
+}
+
+// NodeStorage 节点的磁盘占用
+type NodeStorage struct {
+	NodeID   uint               `json:"node_id"` // 0 表示面板所在主机
+	NodeName string             `json:"node_name"`
+	Disk     *DiskSpace         `json:"disk,omitempty"`
+	Docker   *DockerDiskUsage   `json:"docker,omitempty"`
+	Servers  []ServerVolumeSize `json:"servers"` // 按卷总大小从大到小排序
+	Error    string             `json:"error,omitempty"`
+}
+
+// StorageReport 所有节点的磁盘占用
+type StorageReport struct {
+	Nodes        []NodeStorage `json:"nodes"`
+	Archives     []ArchiveFile `json:"archives"`
+	ArchivesSize int64         `json:"archives_size"`
+	Warnings     []string      `json:"warnings"`
+}
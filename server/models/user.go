@@ -6,13 +6,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleOwner    = "owner"    // 所有者：拥有全部权限，可管理所有用户
+	RoleAdmin    = "admin"    // 管理员：可管理所有服务器，可管理操作员和观察者
+	RoleOperator = "operator" // 操作员：可创建服务器，按授权操作他人的服务器
+	RoleViewer   = "viewer"   // 观察者：只能查看被授权的服务器
+)
+
 type User struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
 	Username  string         `json:"username" gorm:"unique;not null"`
 	Password  string         `json:"-" gorm:"not null"`
+	Role      string         `json:"role" gorm:"not null;default:'viewer'"`  // 用户角色
+	Disabled  bool           `json:"disabled" gorm:"not null;default:false"` // 是否已禁用
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	// 两步验证
+	TOTPSecret        EncryptedString `json:"-"`                                           // 已启用的TOTP密钥
+	TOTPPendingSecret EncryptedString `json:"-"`                                           // 等待确认的TOTP密钥
+	TOTPEnabled       bool            `json:"totp_enabled" gorm:"not null;default:false"`  // 是否已启用两步验证
+	TOTPRequired      bool            `json:"totp_required" gorm:"not null;default:false"` // 管理员是否要求启用两步验证
+	TOTPLastStep      int64           `json:"-"`                                           // 最后一次使用的时间步，防止验证码重放
 }
 
 type UserRequest struct {
@@ -21,6 +38,43 @@ type UserRequest struct {
 }
 
 type UserResponse struct {
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
+	ID        uint   `json:"id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	Disabled  bool   `json:"disabled"`
+	CreatedAt string `json:"created_at,omitempty"`
+
+	TwoFactorEnabled  bool `json:"two_factor_enabled"`
+	TwoFactorRequired bool `json:"two_factor_required"`
+}
+
+// UserCreateRequest 创建用户请求
+type UserCreateRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UserRoleRequest 修改用户角色请求
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UserPasswordResetRequest 重置用户密码请求
+type UserPasswordResetRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// UserTwoFactorRequiredRequest 设置是否强制用户启用两步验证
+type UserTwoFactorRequiredRequest struct {
+	Required bool `json:"required"`
+}
+
+// IsValidRole 检查角色是否有效
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleOwner, RoleAdmin, RoleOperator, RoleViewer:
+		return true
+	}
+	return false
 }
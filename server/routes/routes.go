@@ -1,10 +1,21 @@
 package routes
 
 import (
+	"ark-server-commander/controllers/audits"
 	"ark-server-commander/controllers/auth"
+	"ark-server-commander/controllers/files"
 	"ark-server-commander/controllers/images"
+	"ark-server-commander/controllers/maintenance"
+	"ark-server-commander/controllers/mods"
+	"ark-server-commander/controllers/nodes"
+	"ark-server-commander/controllers/permissions"
+	"ark-server-commander/controllers/plugins"
 	"ark-server-commander/controllers/servers"
+	"ark-server-commander/controllers/sessions"
+	"ark-server-commander/controllers/tokens"
+	"ark-server-commander/controllers/users"
 	"ark-server-commander/middleware"
+	"ark-server-commander/models"
 	"fmt"
 	"net/http"
 	"os"
@@ -58,37 +69,188 @@ func RegisterRoutes(r *gin.Engine) {
 			authRoutes.GET("/check-init", auth.CheckInit)
 			authRoutes.POST("/init", auth.InitUser)
 			authRoutes.POST("/login", auth.Login)
+			authRoutes.POST("/refresh", auth.Refresh)
+			authRoutes.POST("/login/2fa", auth.LoginTwoFactor)
+
+			// OIDC 单点登录
+			authRoutes.GET("/oidc/config", auth.GetOIDCConfig)
+			authRoutes.GET("/oidc/login", auth.OIDCLogin)
+			authRoutes.GET("/oidc/callback", auth.OIDCCallback)
+			authRoutes.POST("/oidc/exchange", auth.OIDCExchange)
 		}
 
 		// 需要认证的路由
 		protected := api.Group("") // 改为空字符串，避免双斜杠
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.AuditMiddleware())
 		{
 			protected.GET("/profile", auth.GetProfile)
 
+			// 会话相关路由（不能使用API令牌访问）
+			accountRoutes := protected.Group("")
+			accountRoutes.Use(middleware.DenyAPIToken())
+			{
+				accountRoutes.POST("/auth/logout", auth.Logout)
+				accountRoutes.POST("/auth/password", auth.ChangePassword)
+				accountRoutes.GET("/sessions", sessions.GetSessions)
+				accountRoutes.DELETE("/sessions/:id", sessions.RevokeSession)
+
+				// 两步验证
+				accountRoutes.GET("/auth/2fa", auth.GetTwoFactorStatus)
+				accountRoutes.POST("/auth/2fa/setup", auth.SetupTwoFactor)
+				accountRoutes.POST("/auth/2fa/confirm", auth.ConfirmTwoFactor)
+				accountRoutes.POST("/auth/2fa/disable", auth.DisableTwoFactor)
+				accountRoutes.POST("/auth/2fa/recovery-codes", auth.RegenerateRecoveryCodes)
+
+				// 外部账号绑定
+				accountRoutes.POST("/auth/oidc/link", auth.LinkOIDCIdentity)
+				accountRoutes.GET("/auth/oidc/identities", auth.GetOIDCIdentities)
+				accountRoutes.DELETE("/auth/oidc/identities/:id", auth.DeleteOIDCIdentity)
+			}
+
+			// 服务器操作权限检查
+			canView := middleware.RequireServerPermission(models.PermissionView)
+			canStartStop := middleware.RequireServerPermission(models.PermissionStartStop)
+			canEditConfig := middleware.RequireServerPermission(models.PermissionEditConfig)
+			canRCON := middleware.RequireServerPermission(models.PermissionRCON)
+			canDelete := middleware.RequireServerDelete()
+			canManage := middleware.RequireServerPermission(models.PermissionManage)
+
 			// 服务器管理路由
 			serverRoutes := protected.Group("/servers")
 			{
 				serverRoutes.GET("", servers.GetServers)
-				serverRoutes.POST("", servers.CreateServer)
-				serverRoutes.GET("/:id", servers.GetServer)
-				serverRoutes.PUT("/:id", servers.UpdateServer)
-				serverRoutes.DELETE("/:id", servers.DeleteServer)
-				serverRoutes.POST("/:id/start", servers.StartServer)
-				serverRoutes.POST("/:id/stop", servers.StopServer)
-				serverRoutes.POST("/:id/recreate", servers.RecreateContainer)
-				serverRoutes.GET("/:id/rcon", servers.GetServerRCON)
+				serverRoutes.POST("", middleware.RequireRole(models.RoleOwner, models.RoleAdmin, models.RoleOperator), servers.CreateServer)
+				serverRoutes.GET("/trash", middleware.RequireRole(models.RoleOwner, models.RoleAdmin), servers.GetDeletedServers)
+				serverRoutes.GET("/:id", canView, servers.GetServer)
+				serverRoutes.PUT("/:id", canEditConfig, servers.UpdateServer)
+				serverRoutes.DELETE("/:id", canDelete, servers.DeleteServer)
+				serverRoutes.POST("/:id/restore", middleware.RequireRole(models.RoleOwner, models.RoleAdmin), servers.RestoreServer)
+				serverRoutes.POST("/:id/start", canStartStop, servers.StartServer)
+				serverRoutes.POST("/:id/stop", canStartStop, servers.StopServer)
+				serverRoutes.POST("/:id/recreate", canStartStop, servers.RecreateContainer)
+				serverRoutes.GET("/:id/rcon", middleware.AuditAccess("servers.rcon.view"), canRCON, servers.GetServerRCON)
+				serverRoutes.GET("/:id/admin-password", middleware.AuditAccess("servers.admin_password.reveal"), canRCON, servers.RevealAdminPassword)
+				serverRoutes.GET("/:id/storage", canView, maintenance.GetServerStorage)
+
+				// 服务器授权管理
+				serverRoutes.GET("/:id/permissions", canManage, permissions.GetServerPermissions)
+				serverRoutes.PUT("/:id/permissions/:user_id", canManage, permissions.SetServerPermissions)
+				serverRoutes.DELETE("/:id/permissions/:user_id", canManage, permissions.DeleteServerPermissions)
+
+				// 服务器模组列表
+				serverRoutes.GET("/:id/mods", canView, mods.GetServerMods)
+				serverRoutes.GET("/:id/mods/status", canView, mods.GetServerModStatus)
+				serverRoutes.POST("/:id/mods", canEditConfig, mods.AddServerMod)
+				serverRoutes.PUT("/:id/mods/order", canEditConfig, mods.ReorderServerMods)
+				serverRoutes.DELETE("/:id/mods/:workshop_id", canEditConfig, mods.RemoveServerMod)
+
+				// 服务器 ArkApi 插件
+				serverRoutes.GET("/:id/plugins", canView, plugins.GetPlugins)
+				serverRoutes.POST("/:id/plugins", canEditConfig, plugins.UploadPlugin)
+				serverRoutes.POST("/:id/plugins/:name/enable", canEditConfig, plugins.EnablePlugin)
+				serverRoutes.POST("/:id/plugins/:name/disable", canEditConfig, plugins.DisablePlugin)
+				serverRoutes.GET("/:id/plugins/:name/config", canEditConfig, plugins.GetPluginConfig)
+				serverRoutes.PUT("/:id/plugins/:name/config", canEditConfig, plugins.UpdatePluginConfig)
+				serverRoutes.DELETE("/:id/plugins/:name", canEditConfig, plugins.DeletePlugin)
+
+				// 服务器卷文件管理（volume: saved 或 plugins）
+				serverRoutes.GET("/:id/files/:volume", canEditConfig, files.ListFiles)
+				serverRoutes.GET("/:id/files/:volume/stat", canEditConfig, files.StatFile)
+				serverRoutes.GET("/:id/files/:volume/download", canEditConfig, files.DownloadFile)
+				serverRoutes.POST("/:id/files/:volume/upload", canEditConfig, files.UploadFile)
+				serverRoutes.POST("/:id/files/:volume/rename", canEditConfig, files.RenameFile)
+				serverRoutes.POST("/:id/files/:volume/mkdir", canEditConfig, files.MakeDirectory)
+				serverRoutes.DELETE("/:id/files/:volume", canEditConfig, files.DeleteFile)
+			}
+
+			// 用户管理路由（所有者和管理员）
+			userRoutes := protected.Group("/users")
+			userRoutes.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
+			{
+				userRoutes.GET("", users.GetUsers)
+				userRoutes.POST("", users.CreateUser)
+				userRoutes.PUT("/:id/role", users.UpdateUserRole)
+				userRoutes.POST("/:id/disable", users.DisableUser)
+				userRoutes.POST("/:id/enable", users.EnableUser)
+				userRoutes.POST("/:id/reset-password", users.ResetUserPassword)
+				userRoutes.PUT("/:id/2fa", users.SetUserTwoFactorRequired)
+				userRoutes.DELETE("/:id/2fa", users.ResetUserTwoFactor)
+				userRoutes.POST("/:id/unlock", users.UnlockUser)
 			}
 
-			// 镜像管理路由
+			// 登录记录（所有者和管理员）
+			protected.GET("/login-attempts", middleware.RequireRole(models.RoleOwner, models.RoleAdmin), users.GetLoginAttempts)
+
+			// 审计日志（所有者和管理员）
+			auditRoutes := protected.Group("/audit")
+			auditRoutes.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
+			{
+				auditRoutes.GET("", audits.GetAuditLog)
+				auditRoutes.GET("/export", audits.ExportAuditLog)
+			}
+
+			// 模组目录路由
+			modRoutes := protected.Group("/mods")
+			{
+				modRoutes.GET("", mods.GetMods)
+				modRoutes.GET("/:workshop_id", mods.GetMod)
+			}
+
+			// API令牌管理路由（不能使用API令牌访问）
+			tokenRoutes := protected.Group("/tokens")
+			tokenRoutes.Use(middleware.DenyAPIToken())
+			{
+				tokenRoutes.GET("", tokens.GetTokens)
+				tokenRoutes.POST("", tokens.CreateToken)
+				tokenRoutes.DELETE("/:id", tokens.RevokeToken)
+			}
+
+			// 镜像管理路由（所有者和管理员）
 			imageRoutes := protected.Group("/images")
+			imageRoutes.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
 			{
 				imageRoutes.GET("/status", images.GetImageStatus)
 				imageRoutes.POST("/pull", images.PullImage)
+				imageRoutes.GET("/pulls", images.GetPullJobs)
+				imageRoutes.GET("/pulls/:id", images.GetPullJob)
+				imageRoutes.GET("/pulls/:id/events", images.StreamPullJob)
+				imageRoutes.POST("/pulls/:id/cancel", images.CancelPullJob)
 				imageRoutes.GET("/check-updates", images.CheckImageUpdates)
 				imageRoutes.POST("/update", images.UpdateImage)
 				imageRoutes.GET("/affected", images.GetAffectedServers)
+				imageRoutes.GET("/registry", images.GetRegistryImages)
+				imageRoutes.POST("/registry", images.CreateRegistryImage)
+				imageRoutes.PUT("/registry/:id", images.UpdateRegistryImage)
+				imageRoutes.DELETE("/registry/:id", images.DeleteRegistryImage)
+				imageRoutes.GET("/credentials", images.GetRegistryCredentials)
+				imageRoutes.POST("/credentials", images.CreateRegistryCredential)
+				imageRoutes.PUT("/credentials/:id", images.UpdateRegistryCredential)
+				imageRoutes.DELETE("/credentials/:id", images.DeleteRegistryCredential)
+				imageRoutes.POST("/credentials/:id/test", images.TestRegistryCredential)
 			}
+
+			// 节点管理路由（所有者和管理员）
+			nodeRoutes := protected.Group("/nodes")
+			nodeRoutes.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
+			{
+				nodeRoutes.GET("", nodes.GetNodes)
+				nodeRoutes.POST("", nodes.CreateNode)
+				nodeRoutes.GET("/:id", nodes.GetNode)
+				nodeRoutes.PUT("/:id", nodes.UpdateNode)
+				nodeRoutes.DELETE("/:id", nodes.DeleteNode)
+				nodeRoutes.POST("/:id/check", nodes.CheckNode)
+			}
+
+			// 维护路由（所有者和管理员）
+			maintenanceRoutes := protected.Group("/maintenance")
+			maintenanceRoutes.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
+			{
+				maintenanceRoutes.GET("/orphans", maintenance.GetOrphans)
+				maintenanceRoutes.POST("/orphans", middleware.AuditAccess("maintenance.orphans.cleanup"), maintenance.CleanupOrphans)
+			}
+
+			// 磁盘占用（所有者和管理员）
+			protected.GET("/storage", middleware.RequireRole(models.RoleOwner, models.RoleAdmin), maintenance.GetStorageReport)
 		}
 	}
 }
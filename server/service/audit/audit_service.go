@@ -0,0 +1,311 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 查询和导出限制
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	maxExportRows   = 100000 // 单次导出的最大条数
+	exportBatchSize = 500
+)
+
+// 导出格式
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// SystemActorName 系统自动执行操作时的操作者名称
+const SystemActorName = "system"
+
+// Record 写入一条审计日志（失败只记录错误日志，不影响业务操作）
+func Record(entry models.AuditEntry) {
+	if entry.Result == "" {
+		entry.Result = models.AuditResultSuccess
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		utils.Error("写入审计日志失败", zap.String("action", entry.Action), zap.Uint("actor_id", entry.ActorID), zap.Error(err))
+	}
+}
+
+// RecordSystem 记录系统自动执行的操作（服务层钩子使用，如自动重启、RCON命令）
+// serverID: 目标服务器ID，0 表示不针对具体服务器
+// opErr: 操作错误，为 nil 时记录为成功
+func RecordSystem(action string, serverID uint, params map[string]interface{}, opErr error) {
+	entry := models.AuditEntry{
+		ActorName: SystemActorName,
+		Action:    action,
+		Params:    EncodeParams(params),
+		Result:    models.AuditResultSuccess,
+	}
+	if serverID != 0 {
+		entry.ServerID = &serverID
+	}
+	if opErr != nil {
+		entry.Result = models.AuditResultFailure
+		entry.Error = opErr.Error()
+	}
+	Record(entry)
+}
+
+// EncodeParams 脱敏后将参数编码为JSON字符串
+func EncodeParams(params interface{}) string {
+	if params == nil {
+		return ""
+	}
+	// 先编解码一次转为通用结构，便于按字段名脱敏
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return ""
+	}
+	encoded, err := json.Marshal(RedactParams(generic))
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// ActionFromRoute 根据HTTP方法和路由模板生成操作名称
+// 例如 POST /api/servers/:id/start -> servers.start，PUT /api/servers/:id -> servers.update
+func ActionFromRoute(method, fullPath string) string {
+	var parts []string
+	endsWithParam := true
+	for _, segment := range strings.Split(strings.TrimPrefix(fullPath, "/api"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			endsWithParam = true
+			continue
+		}
+		parts = append(parts, segment)
+		endsWithParam = false
+	}
+
+	// POST 到具体动作（如 /start）时动作名即为最后一段；POST 到集合（复数名词，如 /mods）视为创建
+	verb := ""
+	switch method {
+	case "POST":
+		if endsWithParam || len(parts) == 0 || strings.HasSuffix(parts[len(parts)-1], "s") {
+			verb = "create"
+		}
+	case "PUT", "PATCH":
+		verb = "update"
+	case "DELETE":
+		verb = "delete"
+	}
+	if verb != "" {
+		parts = append(parts, verb)
+	}
+	return strings.Join(parts, ".")
+}
+
+// AuditService 审计日志查询服务
+type AuditService struct{}
+
+// NewAuditService 创建审计日志查询服务实例
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// List 分页查询审计日志（按时间倒序）
+// 返回: 审计日志、总数和错误信息
+func (s *AuditService) List(query models.AuditQuery) ([]models.AuditEntryResponse, int64, error) {
+	page := query.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	db := filterQuery(database.DB.Model(&models.AuditEntry{}), query)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取审计日志失败: %w", err)
+	}
+
+	var entries []models.AuditEntry
+	if err := db.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取审计日志失败: %w", err)
+	}
+
+	responses := make([]models.AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		responses = append(responses, ToAuditEntryResponse(entry))
+	}
+	return responses, total, nil
+}
+
+// Export 按查询条件导出审计日志（CSV 或 JSON 数组），最多导出 maxExportRows 条
+func (s *AuditService) Export(query models.AuditQuery, format string, w io.Writer) error {
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return fmt.Errorf("无效的导出格式: %s", format)
+	}
+
+	var csvWriter *csv.Writer
+	if format == ExportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write([]string{"id", "created_at", "actor_id", "actor_name", "token_id", "action", "method", "path", "server_id", "result", "status", "error", "ip", "params"})
+	} else if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	written := 0
+	var writeErr error
+	var batch []models.AuditEntry
+	// FindInBatches 按主键顺序分批读取，避免一次加载全部记录
+	result := filterQuery(database.DB.Model(&models.AuditEntry{}), query).
+		Limit(maxExportRows).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, entry := range batch {
+				if csvWriter != nil {
+					writeErr = csvWriter.Write(csvRecord(entry))
+				} else {
+					writeErr = writeJSONEntry(w, entry, written == 0)
+				}
+				if writeErr != nil {
+					return writeErr
+				}
+				written++
+			}
+			if csvWriter != nil {
+				csvWriter.Flush()
+				return csvWriter.Error()
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return fmt.Errorf("导出审计日志失败: %w", result.Error)
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// ToAuditEntryResponse 构建审计日志响应
+func ToAuditEntryResponse(entry models.AuditEntry) models.AuditEntryResponse {
+	response := models.AuditEntryResponse{
+		ID:        entry.ID,
+		ActorID:   entry.ActorID,
+		ActorName: entry.ActorName,
+		TokenID:   entry.TokenID,
+		Action:    entry.Action,
+		Method:    entry.Method,
+		Path:      entry.Path,
+		ServerID:  entry.ServerID,
+		Result:    entry.Result,
+		Status:    entry.Status,
+		Error:     entry.Error,
+		IP:        entry.IP,
+		CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+	}
+	if entry.Params != "" && json.Valid([]byte(entry.Params)) {
+		response.Params = json.RawMessage(entry.Params)
+	}
+	return response
+}
+
+// filterQuery 应用查询条件
+func filterQuery(db *gorm.DB, query models.AuditQuery) *gorm.DB {
+	if query.ActorID != nil {
+		db = db.Where("actor_id = ?", *query.ActorID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ? OR action LIKE ? ESCAPE '!'", query.Action, escapeLike(query.Action)+".%")
+	}
+	if query.ServerID != nil {
+		db = db.Where("server_id = ?", *query.ServerID)
+	}
+	if query.Result != "" {
+		db = db.Where("result = ?", query.Result)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+	return db
+}
+
+// escapeLike 转义 LIKE 通配符（使用 ! 作为转义字符，兼容不同数据库）
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// writeJSONEntry 写入 JSON 数组中的一条记录
+func writeJSONEntry(w io.Writer, entry models.AuditEntry, first bool) error {
+	encoded, err := json.Marshal(ToAuditEntryResponse(entry))
+	if err != nil {
+		return err
+	}
+	if !first {
+		if _, err := io.WriteString(w, ",\n"); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(encoded)
+	return err
+}
+
+// csvRecord 构建 CSV 行
+func csvRecord(entry models.AuditEntry) []string {
+	serverID := ""
+	if entry.ServerID != nil {
+		serverID = strconv.FormatUint(uint64(*entry.ServerID), 10)
+	}
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt.Format(time.RFC3339),
+		strconv.FormatUint(uint64(entry.ActorID), 10),
+		csvCell(entry.ActorName),
+		strconv.FormatUint(uint64(entry.TokenID), 10),
+		csvCell(entry.Action),
+		entry.Method,
+		csvCell(entry.Path),
+		serverID,
+		entry.Result,
+		strconv.Itoa(entry.Status),
+		csvCell(entry.Error),
+		entry.IP,
+		csvCell(entry.Params),
+	}
+}
+
+// csvCell 防止表格软件把以 = + - @ 开头的内容当作公式执行
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
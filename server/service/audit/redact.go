@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedValue 脱敏后的替换值
+const redactedValue = "***"
+
+// sensitiveKeys 字段名包含这些关键字时整体脱敏（不区分大小写）
+var sensitiveKeys = []string{"password", "secret", "token", "recovery_code", "api_key", "private_key"}
+
+// sensitiveExactKeys 字段名完全匹配时脱敏（两步验证码、节点私钥等）
+var sensitiveExactKeys = map[string]bool{"code": true, "tls_key": true, "ssh_key": true}
+
+var (
+	// iniSecretPattern 匹配配置文件中的密码行，如 ServerAdminPassword=xxx
+	iniSecretPattern = regexp.MustCompile(`(?im)^(\s*[\w.]*(?:password|secret|token)[\w.]*\s*=).*$`)
+	// jsonSecretPattern 匹配嵌在字符串中的JSON密码字段，如 "DbPassword": "xxx"
+	jsonSecretPattern = regexp.MustCompile(`(?i)("[^"]*(?:password|secret|token)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// RedactParams 递归脱敏请求参数中的敏感字段
+func RedactParams(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if isSensitiveKey(key) {
+				typed[key] = redactedValue
+				continue
+			}
+			typed[key] = RedactParams(item)
+		}
+		return typed
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = RedactParams(item)
+		}
+		return typed
+	case string:
+		return RedactText(typed)
+	default:
+		return value
+	}
+}
+
+// RedactText 脱敏文本中的配置文件密码行和JSON密码字段
+func RedactText(text string) string {
+	text = iniSecretPattern.ReplaceAllString(text, "${1}"+redactedValue)
+	return jsonSecretPattern.ReplaceAllString(text, `${1}"`+redactedValue+`"`)
+}
+
+// isSensitiveKey 判断字段名是否为敏感字段
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	if sensitiveExactKeys[lower] {
+		return true
+	}
+	for _, keyword := range sensitiveKeys {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -29,7 +29,6 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 
 	containerName := utils.GetServerContainerName(serverID)
 	volumeName := utils.GetServerVolumeName(serverID)
-	imageName := "tbro98/ase-server:latest"
 
 	utils.Info("开始创建容器（带回滚保护）",
 		zap.String("container", containerName),
@@ -47,7 +46,15 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 		}
 	}
 
-	// 步骤2: 检查镜像是否存在
+	// 步骤2: 获取服务器信息
+	var server models.Server
+	if dbErr := database.DB.Where("id = ?", serverID).First(&server).Error; dbErr != nil {
+		err = fmt.Errorf("获取服务器信息失败: %w", dbErr)
+		return "", err
+	}
+
+	// 步骤3: 检查服务器使用的镜像是否存在
+	imageName := server.ImageName()
 	imageExists, checkErr := dm.ImageExists(imageName)
 	if checkErr != nil {
 		err = fmt.Errorf("检查镜像是否存在失败: %w", checkErr)
@@ -58,13 +65,6 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 		return "", err
 	}
 
-	// 步骤3: 获取服务器信息
-	var server models.Server
-	if dbErr := database.DB.Where("id = ?", serverID).First(&server).Error; dbErr != nil {
-		err = fmt.Errorf("获取服务器信息失败: %w", dbErr)
-		return "", err
-	}
-
 	// 步骤4: 构建启动参数
 	serverArgs := models.NewServerArgs()
 	if server.ServerArgsJSON != "" && server.ServerArgsJSON != "{}" {
@@ -85,8 +85,9 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 
 	// 步骤6: 构建容器配置
 	containerConfig := &container.Config{
-		Image: imageName,
-		Env:   envVars,
+		Image:  imageName,
+		Env:    envVars,
+		Labels: resourceLabels(serverID, RoleServer),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/udp", port)):      struct{}{},
 			nat.Port(fmt.Sprintf("%d/tcp", port)):      struct{}{},
@@ -99,17 +100,10 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 		},
 	}
 
-	// 步骤7: 设置重启策略
-	restartPolicyName := container.RestartPolicyMode("unless-stopped")
-	if !autoRestart {
-		restartPolicyName = container.RestartPolicyMode("no")
-	}
-
-	// 步骤8: 构建主机配置
+	// 步骤7: 构建主机配置（重启策略和资源限制）
 	hostConfig := &container.HostConfig{
-		RestartPolicy: container.RestartPolicy{
-			Name: restartPolicyName,
-		},
+		RestartPolicy: restartPolicy(autoRestart, server.Resources.RestartMaxRetries),
+		Resources:     containerResources(server.Resources),
 		PortBindings: nat.PortMap{
 			nat.Port(fmt.Sprintf("%d/udp", port)): {
 				{HostPort: fmt.Sprintf("%d", port)},
@@ -142,9 +136,12 @@ func (dm *DockerManager) CreateContainerWithRollback(serverID uint, serverName s
 		},
 	}
 
+	// 步骤8: 应用网络模式和端口绑定的IP
+	networkingConfig := applyNetwork(server.Network, hostConfig)
+
 	// 步骤9: 创建容器
 	utils.Info("正在创建Docker容器", zap.String("container", containerName))
-	resp, createErr := dm.client.ContainerCreate(dm.ctx, containerConfig, hostConfig, nil, nil, containerName)
+	resp, createErr := dm.client.ContainerCreate(dm.ctx, containerConfig, hostConfig, networkingConfig, nil, containerName)
 	if createErr != nil {
 		err = fmt.Errorf("创建Docker容器失败: %w", createErr)
 		return "", err
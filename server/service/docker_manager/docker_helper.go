@@ -0,0 +1,271 @@
+package docker_manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// 辅助容器中服务器卷的挂载点
+const (
+	HelperSavedMount   = "/data/saved"   // Saved 卷（游戏存档、配置、日志）
+	HelperPluginsMount = "/data/plugins" // ArkApi 插件卷
+)
+
+// VolumeSession 挂载了服务器卷的辅助容器会话
+// 路径均为辅助容器内的绝对路径（以 HelperSavedMount 或 HelperPluginsMount 开头）
+type VolumeSession struct {
+	dm          *DockerManager
+	containerID string
+}
+
+// WithVolumes 在挂载服务器卷的常驻辅助容器中执行操作
+// 辅助容器按需创建，空闲超时后由回收任务删除
+// serverID: 服务器ID
+// fn: 在容器中执行的操作
+// 返回: 错误信息
+func (dm *DockerManager) WithVolumes(serverID uint, fn func(session *VolumeSession) error) error {
+	containerID, release, err := dm.acquireHelper(serverID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn(&VolumeSession{dm: dm, containerID: containerID})
+}
+
+// ReadFile 读取文件内容
+func (vs *VolumeSession) ReadFile(filePath string) ([]byte, error) {
+	return vs.dm.readFileFromContainer(vs.containerID, filePath)
+}
+
+// WriteFile 写入文件（父目录不存在时自动创建）
+func (vs *VolumeSession) WriteFile(filePath string, content []byte) error {
+	dir := path.Dir(filePath)
+	if _, err := vs.Exec("mkdir", "-p", dir); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	archive, err := buildSingleFileTar(path.Base(filePath), content)
+	if err != nil {
+		return err
+	}
+	return vs.dm.copyTarToContainer(vs.containerID, dir, archive)
+}
+
+// WriteStream 以流的方式写入文件（父目录不存在时自动创建）
+// size: 内容大小（tar 文件头需要预先知道大小）
+func (vs *VolumeSession) WriteStream(filePath string, size int64, content io.Reader) error {
+	dir := path.Dir(filePath)
+	if _, err := vs.Exec("mkdir", "-p", dir); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		tarWriter := tar.NewWriter(pipeWriter)
+		header := &tar.Header{
+			Name:    path.Base(filePath),
+			Mode:    0644,
+			Size:    size,
+			ModTime: time.Now(),
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			pipeWriter.CloseWithError(err)
+			return
+		}
+		if _, err := io.CopyN(tarWriter, content, size); err != nil {
+			pipeWriter.CloseWithError(err)
+			return
+		}
+		pipeWriter.CloseWithError(tarWriter.Close())
+	}()
+
+	err := vs.dm.copyTarToContainer(vs.containerID, dir, pipeReader)
+	pipeReader.Close()
+	return err
+}
+
+// OpenFile 以流的方式读取文件，fn 返回前内容有效
+// 返回: 错误信息（文件不存在时错误可用 errdefs.IsNotFound 判断）
+func (vs *VolumeSession) OpenFile(filePath string, fn func(size int64, content io.Reader) error) error {
+	reader, _, err := vs.dm.client.CopyFromContainer(vs.dm.ctx, vs.containerID, filePath)
+	if err != nil {
+		return fmt.Errorf("从容器复制文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+	header, err := tarReader.Next()
+	if err != nil {
+		return fmt.Errorf("读取 tar 文件头失败: %v", err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return fmt.Errorf("路径不是文件: %s", filePath)
+	}
+
+	return fn(header.Size, tarReader)
+}
+
+// Stat 获取路径信息（不跟随符号链接）
+// 返回: 路径信息和错误信息（路径不存在时错误可用 errdefs.IsNotFound 判断）
+func (vs *VolumeSession) Stat(filePath string) (container.PathStat, error) {
+	stat, err := vs.dm.client.ContainerStatPath(vs.dm.ctx, vs.containerID, filePath)
+	if err != nil {
+		return container.PathStat{}, fmt.Errorf("获取路径信息失败: %w", err)
+	}
+	return stat, nil
+}
+
+// Extract 将tar归档解压到指定目录（目录不存在时自动创建）
+func (vs *VolumeSession) Extract(dstDir string, archive io.Reader) error {
+	if _, err := vs.Exec("mkdir", "-p", dstDir); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return vs.dm.copyTarToContainer(vs.containerID, dstDir, archive)
+}
+
+// Exec 在辅助容器中执行命令
+func (vs *VolumeSession) Exec(cmd ...string) (string, error) {
+	return vs.dm.execInContainer(vs.containerID, cmd)
+}
+
+// Exists 检查路径是否存在
+func (vs *VolumeSession) Exists(filePath string) (bool, error) {
+	_, err := vs.dm.client.ContainerStatPath(vs.dm.ctx, vs.containerID, filePath)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("检查路径失败: %v", err)
+	}
+	return true, nil
+}
+
+// ListDirs 列出目录下的子目录名称（目录不存在时返回空列表）
+func (vs *VolumeSession) ListDirs(dir string) ([]string, error) {
+	exists, err := vs.Exists(dir)
+	if err != nil || !exists {
+		return []string{}, err
+	}
+
+	output, err := vs.Exec("find", dir, "-mindepth", "1", "-maxdepth", "1", "-type", "d")
+	if err != nil {
+		return nil, fmt.Errorf("列出目录失败: %w", err)
+	}
+
+	names := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, path.Base(line))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// buildSingleFileTar 构建只包含一个文件的tar归档
+func buildSingleFileTar(name string, content []byte) (io.Reader, error) {
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("构建 tar 文件头失败: %v", err)
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		return nil, fmt.Errorf("写入 tar 内容失败: %v", err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("构建 tar 归档失败: %v", err)
+	}
+
+	return &buffer, nil
+}
+
+// execInContainer 在容器中直接执行命令（不经过shell，参数无需转义）
+// containerID: 容器ID
+// cmd: 命令及参数
+// 返回: 标准输出和错误信息（退出码非0时错误中包含标准错误输出）
+func (dm *DockerManager) execInContainer(containerID string, cmd []string) (string, error) {
+	execResp, err := dm.client.ContainerExecCreate(dm.ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建执行实例失败: %v", err)
+	}
+
+	resp, err := dm.client.ContainerExecAttach(dm.ctx, execResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("执行命令失败: %v", err)
+	}
+	defer resp.Close()
+
+	// 分离标准输出和标准错误
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return "", fmt.Errorf("读取命令输出失败: %v", err)
+	}
+
+	inspectResp, err := dm.client.ContainerExecInspect(dm.ctx, execResp.ID)
+	if err != nil {
+		return "", fmt.Errorf("检查执行结果失败: %v", err)
+	}
+	if inspectResp.ExitCode != 0 {
+		return stdout.String(), fmt.Errorf("命令执行失败，退出码: %d: %s", inspectResp.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// readFileFromContainer 从容器中读取单个文件
+// 返回: 文件内容和错误信息（文件不存在时错误可用 errdefs.IsNotFound 判断）
+func (dm *DockerManager) readFileFromContainer(containerID, filePath string) ([]byte, error) {
+	reader, _, err := dm.client.CopyFromContainer(dm.ctx, containerID, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("从容器复制文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+	header, err := tarReader.Next()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("文件不存在: %s", filePath)
+		}
+		return nil, fmt.Errorf("读取 tar 文件头失败: %v", err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("路径不是文件: %s", filePath)
+	}
+
+	content, err := io.ReadAll(tarReader)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件内容失败: %v", err)
+	}
+	return content, nil
+}
+
+// copyTarToContainer 将tar归档解压到容器的指定目录
+func (dm *DockerManager) copyTarToContainer(containerID, dstDir string, archive io.Reader) error {
+	if err := dm.client.CopyToContainer(dm.ctx, containerID, dstDir, archive, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("复制文件到容器失败: %v", err)
+	}
+	return nil
+}
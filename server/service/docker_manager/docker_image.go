@@ -1,13 +1,15 @@
 package docker_manager
 
 import (
+	"ark-server-commander/config"
+	"ark-server-commander/models"
 	"ark-server-commander/utils"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/containerd/errdefs"
@@ -24,7 +26,8 @@ type ImagePullProgress struct {
 		Current int64 `json:"current"`
 		Total   int64 `json:"total"`
 	} `json:"progressDetail"`
-	ID string `json:"id"`
+	ID    string `json:"id"`
+	Error string `json:"error"` // 拉取失败时的错误信息（如仓库拒绝访问）
 }
 
 // LayerStatus 层级状态信息
@@ -37,142 +40,100 @@ type LayerStatus struct {
 
 // ImageStatus 镜像状态信息
 type ImageStatus struct {
-	Exists       bool                    `json:"exists"`        // 镜像是否存在
-	Pulling      bool                    `json:"pulling"`       // 是否正在拉取
-	Ready        bool                    `json:"ready"`         // 是否准备就绪
-	Error        string                  `json:"error"`         // 错误信息
-	CurrentLayer string                  `json:"current_layer"` // 当前下载的层级
-	Layers       map[string]*LayerStatus `json:"layers"`        // 所有层级的状态
+	Exists       bool                    `json:"exists"`              // 镜像是否存在
+	Pulling      bool                    `json:"pulling"`             // 是否正在拉取
+	Ready        bool                    `json:"ready"`               // 是否准备就绪
+	Error        string                  `json:"error"`               // 错误信息
+	CurrentLayer string                  `json:"current_layer"`       // 当前下载的层级
+	Layers       map[string]*LayerStatus `json:"layers"`              // 所有层级的状态
+	JobID        string                  `json:"job_id,omitempty"`    // 正在进行的拉取任务ID
+	LastPull     *PullJobInfo            `json:"last_pull,omitempty"` // 未拉取时最近一次拉取的结果（如失败原因）
 }
 
-// 全局镜像状态管理
-var (
-	imagePullStatus       = make(map[string]bool)                    // 记录镜像是否正在拉取
-	imagePullCurrentLayer = make(map[string]string)                  // 记录当前下载的层级
-	imagePullLayers       = make(map[string]map[string]*LayerStatus) // 记录所有层级的状态
-	imagePullMutex        sync.RWMutex
-)
-
-// PullImageWithProgress 拉取Docker镜像并显示进度
+// PullImageWithProgress 拉取Docker镜像并等待完成（镜像已在拉取时等待正在进行的任务）
 // imageName: 镜像名称
 // 返回: 错误信息
 func (dm *DockerManager) PullImageWithProgress(imageName string) error {
-	utils.Info("开始拉取Docker镜像", zap.String("image", imageName))
-
-	// 设置拉取状态
-	imagePullMutex.Lock()
-	imagePullStatus[imageName] = true
-	imagePullCurrentLayer[imageName] = ""
-	imagePullLayers[imageName] = make(map[string]*LayerStatus)
-	imagePullMutex.Unlock()
-
-	// 确保在函数结束时清理状态
-	defer func() {
-		imagePullMutex.Lock()
-		imagePullStatus[imageName] = false
-		delete(imagePullCurrentLayer, imageName)
-		delete(imagePullLayers, imageName)
-		imagePullMutex.Unlock()
-	}()
-
-	// 拉取镜像
-	reader, err := dm.client.ImagePull(dm.ctx, imageName, image.PullOptions{})
+	job, err := dm.StartPull(imageName)
+	if err != nil {
+		return err
+	}
+	<-job.Done()
+	return job.Err()
+}
+
+// runPull 执行拉取任务：依次尝试加速地址和原仓库
+func (dm *DockerManager) runPull(ctx context.Context, job *PullJob) {
+	imageName := job.image
+
+	var pullErr error
+	for _, ref := range pullReferences(imageName, config.RegistryMirrors) {
+		if pullErr = dm.pullImage(ctx, job, ref); pullErr != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if ref != imageName {
+				utils.Warn("从加速地址拉取镜像失败，尝试下一个地址", zap.String("mirror", ref), zap.Error(pullErr))
+			}
+			continue
+		}
+
+		// 从加速地址拉取的镜像标记为原镜像名称，容器始终使用原镜像名称
+		if ref != imageName {
+			if err := dm.client.ImageTag(dm.ctx, ref, imageName); err != nil {
+				job.finish(fmt.Errorf("标记镜像 %s 失败: %v", imageName, err), false)
+				return
+			}
+			if _, err := dm.client.ImageRemove(dm.ctx, ref, image.RemoveOptions{}); err != nil {
+				utils.Warn("删除加速地址的镜像名称失败", zap.String("mirror", ref), zap.Error(err))
+			}
+		}
+
+		utils.Info("Docker镜像拉取成功", zap.String("image", imageName), zap.String("from", ref), zap.String("job_id", job.id))
+		job.finish(nil, false)
+		return
+	}
+
+	canceled := ctx.Err() != nil
+	if !canceled {
+		utils.Error("拉取Docker镜像失败", zap.String("image", imageName), zap.String("job_id", job.id), zap.Error(pullErr))
+	}
+	job.finish(pullErr, canceled)
+}
+
+// pullImage 从指定地址拉取镜像并更新任务进度（使用登记的仓库凭据）
+// ref: 拉取的镜像引用
+func (dm *DockerManager) pullImage(ctx context.Context, job *PullJob, ref string) error {
+	job.startAttempt(ref)
+
+	auth, err := registryAuth(ref)
+	if err != nil {
+		return err
+	}
+	reader, err := dm.client.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: auth})
 	if err != nil {
 		return fmt.Errorf("拉取Docker镜像失败: %v", err)
 	}
 	defer reader.Close()
 
-	// 读取并显示拉取进度
-	buffer := make([]byte, 1024)
+	// 进度是连续的JSON对象流
+	decoder := json.NewDecoder(reader)
 	for {
-		n, err := reader.Read(buffer)
-		if n > 0 {
-			// 解析JSON进度信息
-			progress := string(buffer[:n])
-			lines := strings.Split(progress, "\n")
-
-			for _, line := range lines {
-				if line == "" {
-					continue
-				}
-
-				var progressInfo ImagePullProgress
-				if err := json.Unmarshal([]byte(line), &progressInfo); err == nil {
-					// 更新进度信息
-					imagePullMutex.Lock()
-
-					// 处理层级信息
-					layerID := progressInfo.ID
-					if layerID != "" {
-						imagePullCurrentLayer[imageName] = layerID
-
-						// 确保层级存在
-						if imagePullLayers[imageName][layerID] == nil {
-							imagePullLayers[imageName][layerID] = &LayerStatus{
-								ID:       layerID,
-								Size:     0,
-								Progress: 0,
-								Status:   "pending",
-							}
-						}
-
-						// 更新层级大小信息
-						if progressInfo.ProgressDetail != nil {
-							if progressInfo.ProgressDetail.Total > 0 {
-								imagePullLayers[imageName][layerID].Size = progressInfo.ProgressDetail.Total
-							}
-							if progressInfo.ProgressDetail.Current > 0 {
-								imagePullLayers[imageName][layerID].Progress = progressInfo.ProgressDetail.Current
-							}
-						}
-
-						// 如果ProgressDetail中没有大小信息，尝试从Progress字符串中解析
-						if imagePullLayers[imageName][layerID].Size == 0 && progressInfo.Progress != "" {
-							if size := parseSizeFromProgress(progressInfo.Progress); size > 0 {
-								imagePullLayers[imageName][layerID].Size = size
-							}
-						}
-
-						// 更新层级状态
-						if strings.Contains(progressInfo.Status, "Downloading") {
-							imagePullLayers[imageName][layerID].Status = "downloading"
-						} else if strings.Contains(progressInfo.Status, "Extracting") {
-							imagePullLayers[imageName][layerID].Status = "extracting"
-						} else if strings.Contains(progressInfo.Status, "Verifying") {
-							imagePullLayers[imageName][layerID].Status = "verifying"
-						} else if strings.Contains(progressInfo.Status, "Pull complete") || strings.Contains(progressInfo.Status, "complete") {
-							imagePullLayers[imageName][layerID].Status = "complete"
-							// 完成时，如果没有大小信息，设置一个默认值
-							if imagePullLayers[imageName][layerID].Size == 0 {
-								imagePullLayers[imageName][layerID].Size = imagePullLayers[imageName][layerID].Progress
-								if imagePullLayers[imageName][layerID].Size == 0 {
-									imagePullLayers[imageName][layerID].Size = 1024 * 1024 // 默认1MB
-								}
-							}
-							imagePullLayers[imageName][layerID].Progress = imagePullLayers[imageName][layerID].Size
-						}
-					}
-
-					imagePullMutex.Unlock()
-
-					// 打印进度到控制台
-					if strings.Contains(progressInfo.Status, "Downloading") || strings.Contains(progressInfo.Status, "Extracting") {
-						// 进度显示静默（避免日志刷屏）
-					}
-				}
+		var progressInfo ImagePullProgress
+		if err := decoder.Decode(&progressInfo); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
 			return fmt.Errorf("读取镜像拉取进度失败: %v", err)
 		}
+		if progressInfo.Error != "" {
+			return fmt.Errorf("拉取Docker镜像失败: %s", progressInfo.Error)
+		}
+		job.applyProgress(progressInfo)
 	}
-
-	utils.Info("Docker镜像拉取成功", zap.String("image", imageName))
-
-	return nil
 }
 
 // ImageExists 检查Docker镜像是否存在
@@ -217,34 +178,38 @@ func (dm *DockerManager) GetImageStatus(imageName string) *ImageStatus {
 		return status
 	}
 
-	// 检查是否正在拉取中
-	imagePullMutex.RLock()
-	status.Pulling = imagePullStatus[imageName]
-	if status.Pulling {
-		status.CurrentLayer = imagePullCurrentLayer[imageName]
-
-		// 复制层级状态
-		if layers, exists := imagePullLayers[imageName]; exists {
-			for layerID, layerStatus := range layers {
-				status.Layers[layerID] = &LayerStatus{
-					ID:       layerStatus.ID,
-					Size:     layerStatus.Size,
-					Progress: layerStatus.Progress,
-					Status:   layerStatus.Status,
-				}
-			}
+	// 检查是否正在拉取中，未拉取时返回最近一次拉取的结果
+	key := dm.pullKey(imageName)
+	pullJobsMutex.Lock()
+	job := activePulls[key]
+	pullJobsMutex.Unlock()
+	if job != nil {
+		info := job.Info()
+		status.Pulling = true
+		status.JobID = info.ID
+		status.CurrentLayer = info.CurrentLayer
+		for i := range info.Layers {
+			status.Layers[info.Layers[i].ID] = &info.Layers[i]
 		}
+	} else if last := lastPull(key); last != nil {
+		info := last.Info()
+		status.LastPull = &info
 	}
-	imagePullMutex.RUnlock()
 
 	return status
 }
 
-// IsImagePulling 检查镜像是否正在拉取中
-func IsImagePulling(imageName string) bool {
-	imagePullMutex.RLock()
-	defer imagePullMutex.RUnlock()
-	return imagePullStatus[imageName]
+// IsImagePulling 检查镜像是否正在当前节点上拉取
+func (dm *DockerManager) IsImagePulling(imageName string) bool {
+	pullJobsMutex.Lock()
+	defer pullJobsMutex.Unlock()
+	_, ok := activePulls[dm.pullKey(imageName)]
+	return ok
+}
+
+// pullKey 镜像拉取状态的键（同一镜像可以在不同节点上同时拉取）
+func (dm *DockerManager) pullKey(imageName string) string {
+	return fmt.Sprintf("%d/%s", dm.nodeID, imageName)
 }
 
 // WaitForImage 等待镜像拉取完成（已废弃，请使用 GetImageStatus）
@@ -267,7 +232,7 @@ func (dm *DockerManager) WaitForImage(imageName string, timeout int) (bool, erro
 		}
 
 		// 检查是否正在拉取中
-		if IsImagePulling(imageName) {
+		if dm.IsImagePulling(imageName) {
 			utils.Debug("镜像正在拉取中，继续等待", zap.String("image", imageName))
 		}
 
@@ -395,6 +360,18 @@ func (dm *DockerManager) GetContainersByImage(imageName string) ([]ContainerInfo
 	return result, nil
 }
 
+// ContainerImageChanged 检查容器使用的镜像是否与服务器配置的镜像不同（不同时需要重建容器）
+func (dm *DockerManager) ContainerImageChanged(containerName string, server models.Server) (bool, error) {
+	containerInfo, err := dm.client.ContainerInspect(dm.ctx, containerName)
+	if err != nil {
+		return false, fmt.Errorf("获取Docker容器信息失败: %v", err)
+	}
+	if containerInfo.Config == nil {
+		return true, nil
+	}
+	return containerInfo.Config.Image != server.ImageName(), nil
+}
+
 // ContainerInfo 容器信息结构体
 type ContainerInfo struct {
 	ID     string `json:"id"`     // 容器ID
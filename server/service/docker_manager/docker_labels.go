@@ -0,0 +1,78 @@
+package docker_manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/utils"
+)
+
+// 面板创建的容器和卷的标签，用于识别所属服务器和面板实例（清理遗留资源时使用）
+const (
+	ManagerLabel  = "ase-server-commander.manager"   // 创建资源的面板实例（MANAGER_INSTANCE）
+	ServerIDLabel = "ase-server-commander.server-id" // 所属服务器ID
+	RoleLabel     = "ase-server-commander.role"      // 资源用途，见 Role* 常量
+)
+
+// 资源用途
+const (
+	RoleServer  = "server"  // 游戏服务器容器
+	RoleHelper  = "helper"  // 卷辅助容器
+	RoleBackup  = "backup"  // 备份卷时使用的临时容器
+	RoleProbe   = "probe"   // 获取磁盘空间时使用的临时容器
+	RoleSaved   = "saved"   // 游戏数据卷
+	RolePlugins = "plugins" // 插件卷
+)
+
+// resourceLabels 面板创建的资源的标签
+// serverID: 所属服务器ID，0 表示不属于某个服务器
+func resourceLabels(serverID uint, role string) map[string]string {
+	labels := map[string]string{
+		ManagerLabel: config.ManagerInstance,
+		RoleLabel:    role,
+	}
+	if serverID != 0 {
+		labels[ServerIDLabel] = strconv.FormatUint(uint64(serverID), 10)
+	}
+	return labels
+}
+
+// namePattern 面板创建的资源的命名规则
+type namePattern struct {
+	role string
+	name func(serverID uint) string
+}
+
+// 添加标签之前创建的资源只能按名称识别
+var (
+	containerNamePatterns = []namePattern{
+		{RoleServer, utils.GetServerContainerName},
+		{RoleHelper, utils.GetServerHelperContainerName},
+	}
+	volumeNamePatterns = []namePattern{
+		{RoleSaved, utils.GetServerVolumeName},
+		{RolePlugins, utils.GetServerPluginsVolumeName},
+	}
+)
+
+// identifyByName 根据名称识别面板创建的资源
+// 返回: 资源用途、所属服务器ID，不符合命名规则时 ok 为 false
+func identifyByName(patterns []namePattern, name string) (role string, serverID uint, ok bool) {
+	for _, pattern := range patterns {
+		// 名称格式为 前缀+服务器ID，用生成的名称反向校验，避免 ase-server-1x 之类的名称误匹配
+		prefix := strings.TrimSuffix(pattern.name(0), "0")
+		var id uint
+		if _, err := fmt.Sscanf(name, prefix+"%d", &id); err == nil && id != 0 && pattern.name(id) == name {
+			return pattern.role, id, true
+		}
+	}
+	return "", 0, false
+}
+
+// IdentifyVolume 根据名称识别服务器卷
+// 返回: 卷用途（RoleSaved 或 RolePlugins）、所属服务器ID，不是服务器卷时 ok 为 false
+func IdentifyVolume(name string) (role string, serverID uint, ok bool) {
+	return identifyByName(volumeNamePatterns, name)
+}
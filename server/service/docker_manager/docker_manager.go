@@ -17,10 +17,14 @@ import (
 	"go.uber.org/zap"
 )
 
-// DockerManager Docker管理器结构体
+// DockerManager Docker管理器结构体（每个节点一个）
 type DockerManager struct {
-	client *client.Client
-	ctx    context.Context
+	client  DockerClient
+	ctx     context.Context
+	nodeID  uint        // 所属节点，面板所在主机为 0
+	host    string      // 远程节点的连接地址，面板所在主机为空
+	closer  io.Closer   // 关闭时需要一起释放的连接（如 SSH 连接）
+	helpers *helperPool // 卷辅助容器
 }
 
 // 单例相关变量
@@ -29,7 +33,20 @@ var (
 	once     sync.Once
 )
 
-// GetDockerManager 获取Docker管理器单例实例
+// newDockerManager 创建Docker管理器
+func newDockerManager(cli DockerClient, nodeID uint, host string, closer io.Closer) *DockerManager {
+	return &DockerManager{
+		client:  cli,
+		ctx:     context.Background(),
+		nodeID:  nodeID,
+		host:    host,
+		closer:  closer,
+		helpers: newHelperPool(),
+	}
+}
+
+// GetDockerManager 获取面板所在主机的Docker管理器单例实例
+// 服务器相关的操作请使用 GetNodeManager 或 GetServerManager，以便路由到服务器所在的节点
 func GetDockerManager() (*DockerManager, error) {
 	var err error
 	once.Do(func() {
@@ -40,10 +57,7 @@ func GetDockerManager() (*DockerManager, error) {
 			return
 		}
 
-		instance = &DockerManager{
-			client: cli,
-			ctx:    context.Background(),
-		}
+		instance = newDockerManager(cli, 0, "", nil)
 	})
 
 	if err != nil {
@@ -53,8 +67,9 @@ func GetDockerManager() (*DockerManager, error) {
 	return instance, nil
 }
 
-// CloseDockerManager 关闭Docker管理器（通常在程序退出时调用）
+// CloseDockerManager 关闭所有节点的Docker管理器（通常在程序退出时调用）
 func CloseDockerManager() error {
+	closeNodeManagers()
 	if instance != nil && instance.client != nil {
 		err := instance.client.Close()
 		instance = nil
@@ -63,6 +78,22 @@ func CloseDockerManager() error {
 	return nil
 }
 
+// RemoteHost 远程节点的连接地址（玩家和RCON连接使用），面板所在主机返回空字符串
+func (dm *DockerManager) RemoteHost() string {
+	return dm.host
+}
+
+// close 关闭客户端和底层连接
+func (dm *DockerManager) close() error {
+	err := dm.client.Close()
+	if dm.closer != nil {
+		if closeErr := dm.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
 // CheckDockerStatus 检查Docker环境状态
 // 返回: Docker是否可用和错误信息
 func CheckDockerStatus() error {
@@ -81,11 +112,14 @@ func CheckDockerStatus() error {
 	return nil
 }
 
-// ValidateRequiredImages 验证必要的镜像是否存在（不自动下载）
-func (dm *DockerManager) ValidateRequiredImages() ([]string, error) {
-	requiredImages := []string{
-		"tbro98/ase-server:latest", // ARK服务器镜像
-		"alpine:latest",            // Alpine镜像（用于配置文件操作）
+// ValidateRequiredImages 验证启动服务器所需的镜像是否存在（不自动下载）
+// serverImages: 服务器使用的镜像，辅助容器使用的Alpine镜像总是需要
+func (dm *DockerManager) ValidateRequiredImages(serverImages ...string) ([]string, error) {
+	requiredImages := []string{HelperImage}
+	for _, imageName := range serverImages {
+		if imageName != HelperImage {
+			requiredImages = append(requiredImages, imageName)
+		}
 	}
 
 	var missingImages []string
@@ -137,7 +171,6 @@ func (dm *DockerManager) EnsureRequiredImages() error {
 func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port, queryPort, rconPort int, adminPassword, mapName, gameModIds string, autoRestart bool) (string, error) {
 	containerName := utils.GetServerContainerName(serverID)
 	volumeName := utils.GetServerVolumeName(serverID)
-	imageName := "tbro98/ase-server:latest"
 
 	// 检查容器是否已存在
 	if exists, err := dm.ContainerExists(containerName); err != nil {
@@ -149,7 +182,14 @@ func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port,
 		}
 	}
 
-	// 检查镜像是否存在
+	// 1. 查数据库获取Server对象
+	var server models.Server
+	if err := database.DB.Where("id = ?", serverID).First(&server).Error; err != nil {
+		return "", fmt.Errorf("获取服务器信息失败: %v", err)
+	}
+
+	// 检查服务器使用的镜像是否存在
+	imageName := server.ImageName()
 	exists, err := dm.ImageExists(imageName)
 	if err != nil {
 		return "", fmt.Errorf("检查镜像是否存在失败: %v", err)
@@ -158,12 +198,6 @@ func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port,
 		return "", fmt.Errorf("镜像 %s 不存在，请等待镜像下载完成", imageName)
 	}
 
-	// 1. 查数据库获取Server对象
-	var server models.Server
-	if err := database.DB.Where("id = ?", serverID).First(&server).Error; err != nil {
-		return "", fmt.Errorf("获取服务器信息失败: %v", err)
-	}
-
 	// 2. 反序列化ServerArgsJSON
 	serverArgs := models.NewServerArgs()
 	if server.ServerArgsJSON != "" && server.ServerArgsJSON != "{}" {
@@ -184,8 +218,9 @@ func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port,
 
 	// 构建容器配置
 	containerConfig := &container.Config{
-		Image: imageName,
-		Env:   envVars,
+		Image:  imageName,
+		Env:    envVars,
+		Labels: resourceLabels(serverID, RoleServer),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/udp", port)):      struct{}{},
 			nat.Port(fmt.Sprintf("%d/tcp", port)):      struct{}{},
@@ -198,17 +233,10 @@ func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port,
 		},
 	}
 
-	// 根据autoRestart设置重启策略
-	restartPolicyName := container.RestartPolicyMode("unless-stopped")
-	if !autoRestart {
-		restartPolicyName = container.RestartPolicyMode("no")
-	}
-
-	// 构建主机配置
+	// 构建主机配置（重启策略和资源限制）
 	hostConfig := &container.HostConfig{
-		RestartPolicy: container.RestartPolicy{
-			Name: restartPolicyName,
-		},
+		RestartPolicy: restartPolicy(autoRestart, server.Resources.RestartMaxRetries),
+		Resources:     containerResources(server.Resources),
 		PortBindings: nat.PortMap{
 			nat.Port(fmt.Sprintf("%d/udp", port)): {
 				{HostPort: fmt.Sprintf("%d", port)},
@@ -241,9 +269,12 @@ func (dm *DockerManager) CreateContainer(serverID uint, serverName string, port,
 		},
 	}
 
+	// 网络模式和端口绑定的IP
+	networkingConfig := applyNetwork(server.Network, hostConfig)
+
 	// 创建容器
 	utils.Infof("正在创建Docker容器: %s", containerName)
-	resp, err := dm.client.ContainerCreate(dm.ctx, containerConfig, hostConfig, nil, nil, containerName)
+	resp, err := dm.client.ContainerCreate(dm.ctx, containerConfig, hostConfig, networkingConfig, nil, containerName)
 	if err != nil {
 		return "", fmt.Errorf("创建Docker容器失败: %v", err)
 	}
@@ -423,3 +454,27 @@ func (dm *DockerManager) GetContainerEnvVars(containerName string) (map[string]s
 
 	return envVars, nil
 }
+
+// GetContainerIP 获取容器在Docker网络中的IP地址
+// containerName: 容器名称
+// 返回: IP地址（容器未连接网络时为空字符串）和错误信息
+func (dm *DockerManager) GetContainerIP(containerName string) (string, error) {
+	containerInfo, err := dm.client.ContainerInspect(dm.ctx, containerName)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", fmt.Errorf("容器不存在: %s", containerName)
+		}
+		return "", fmt.Errorf("获取Docker容器信息失败: %v", err)
+	}
+
+	if containerInfo.NetworkSettings == nil {
+		return "", nil
+	}
+	for _, network := range containerInfo.NetworkSettings.Networks {
+		if network != nil && network.IPAddress != "" {
+			return network.IPAddress, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,267 @@
+package docker_manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager/dockertest"
+	"ark-server-commander/utils"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+)
+
+var _ DockerClient = (*dockertest.FakeClient)(nil)
+
+// newFakeManager 创建使用模拟客户端的Docker管理器
+func newFakeManager(t *testing.T, images ...string) (*DockerManager, *dockertest.FakeClient) {
+	t.Helper()
+	fake := dockertest.NewFakeClient(images...)
+	dm := newDockerManager(fake, 0, "", nil)
+	t.Cleanup(dm.removeAllHelpers)
+	return dm, fake
+}
+
+// setupServer 创建内存数据库并写入服务器记录
+func setupServer(t *testing.T, server models.Server) models.Server {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	db := dbtest.Open(t)
+
+	if err := db.Create(&server).Error; err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	return server
+}
+
+func TestVolumeSessionFileOperations(t *testing.T) {
+	dm, fake := newFakeManager(t, HelperImage)
+
+	content := "[ServerSettings]\nServerPassword=a\"b$c\n"
+	if err := dm.WriteConfigFile(1, utils.GameUserSettingsFileName, content); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	if data, ok := fake.ReadVolumeFile(utils.GetServerVolumeName(1), utils.ConfigDirectory+"/"+utils.GameUserSettingsFileName); !ok || string(data) != content {
+		t.Fatalf("配置文件应原样写入 Saved 卷: %q", data)
+	}
+	if read, err := dm.ReadConfigFile(1, utils.GameUserSettingsFileName); err != nil || read != content {
+		t.Fatalf("读取配置文件错误: %q, %v", read, err)
+	}
+	if _, err := dm.ReadSavedFile(1, "Logs/missing.log"); !errdefs.IsNotFound(err) {
+		t.Fatalf("读取不存在的文件应返回 NotFound: %v", err)
+	}
+
+	err := dm.WithVolumes(1, func(session *VolumeSession) error {
+		for _, dir := range []string{"SavedArks/TheIsland", "Logs"} {
+			if _, err := session.Exec("mkdir", "-p", HelperSavedMount+"/"+dir); err != nil {
+				return err
+			}
+		}
+		dirs, err := session.ListDirs(HelperSavedMount)
+		if err != nil {
+			return err
+		}
+		if strings.Join(dirs, ",") != "Config,Logs,SavedArks" {
+			return fmt.Errorf("子目录列表错误: %v", dirs)
+		}
+
+		if err := session.WriteStream(HelperPluginsMount+"/Permissions/config.json", 2, strings.NewReader("{}")); err != nil {
+			return err
+		}
+		return session.OpenFile(HelperPluginsMount+"/Permissions/config.json", func(size int64, reader io.Reader) error {
+			data, _ := io.ReadAll(reader)
+			if size != 2 || string(data) != "{}" {
+				return fmt.Errorf("读取插件文件错误: %d %q", size, data)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 多次操作复用同一个辅助容器
+	if count := fake.CallCount("ContainerCreate"); count != 1 {
+		t.Fatalf("辅助容器应只创建一次，实际 %d 次", count)
+	}
+	err = dm.WithVolumes(1, func(session *VolumeSession) error {
+		_, err := session.Exec("false")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "退出码: 127") {
+		t.Fatalf("命令执行失败应返回退出码: %v", err)
+	}
+}
+
+func TestHelperRecreatedAfterRemoval(t *testing.T) {
+	dm, fake := newFakeManager(t, HelperImage)
+
+	if err := dm.WriteConfigFile(2, utils.GameIniFileName, "[a]\n"); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	// 辅助容器被外部删除后自动重新创建
+	fake.ContainerRemove(dm.ctx, utils.GetServerHelperContainerName(2), container.RemoveOptions{Force: true})
+	if _, err := dm.ReadConfigFile(2, utils.GameIniFileName); err != nil {
+		t.Fatalf("辅助容器被删除后应重新创建: %v", err)
+	}
+
+	dm.RemoveHelper(2)
+	if names := fake.ContainerNames(); len(names) != 0 {
+		t.Fatalf("删除后不应遗留辅助容器: %v", names)
+	}
+}
+
+func TestRemoveHelperDoesNotBlockOtherServers(t *testing.T) {
+	dm, fake := newFakeManager(t, HelperImage)
+	for _, id := range []uint{1, 2} {
+		if err := dm.WriteConfigFile(id, utils.GameIniFileName, "[a]\n"); err != nil {
+			t.Fatalf("写入配置文件失败: %v", err)
+		}
+	}
+
+	// 删除服务器 1 的辅助容器时Docker响应很慢
+	removing, release := make(chan struct{}), make(chan struct{})
+	fake.BeforeRemove = func(containerID string) {
+		close(removing)
+		<-release
+	}
+	done := make(chan struct{})
+	go func() {
+		dm.RemoveHelper(1)
+		close(done)
+	}()
+	<-removing
+
+	// 其他服务器的辅助容器不受影响
+	read := make(chan error, 1)
+	go func() {
+		_, err := dm.ReadConfigFile(2, utils.GameIniFileName)
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatalf("读取配置文件失败: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("删除辅助容器时不应阻塞其他服务器")
+	}
+
+	fake.BeforeRemove = nil
+	close(release)
+	<-done
+	if names := fake.ContainerNames(); len(names) != 1 || names[0] != utils.GetServerHelperContainerName(2) {
+		t.Fatalf("应只删除服务器 1 的辅助容器: %v", names)
+	}
+}
+
+func TestHelperRequiresAlpineImage(t *testing.T) {
+	dm, _ := newFakeManager(t)
+
+	err := dm.WriteConfigFile(1, utils.GameIniFileName, "")
+	if err == nil || !strings.Contains(err.Error(), "Alpine镜像不存在") {
+		t.Fatalf("缺少Alpine镜像时应返回错误: %v", err)
+	}
+}
+
+func TestRemoveVolumeRemovesPluginsVolumeAndHelper(t *testing.T) {
+	dm, fake := newFakeManager(t, HelperImage)
+
+	volumeName, err := dm.CreateVolume(3)
+	if err != nil {
+		t.Fatalf("创建卷失败: %v", err)
+	}
+	if names := fake.VolumeNames(); strings.Join(names, ",") != "ase-server-3,ase-server-plugins-3" {
+		t.Fatalf("应创建数据卷和插件卷: %v", names)
+	}
+	if err := dm.WriteConfigFile(3, utils.GameIniFileName, "[a]\n"); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	// 辅助容器挂载着卷，删除卷前需要先删除辅助容器
+	if err := dm.RemoveVolume(volumeName); err != nil {
+		t.Fatalf("删除卷失败: %v", err)
+	}
+	if names := fake.VolumeNames(); len(names) != 0 {
+		t.Fatalf("数据卷和插件卷都应被删除: %v", names)
+	}
+	if names := fake.ContainerNames(); len(names) != 0 {
+		t.Fatalf("辅助容器应被删除: %v", names)
+	}
+}
+
+func TestCreateVolumeCleansUpOnFailure(t *testing.T) {
+	dm, fake := newFakeManager(t)
+	fake.FailOn("VolumeCreate", utils.GetServerPluginsVolumeName(4), errors.New("no space left on device"))
+
+	if _, err := dm.CreateVolume(4); err == nil || !strings.Contains(err.Error(), "创建插件卷失败") {
+		t.Fatalf("插件卷创建失败时应返回错误: %v", err)
+	}
+	if names := fake.VolumeNames(); len(names) != 0 {
+		t.Fatalf("已创建的数据卷应被清理: %v", names)
+	}
+}
+
+func TestPluginsVolumeFor(t *testing.T) {
+	if name, ok := pluginsVolumeFor("ase-server-12"); !ok || name != "ase-server-plugins-12" {
+		t.Fatalf("插件卷名称错误: %s, %v", name, ok)
+	}
+	for _, name := range []string{"ase-server-plugins-12", "ase-server-12x", "other"} {
+		if _, ok := pluginsVolumeFor(name); ok {
+			t.Errorf("%s 不是服务器数据卷", name)
+		}
+	}
+}
+
+func TestCreateContainerAppliesServerConfig(t *testing.T) {
+	server := setupServer(t, models.Server{
+		Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, Map: "TheIsland", MaxPlayers: 70,
+		GameModIds: "111,222", AutoRestart: true, ServerArgsJSON: "{}",
+		Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 2, RestartMaxRetries: 3},
+	})
+	dm, fake := newFakeManager(t)
+	containerName := utils.GetServerContainerName(server.ID)
+
+	if _, err := dm.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart); err == nil || !strings.Contains(err.Error(), "不存在") {
+		t.Fatalf("镜像不存在时应返回错误: %v", err)
+	}
+	fake.AddImage("tbro98/ase-server:latest")
+
+	create := map[string]func() (string, error){
+		"CreateContainer": func() (string, error) {
+			return dm.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart)
+		},
+		"CreateContainerWithRollback": func() (string, error) {
+			return dm.CreateContainerWithRollback(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart)
+		},
+	}
+	for name, fn := range create {
+		if _, err := fn(); err != nil {
+			t.Fatalf("%s: 创建容器失败: %v", name, err)
+		}
+
+		envVars, err := dm.GetContainerEnvVars(containerName)
+		if err != nil || envVars["GameModIds"] != "111,222" || !strings.Contains(envVars["SERVER_ARGS"], "TheIsland") {
+			t.Fatalf("%s: 环境变量错误: %v, %v", name, envVars, err)
+		}
+		if changed, err := dm.ContainerResourcesChanged(containerName, server); err != nil || changed {
+			t.Fatalf("%s: 容器应使用服务器的资源限制: %v, %v", name, changed, err)
+		}
+		if status, _ := dm.GetContainerStatus(containerName); status != "stopped" {
+			t.Fatalf("%s: 新建的容器应处于停止状态: %s", name, status)
+		}
+	}
+
+	// 再次创建时替换已存在的容器
+	if names := fake.ContainerNames(); len(names) != 1 || names[0] != containerName {
+		t.Fatalf("应只保留一个服务器容器: %v", names)
+	}
+}
@@ -0,0 +1,199 @@
+package docker_manager
+
+import (
+	"fmt"
+	"net"
+
+	"ark-server-commander/models"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// builtinNetworks Docker 内置的网络，需要通过对应的网络模式使用
+var builtinNetworks = map[string]bool{"bridge": true, "host": true, "none": true, "default": true}
+
+// ValidateNetwork 校验网络配置，并检查节点上是否存在指定的 Docker 网络
+func (dm *DockerManager) ValidateNetwork(serverNetwork models.ServerNetwork) error {
+	if err := checkNetwork(serverNetwork); err != nil {
+		return err
+	}
+	if serverNetwork.NetworkName == "" {
+		return nil
+	}
+
+	info, err := dm.client.NetworkInspect(dm.ctx, serverNetwork.NetworkName, network.InspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return fmt.Errorf("网络配置错误: Docker 网络 %s 不存在", serverNetwork.NetworkName)
+		}
+		return fmt.Errorf("检查Docker网络失败: %v", err)
+	}
+	return checkNetworkDriver(serverNetwork, info)
+}
+
+// checkNetwork 校验网络模式、网络名称和IP
+func checkNetwork(serverNetwork models.ServerNetwork) error {
+	mode := serverNetwork.Mode()
+	switch mode {
+	case models.NetworkModeBridge, models.NetworkModeHost:
+		if serverNetwork.NetworkName != "" {
+			return fmt.Errorf("网络配置错误: %s 模式不能指定 Docker 网络", mode)
+		}
+	case models.NetworkModeNetwork, models.NetworkModeMacvlan:
+		if serverNetwork.NetworkName == "" {
+			return fmt.Errorf("网络配置错误: %s 模式需要指定 Docker 网络", mode)
+		}
+		if builtinNetworks[serverNetwork.NetworkName] {
+			return fmt.Errorf("网络配置错误: %s 是 Docker 内置网络，请选择对应的网络模式", serverNetwork.NetworkName)
+		}
+	default:
+		return fmt.Errorf("网络配置错误: 不支持的网络模式 %q", serverNetwork.NetworkMode)
+	}
+
+	if serverNetwork.BindIP != "" {
+		ip := net.ParseIP(serverNetwork.BindIP)
+		if ip == nil {
+			return fmt.Errorf("网络配置错误: 无效的IP地址 %q", serverNetwork.BindIP)
+		}
+		if ip.IsUnspecified() {
+			return fmt.Errorf("网络配置错误: 绑定所有地址时请不要指定IP")
+		}
+	}
+	return nil
+}
+
+// checkNetworkDriver 检查 Docker 网络的驱动是否与网络模式一致，macvlan 模式下检查IP是否在网络的子网内
+func checkNetworkDriver(serverNetwork models.ServerNetwork, info network.Inspect) error {
+	isMacvlan := info.Driver == "macvlan"
+	switch serverNetwork.Mode() {
+	case models.NetworkModeNetwork:
+		if isMacvlan {
+			return fmt.Errorf("网络配置错误: 网络 %s 是 macvlan 网络，请使用 macvlan 模式", info.Name)
+		}
+	case models.NetworkModeMacvlan:
+		if !isMacvlan {
+			return fmt.Errorf("网络配置错误: 网络 %s 不是 macvlan 网络（驱动为 %s）", info.Name, info.Driver)
+		}
+		if serverNetwork.BindIP == "" || len(info.IPAM.Config) == 0 {
+			return nil
+		}
+		ip := net.ParseIP(serverNetwork.BindIP)
+		for _, config := range info.IPAM.Config {
+			if _, subnet, err := net.ParseCIDR(config.Subnet); err == nil && subnet.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("网络配置错误: IP %s 不在网络 %s 的子网内", serverNetwork.BindIP, info.Name)
+	}
+	return nil
+}
+
+// applyNetwork 将网络配置应用到容器的主机配置
+// 端口映射模式下将端口绑定到指定IP；host 和 macvlan 模式下不映射端口
+// 返回: 创建容器时使用的网络配置（macvlan 模式下指定容器IP）
+func applyNetwork(serverNetwork models.ServerNetwork, hostConfig *container.HostConfig) *network.NetworkingConfig {
+	hostConfig.NetworkMode = container.NetworkMode(networkModeName(serverNetwork))
+
+	switch serverNetwork.Mode() {
+	case models.NetworkModeHost:
+		hostConfig.PortBindings = nil
+	case models.NetworkModeMacvlan:
+		hostConfig.PortBindings = nil
+		if serverNetwork.BindIP != "" {
+			return &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+				serverNetwork.NetworkName: {IPAMConfig: endpointIPAMConfig(serverNetwork.BindIP)},
+			}}
+		}
+	default:
+		for port, bindings := range hostConfig.PortBindings {
+			for i := range bindings {
+				bindings[i].HostIP = serverNetwork.BindIP
+			}
+			hostConfig.PortBindings[port] = bindings
+		}
+	}
+	return nil
+}
+
+// networkModeName 容器的 NetworkMode（bridge、host 或 Docker 网络名称）
+func networkModeName(serverNetwork models.ServerNetwork) string {
+	switch serverNetwork.Mode() {
+	case models.NetworkModeNetwork, models.NetworkModeMacvlan:
+		return serverNetwork.NetworkName
+	}
+	return serverNetwork.Mode()
+}
+
+// endpointIPAMConfig 按IP类型设置容器的固定IP
+func endpointIPAMConfig(ip string) *network.EndpointIPAMConfig {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return &network.EndpointIPAMConfig{IPv6Address: ip}
+	}
+	return &network.EndpointIPAMConfig{IPv4Address: ip}
+}
+
+// ContainerNetworkChanged 检查容器的网络模式、端口绑定的IP和容器IP是否与服务器配置不同（不同时需要重建容器）
+func (dm *DockerManager) ContainerNetworkChanged(containerName string, server models.Server) (bool, error) {
+	containerInfo, err := dm.client.ContainerInspect(dm.ctx, containerName)
+	if err != nil {
+		return false, fmt.Errorf("获取Docker容器信息失败: %v", err)
+	}
+	if containerInfo.ContainerJSONBase == nil || containerInfo.HostConfig == nil {
+		return true, nil
+	}
+	return !networkMatch(containerInfo, server.Network), nil
+}
+
+// networkMatch 比较容器的网络配置与服务器的网络配置
+func networkMatch(containerInfo container.InspectResponse, serverNetwork models.ServerNetwork) bool {
+	hostConfig := containerInfo.HostConfig
+	// 未指定网络模式创建的容器使用默认的桥接网络
+	actualMode := string(hostConfig.NetworkMode)
+	if actualMode == "" || actualMode == "default" {
+		actualMode = models.NetworkModeBridge
+	}
+	if actualMode != networkModeName(serverNetwork) {
+		return false
+	}
+
+	switch serverNetwork.Mode() {
+	case models.NetworkModeHost, models.NetworkModeMacvlan:
+		if len(hostConfig.PortBindings) > 0 {
+			return false
+		}
+	default:
+		if len(hostConfig.PortBindings) == 0 {
+			return false
+		}
+		for _, bindings := range hostConfig.PortBindings {
+			for _, binding := range bindings {
+				if normalizeHostIP(binding.HostIP) != serverNetwork.BindIP {
+					return false
+				}
+			}
+		}
+	}
+
+	if serverNetwork.Mode() == models.NetworkModeMacvlan && serverNetwork.BindIP != "" {
+		if containerInfo.NetworkSettings == nil {
+			return false
+		}
+		endpoint := containerInfo.NetworkSettings.Networks[serverNetwork.NetworkName]
+		expected := endpointIPAMConfig(serverNetwork.BindIP)
+		if endpoint == nil || endpoint.IPAMConfig == nil ||
+			endpoint.IPAMConfig.IPv4Address != expected.IPv4Address || endpoint.IPAMConfig.IPv6Address != expected.IPv6Address {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeHostIP 端口绑定的主机IP（绑定所有地址时为空）
+func normalizeHostIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsUnspecified() {
+		return ""
+	}
+	return ip
+}
@@ -0,0 +1,151 @@
+package docker_manager
+
+import (
+	"strings"
+	"testing"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	dm, fake := newFakeManager(t)
+	fake.AddNetwork("games", "bridge", "172.30.0.0/16")
+	fake.AddNetwork("lan", "macvlan", "192.168.1.0/24")
+
+	cases := []struct {
+		network models.ServerNetwork
+		err     string
+	}{
+		{models.ServerNetwork{}, ""},
+		{models.ServerNetwork{BindIP: "203.0.113.10"}, ""},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeHost, BindIP: "203.0.113.10"}, ""},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeNetwork, NetworkName: "games"}, ""},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan", BindIP: "192.168.1.50"}, ""},
+		{models.ServerNetwork{NetworkMode: "overlay"}, "不支持的网络模式"},
+		{models.ServerNetwork{BindIP: "not-an-ip"}, "无效的IP地址"},
+		{models.ServerNetwork{BindIP: "0.0.0.0"}, "绑定所有地址时请不要指定IP"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeHost, NetworkName: "games"}, "host 模式不能指定 Docker 网络"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeNetwork}, "network 模式需要指定 Docker 网络"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeNetwork, NetworkName: "host"}, "Docker 内置网络"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeNetwork, NetworkName: "missing"}, "Docker 网络 missing 不存在"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeNetwork, NetworkName: "lan"}, "请使用 macvlan 模式"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "games"}, "不是 macvlan 网络"},
+		{models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan", BindIP: "10.0.0.5"}, "不在网络 lan 的子网内"},
+	}
+	for _, item := range cases {
+		err := dm.ValidateNetwork(item.network)
+		if item.err == "" {
+			if err != nil {
+				t.Errorf("%+v: 不应返回错误: %v", item.network, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "网络配置错误") || !strings.Contains(err.Error(), item.err) {
+			t.Errorf("%+v: 期望错误包含 %q，实际为 %v", item.network, item.err, err)
+		}
+	}
+}
+
+func TestCreateContainerAppliesNetwork(t *testing.T) {
+	server := setupServer(t, models.Server{
+		Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, Map: "TheIsland", MaxPlayers: 70,
+		ServerArgsJSON: `{"query_params":{"MultiHome":"10.0.0.1"},"command_line_args":{"MULTIHOME":true}}`,
+		Network:        models.ServerNetwork{BindIP: "203.0.113.10"},
+	})
+	dm, fake := newFakeManager(t, "tbro98/ase-server:latest")
+	fake.AddNetwork("lan", "macvlan", "192.168.1.0/24")
+	containerName := utils.GetServerContainerName(server.ID)
+
+	create := func() {
+		t.Helper()
+		if err := database.DB.Model(&server).Select("network_mode", "network_name", "bind_ip").Updates(&server).Error; err != nil {
+			t.Fatalf("更新服务器失败: %v", err)
+		}
+		if _, err := dm.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart); err != nil {
+			t.Fatalf("创建容器失败: %v", err)
+		}
+		if changed, err := dm.ContainerNetworkChanged(containerName, server); err != nil || changed {
+			t.Fatalf("容器应使用服务器的网络配置: %v, %v", changed, err)
+		}
+	}
+
+	// 端口映射模式：端口绑定到指定IP，容器内没有该IP，使用启动参数中的 MultiHome
+	create()
+	info, _ := fake.ContainerInspect(dm.ctx, containerName)
+	if info.HostConfig.NetworkMode != "bridge" || len(info.HostConfig.PortBindings) != 8 {
+		t.Fatalf("应使用桥接网络并映射端口: %+v", info.HostConfig)
+	}
+	for port, bindings := range info.HostConfig.PortBindings {
+		if len(bindings) != 1 || bindings[0].HostIP != "203.0.113.10" {
+			t.Fatalf("端口 %s 应绑定到指定IP: %+v", port, bindings)
+		}
+	}
+	envVars, _ := dm.GetContainerEnvVars(containerName)
+	if !strings.Contains(envVars["SERVER_ARGS"], "?MultiHome=10.0.0.1") {
+		t.Fatalf("端口映射模式下应保留启动参数中的 MultiHome: %s", envVars["SERVER_ARGS"])
+	}
+
+	// 修改绑定IP或网络模式后需要重建容器
+	for _, network := range []models.ServerNetwork{
+		{},
+		{BindIP: "203.0.113.11"},
+		{NetworkMode: models.NetworkModeHost},
+		{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan"},
+	} {
+		if changed, err := dm.ContainerNetworkChanged(containerName, models.Server{Network: network}); err != nil || !changed {
+			t.Errorf("网络配置 %+v 与容器不同，应需要重建: %v, %v", network, changed, err)
+		}
+	}
+
+	// host 模式：不映射端口，由面板生成 MultiHome 参数
+	server.Network = models.ServerNetwork{NetworkMode: models.NetworkModeHost, BindIP: "203.0.113.10"}
+	create()
+	info, _ = fake.ContainerInspect(dm.ctx, containerName)
+	if info.HostConfig.NetworkMode != "host" || len(info.HostConfig.PortBindings) != 0 {
+		t.Fatalf("host 模式不应映射端口: %+v", info.HostConfig)
+	}
+	envVars, _ = dm.GetContainerEnvVars(containerName)
+	args := envVars["SERVER_ARGS"]
+	if !strings.Contains(args, "?MultiHome=203.0.113.10") || strings.Contains(args, "10.0.0.1") || strings.Count(args, "-MULTIHOME") != 1 {
+		t.Fatalf("host 模式应生成 MultiHome 参数并替换启动参数中的同名参数: %s", args)
+	}
+
+	// macvlan 模式：容器使用指定的IP
+	server.Network = models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan", BindIP: "192.168.1.50"}
+	create()
+	info, _ = fake.ContainerInspect(dm.ctx, containerName)
+	endpoint := info.NetworkSettings.Networks["lan"]
+	if info.HostConfig.NetworkMode != "lan" || len(info.HostConfig.PortBindings) != 0 ||
+		endpoint == nil || endpoint.IPAMConfig == nil || endpoint.IPAMConfig.IPv4Address != "192.168.1.50" {
+		t.Fatalf("macvlan 模式应使用指定的容器IP: %+v %+v", info.HostConfig, info.NetworkSettings)
+	}
+	if changed, _ := dm.ContainerNetworkChanged(containerName, models.Server{Network: models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan", BindIP: "192.168.1.51"}}); !changed {
+		t.Fatal("修改容器IP后应需要重建")
+	}
+}
+
+func TestNetworkMatchLegacyContainer(t *testing.T) {
+	server := setupServer(t, models.Server{Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, ServerArgsJSON: "{}"})
+	dm, fake := newFakeManager(t, "tbro98/ase-server:latest")
+	containerName := utils.GetServerContainerName(server.ID)
+	if _, err := dm.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart); err != nil {
+		t.Fatalf("创建容器失败: %v", err)
+	}
+
+	// 未指定网络模式创建的容器（Docker 报告为 default 或空）、端口绑定到 0.0.0.0 时与默认配置一致
+	info, _ := fake.ContainerInspect(dm.ctx, containerName)
+	for _, mode := range []string{"", "default"} {
+		info.HostConfig.NetworkMode = container.NetworkMode(mode)
+		for port, bindings := range info.HostConfig.PortBindings {
+			bindings[0].HostIP = "0.0.0.0"
+			info.HostConfig.PortBindings[port] = bindings
+		}
+		if !networkMatch(info, models.ServerNetwork{}) {
+			t.Errorf("NetworkMode=%q 的容器应与默认网络配置一致", mode)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package docker_manager
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/utils"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/volume"
+	"go.uber.org/zap"
+)
+
+// 资源类型
+const (
+	ResourceContainer = "container"
+	ResourceVolume    = "volume"
+)
+
+// backupMount 备份卷时临时容器中的挂载路径
+const backupMount = "/backup"
+
+// ManagedResource 面板创建的容器或卷
+type ManagedResource struct {
+	Kind      string    // container 或 volume
+	Name      string    // 容器或卷名称
+	ID        string    // 容器ID（卷为空）
+	Role      string    // 资源用途，见 Role* 常量
+	ServerID  uint      // 所属服务器ID，0 表示无法识别
+	Running   bool      // 容器是否运行中
+	CreatedAt time.Time // 创建时间（无法获取时为零值）
+	Legacy    bool      // 没有面板标签，按名称识别（添加标签之前创建的资源）
+}
+
+// ListManagedResources 列出节点上由当前面板实例创建的容器和卷（容器在前）
+// 带有其他面板实例标签的资源不会返回；没有标签的资源按名称识别
+func (dm *DockerManager) ListManagedResources() ([]ManagedResource, error) {
+	containers, err := dm.client.ContainerList(dm.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("获取容器列表失败: %v", err)
+	}
+	volumes, err := dm.client.VolumeList(dm.ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取卷列表失败: %v", err)
+	}
+
+	var resources []ManagedResource
+	for _, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		resource, ok := identifyResource(ResourceContainer, name, c.Labels)
+		if !ok {
+			continue
+		}
+		resource.ID = c.ID
+		resource.Running = c.State == container.StateRunning
+		resource.CreatedAt = time.Unix(c.Created, 0)
+		resources = append(resources, resource)
+	}
+	for _, v := range volumes.Volumes {
+		if v == nil {
+			continue
+		}
+		resource, ok := identifyResource(ResourceVolume, v.Name, v.Labels)
+		if !ok {
+			continue
+		}
+		if createdAt, err := time.Parse(time.RFC3339, v.CreatedAt); err == nil {
+			resource.CreatedAt = createdAt
+		}
+		resources = append(resources, resource)
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind == ResourceContainer
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// identifyResource 根据标签（没有标签时根据名称）识别面板创建的资源
+func identifyResource(kind, name string, labels map[string]string) (ManagedResource, bool) {
+	resource := ManagedResource{Kind: kind, Name: name}
+
+	if manager, ok := labels[ManagerLabel]; ok {
+		if manager != config.ManagerInstance {
+			return resource, false
+		}
+		resource.Role = labels[RoleLabel]
+		if serverID, err := strconv.ParseUint(labels[ServerIDLabel], 10, 32); err == nil {
+			resource.ServerID = uint(serverID)
+		}
+		return resource, true
+	}
+
+	patterns := volumeNamePatterns
+	if kind == ResourceContainer {
+		patterns = containerNamePatterns
+	}
+	role, serverID, ok := identifyByName(patterns, name)
+	if !ok {
+		return resource, false
+	}
+	resource.Role, resource.ServerID, resource.Legacy = role, serverID, true
+	return resource, true
+}
+
+// RemoveManagedResource 删除面板创建的容器或卷
+// 辅助容器同时从辅助容器池中移除；卷被其他容器使用时返回错误
+func (dm *DockerManager) RemoveManagedResource(resource ManagedResource) error {
+	if resource.Kind == ResourceVolume {
+		if err := dm.client.VolumeRemove(dm.ctx, resource.Name, false); err != nil && !errdefs.IsNotFound(err) {
+			return fmt.Errorf("删除Docker卷失败: %v", err)
+		}
+		utils.Info("遗留Docker卷已删除", zap.String("volume", resource.Name), zap.Uint("node_id", dm.nodeID))
+		return nil
+	}
+
+	if resource.Role == RoleHelper && resource.ServerID != 0 {
+		dm.helpers.mu.Lock()
+		delete(dm.helpers.helpers, resource.ServerID)
+		dm.helpers.mu.Unlock()
+	}
+	ref := resource.ID
+	if ref == "" {
+		ref = resource.Name
+	}
+	if err := dm.client.ContainerRemove(dm.ctx, ref, container.RemoveOptions{Force: true}); err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("删除Docker容器失败: %v", err)
+	}
+	utils.Info("遗留Docker容器已删除", zap.String("container", resource.Name), zap.Uint("node_id", dm.nodeID))
+	return nil
+}
+
+// BackupVolume 将卷的全部内容以 tar.gz 格式写入 w
+// 使用挂载该卷（只读）的临时容器读取，读取完成后删除临时容器
+func (dm *DockerManager) BackupVolume(volumeName string, w io.Writer) error {
+	exists, err := dm.ImageExists(HelperImage)
+	if err != nil {
+		return fmt.Errorf("检查Alpine镜像失败: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("Alpine镜像不存在，无法备份卷")
+	}
+
+	resp, err := dm.client.ContainerCreate(dm.ctx, &container.Config{
+		Image:  HelperImage,
+		Cmd:    []string{"true"},
+		Labels: resourceLabels(0, RoleBackup),
+	}, &container.HostConfig{
+		Binds: []string{fmt.Sprintf("%s:%s:ro", volumeName, backupMount)},
+	}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("创建备份容器失败: %v", err)
+	}
+	defer func() {
+		if err := dm.client.ContainerRemove(dm.ctx, resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			utils.Warn("删除备份容器失败", zap.String("container_id", resp.ID), zap.Error(err))
+		}
+	}()
+
+	reader, _, err := dm.client.CopyFromContainer(dm.ctx, resp.ID, backupMount)
+	if err != nil {
+		return fmt.Errorf("读取卷内容失败: %v", err)
+	}
+	defer reader.Close()
+
+	gzipWriter := gzip.NewWriter(w)
+	if _, err := io.Copy(gzipWriter, reader); err != nil {
+		return fmt.Errorf("写入卷备份失败: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("写入卷备份失败: %v", err)
+	}
+	return nil
+}
+
+// NodeID 管理器所属的节点ID（0 表示面板所在主机）
+func (dm *DockerManager) NodeID() uint {
+	return dm.nodeID
+}
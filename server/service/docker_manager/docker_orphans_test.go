@@ -0,0 +1,59 @@
+package docker_manager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/docker/docker/api/types/volume"
+)
+
+func TestListManagedResources(t *testing.T) {
+	server := setupServer(t, models.Server{Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, ServerArgsJSON: "{}"})
+	dm, fake := newFakeManager(t, HelperImage, "tbro98/ase-server:latest")
+
+	if _, err := dm.CreateVolume(server.ID); err != nil {
+		t.Fatalf("创建卷失败: %v", err)
+	}
+	if err := dm.WriteConfigFile(server.ID, utils.GameIniFileName, "[a]\n"); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	if _, err := dm.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart); err != nil {
+		t.Fatalf("创建容器失败: %v", err)
+	}
+	// 添加标签之前创建的卷、其他面板实例的卷和无关的卷
+	fake.VolumeCreate(dm.ctx, volume.CreateOptions{Name: utils.GetServerPluginsVolumeName(7)})
+	fake.VolumeCreate(dm.ctx, volume.CreateOptions{Name: utils.GetServerVolumeName(8), Labels: map[string]string{ManagerLabel: "other", ServerIDLabel: "8"}})
+	fake.VolumeCreate(dm.ctx, volume.CreateOptions{Name: "ase-server-9x"})
+
+	resources, err := dm.ListManagedResources()
+	if err != nil {
+		t.Fatalf("获取面板资源失败: %v", err)
+	}
+	var got []string
+	for _, r := range resources {
+		got = append(got, fmt.Sprintf("%s:%s:%s:%d:%v", r.Kind, r.Name, r.Role, r.ServerID, r.Legacy))
+	}
+	id := server.ID
+	want := []string{
+		fmt.Sprintf("container:ase-helper-%d:helper:%d:false", id, id),
+		fmt.Sprintf("container:ase-server-%d:server:%d:false", id, id),
+		fmt.Sprintf("volume:ase-server-%d:saved:%d:false", id, id),
+		fmt.Sprintf("volume:ase-server-plugins-%d:plugins:%d:false", id, id),
+		"volume:ase-server-plugins-7:plugins:7:true",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("面板资源 = %v，期望 %v", got, want)
+	}
+
+	// 删除辅助容器时同时从辅助容器池中移除，之后可以重新创建
+	if err := dm.RemoveManagedResource(resources[0]); err != nil {
+		t.Fatalf("删除辅助容器失败: %v", err)
+	}
+	if _, err := dm.ReadConfigFile(server.ID, utils.GameIniFileName); err != nil {
+		t.Fatalf("辅助容器删除后应重新创建: %v", err)
+	}
+}
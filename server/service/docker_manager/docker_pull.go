@@ -0,0 +1,410 @@
+package docker_manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// 拉取任务状态
+const (
+	PullStatusPulling   = "pulling"
+	PullStatusSucceeded = "succeeded"
+	PullStatusFailed    = "failed"
+	PullStatusCanceled  = "canceled"
+)
+
+// 拉取事件类型
+const (
+	PullEventLayer  = "layer"  // 层级进度更新
+	PullEventStatus = "status" // 任务状态变化（开始拉取或切换拉取地址）
+)
+
+const (
+	pullJobRetention  = time.Hour // 已结束的任务保留时间
+	pullEventCapacity = 256       // 每个订阅者缓存的事件数量
+)
+
+// PullJob 镜像拉取任务，记录拉取进度和最终结果，结束后保留一段时间供查询
+type PullJob struct {
+	id     string
+	nodeID uint
+	image  string
+	key    string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu           sync.Mutex
+	status       string
+	from         string
+	err          error
+	digest       string
+	currentLayer string
+	layers       map[string]*LayerStatus
+	layerOrder   []string
+	startedAt    time.Time
+	finishedAt   time.Time
+	subscribers  map[chan PullEvent]struct{}
+}
+
+// PullJobInfo 镜像拉取任务信息
+type PullJobInfo struct {
+	ID           string        `json:"id"`
+	NodeID       uint          `json:"node_id"`
+	Image        string        `json:"image"`
+	Status       string        `json:"status"`          // pulling, succeeded, failed, canceled
+	From         string        `json:"from,omitempty"`  // 实际拉取的地址（从加速地址拉取时与镜像名称不同）
+	Error        string        `json:"error,omitempty"` // 失败原因
+	Digest       string        `json:"digest,omitempty"`
+	CurrentLayer string        `json:"current_layer"`
+	Layers       []LayerStatus `json:"layers"` // 按出现顺序排列
+	StartedAt    string        `json:"started_at"`
+	FinishedAt   string        `json:"finished_at,omitempty"`
+	DurationMs   int64         `json:"duration_ms"` // 已用时间（结束后为总耗时）
+}
+
+// PullEvent 镜像拉取事件
+type PullEvent struct {
+	Type  string       `json:"type"`
+	Layer *LayerStatus `json:"layer,omitempty"` // layer 事件的层级状态
+	Job   *PullJobInfo `json:"job,omitempty"`   // status 事件的任务信息
+}
+
+// 拉取任务管理（所有节点共用）
+var (
+	pullJobs      = make(map[string]*PullJob) // 按任务ID，包括已结束的任务
+	activePulls   = make(map[string]*PullJob) // 正在进行的任务，按 pullKey
+	pullJobsMutex sync.Mutex
+)
+
+// StartPull 在后台拉取镜像，镜像已在该节点上拉取时返回正在进行的任务
+func (dm *DockerManager) StartPull(imageName string) (*PullJob, error) {
+	key := dm.pullKey(imageName)
+
+	pullJobsMutex.Lock()
+	defer pullJobsMutex.Unlock()
+
+	if job, ok := activePulls[key]; ok {
+		return job, nil
+	}
+	pruneJobsLocked(time.Now())
+
+	id, err := utils.RandomToken("", 12)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(dm.ctx)
+	job := &PullJob{
+		id:          id,
+		nodeID:      dm.nodeID,
+		image:       imageName,
+		key:         key,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		status:      PullStatusPulling,
+		layers:      make(map[string]*LayerStatus),
+		startedAt:   time.Now(),
+		subscribers: make(map[chan PullEvent]struct{}),
+	}
+	pullJobs[id] = job
+	activePulls[key] = job
+
+	utils.Info("开始拉取Docker镜像", zap.String("image", imageName), zap.Uint("node_id", dm.nodeID), zap.String("job_id", id))
+	go dm.runPull(ctx, job)
+	return job, nil
+}
+
+// GetPullJob 按ID获取拉取任务
+func GetPullJob(id string) (*PullJob, error) {
+	pullJobsMutex.Lock()
+	defer pullJobsMutex.Unlock()
+
+	job, ok := pullJobs[id]
+	if !ok {
+		return nil, fmt.Errorf("拉取任务不存在")
+	}
+	return job, nil
+}
+
+// ListPullJobs 获取正在进行和最近结束的拉取任务（按开始时间从新到旧）
+func ListPullJobs() []PullJobInfo {
+	pullJobsMutex.Lock()
+	pruneJobsLocked(time.Now())
+	jobs := make([]*PullJob, 0, len(pullJobs))
+	for _, job := range pullJobs {
+		jobs = append(jobs, job)
+	}
+	pullJobsMutex.Unlock()
+
+	infos := make([]PullJobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		infos = append(infos, job.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt > infos[j].StartedAt
+	})
+	return infos
+}
+
+// CancelPullJob 取消正在进行的拉取任务
+func CancelPullJob(id string) error {
+	job, err := GetPullJob(id)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		return fmt.Errorf("拉取任务已结束")
+	}
+	job.cancel()
+	utils.Info("已取消镜像拉取", zap.String("image", job.image), zap.String("job_id", job.id))
+	return nil
+}
+
+// lastPull 该节点上最近结束的拉取任务（没有时为 nil）
+func lastPull(key string) *PullJob {
+	pullJobsMutex.Lock()
+	defer pullJobsMutex.Unlock()
+
+	var last *PullJob
+	for _, job := range pullJobs {
+		if job.key == key && job.Finished() && (last == nil || job.startedAt.After(last.startedAt)) {
+			last = job
+		}
+	}
+	return last
+}
+
+// pruneJobsLocked 删除超过保留时间的已结束任务（调用方需持有 pullJobsMutex）
+func pruneJobsLocked(now time.Time) {
+	for id, job := range pullJobs {
+		job.mu.Lock()
+		expired := !job.finishedAt.IsZero() && now.Sub(job.finishedAt) > pullJobRetention
+		job.mu.Unlock()
+		if expired {
+			delete(pullJobs, id)
+		}
+	}
+}
+
+// ID 任务ID
+func (j *PullJob) ID() string {
+	return j.id
+}
+
+// Done 任务结束时关闭的通道
+func (j *PullJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Err 任务的错误信息（成功或未结束时为 nil）
+func (j *PullJob) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Finished 任务是否已结束
+func (j *PullJob) Finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Info 任务信息快照
+func (j *PullJob) Info() PullJobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.infoLocked()
+}
+
+// Subscribe 订阅任务事件，返回订阅时的任务信息和事件通道
+// 任务结束时关闭通道；订阅者处理过慢时丢弃层级进度事件，不阻塞拉取
+// 返回的函数用于取消订阅
+func (j *PullJob) Subscribe() (PullJobInfo, <-chan PullEvent, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	events := make(chan PullEvent, pullEventCapacity)
+	if j.status != PullStatusPulling {
+		close(events)
+		return j.infoLocked(), events, func() {}
+	}
+	j.subscribers[events] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			if _, ok := j.subscribers[events]; ok {
+				delete(j.subscribers, events)
+				close(events)
+			}
+		})
+	}
+	return j.infoLocked(), events, unsubscribe
+}
+
+// infoLocked 任务信息快照（调用方需持有 mu）
+func (j *PullJob) infoLocked() PullJobInfo {
+	info := PullJobInfo{
+		ID:           j.id,
+		NodeID:       j.nodeID,
+		Image:        j.image,
+		Status:       j.status,
+		From:         j.from,
+		Digest:       j.digest,
+		CurrentLayer: j.currentLayer,
+		Layers:       make([]LayerStatus, 0, len(j.layerOrder)),
+		StartedAt:    j.startedAt.Format(time.RFC3339),
+	}
+	if j.err != nil {
+		info.Error = j.err.Error()
+	}
+	for _, layerID := range j.layerOrder {
+		info.Layers = append(info.Layers, *j.layers[layerID])
+	}
+	end := time.Now()
+	if !j.finishedAt.IsZero() {
+		end = j.finishedAt
+		info.FinishedAt = j.finishedAt.Format(time.RFC3339)
+	}
+	info.DurationMs = end.Sub(j.startedAt).Milliseconds()
+	return info
+}
+
+// publishLocked 向所有订阅者发送事件（调用方需持有 mu）
+func (j *PullJob) publishLocked(event PullEvent) {
+	for events := range j.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// startAttempt 开始从指定地址拉取，重置层级进度
+func (j *PullJob) startAttempt(ref string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.from = ref
+	j.currentLayer = ""
+	j.layers = make(map[string]*LayerStatus)
+	j.layerOrder = nil
+	info := j.infoLocked()
+	j.publishLocked(PullEvent{Type: PullEventStatus, Job: &info})
+}
+
+// applyProgress 根据拉取进度信息更新层级状态
+func (j *PullJob) applyProgress(progressInfo ImagePullProgress) {
+	if digest, ok := strings.CutPrefix(progressInfo.Status, "Digest: "); ok {
+		j.mu.Lock()
+		j.digest = strings.TrimSpace(digest)
+		j.mu.Unlock()
+		return
+	}
+
+	layerID := progressInfo.ID
+	if layerID == "" {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.currentLayer = layerID
+
+	// 确保层级存在
+	layer := j.layers[layerID]
+	if layer == nil {
+		layer = &LayerStatus{
+			ID:       layerID,
+			Size:     0,
+			Progress: 0,
+			Status:   "pending",
+		}
+		j.layers[layerID] = layer
+		j.layerOrder = append(j.layerOrder, layerID)
+	}
+
+	// 更新层级大小信息
+	if progressInfo.ProgressDetail != nil {
+		if progressInfo.ProgressDetail.Total > 0 {
+			layer.Size = progressInfo.ProgressDetail.Total
+		}
+		if progressInfo.ProgressDetail.Current > 0 {
+			layer.Progress = progressInfo.ProgressDetail.Current
+		}
+	}
+
+	// 如果ProgressDetail中没有大小信息，尝试从Progress字符串中解析
+	if layer.Size == 0 && progressInfo.Progress != "" {
+		if size := parseSizeFromProgress(progressInfo.Progress); size > 0 {
+			layer.Size = size
+		}
+	}
+
+	// 更新层级状态
+	if strings.Contains(progressInfo.Status, "Downloading") {
+		layer.Status = "downloading"
+	} else if strings.Contains(progressInfo.Status, "Extracting") {
+		layer.Status = "extracting"
+	} else if strings.Contains(progressInfo.Status, "Verifying") {
+		layer.Status = "verifying"
+	} else if strings.Contains(progressInfo.Status, "Pull complete") || strings.Contains(progressInfo.Status, "complete") {
+		layer.Status = "complete"
+		// 完成时，如果没有大小信息，设置一个默认值
+		if layer.Size == 0 {
+			layer.Size = layer.Progress
+			if layer.Size == 0 {
+				layer.Size = 1024 * 1024 // 默认1MB
+			}
+		}
+		layer.Progress = layer.Size
+	}
+
+	snapshot := *layer
+	j.publishLocked(PullEvent{Type: PullEventLayer, Layer: &snapshot})
+}
+
+// finish 记录任务结果，关闭所有订阅并从正在进行的任务中移除
+func (j *PullJob) finish(err error, canceled bool) {
+	j.mu.Lock()
+	j.finishedAt = time.Now()
+	switch {
+	case canceled:
+		j.status = PullStatusCanceled
+		j.err = errors.New("镜像拉取已取消")
+	case err != nil:
+		j.status = PullStatusFailed
+		j.err = err
+	default:
+		j.status = PullStatusSucceeded
+	}
+	for events := range j.subscribers {
+		close(events)
+	}
+	j.subscribers = make(map[chan PullEvent]struct{})
+	j.mu.Unlock()
+
+	pullJobsMutex.Lock()
+	if activePulls[j.key] == j {
+		delete(activePulls, j.key)
+	}
+	pullJobsMutex.Unlock()
+
+	close(j.done)
+	j.cancel()
+}
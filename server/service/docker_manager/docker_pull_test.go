@@ -0,0 +1,164 @@
+package docker_manager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager/dockertest"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// setupPullTest 创建拉取镜像需要的数据库（查询仓库凭据）
+func setupPullTest(t *testing.T) {
+	t.Helper()
+	setupServer(t, models.Server{Identifier: "ark", UserID: 1})
+}
+
+// waitJob 等待拉取任务结束
+func waitJob(t *testing.T, job *PullJob) PullJobInfo {
+	t.Helper()
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待拉取任务结束超时")
+	}
+	return job.Info()
+}
+
+func TestPullJobStreamsProgressAndKeepsResult(t *testing.T) {
+	setupPullTest(t)
+	dm, fake := newFakeManager(t)
+	fake.PullDelay = 5 * time.Millisecond
+
+	job, err := dm.StartPull("tbro98/ase-server:latest")
+	if err != nil {
+		t.Fatalf("创建拉取任务失败: %v", err)
+	}
+	if again, _ := dm.StartPull("tbro98/ase-server:latest"); again != job {
+		t.Fatal("正在拉取的镜像应返回同一个任务")
+	}
+	if !dm.IsImagePulling("tbro98/ase-server:latest") {
+		t.Fatal("镜像应处于拉取中")
+	}
+
+	info, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+	if info.Status != PullStatusPulling {
+		t.Fatalf("订阅时的任务状态错误: %+v", info)
+	}
+	var layerEvents int
+	for event := range events {
+		if event.Type == PullEventLayer {
+			layerEvents++
+		}
+	}
+	if layerEvents == 0 {
+		t.Fatal("应收到层级进度事件")
+	}
+
+	// 任务结束后保留结果
+	info = waitJob(t, job)
+	if info.Status != PullStatusSucceeded || info.Error != "" || !strings.HasPrefix(info.Digest, "sha256:") || info.FinishedAt == "" {
+		t.Fatalf("拉取结果错误: %+v", info)
+	}
+	if len(info.Layers) != 2 || info.Layers[1].Status != "complete" || info.Layers[1].Progress != 1<<20 {
+		t.Fatalf("层级进度错误: %+v", info.Layers)
+	}
+	if found, err := GetPullJob(job.ID()); err != nil || found != job {
+		t.Fatalf("结束的任务应可以查询: %v", err)
+	}
+	if exists, _ := dm.ImageExists("tbro98/ase-server:latest"); !exists || dm.IsImagePulling("tbro98/ase-server:latest") {
+		t.Fatal("拉取完成后镜像应存在")
+	}
+
+	// 结束后订阅立即关闭事件通道
+	if info, events, _ := job.Subscribe(); info.Status != PullStatusSucceeded {
+		t.Fatalf("结束后订阅的任务状态错误: %+v", info)
+	} else if _, ok := <-events; ok {
+		t.Fatal("结束后订阅的事件通道应已关闭")
+	}
+}
+
+func TestPullJobFailureIsRetained(t *testing.T) {
+	setupPullTest(t)
+	dm, fake := newFakeManager(t)
+	fake.FailOn("ImagePull", "", errors.New("pull access denied"))
+
+	err := dm.PullImageWithProgress("tbro98/ase-server:v9")
+	if err == nil || !strings.Contains(err.Error(), "pull access denied") {
+		t.Fatalf("拉取失败应返回错误: %v", err)
+	}
+
+	status := dm.GetImageStatus("tbro98/ase-server:v9")
+	if status.Pulling || status.LastPull == nil || status.LastPull.Status != PullStatusFailed || !strings.Contains(status.LastPull.Error, "pull access denied") {
+		t.Fatalf("镜像状态应包含最近一次拉取的失败原因: %+v", status)
+	}
+}
+
+func TestCancelPullJob(t *testing.T) {
+	setupPullTest(t)
+	dm, fake := newFakeManager(t)
+	fake.PullDelay = time.Second
+
+	job, err := dm.StartPull("tbro98/ase-server:latest")
+	if err != nil {
+		t.Fatalf("创建拉取任务失败: %v", err)
+	}
+	if err := CancelPullJob(job.ID()); err != nil {
+		t.Fatalf("取消拉取失败: %v", err)
+	}
+	info := waitJob(t, job)
+	if info.Status != PullStatusCanceled || info.Error != "镜像拉取已取消" {
+		t.Fatalf("取消后的任务状态错误: %+v", info)
+	}
+	if exists, _ := dm.ImageExists("tbro98/ase-server:latest"); exists {
+		t.Fatal("取消拉取后镜像不应存在")
+	}
+	if err := CancelPullJob(job.ID()); err == nil || err.Error() != "拉取任务已结束" {
+		t.Fatalf("重复取消应返回错误: %v", err)
+	}
+	if err := CancelPullJob("missing"); err == nil || err.Error() != "拉取任务不存在" {
+		t.Fatalf("取消不存在的任务应返回错误: %v", err)
+	}
+}
+
+// streamClient 返回固定进度流的客户端，每次只读取一个字节
+type streamClient struct {
+	*dockertest.FakeClient
+	stream string
+}
+
+func (c streamClient) ImagePull(ctx context.Context, ref string, options image.PullOptions) (io.ReadCloser, error) {
+	return io.NopCloser(iotest.OneByteReader(strings.NewReader(c.stream))), nil
+}
+
+func TestPullDecodesJSONStream(t *testing.T) {
+	setupPullTest(t)
+
+	progress := `{"status":"Pulling fs layer","id":"aaa"}` + "\n" +
+		`{"status":"Downloading","id":"aaa","progressDetail":{"current":2048,"total":4096}}` + "\r\n" +
+		`{"status":"Digest: sha256:abc"}`
+	dm := newDockerManager(streamClient{FakeClient: dockertest.NewFakeClient(), stream: progress}, 0, "", nil)
+	t.Cleanup(dm.removeAllHelpers)
+
+	job, _ := dm.StartPull("tbro98/ase-server:latest")
+	info := waitJob(t, job)
+	if info.Status != PullStatusSucceeded || info.Digest != "sha256:abc" || len(info.Layers) != 1 || info.Layers[0].Progress != 2048 || info.Layers[0].Size != 4096 {
+		t.Fatalf("解析拉取进度错误: %+v", info)
+	}
+
+	// 进度流中的错误信息
+	dm = newDockerManager(streamClient{FakeClient: dockertest.NewFakeClient(), stream: progress + "\n" +
+		`{"errorDetail":{"message":"denied"},"error":"denied: requested access to the resource is denied"}`}, 0, "", nil)
+	t.Cleanup(dm.removeAllHelpers)
+	if err := dm.PullImageWithProgress("tbro98/ase-server:latest"); err == nil || !strings.Contains(err.Error(), "requested access to the resource is denied") {
+		t.Fatalf("进度流中的错误应返回: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package docker_manager
+
+import (
+	"fmt"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// DockerHubRegistry Docker Hub 在镜像引用中的仓库地址
+const DockerHubRegistry = "docker.io"
+
+// NormalizeRegistry 规范化仓库地址（去掉协议和路径，Docker Hub 的各种地址统一为 docker.io）
+func NormalizeRegistry(address string) string {
+	address = strings.TrimSpace(strings.ToLower(address))
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	if host, _, found := strings.Cut(address, "/"); found {
+		address = host
+	}
+	switch address {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHubRegistry
+	}
+	return address
+}
+
+// pullReferences 拉取镜像时依次尝试的引用：匹配加速规则的加速地址在前，原镜像在最后
+// 使用摘要固定版本的镜像不经过加速地址（摘要引用拉取后不能再标记为原镜像名称）
+func pullReferences(imageName string, mirrors []config.RegistryMirror) []string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return []string{imageName}
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return []string{imageName}
+	}
+	named = reference.TagNameOnly(named)
+	tagged, ok := named.(reference.Tagged)
+	if !ok {
+		return []string{imageName}
+	}
+
+	var refs []string
+	name := named.Name()
+	for _, mirror := range mirrors {
+		source := mirror.Source
+		if !strings.Contains(source, ".") && !strings.Contains(source, ":") && source != "localhost" {
+			// 未写仓库地址的前缀（如 tbro98）指 Docker Hub 上的镜像
+			source = DockerHubRegistry + "/" + source
+		}
+		if name != source && !strings.HasPrefix(name, source+"/") {
+			continue
+		}
+		mirrored, err := reference.ParseNormalizedNamed(mirror.Mirror + strings.TrimPrefix(name, source))
+		if err != nil {
+			continue
+		}
+		mirroredTagged, err := reference.WithTag(mirrored, tagged.Tag())
+		if err != nil {
+			continue
+		}
+		ref := reference.FamiliarString(mirroredTagged)
+		if !containsString(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return append(refs, imageName)
+}
+
+// registryAuth 拉取镜像时使用的认证信息（未登记该仓库的凭据时为空）
+func registryAuth(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", nil
+	}
+	domain := reference.Domain(named)
+
+	var credentials []models.RegistryCredential
+	if err := database.DB.Where("registry = ?", domain).Limit(1).Find(&credentials).Error; err != nil {
+		return "", fmt.Errorf("获取仓库凭据失败: %v", err)
+	}
+	if len(credentials) == 0 {
+		return "", nil
+	}
+
+	auth, err := registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      credentials[0].Username,
+		Password:      string(credentials[0].Password),
+		ServerAddress: domain,
+	})
+	if err != nil {
+		return "", fmt.Errorf("编码仓库凭据失败: %v", err)
+	}
+	return auth, nil
+}
+
+// containsString 切片中是否包含指定字符串
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
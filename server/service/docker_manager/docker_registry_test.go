@@ -0,0 +1,95 @@
+package docker_manager
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+func TestPullReferences(t *testing.T) {
+	mirrors := []config.RegistryMirror{
+		{Source: "tbro98", Mirror: "mirror.example.com/hub/tbro98"},
+		{Source: "docker.io", Mirror: "mirror.example.com/hub"},
+		{Source: "ghcr.io/ark", Mirror: "registry.example.com:5000/ark"},
+	}
+	cases := map[string]string{
+		"tbro98/ase-server":      "mirror.example.com/hub/tbro98/ase-server:latest,tbro98/ase-server",
+		"alpine:3.20":            "mirror.example.com/hub/library/alpine:3.20,alpine:3.20",
+		"ghcr.io/ark/server:v2":  "registry.example.com:5000/ark/server:v2,ghcr.io/ark/server:v2",
+		"ghcr.io/arkadia/server": "ghcr.io/arkadia/server",
+		"tbro98/ase-server@sha256:" + strings.Repeat("a", 64): "tbro98/ase-server@sha256:" + strings.Repeat("a", 64),
+	}
+	for image, want := range cases {
+		if got := strings.Join(pullReferences(image, mirrors), ","); got != want {
+			t.Errorf("pullReferences(%q) = %s，期望 %s", image, got, want)
+		}
+	}
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	cases := map[string]string{
+		"https://index.docker.io/v1/":  "docker.io",
+		"registry-1.docker.io":         "docker.io",
+		" Registry.Example.com:5000/ ": "registry.example.com:5000",
+		"http://localhost:5000/v2/":    "localhost:5000",
+	}
+	for address, want := range cases {
+		if got := NormalizeRegistry(address); got != want {
+			t.Errorf("NormalizeRegistry(%q) = %q，期望 %q", address, got, want)
+		}
+	}
+}
+
+func TestPullImageUsesMirrorsAndCredentials(t *testing.T) {
+	setupServer(t, models.Server{Identifier: "ark", UserID: 1})
+	database.DB.Create(&models.RegistryCredential{Registry: "mirror.example.com", Username: "ark", Password: "secret"})
+
+	original := config.RegistryMirrors
+	config.RegistryMirrors = []config.RegistryMirror{{Source: "tbro98", Mirror: "mirror.example.com/tbro98"}}
+	t.Cleanup(func() { config.RegistryMirrors = original })
+
+	dm, fake := newFakeManager(t)
+
+	// 从加速地址拉取后标记为原镜像名称，并使用加速地址的凭据
+	if err := dm.PullImageWithProgress("tbro98/ase-server:latest"); err != nil {
+		t.Fatalf("拉取镜像失败: %v", err)
+	}
+	if exists, _ := dm.ImageExists("tbro98/ase-server:latest"); !exists {
+		t.Fatal("从加速地址拉取的镜像应标记为原镜像名称")
+	}
+	auth := fake.PullAuth("mirror.example.com/tbro98/ase-server:latest")
+	if auth == "" {
+		t.Fatal("拉取加速地址的镜像应使用登记的凭据")
+	}
+	decoded, _ := base64.URLEncoding.DecodeString(auth)
+	var authConfig registry.AuthConfig
+	if err := json.Unmarshal(decoded, &authConfig); err != nil || authConfig.Username != "ark" || authConfig.Password != "secret" || authConfig.ServerAddress != "mirror.example.com" {
+		t.Fatalf("拉取镜像的认证信息错误: %s", decoded)
+	}
+
+	// 加速地址失败时回退到原仓库（未登记凭据，不认证）
+	fake.FailOn("ImagePull", "mirror.example.com/tbro98/ase-server:v2", errors.New("mirror unavailable"))
+	if err := dm.PullImageWithProgress("tbro98/ase-server:v2"); err != nil {
+		t.Fatalf("加速地址失败时应回退到原仓库: %v", err)
+	}
+	if exists, _ := dm.ImageExists("tbro98/ase-server:v2"); !exists {
+		t.Fatal("应从原仓库拉取镜像")
+	}
+	if fake.PullAuth("tbro98/ase-server:v2") != "" {
+		t.Fatal("未登记凭据的仓库不应使用认证信息")
+	}
+
+	// 所有地址都失败时返回错误
+	fake.FailOn("ImagePull", "", errors.New("network down"))
+	if err := dm.PullImageWithProgress("tbro98/ase-server:v3"); err == nil {
+		t.Fatal("所有地址都失败时应返回错误")
+	}
+}
@@ -0,0 +1,172 @@
+package docker_manager
+
+import (
+	"ark-server-commander/models"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// 资源限制相关常量
+const (
+	minMemoryLimitMB = 6      // Docker 允许的最小内存上限
+	minCPUShares     = 2      // Docker 允许的最小 CPU 权重
+	maxCPUShares     = 262144 // Docker 允许的最大 CPU 权重
+	cpuPeriod        = 100000 // CPU quota 的计算周期（微秒）
+	hostInfoTimeout  = 10 * time.Second
+	bytesPerMB       = 1 << 20
+)
+
+// validUlimits Docker 支持的 ulimit 名称
+var validUlimits = map[string]bool{
+	"core": true, "cpu": true, "data": true, "fsize": true, "locks": true, "memlock": true, "msgqueue": true,
+	"nice": true, "nofile": true, "nproc": true, "rss": true, "rtprio": true, "rttime": true, "sigpending": true, "stack": true,
+}
+
+// ValidateResources 校验资源限制，并检查是否超过节点主机的CPU和内存
+func (dm *DockerManager) ValidateResources(resources models.ServerResources) error {
+	if resources.IsZero() {
+		return nil
+	}
+	info, err := dm.GetHostInfo(hostInfoTimeout)
+	if err != nil {
+		return fmt.Errorf("获取主机资源失败: %w", err)
+	}
+	return checkResources(resources, info)
+}
+
+// checkResources 校验资源限制（host 为空时不检查主机容量）
+func checkResources(resources models.ServerResources, host *HostInfo) error {
+	if resources.MemoryLimitMB < 0 || resources.MemoryReservationMB < 0 || resources.CPUShares < 0 ||
+		resources.CPULimit < 0 || resources.PidsLimit < 0 || resources.RestartMaxRetries < 0 {
+		return fmt.Errorf("资源限制不能为负数")
+	}
+	if resources.MemoryLimitMB > 0 && resources.MemoryLimitMB < minMemoryLimitMB {
+		return fmt.Errorf("资源限制错误: 内存上限不能小于 %dMB", minMemoryLimitMB)
+	}
+	if resources.MemoryLimitMB > 0 && resources.MemoryReservationMB > resources.MemoryLimitMB {
+		return fmt.Errorf("资源限制错误: 内存软限制不能大于内存上限")
+	}
+	if resources.CPUShares != 0 && (resources.CPUShares < minCPUShares || resources.CPUShares > maxCPUShares) {
+		return fmt.Errorf("资源限制错误: CPU 权重必须在 %d 到 %d 之间", minCPUShares, maxCPUShares)
+	}
+	if resources.CPULimit > 0 && resources.CPULimit < 0.01 {
+		return fmt.Errorf("资源限制错误: CPU 核数不能小于 0.01")
+	}
+
+	seen := make(map[string]bool, len(resources.Ulimits))
+	for _, ulimit := range resources.Ulimits {
+		if !validUlimits[ulimit.Name] {
+			return fmt.Errorf("资源限制错误: 不支持的 ulimit %q", ulimit.Name)
+		}
+		if seen[ulimit.Name] {
+			return fmt.Errorf("资源限制错误: ulimit %s 重复", ulimit.Name)
+		}
+		seen[ulimit.Name] = true
+		if ulimit.Soft < 0 || ulimit.Hard < 0 || ulimit.Soft > ulimit.Hard {
+			return fmt.Errorf("资源限制错误: ulimit %s 的软限制必须在 0 到硬限制之间", ulimit.Name)
+		}
+	}
+
+	if host == nil {
+		return nil
+	}
+	hostMemoryMB := host.MemoryBytes / bytesPerMB
+	if hostMemoryMB > 0 {
+		if resources.MemoryLimitMB > hostMemoryMB {
+			return fmt.Errorf("资源限制错误: 内存上限 %dMB 超过主机内存 %dMB", resources.MemoryLimitMB, hostMemoryMB)
+		}
+		if resources.MemoryReservationMB > hostMemoryMB {
+			return fmt.Errorf("资源限制错误: 内存软限制 %dMB 超过主机内存 %dMB", resources.MemoryReservationMB, hostMemoryMB)
+		}
+	}
+	if host.CPUs > 0 && resources.CPULimit > float64(host.CPUs) {
+		return fmt.Errorf("资源限制错误: CPU 核数 %g 超过主机的 %d 核", resources.CPULimit, host.CPUs)
+	}
+	return nil
+}
+
+// containerResources 将资源限制转换为容器配置
+func containerResources(resources models.ServerResources) container.Resources {
+	result := container.Resources{
+		Memory:            resources.MemoryLimitMB * bytesPerMB,
+		MemoryReservation: resources.MemoryReservationMB * bytesPerMB,
+		CPUShares:         resources.CPUShares,
+	}
+	if resources.CPULimit > 0 {
+		result.CPUPeriod = cpuPeriod
+		result.CPUQuota = int64(resources.CPULimit * cpuPeriod)
+	}
+	if resources.PidsLimit > 0 {
+		pidsLimit := resources.PidsLimit
+		result.PidsLimit = &pidsLimit
+	}
+	for _, ulimit := range resources.Ulimits {
+		result.Ulimits = append(result.Ulimits, &container.Ulimit{Name: ulimit.Name, Soft: ulimit.Soft, Hard: ulimit.Hard})
+	}
+	return result
+}
+
+// restartPolicy 根据自动重启设置生成重启策略（限制了重试次数时只在异常退出后重启）
+func restartPolicy(autoRestart bool, maxRetries int) container.RestartPolicy {
+	if !autoRestart {
+		return container.RestartPolicy{Name: container.RestartPolicyDisabled}
+	}
+	if maxRetries > 0 {
+		return container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: maxRetries}
+	}
+	return container.RestartPolicy{Name: container.RestartPolicyUnlessStopped}
+}
+
+// ContainerResourcesChanged 检查容器的资源限制和重启策略是否与服务器配置不同（不同时需要重建容器）
+func (dm *DockerManager) ContainerResourcesChanged(containerName string, server models.Server) (bool, error) {
+	containerInfo, err := dm.client.ContainerInspect(dm.ctx, containerName)
+	if err != nil {
+		return false, fmt.Errorf("获取Docker容器信息失败: %v", err)
+	}
+	if containerInfo.HostConfig == nil {
+		return true, nil
+	}
+	return !resourcesMatch(containerInfo.HostConfig, server), nil
+}
+
+// resourcesMatch 比较容器的主机配置与服务器的资源限制和重启策略
+func resourcesMatch(hostConfig *container.HostConfig, server models.Server) bool {
+	expected := containerResources(server.Resources)
+	actual := hostConfig.Resources
+
+	if actual.Memory != expected.Memory || actual.MemoryReservation != expected.MemoryReservation ||
+		actual.CPUShares != expected.CPUShares || actual.CPUQuota != expected.CPUQuota ||
+		pidsLimitValue(actual.PidsLimit) != pidsLimitValue(expected.PidsLimit) {
+		return false
+	}
+	// 未设置 quota 时 Docker 使用默认周期，只在设置了 quota 时比较周期
+	if expected.CPUQuota > 0 && actual.CPUPeriod != expected.CPUPeriod {
+		return false
+	}
+
+	if len(actual.Ulimits) != len(expected.Ulimits) {
+		return false
+	}
+	for i, ulimit := range expected.Ulimits {
+		if actual.Ulimits[i] == nil || *actual.Ulimits[i] != *ulimit {
+			return false
+		}
+	}
+
+	policy := restartPolicy(server.AutoRestart, server.Resources.RestartMaxRetries)
+	actualPolicy := hostConfig.RestartPolicy
+	if actualPolicy.IsNone() {
+		actualPolicy.Name = container.RestartPolicyDisabled
+	}
+	return actualPolicy.Name == policy.Name && actualPolicy.MaximumRetryCount == policy.MaximumRetryCount
+}
+
+// pidsLimitValue 进程数限制（未设置、0 和 -1 都表示不限制）
+func pidsLimitValue(limit *int64) int64 {
+	if limit == nil || *limit < 0 {
+		return 0
+	}
+	return *limit
+}
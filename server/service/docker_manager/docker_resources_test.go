@@ -0,0 +1,125 @@
+package docker_manager
+
+import (
+	"strings"
+	"testing"
+
+	"ark-server-commander/models"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestCheckResources(t *testing.T) {
+	host := &HostInfo{CPUs: 4, MemoryBytes: 8 << 30}
+
+	cases := []struct {
+		name      string
+		resources models.ServerResources
+		err       string
+	}{
+		{"不限制", models.ServerResources{}, ""},
+		{"正常", models.ServerResources{MemoryLimitMB: 6144, MemoryReservationMB: 4096, CPUShares: 512, CPULimit: 2.5, PidsLimit: 512,
+			Ulimits: models.Ulimits{{Name: "nofile", Soft: 10000, Hard: 100000}}, RestartMaxRetries: 3}, ""},
+		{"负数", models.ServerResources{PidsLimit: -1}, "资源限制不能为负数"},
+		{"内存过小", models.ServerResources{MemoryLimitMB: 4}, "内存上限不能小于 6MB"},
+		{"软限制大于上限", models.ServerResources{MemoryLimitMB: 2048, MemoryReservationMB: 4096}, "内存软限制不能大于内存上限"},
+		{"CPU权重过小", models.ServerResources{CPUShares: 1}, "CPU 权重必须在 2 到 262144 之间"},
+		{"未知ulimit", models.ServerResources{Ulimits: models.Ulimits{{Name: "files", Soft: 1, Hard: 1}}}, "不支持的 ulimit"},
+		{"重复ulimit", models.ServerResources{Ulimits: models.Ulimits{{Name: "nofile", Soft: 1, Hard: 1}, {Name: "nofile", Soft: 2, Hard: 2}}}, "ulimit nofile 重复"},
+		{"ulimit软限制大于硬限制", models.ServerResources{Ulimits: models.Ulimits{{Name: "nproc", Soft: 10, Hard: 1}}}, "软限制必须在 0 到硬限制之间"},
+		{"超过主机内存", models.ServerResources{MemoryLimitMB: 16384}, "内存上限 16384MB 超过主机内存 8192MB"},
+		{"软限制超过主机内存", models.ServerResources{MemoryReservationMB: 10000}, "内存软限制 10000MB 超过主机内存 8192MB"},
+		{"超过主机CPU", models.ServerResources{CPULimit: 6}, "CPU 核数 6 超过主机的 4 核"},
+	}
+
+	for _, item := range cases {
+		err := checkResources(item.resources, host)
+		if item.err == "" {
+			if err != nil {
+				t.Errorf("%s: 不应返回错误: %v", item.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "资源限制") || !strings.Contains(err.Error(), item.err) {
+			t.Errorf("%s: 期望错误包含 %q，实际为 %v", item.name, item.err, err)
+		}
+	}
+}
+
+func TestContainerResources(t *testing.T) {
+	resources := containerResources(models.ServerResources{
+		MemoryLimitMB: 4096, MemoryReservationMB: 2048, CPUShares: 512, CPULimit: 1.5, PidsLimit: 256,
+		Ulimits: models.Ulimits{{Name: "nofile", Soft: 1024, Hard: 4096}},
+	})
+
+	if resources.Memory != 4096<<20 || resources.MemoryReservation != 2048<<20 || resources.CPUShares != 512 {
+		t.Fatalf("内存和CPU权重转换错误: %+v", resources)
+	}
+	if resources.CPUPeriod != 100000 || resources.CPUQuota != 150000 {
+		t.Fatalf("CPU quota 转换错误: period=%d quota=%d", resources.CPUPeriod, resources.CPUQuota)
+	}
+	if resources.PidsLimit == nil || *resources.PidsLimit != 256 {
+		t.Fatalf("进程数限制转换错误: %v", resources.PidsLimit)
+	}
+	if len(resources.Ulimits) != 1 || *resources.Ulimits[0] != (container.Ulimit{Name: "nofile", Soft: 1024, Hard: 4096}) {
+		t.Fatalf("ulimit 转换错误: %+v", resources.Ulimits)
+	}
+
+	if empty := containerResources(models.ServerResources{}); empty.Memory != 0 || empty.CPUQuota != 0 || empty.PidsLimit != nil || empty.Ulimits != nil {
+		t.Fatalf("未配置时不应设置限制: %+v", empty)
+	}
+}
+
+func TestResourcesMatch(t *testing.T) {
+	server := models.Server{AutoRestart: true, Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 2, PidsLimit: 256}}
+	created := &container.HostConfig{
+		Resources:     containerResources(server.Resources),
+		RestartPolicy: restartPolicy(server.AutoRestart, server.Resources.RestartMaxRetries),
+	}
+	if !resourcesMatch(created, server) {
+		t.Fatal("按当前配置创建的容器不应需要重建")
+	}
+
+	// 升级前创建的容器：没有资源限制，Docker 返回默认的 CPU 周期和进程数限制
+	zero := int64(0)
+	legacy := &container.HostConfig{
+		Resources:     container.Resources{CPUPeriod: 0, PidsLimit: &zero},
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
+	}
+	if !resourcesMatch(legacy, models.Server{AutoRestart: true}) {
+		t.Fatal("未配置资源限制时旧容器不应需要重建")
+	}
+	if resourcesMatch(legacy, server) {
+		t.Fatal("新增资源限制后应重建容器")
+	}
+
+	changed := []models.Server{
+		{AutoRestart: true, Resources: models.ServerResources{MemoryLimitMB: 8192, CPULimit: 2, PidsLimit: 256}},
+		{AutoRestart: true, Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 1, PidsLimit: 256}},
+		{AutoRestart: true, Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 2, PidsLimit: 256, Ulimits: models.Ulimits{{Name: "nofile", Soft: 1, Hard: 1}}}},
+		{AutoRestart: true, Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 2, PidsLimit: 256, RestartMaxRetries: 5}},
+		{AutoRestart: false, Resources: models.ServerResources{MemoryLimitMB: 4096, CPULimit: 2, PidsLimit: 256}},
+	}
+	for i, item := range changed {
+		if resourcesMatch(created, item) {
+			t.Errorf("第 %d 项配置变化后应重建容器", i)
+		}
+	}
+}
+
+func TestRestartPolicy(t *testing.T) {
+	cases := []struct {
+		autoRestart bool
+		maxRetries  int
+		expected    container.RestartPolicy
+	}{
+		{false, 3, container.RestartPolicy{Name: container.RestartPolicyDisabled}},
+		{true, 0, container.RestartPolicy{Name: container.RestartPolicyUnlessStopped}},
+		{true, 3, container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3}},
+	}
+	for _, item := range cases {
+		if got := restartPolicy(item.autoRestart, item.maxRetries); got != item.expected {
+			t.Errorf("autoRestart=%v maxRetries=%d: 期望 %+v，实际 %+v", item.autoRestart, item.maxRetries, item.expected, got)
+		}
+	}
+}
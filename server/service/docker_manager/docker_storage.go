@@ -0,0 +1,182 @@
+package docker_manager
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"go.uber.org/zap"
+)
+
+// 服务器卷类型
+const (
+	VolumeKindSaved   = "saved"
+	VolumeKindPlugins = "plugins"
+)
+
+// VolumeUsage 统计服务器卷及其顶层目录的占用空间（在辅助容器中执行 du）和卷所在磁盘的空间（df）
+func (dm *DockerManager) VolumeUsage(serverID uint) ([]models.VolumeUsage, *models.DiskSpace, error) {
+	volumes := []struct {
+		kind  string
+		name  string
+		mount string
+	}{
+		{VolumeKindSaved, utils.GetServerVolumeName(serverID), HelperSavedMount},
+		{VolumeKindPlugins, utils.GetServerPluginsVolumeName(serverID), HelperPluginsMount},
+	}
+
+	var usages []models.VolumeUsage
+	var disk *models.DiskSpace
+	err := dm.WithVolumes(serverID, func(session *VolumeSession) error {
+		for _, v := range volumes {
+			output, err := session.Exec("du", "-k", "-d", "1", v.mount)
+			if err != nil {
+				return fmt.Errorf("统计卷 %s 的占用空间失败: %w", v.name, err)
+			}
+			usage := parseDu(output, v.mount)
+			usage.Name, usage.Kind = v.name, v.kind
+			usages = append(usages, usage)
+		}
+
+		output, err := session.Exec("df", "-P", "-k", HelperSavedMount)
+		if err != nil {
+			return fmt.Errorf("获取磁盘空间失败: %w", err)
+		}
+		disk, err = parseDf(output)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return usages, disk, nil
+}
+
+// HostDiskSpace 获取Docker数据目录所在磁盘的空间（在临时容器中执行 df）
+func (dm *DockerManager) HostDiskSpace() (*models.DiskSpace, error) {
+	exists, err := dm.ImageExists(HelperImage)
+	if err != nil {
+		return nil, fmt.Errorf("检查Alpine镜像失败: %v", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("Alpine镜像不存在，无法获取磁盘空间")
+	}
+
+	initProcess := true
+	resp, err := dm.client.ContainerCreate(dm.ctx, &container.Config{
+		Image:  HelperImage,
+		Cmd:    []string{"tail", "-f", "/dev/null"},
+		Labels: resourceLabels(0, RoleProbe),
+	}, &container.HostConfig{Init: &initProcess}, nil, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时容器失败: %v", err)
+	}
+	defer func() {
+		if err := dm.client.ContainerRemove(dm.ctx, resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			utils.Warn("删除临时容器失败", zap.String("container_id", resp.ID), zap.Error(err))
+		}
+	}()
+
+	if err := dm.client.ContainerStart(dm.ctx, resp.ID, container.StartOptions{}); err != nil {
+		return nil, fmt.Errorf("启动临时容器失败: %v", err)
+	}
+	output, err := dm.execInContainer(resp.ID, []string{"df", "-P", "-k", "/"})
+	if err != nil {
+		return nil, fmt.Errorf("获取磁盘空间失败: %w", err)
+	}
+	return parseDf(output)
+}
+
+// DiskUsage 获取Docker的磁盘占用
+// 返回: 镜像、容器、卷和构建缓存的总大小，各个卷的大小（-1 表示无法统计）和错误信息
+func (dm *DockerManager) DiskUsage() (*models.DockerDiskUsage, map[string]int64, error) {
+	usage, err := dm.client.DiskUsage(dm.ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.ImageObject, types.ContainerObject, types.VolumeObject, types.BuildCacheObject},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取Docker磁盘占用失败: %v", err)
+	}
+
+	result := &models.DockerDiskUsage{Images: usage.LayersSize}
+	for _, c := range usage.Containers {
+		if c != nil {
+			result.Containers += c.SizeRw
+		}
+	}
+	for _, record := range usage.BuildCache {
+		if record != nil {
+			result.BuildCache += record.Size
+		}
+	}
+	volumeSizes := make(map[string]int64, len(usage.Volumes))
+	for _, v := range usage.Volumes {
+		if v == nil {
+			continue
+		}
+		size := int64(-1)
+		if v.UsageData != nil {
+			size = v.UsageData.Size
+		}
+		if size > 0 {
+			result.Volumes += size
+		}
+		volumeSizes[v.Name] = size
+	}
+	return result, volumeSizes, nil
+}
+
+// parseDu 解析 du -k -d 1 的输出（每行为 "KB<TAB>路径"），顶层目录按大小从大到小排序
+func parseDu(output, root string) models.VolumeUsage {
+	usage := models.VolumeUsage{Directories: []models.DirectoryUsage{}}
+	for _, line := range strings.Split(output, "\n") {
+		sizeField, dir, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseInt(sizeField, 10, 64)
+		if err != nil {
+			continue
+		}
+		if path.Clean(dir) == path.Clean(root) {
+			usage.Size = kb * 1024
+			continue
+		}
+		usage.Directories = append(usage.Directories, models.DirectoryUsage{Name: path.Base(dir), Size: kb * 1024})
+	}
+	sort.SliceStable(usage.Directories, func(i, j int) bool {
+		return usage.Directories[i].Size > usage.Directories[j].Size
+	})
+	return usage
+}
+
+// parseDf 解析 df -P -k 的输出（第二行为 文件系统 总大小 已用 可用 使用率 挂载点）
+func parseDf(output string) (*models.DiskSpace, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("无法解析磁盘空间: %s", strings.TrimSpace(output))
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("无法解析磁盘空间: %s", lines[len(lines)-1])
+	}
+
+	var values [3]int64
+	for i := range values {
+		kb, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析磁盘空间: %s", lines[len(lines)-1])
+		}
+		values[i] = kb * 1024
+	}
+	disk := &models.DiskSpace{Total: values[0], Used: values[1], Available: values[2]}
+	if disk.Total > 0 {
+		disk.UsedPercent = float64(disk.Total-disk.Available) * 100 / float64(disk.Total)
+	}
+	return disk, nil
+}
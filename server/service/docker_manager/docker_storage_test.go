@@ -0,0 +1,94 @@
+package docker_manager
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func TestVolumeUsage(t *testing.T) {
+	server := setupServer(t, models.Server{Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, ServerArgsJSON: "{}"})
+	dm, fake := newFakeManager(t, HelperImage)
+	fake.DiskTotal, fake.DiskFree = 100<<30, 5<<30
+
+	if _, err := dm.CreateVolume(server.ID); err != nil {
+		t.Fatalf("创建卷失败: %v", err)
+	}
+	saved := utils.GetServerVolumeName(server.ID)
+	fake.WriteVolumeFile(saved, "SavedArks/TheIsland.ark", bytes.Repeat([]byte("a"), 8192))
+	fake.WriteVolumeFile(saved, "Logs/ShooterGame.log", bytes.Repeat([]byte("b"), 2048))
+	fake.WriteVolumeFile(utils.GetServerPluginsVolumeName(server.ID), "Permissions/config.json", []byte("{}"))
+
+	volumes, disk, err := dm.VolumeUsage(server.ID)
+	if err != nil {
+		t.Fatalf("统计卷占用失败: %v", err)
+	}
+	if len(volumes) != 2 || volumes[0].Kind != VolumeKindSaved || volumes[1].Kind != VolumeKindPlugins {
+		t.Fatalf("卷列表错误: %+v", volumes)
+	}
+	if volumes[0].Size != 10<<10 || len(volumes[0].Directories) != 2 ||
+		volumes[0].Directories[0].Name != "SavedArks" || volumes[0].Directories[0].Size != 8<<10 {
+		t.Fatalf("Saved 卷占用错误（目录应按大小排序）: %+v", volumes[0])
+	}
+	if volumes[1].Size != 1<<10 {
+		t.Fatalf("插件卷占用应按KB向上取整: %+v", volumes[1])
+	}
+	if disk.Total != 100<<30 || disk.Available != 5<<30 || disk.UsedPercent != 95 {
+		t.Fatalf("磁盘空间错误: %+v", disk)
+	}
+}
+
+func TestHostDiskSpaceRemovesProbeContainer(t *testing.T) {
+	dm, fake := newFakeManager(t, HelperImage)
+	fake.DiskTotal, fake.DiskFree = 40<<30, 10<<30
+
+	disk, err := dm.HostDiskSpace()
+	if err != nil {
+		t.Fatalf("获取磁盘空间失败: %v", err)
+	}
+	if disk.Total != 40<<30 || disk.Used != 30<<30 || disk.Available != 10<<30 {
+		t.Fatalf("磁盘空间错误: %+v", disk)
+	}
+	if names := fake.ContainerNames(); len(names) != 0 {
+		t.Fatalf("临时容器应被删除: %v", names)
+	}
+
+	dm, _ = newFakeManager(t)
+	if _, err := dm.HostDiskSpace(); err == nil || !strings.Contains(err.Error(), "Alpine镜像不存在") {
+		t.Fatalf("缺少Alpine镜像时应返回错误: %v", err)
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	server := setupServer(t, models.Server{Identifier: "island", Port: 7777, QueryPort: 27015, RCONPort: 27020, ServerArgsJSON: "{}"})
+	dm, fake := newFakeManager(t, HelperImage)
+
+	if _, err := dm.CreateVolume(server.ID); err != nil {
+		t.Fatalf("创建卷失败: %v", err)
+	}
+	fake.WriteVolumeFile(utils.GetServerVolumeName(server.ID), "SavedArks/TheIsland.ark", []byte("map data"))
+
+	usage, volumeSizes, err := dm.DiskUsage()
+	if err != nil {
+		t.Fatalf("获取Docker磁盘占用失败: %v", err)
+	}
+	if usage.Volumes != 8 || volumeSizes[utils.GetServerVolumeName(server.ID)] != 8 {
+		t.Fatalf("卷大小错误: %+v %v", usage, volumeSizes)
+	}
+	if size, ok := volumeSizes[utils.GetServerPluginsVolumeName(server.ID)]; !ok || size != 0 {
+		t.Fatalf("插件卷应出现在列表中: %v", volumeSizes)
+	}
+}
+
+func TestParseDf(t *testing.T) {
+	disk, err := parseDf("Filesystem     1024-blocks     Used Available Capacity Mounted on\n/dev/sda1        1000   600   400  60% /\n")
+	if err != nil || disk.Total != 1000<<10 || disk.Used != 600<<10 || disk.Available != 400<<10 || disk.UsedPercent != 60 {
+		t.Fatalf("解析 df 输出错误: %+v, %v", disk, err)
+	}
+	if _, err := parseDf("df: /data: No such file or directory"); err == nil {
+		t.Fatal("无法解析的输出应返回错误")
+	}
+}
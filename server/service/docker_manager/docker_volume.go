@@ -17,12 +17,12 @@ func (dm *DockerManager) CreateVolume(serverID uint) (string, error) {
 	pluginsVolumeName := utils.GetServerPluginsVolumeName(serverID)
 
 	// 创建游戏数据卷
-	if err := dm.createSingleVolume(volumeName); err != nil {
+	if err := dm.createSingleVolume(volumeName, resourceLabels(serverID, RoleSaved)); err != nil {
 		return "", fmt.Errorf("创建游戏数据卷失败: %v", err)
 	}
 
 	// 创建插件卷
-	if err := dm.createSingleVolume(pluginsVolumeName); err != nil {
+	if err := dm.createSingleVolume(pluginsVolumeName, resourceLabels(serverID, RolePlugins)); err != nil {
 		// 如果插件卷创建失败，清理已创建的游戏数据卷
 		dm.RemoveVolume(volumeName)
 		return "", fmt.Errorf("创建插件卷失败: %v", err)
@@ -34,8 +34,9 @@ func (dm *DockerManager) CreateVolume(serverID uint) (string, error) {
 
 // createSingleVolume 创建单个Docker卷
 // volumeName: 卷名称
+// labels: 卷的标签
 // 返回: 错误信息
-func (dm *DockerManager) createSingleVolume(volumeName string) error {
+func (dm *DockerManager) createSingleVolume(volumeName string, labels map[string]string) error {
 	// 检查卷是否已存在
 	exists, err := dm.VolumeExists(volumeName)
 	if err != nil {
@@ -49,7 +50,8 @@ func (dm *DockerManager) createSingleVolume(volumeName string) error {
 	// 创建卷
 	utils.Infof("正在创建Docker卷: %s", volumeName)
 	volumeCreateBody := volume.CreateOptions{
-		Name: volumeName,
+		Name:   volumeName,
+		Labels: labels,
 	}
 
 	_, err = dm.client.VolumeCreate(dm.ctx, volumeCreateBody)
@@ -65,18 +67,20 @@ func (dm *DockerManager) createSingleVolume(volumeName string) error {
 // volumeName: 游戏数据卷名称
 // 返回: 错误信息
 func (dm *DockerManager) RemoveVolume(volumeName string) error {
-	// 从游戏数据卷名称推导出插件卷名称
-	// 这里假设volumeName是游戏数据卷名称，需要推导出插件卷名称
-	// 由于当前的设计，我们需要通过serverID来推导，但这里只有volumeName
-	// 所以我们先删除游戏数据卷，然后尝试删除可能的插件卷
+	// 先删除挂载该卷的辅助容器，否则卷会被占用
+	dm.removeHelpersByVolume(volumeName)
 
 	// 删除游戏数据卷
 	if err := dm.removeSingleVolume(volumeName); err != nil {
 		return err
 	}
 
-	// 尝试删除插件卷（通过替换卷名称）
-	pluginsVolumeName := volumeName + "-plugins"
+	// 从游戏数据卷名称中解析服务器ID，删除对应的插件卷
+	pluginsVolumeName, ok := pluginsVolumeFor(volumeName)
+	if !ok {
+		utils.Warn("无法从卷名称推导插件卷，跳过删除", zap.String("volume", volumeName))
+		return nil
+	}
 	if err := dm.removeSingleVolume(pluginsVolumeName); err != nil {
 		// 插件卷删除失败不影响主流程，只记录警告
 		utils.Warn("删除插件卷失败", zap.String("volume", pluginsVolumeName), zap.Error(err))
@@ -85,6 +89,15 @@ func (dm *DockerManager) RemoveVolume(volumeName string) error {
 	return nil
 }
 
+// pluginsVolumeFor 根据游戏数据卷名称获取同一服务器的插件卷名称
+func pluginsVolumeFor(volumeName string) (string, bool) {
+	var serverID uint
+	if _, err := fmt.Sscanf(volumeName, "ase-server-%d", &serverID); err != nil || utils.GetServerVolumeName(serverID) != volumeName {
+		return "", false
+	}
+	return utils.GetServerPluginsVolumeName(serverID), true
+}
+
 // removeSingleVolume 删除单个Docker卷
 // volumeName: 卷名称
 // 返回: 错误信息
@@ -0,0 +1,148 @@
+package dockertest
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerStatPath 获取容器内路径的信息
+func (f *FakeClient) ContainerStatPath(ctx context.Context, containerID, p string) (container.PathStat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerStatPath", containerID)
+	if err != nil {
+		return container.PathStat{}, err
+	}
+	return c.stat(p)
+}
+
+// CopyFromContainer 以tar归档读取容器内的文件或目录（目录以其名称为根）
+func (f *FakeClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("CopyFromContainer", containerID)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+	stat, err := c.stat(srcPath)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+	fs, inner := c.resolve(srcPath)
+	base := path.Base(cleanPath(srcPath))
+	var writeErr error
+	fs.walk(inner, func(name string, entry *memEntry) {
+		if writeErr != nil {
+			return
+		}
+		header := &tar.Header{
+			Name:    path.Join(base, strings.TrimPrefix(name, inner)),
+			Mode:    int64(entry.mode.Perm()),
+			ModTime: entry.modTime,
+		}
+		if entry.dir {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.data))
+		}
+		if writeErr = tarWriter.WriteHeader(header); writeErr == nil && !entry.dir {
+			_, writeErr = tarWriter.Write(entry.data)
+		}
+	})
+	if writeErr == nil {
+		writeErr = tarWriter.Close()
+	}
+	if writeErr != nil {
+		return nil, container.PathStat{}, fmt.Errorf("构建 tar 归档失败: %v", writeErr)
+	}
+	return io.NopCloser(&buffer), stat, nil
+}
+
+// CopyToContainer 将tar归档解压到容器内已存在的目录
+func (f *FakeClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	// 先读取归档内容，避免持有锁时阻塞在调用方的流上
+	tarReader := tar.NewReader(content)
+	type file struct {
+		header *tar.Header
+		data   []byte
+	}
+	var files []file
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 归档失败: %v", err)
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return fmt.Errorf("读取 tar 归档失败: %v", err)
+		}
+		files = append(files, file{header: header, data: data})
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("CopyToContainer", containerID)
+	if err != nil {
+		return err
+	}
+	fs, inner := c.resolve(dstPath)
+	if entry, ok := fs.get(inner); !ok {
+		return notFound("Could not find the file %s in container %s", dstPath, c.name)
+	} else if !entry.dir {
+		return invalidParameter("extraction point is not a directory")
+	}
+
+	for _, item := range files {
+		target := path.Join(cleanPath(dstPath), item.header.Name)
+		fs, inner := c.resolve(target)
+		switch item.header.Typeflag {
+		case tar.TypeDir:
+			if err := fs.mkdirAll(inner); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := fs.mkdirAll(parentDir(inner)); err != nil {
+				return err
+			}
+			if err := fs.writeFile(inner, item.data, os.FileMode(item.header.Mode), item.header.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stat 获取容器内路径的信息（解析路径中间的符号链接，最后一级为符号链接时返回链接本身的信息）
+func (c *fakeContainer) stat(p string) (container.PathStat, error) {
+	fs, inner := c.resolve(c.realpath(p, false))
+	entry, ok := fs.get(inner)
+	if !ok {
+		return container.PathStat{}, notFound("Could not find the file %s in container %s", p, c.name)
+	}
+	stat := container.PathStat{Name: path.Base(cleanPath(p)), Mode: entry.mode, Mtime: entry.modTime}
+	if entry.link != "" {
+		stat.LinkTarget = c.realpath(p, true)
+	} else if !entry.dir {
+		stat.Size = int64(len(entry.data))
+	}
+	return stat, nil
+}
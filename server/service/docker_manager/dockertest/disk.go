@@ -0,0 +1,149 @@
+package dockertest
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// DiskUsage 获取镜像、容器和卷的磁盘占用（卷大小为其中文件大小之和）
+func (f *FakeClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("DiskUsage", ""); err != nil {
+		return types.DiskUsage{}, err
+	}
+
+	var usage types.DiskUsage
+	seen := make(map[string]bool)
+	for _, ref := range sortedKeys(f.images) {
+		img := f.images[ref]
+		if seen[img.ID] {
+			continue
+		}
+		seen[img.ID] = true
+		usage.LayersSize += img.Size
+		usage.Images = append(usage.Images, &image.Summary{ID: img.ID, RepoTags: img.RepoTags, Size: img.Size})
+	}
+	for _, c := range f.sortedContainers() {
+		summary := c.summary()
+		usage.Containers = append(usage.Containers, &summary)
+	}
+	for _, name := range sortedKeys(f.volumes) {
+		v := f.volumes[name]
+		refCount := 0
+		for _, c := range f.containers {
+			for _, m := range c.mounts {
+				if m.kind == mount.TypeVolume && m.source == name {
+					refCount++
+				}
+			}
+		}
+		item := v.volume
+		item.UsageData = &volume.UsageData{Size: v.fs.size("/"), RefCount: int64(refCount)}
+		usage.Volumes = append(usage.Volumes, &item)
+	}
+	return usage, nil
+}
+
+// size 路径下所有文件的大小之和（字节）
+func (fs *memFS) size(p string) int64 {
+	var total int64
+	fs.walk(p, func(name string, entry *memEntry) {
+		if !entry.dir {
+			total += int64(len(entry.data))
+		}
+	})
+	return total
+}
+
+// du 统计目录占用（支持 -k 和 -d 1，按KB向上取整，子目录在前、目录本身在后）
+func (c *fakeContainer) du(args []string) (string, string, int) {
+	maxDepth := 0
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-k":
+		case "-d":
+			if i+1 >= len(args) {
+				return "", "du: option requires an argument -- 'd'\n", 1
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return "", fmt.Sprintf("du: invalid number '%s'\n", args[i+1]), 1
+			}
+			maxDepth = n
+			i++
+		default:
+			paths = append(paths, args[i])
+		}
+	}
+
+	var out strings.Builder
+	for _, p := range paths {
+		fs, inner := c.resolve(p)
+		if _, ok := fs.get(inner); !ok {
+			return out.String(), fmt.Sprintf("du: %s: No such file or directory\n", p), 1
+		}
+		var dirs []string
+		fs.walk(inner, func(name string, entry *memEntry) {
+			if entry.dir && name != inner && depth(name, inner) <= maxDepth {
+				dirs = append(dirs, name)
+			}
+		})
+		for _, dir := range append(dirs, inner) {
+			var kb int64
+			fs.walk(dir, func(name string, entry *memEntry) {
+				if !entry.dir {
+					kb += (int64(len(entry.data)) + 1023) / 1024
+				}
+			})
+			fmt.Fprintf(&out, "%d\t%s\n", kb, path.Join(p, strings.TrimPrefix(dir, inner)))
+		}
+	}
+	return out.String(), "", 0
+}
+
+// df 以 POSIX 格式（-P -k）输出磁盘空间，使用 DiskTotal 和 DiskFree
+func (f *FakeClient) df(args []string) (string, string, int) {
+	mountPoint := "/"
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			mountPoint = arg
+		}
+	}
+	total, free := f.DiskTotal/1024, f.DiskFree/1024
+	used := total - free
+	percent := 0
+	if total > 0 {
+		percent = int((used*100 + total - 1) / total)
+	}
+	return fmt.Sprintf("Filesystem 1024-blocks Used Available Capacity Mounted on\noverlay %d %d %d %d%% %s\n", total, used, free, percent, mountPoint), "", 0
+}
+
+// run 执行内置命令（df 需要读取客户端的磁盘配置）
+func (f *FakeClient) run(c *fakeContainer, cmd []string) (string, string, int) {
+	if path.Base(cmd[0]) == "df" {
+		return f.df(cmd[1:])
+	}
+	return c.run(cmd)
+}
+
+// sortedKeys 按名称排序的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,343 @@
+package dockertest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// fakeExec 模拟的执行实例（在 ContainerExecAttach 时执行命令）
+type fakeExec struct {
+	id          string
+	containerID string
+	cmd         []string
+	done        bool
+	exitCode    int
+}
+
+// ContainerExecCreate 创建执行实例（容器必须在运行中）
+func (f *FakeClient) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (container.ExecCreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerExecCreate", containerID)
+	if err != nil {
+		return container.ExecCreateResponse{}, err
+	}
+	if !c.state.Running {
+		return container.ExecCreateResponse{}, conflict("container %s is not running", c.id)
+	}
+	if len(options.Cmd) == 0 {
+		return container.ExecCreateResponse{}, invalidParameter("No exec command specified")
+	}
+
+	exec := &fakeExec{id: f.newID(), containerID: c.id, cmd: append([]string(nil), options.Cmd...)}
+	f.execs[exec.id] = exec
+	return container.ExecCreateResponse{ID: exec.id}, nil
+}
+
+// ContainerExecAttach 执行命令，返回多路复用的标准输出和标准错误
+func (f *FakeClient) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	f.mu.Lock()
+	if err := f.check("ContainerExecAttach", execID); err != nil {
+		f.mu.Unlock()
+		return types.HijackedResponse{}, err
+	}
+	exec, ok := f.execs[execID]
+	if !ok {
+		f.mu.Unlock()
+		return types.HijackedResponse{}, notFound("No such exec instance: %s", execID)
+	}
+	c, ok := f.containers[exec.containerID]
+	if !ok || !c.state.Running {
+		f.mu.Unlock()
+		return types.HijackedResponse{}, conflict("container %s is not running", exec.containerID)
+	}
+	name, handler := c.name, f.ExecHandler
+	f.mu.Unlock()
+
+	// 自定义处理函数可能回调模拟客户端，不能持有锁
+	var stdout, stderr string
+	exitCode, handled := 0, false
+	if handler != nil {
+		stdout, stderr, exitCode, handled = handler(name, exec.cmd)
+	}
+
+	f.mu.Lock()
+	if !handled {
+		if c, ok = f.containers[exec.containerID]; ok {
+			stdout, stderr, exitCode = f.run(c, exec.cmd)
+		} else {
+			stderr, exitCode = "container removed", 137
+		}
+	}
+	exec.done = true
+	exec.exitCode = exitCode
+	f.mu.Unlock()
+
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		defer serverConn.Close()
+		if stdout != "" {
+			stdcopy.NewStdWriter(serverConn, stdcopy.Stdout).Write([]byte(stdout))
+		}
+		if stderr != "" {
+			stdcopy.NewStdWriter(serverConn, stdcopy.Stderr).Write([]byte(stderr))
+		}
+	}()
+	return types.NewHijackedResponse(clientConn, "application/vnd.docker.multiplexed-stream"), nil
+}
+
+// ContainerExecInspect 获取执行结果
+func (f *FakeClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ContainerExecInspect", execID); err != nil {
+		return container.ExecInspect{}, err
+	}
+	exec, ok := f.execs[execID]
+	if !ok {
+		return container.ExecInspect{}, notFound("No such exec instance: %s", execID)
+	}
+	return container.ExecInspect{ExecID: exec.id, ContainerID: exec.containerID, Running: !exec.done, ExitCode: exec.exitCode}, nil
+}
+
+// resolve 将容器内的路径转换为所在文件系统和文件系统内的路径
+func (c *fakeContainer) resolve(p string) (*memFS, string) {
+	p = cleanPath(p)
+	for _, m := range c.mounts {
+		if p == m.target || strings.HasPrefix(p, m.target+"/") {
+			return m.fs, cleanPath(strings.TrimPrefix(p, m.target))
+		}
+	}
+	return c.rootfs, p
+}
+
+// realpath 解析容器内路径中的符号链接（与 readlink -f 相同，不存在的部分原样保留）
+// followLast: 是否解析最后一级的符号链接
+func (c *fakeContainer) realpath(p string, followLast bool) string {
+	parts := strings.Split(strings.TrimPrefix(cleanPath(p), "/"), "/")
+	resolved := "/"
+	for hops := 0; len(parts) > 0 && hops < 40; {
+		part := parts[0]
+		parts = parts[1:]
+		if part == "" {
+			continue
+		}
+		next := path.Join(resolved, part)
+		if len(parts) == 0 && !followLast {
+			return next
+		}
+		fs, inner := c.resolve(next)
+		entry, ok := fs.get(inner)
+		if !ok || entry.link == "" {
+			resolved = next
+			continue
+		}
+
+		// 用链接目标替换当前部分，相对路径相对于链接所在的目录
+		hops++
+		target := entry.link
+		if !path.IsAbs(target) {
+			target = path.Join(resolved, target)
+		}
+		parts = append(strings.Split(strings.TrimPrefix(cleanPath(target), "/"), "/"), parts...)
+		resolved = "/"
+	}
+	return resolved
+}
+
+// run 执行内置命令
+// 返回: 标准输出、标准错误和退出码
+func (c *fakeContainer) run(cmd []string) (string, string, int) {
+	args := cmd[1:]
+	switch path.Base(cmd[0]) {
+	case "mkdir":
+		parents, paths := splitFlags(args)
+		for _, p := range paths {
+			fs, inner := c.resolve(p)
+			if !parents["-p"] {
+				if _, ok := fs.get(parentDir(inner)); !ok {
+					return "", fmt.Sprintf("mkdir: can't create directory '%s': No such file or directory\n", p), 1
+				}
+			}
+			if err := fs.mkdirAll(inner); err != nil {
+				return "", fmt.Sprintf("mkdir: %v\n", err), 1
+			}
+		}
+		return "", "", 0
+
+	case "rm":
+		flags, paths := splitFlags(args)
+		force := flags["-rf"] || flags["-fr"] || flags["-f"]
+		recursive := flags["-rf"] || flags["-fr"] || flags["-r"]
+		for _, p := range paths {
+			fs, inner := c.resolve(p)
+			entry, ok := fs.get(inner)
+			if !ok {
+				if force {
+					continue
+				}
+				return "", fmt.Sprintf("rm: can't remove '%s': No such file or directory\n", p), 1
+			}
+			if entry.dir && !recursive {
+				return "", fmt.Sprintf("rm: '%s' is a directory\n", p), 1
+			}
+			fs.removeAll(inner)
+		}
+		return "", "", 0
+
+	case "mv":
+		_, paths := splitFlags(args)
+		if len(paths) != 2 {
+			return "", "mv: need source and destination\n", 1
+		}
+		if err := c.move(paths[0], paths[1]); err != nil {
+			return "", fmt.Sprintf("mv: %v\n", err), 1
+		}
+		return "", "", 0
+
+	case "find":
+		return c.find(args)
+
+	case "readlink":
+		_, paths := splitFlags(args)
+		if len(paths) != 1 {
+			return "", "readlink: need one path\n", 1
+		}
+		return c.realpath(paths[0], true) + "\n", "", 0
+
+	case "du":
+		return c.du(args)
+
+	case "cat":
+		var out bytes.Buffer
+		for _, p := range args {
+			fs, inner := c.resolve(p)
+			entry, ok := fs.get(inner)
+			if !ok || entry.dir {
+				return out.String(), fmt.Sprintf("cat: can't open '%s': No such file or directory\n", p), 1
+			}
+			out.Write(entry.data)
+		}
+		return out.String(), "", 0
+	}
+
+	return "", fmt.Sprintf("exec: \"%s\": executable file not found in $PATH\n", cmd[0]), 127
+}
+
+// move 移动文件或目录（目标为已存在的目录时移动到该目录下）
+func (c *fakeContainer) move(source, target string) error {
+	srcFS, srcPath := c.resolve(source)
+	if _, ok := srcFS.get(srcPath); !ok {
+		return fmt.Errorf("can't rename '%s': No such file or directory", source)
+	}
+
+	dstFS, dstPath := c.resolve(target)
+	if entry, ok := dstFS.get(dstPath); ok && entry.dir {
+		dstPath = path.Join(dstPath, path.Base(srcPath))
+	}
+	if srcFS == dstFS && (dstPath == srcPath || isUnder(dstPath, srcPath)) {
+		return fmt.Errorf("can't move '%s' to a subdirectory of itself", source)
+	}
+	if _, ok := dstFS.get(parentDir(dstPath)); !ok {
+		return fmt.Errorf("can't rename '%s': No such file or directory", source)
+	}
+
+	var moved []struct {
+		name  string
+		entry memEntry
+	}
+	srcFS.walk(srcPath, func(name string, entry *memEntry) {
+		moved = append(moved, struct {
+			name  string
+			entry memEntry
+		}{path.Join(dstPath, strings.TrimPrefix(name, srcPath)), *entry})
+	})
+	srcFS.removeAll(srcPath)
+	dstFS.removeAll(dstPath)
+	for _, item := range moved {
+		entry := item.entry
+		dstFS.entries[item.name] = &entry
+	}
+	return nil
+}
+
+// find 列出目录下的条目（支持 -mindepth、-maxdepth、-type 和 -name）
+func (c *fakeContainer) find(args []string) (string, string, int) {
+	if len(args) == 0 {
+		return "", "find: need a path\n", 1
+	}
+	root := args[0]
+	minDepth, maxDepth, kind, pattern := 0, -1, "", ""
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return "", fmt.Sprintf("find: missing argument to '%s'\n", args[i]), 1
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "-mindepth", "-maxdepth":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return "", fmt.Sprintf("find: invalid number '%s'\n", value), 1
+			}
+			if args[i] == "-mindepth" {
+				minDepth = n
+			} else {
+				maxDepth = n
+			}
+		case "-type":
+			kind = value
+		case "-name":
+			pattern = value
+		default:
+			return "", fmt.Sprintf("find: unrecognized: %s\n", args[i]), 1
+		}
+	}
+
+	fs, inner := c.resolve(root)
+	if _, ok := fs.get(inner); !ok {
+		return "", fmt.Sprintf("find: %s: No such file or directory\n", root), 1
+	}
+
+	var out strings.Builder
+	fs.walk(inner, func(name string, entry *memEntry) {
+		d := depth(name, inner)
+		if d < minDepth || (maxDepth >= 0 && d > maxDepth) {
+			return
+		}
+		if (kind == "d" && !entry.dir) || (kind == "f" && entry.dir) {
+			return
+		}
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, path.Base(name)); !matched {
+				return
+			}
+		}
+		out.WriteString(path.Join(root, strings.TrimPrefix(name, inner)) + "\n")
+	})
+	return out.String(), "", 0
+}
+
+// splitFlags 分离以 - 开头的选项和其余参数
+func splitFlags(args []string) (map[string]bool, []string) {
+	flags := make(map[string]bool)
+	var rest []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			flags[arg] = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return flags, rest
+}
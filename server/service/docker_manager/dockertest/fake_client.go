@@ -0,0 +1,909 @@
+package dockertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// FakeClient 内存中的模拟Docker客户端，实现 docker_manager.DockerClient
+// 模拟容器的生命周期、卷（带内存文件系统）、镜像、命令执行和文件复制，无需Docker守护进程
+type FakeClient struct {
+	NCPU      int           // Info 返回的CPU核数
+	MemTotal  int64         // Info 返回的内存大小（字节）
+	DiskTotal int64         // df 返回的磁盘大小（字节）
+	DiskFree  int64         // df 返回的可用空间（字节）
+	PullDelay time.Duration // ImagePull 每条进度之间的等待时间
+
+	// ExecHandler 自定义命令执行（handled 为 false 时使用内置的命令实现）
+	// 内置命令: mkdir、rm、mv、find、readlink、cat、du、df
+	ExecHandler func(containerName string, cmd []string) (stdout, stderr string, exitCode int, handled bool)
+
+	// BeforeRemove 在 ContainerRemove 执行前调用（不持有锁），可用于模拟响应慢的节点
+	BeforeRemove func(containerID string)
+
+	mu         sync.Mutex
+	containers map[string]*fakeContainer // 按容器ID
+	volumes    map[string]*fakeVolume    // 按卷名称
+	binds      map[string]*memFS         // 主机目录挂载，按主机路径
+	images     map[string]*image.InspectResponse
+	networks   map[string]*network.Inspect // 按网络名称
+	pullAuths  map[string]string           // 最近一次拉取使用的认证信息，按镜像名称
+	execs      map[string]*fakeExec
+	failures   []failure
+	calls      map[string]int
+	nextID     int
+}
+
+// fakeContainer 模拟的容器
+type fakeContainer struct {
+	id         string
+	name       string
+	created    time.Time
+	config     container.Config
+	hostConfig container.HostConfig
+	endpoints  map[string]*network.EndpointSettings // 创建时指定的网络配置，按网络名称
+	state      container.State
+	rootfs     *memFS
+	mounts     []fakeMount // 按挂载路径长度从长到短排序
+}
+
+// fakeMount 容器中的挂载点
+type fakeMount struct {
+	target string
+	source string // 卷名称或主机路径
+	kind   mount.Type
+	fs     *memFS
+}
+
+// fakeVolume 模拟的卷
+type fakeVolume struct {
+	volume volume.Volume
+	fs     *memFS
+}
+
+// failure 注入的错误
+type failure struct {
+	method string
+	ref    string
+	err    error
+}
+
+// NewFakeClient 创建模拟客户端
+// images: 预先存在的镜像
+func NewFakeClient(images ...string) *FakeClient {
+	f := &FakeClient{
+		NCPU:       8,
+		MemTotal:   16 << 30,
+		DiskTotal:  100 << 30,
+		DiskFree:   60 << 30,
+		containers: make(map[string]*fakeContainer),
+		volumes:    make(map[string]*fakeVolume),
+		binds:      make(map[string]*memFS),
+		images:     make(map[string]*image.InspectResponse),
+		networks:   make(map[string]*network.Inspect),
+		pullAuths:  make(map[string]string),
+		execs:      make(map[string]*fakeExec),
+		calls:      make(map[string]int),
+	}
+	for _, ref := range images {
+		f.AddImage(ref)
+	}
+	for _, name := range []string{"bridge", "host", "none"} {
+		driver := name
+		if name == "none" {
+			driver = "null"
+		}
+		f.addNetworkLocked(name, driver, "")
+	}
+	return f
+}
+
+// AddImage 添加本地镜像
+func (f *FakeClient) AddImage(ref string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addImageLocked(ref)
+}
+
+// FailOn 让指定方法返回错误，直到调用 ClearFailures
+// ref: 只对该容器（名称或ID）、卷或镜像生效，为空时对所有调用生效
+func (f *FakeClient) FailOn(method, ref string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, failure{method: method, ref: ref, err: err})
+}
+
+// ClearFailures 清除所有注入的错误
+func (f *FakeClient) ClearFailures() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = nil
+}
+
+// CallCount 方法被调用的次数
+func (f *FakeClient) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// ContainerNames 所有容器的名称（已排序）
+func (f *FakeClient) ContainerNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.containers))
+	for _, c := range f.containers {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VolumeNames 所有卷的名称（已排序）
+func (f *FakeClient) VolumeNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.volumes))
+	for name := range f.volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadVolumeFile 读取卷中的文件（p 为相对于卷根目录的路径）
+func (f *FakeClient) ReadVolumeFile(volumeName, p string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.volumes[volumeName]
+	if !ok {
+		return nil, false
+	}
+	entry, ok := v.fs.get(p)
+	if !ok || entry.dir {
+		return nil, false
+	}
+	return append([]byte(nil), entry.data...), true
+}
+
+// WriteVolumeFile 向卷中写入文件，父目录不存在时自动创建（卷不存在时自动创建）
+func (f *FakeClient) WriteVolumeFile(volumeName, p string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fs := f.volumeLocked(volumeName).fs
+	if err := fs.mkdirAll(parentDir(p)); err != nil {
+		return err
+	}
+	return fs.writeFile(p, data, 0644, time.Now())
+}
+
+// SymlinkVolumeFile 在卷中创建符号链接，target 为挂载卷的容器内看到的路径（父目录不存在时自动创建）
+func (f *FakeClient) SymlinkVolumeFile(volumeName, p, target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fs := f.volumeLocked(volumeName).fs
+	if err := fs.mkdirAll(parentDir(p)); err != nil {
+		return err
+	}
+	fs.symlink(p, target)
+	return nil
+}
+
+// ContainerCreate 创建容器（镜像必须已存在，命名卷不存在时自动创建）
+func (f *FakeClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ContainerCreate", containerName); err != nil {
+		return container.CreateResponse{}, err
+	}
+	if config == nil {
+		config = &container.Config{}
+	}
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+	if _, ok := f.imageLocked(config.Image); !ok {
+		return container.CreateResponse{}, notFound("No such image: %s", config.Image)
+	}
+	if _, ok := f.networkLocked(containerNetwork(*hostConfig)); !ok {
+		return container.CreateResponse{}, notFound("network %s not found", hostConfig.NetworkMode)
+	}
+	if containerName != "" {
+		if _, ok := f.containerLocked(containerName); ok {
+			return container.CreateResponse{}, conflict("Conflict. The container name \"/%s\" is already in use", containerName)
+		}
+	}
+
+	id := f.newID()
+	if containerName == "" {
+		containerName = "fake_" + id[:12]
+	}
+	c := &fakeContainer{
+		id:         id,
+		name:       containerName,
+		created:    time.Now(),
+		config:     *config,
+		hostConfig: *hostConfig,
+		endpoints:  make(map[string]*network.EndpointSettings),
+		state:      container.State{Status: container.StateCreated},
+		rootfs:     newMemFS(),
+	}
+
+	if networkingConfig != nil {
+		for name, endpoint := range networkingConfig.EndpointsConfig {
+			if endpoint != nil {
+				settings := *endpoint
+				c.endpoints[name] = &settings
+			}
+		}
+	}
+
+	for _, bind := range hostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			return container.CreateResponse{}, invalidParameter("invalid volume specification: '%s'", bind)
+		}
+		if err := f.mountLocked(c, parts[0], parts[1]); err != nil {
+			return container.CreateResponse{}, err
+		}
+	}
+	for _, m := range hostConfig.Mounts {
+		if err := f.mountLocked(c, m.Source, m.Target); err != nil {
+			return container.CreateResponse{}, err
+		}
+	}
+	sort.Slice(c.mounts, func(i, j int) bool { return len(c.mounts[i].target) > len(c.mounts[j].target) })
+
+	f.containers[id] = c
+	return container.CreateResponse{ID: id}, nil
+}
+
+// ContainerStart 启动容器（已运行时不做任何操作）
+func (f *FakeClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerStart", containerID)
+	if err != nil {
+		return err
+	}
+	if c.state.Running {
+		return nil
+	}
+	c.state = container.State{
+		Status:    container.StateRunning,
+		Running:   true,
+		Pid:       1000 + len(f.containers),
+		StartedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return nil
+}
+
+// ContainerStop 停止容器
+func (f *FakeClient) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerStop", containerID)
+	if err != nil {
+		return err
+	}
+	c.stop(0)
+	return nil
+}
+
+// ContainerRemove 删除容器（运行中的容器需要 Force）
+func (f *FakeClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	if f.BeforeRemove != nil {
+		f.BeforeRemove(containerID)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerRemove", containerID)
+	if err != nil {
+		return err
+	}
+	if c.state.Running && !options.Force {
+		return conflict("cannot remove container \"/%s\": container is running: stop the container before removing or force remove", c.name)
+	}
+	delete(f.containers, c.id)
+	return nil
+}
+
+// ContainerInspect 获取容器详细信息
+func (f *FakeClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.findContainer("ContainerInspect", containerID)
+	if err != nil {
+		return container.InspectResponse{}, err
+	}
+	return c.inspect(), nil
+}
+
+// ContainerList 列出容器（支持 label 和 name 过滤）
+func (f *FakeClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ContainerList", ""); err != nil {
+		return nil, err
+	}
+
+	result := []container.Summary{}
+	for _, c := range f.sortedContainers() {
+		if !options.All && !c.state.Running {
+			continue
+		}
+		if !matchFilters(options.Filters, c) {
+			continue
+		}
+		result = append(result, c.summary())
+	}
+	return result, nil
+}
+
+// ImageInspect 获取镜像信息
+func (f *FakeClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ImageInspect", imageID); err != nil {
+		return image.InspectResponse{}, err
+	}
+	img, ok := f.imageLocked(imageID)
+	if !ok {
+		return image.InspectResponse{}, notFound("No such image: %s", imageID)
+	}
+	return *img, nil
+}
+
+// ImagePull 拉取镜像，返回与Docker相同格式的JSON进度流，进度流读取完成后镜像即可使用
+// 设置 PullDelay 时每条进度之间等待，ctx 取消时进度流返回错误且不保存镜像
+func (f *FakeClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ImagePull", refStr); err != nil {
+		return nil, err
+	}
+	ref := normalizeImage(refStr)
+	repository := repositoryName(ref)
+	f.pullAuths[ref] = options.RegistryAuth
+	id := f.newID()
+	delay := f.PullDelay
+
+	layer := id[len(id)-12:]
+	reader, writer := io.Pipe()
+	go func() {
+		encoder := json.NewEncoder(writer)
+		messages := []map[string]interface{}{
+			{"status": "Pulling from " + repository, "id": "latest"},
+			{"status": "Pulling fs layer", "id": layer},
+			{"status": "Downloading", "id": layer, "progress": "[=====>     ]  512kB/1MB", "progressDetail": map[string]int64{"current": 512 << 10, "total": 1 << 20}},
+			{"status": "Download complete", "id": layer},
+			{"status": "Pull complete", "id": layer},
+			{"status": "Digest: sha256:" + id},
+			{"status": "Status: Downloaded newer image for " + ref},
+		}
+		for _, message := range messages {
+			if delay > 0 {
+				select {
+				case <-ctx.Done():
+					writer.CloseWithError(ctx.Err())
+					return
+				case <-time.After(delay):
+				}
+			}
+			if err := encoder.Encode(message); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+
+		f.mu.Lock()
+		f.addImageLocked(ref)
+		f.images[ref].RepoDigests = []string{repository + "@sha256:" + id}
+		f.mu.Unlock()
+		writer.Close()
+	}()
+	return reader, nil
+}
+
+// ImageTag 为镜像添加新的名称
+func (f *FakeClient) ImageTag(ctx context.Context, source, target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ImageTag", source, target); err != nil {
+		return err
+	}
+	img, ok := f.imageLocked(source)
+	if !ok {
+		return notFound("No such image: %s", source)
+	}
+	if strings.Contains(target, "@") {
+		return invalidParameter("refusing to create a tag with a digest reference")
+	}
+	tagged := *img
+	tagged.RepoTags = []string{normalizeImage(target)}
+	f.images[normalizeImage(target)] = &tagged
+	return nil
+}
+
+// PullAuth 最近一次拉取镜像时使用的认证信息（base64编码的JSON，未拉取或未认证时为空）
+func (f *FakeClient) PullAuth(ref string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pullAuths[normalizeImage(ref)]
+}
+
+// ImageRemove 删除镜像（有容器使用时需要 Force）
+func (f *FakeClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ImageRemove", imageID); err != nil {
+		return nil, err
+	}
+	img, ok := f.imageLocked(imageID)
+	if !ok {
+		return nil, notFound("No such image: %s", imageID)
+	}
+	if !options.Force {
+		for _, c := range f.containers {
+			if normalizeImage(c.config.Image) == img.RepoTags[0] {
+				return nil, conflict("unable to remove image %s: image is being used by container %s", imageID, c.id[:12])
+			}
+		}
+	}
+	delete(f.images, img.RepoTags[0])
+	return []image.DeleteResponse{{Untagged: img.RepoTags[0]}, {Deleted: img.ID}}, nil
+}
+
+// ImageHistory 获取镜像历史（只有一层）
+func (f *FakeClient) ImageHistory(ctx context.Context, imageID string, historyOpts ...client.ImageHistoryOption) ([]image.HistoryResponseItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("ImageHistory", imageID); err != nil {
+		return nil, err
+	}
+	img, ok := f.imageLocked(imageID)
+	if !ok {
+		return nil, notFound("No such image: %s", imageID)
+	}
+	created, _ := time.Parse(time.RFC3339Nano, img.Created)
+	return []image.HistoryResponseItem{{ID: img.ID, Created: created.Unix(), CreatedBy: "/bin/sh -c #(nop) ADD file", Size: img.Size, Tags: img.RepoTags}}, nil
+}
+
+// VolumeCreate 创建卷（已存在时返回现有的卷）
+func (f *FakeClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("VolumeCreate", options.Name); err != nil {
+		return volume.Volume{}, err
+	}
+	if options.Name == "" {
+		options.Name = f.newID()
+	}
+	v := f.volumeLocked(options.Name)
+	if len(options.Labels) > 0 && v.volume.Labels == nil {
+		v.volume.Labels = options.Labels
+	}
+	return v.volume, nil
+}
+
+// VolumeInspect 获取卷信息
+func (f *FakeClient) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("VolumeInspect", volumeID); err != nil {
+		return volume.Volume{}, err
+	}
+	v, ok := f.volumes[volumeID]
+	if !ok {
+		return volume.Volume{}, notFound("get %s: no such volume", volumeID)
+	}
+	return v.volume, nil
+}
+
+// VolumeList 列出卷（支持 label 过滤，按名称排序）
+func (f *FakeClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("VolumeList", ""); err != nil {
+		return volume.ListResponse{}, err
+	}
+
+	names := make([]string, 0, len(f.volumes))
+	for name := range f.volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := volume.ListResponse{Volumes: []*volume.Volume{}}
+	for _, name := range names {
+		v := f.volumes[name].volume
+		if !matchLabels(options.Filters, v.Labels) {
+			continue
+		}
+		result.Volumes = append(result.Volumes, &v)
+	}
+	return result, nil
+}
+
+// VolumeRemove 删除卷（有容器挂载时返回冲突错误）
+func (f *FakeClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("VolumeRemove", volumeID); err != nil {
+		return err
+	}
+	if _, ok := f.volumes[volumeID]; !ok {
+		if force {
+			return nil
+		}
+		return notFound("get %s: no such volume", volumeID)
+	}
+	for _, c := range f.sortedContainers() {
+		for _, m := range c.mounts {
+			if m.kind == mount.TypeVolume && m.source == volumeID {
+				return conflict("remove %s: volume is in use - [%s]", volumeID, c.id)
+			}
+		}
+	}
+	delete(f.volumes, volumeID)
+	return nil
+}
+
+// Ping 检查连接
+func (f *FakeClient) Ping(ctx context.Context) (types.Ping, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("Ping", ""); err != nil {
+		return types.Ping{}, err
+	}
+	return types.Ping{APIVersion: "1.45", OSType: "linux"}, nil
+}
+
+// Info 获取主机信息
+func (f *FakeClient) Info(ctx context.Context) (system.Info, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("Info", ""); err != nil {
+		return system.Info{}, err
+	}
+	running := 0
+	for _, c := range f.containers {
+		if c.state.Running {
+			running++
+		}
+	}
+	return system.Info{
+		ServerVersion:     "28.3.1",
+		OSType:            "linux",
+		NCPU:              f.NCPU,
+		MemTotal:          f.MemTotal,
+		Containers:        len(f.containers),
+		ContainersRunning: running,
+		Images:            len(f.images),
+	}, nil
+}
+
+// Close 关闭客户端
+func (f *FakeClient) Close() error {
+	return nil
+}
+
+// check 记录调用并返回注入的错误（调用方需持有 mu）
+func (f *FakeClient) check(method string, refs ...string) error {
+	f.calls[method]++
+	for _, item := range f.failures {
+		if item.method != method {
+			continue
+		}
+		if item.ref == "" {
+			return item.err
+		}
+		for _, ref := range refs {
+			if ref != "" && strings.TrimPrefix(ref, "/") == item.ref {
+				return item.err
+			}
+		}
+	}
+	return nil
+}
+
+// findContainer 记录调用并按名称或ID查找容器（调用方需持有 mu）
+func (f *FakeClient) findContainer(method, ref string) (*fakeContainer, error) {
+	c, ok := f.containerLocked(ref)
+	refs := []string{ref}
+	if ok {
+		refs = append(refs, c.id, c.name)
+	}
+	if err := f.check(method, refs...); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, notFound("No such container: %s", ref)
+	}
+	return c, nil
+}
+
+// containerLocked 按名称或ID查找容器
+func (f *FakeClient) containerLocked(ref string) (*fakeContainer, bool) {
+	if c, ok := f.containers[ref]; ok {
+		return c, true
+	}
+	name := strings.TrimPrefix(ref, "/")
+	for _, c := range f.containers {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// sortedContainers 按创建顺序排列的容器
+func (f *FakeClient) sortedContainers() []*fakeContainer {
+	containers := make([]*fakeContainer, 0, len(f.containers))
+	for _, c := range f.containers {
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].id < containers[j].id })
+	return containers
+}
+
+// mountLocked 将卷或主机目录挂载到容器
+func (f *FakeClient) mountLocked(c *fakeContainer, source, target string) error {
+	target = cleanPath(target)
+	m := fakeMount{target: target, source: source}
+	if strings.HasPrefix(source, "/") {
+		fs, ok := f.binds[source]
+		if !ok {
+			fs = newMemFS()
+			f.binds[source] = fs
+		}
+		m.kind, m.fs = mount.TypeBind, fs
+	} else {
+		m.kind, m.fs = mount.TypeVolume, f.volumeLocked(source).fs
+	}
+	if err := c.rootfs.mkdirAll(target); err != nil {
+		return err
+	}
+	c.mounts = append(c.mounts, m)
+	return nil
+}
+
+// volumeLocked 获取卷，不存在时创建
+func (f *FakeClient) volumeLocked(name string) *fakeVolume {
+	if v, ok := f.volumes[name]; ok {
+		return v
+	}
+	v := &fakeVolume{
+		volume: volume.Volume{
+			Name:       name,
+			Driver:     "local",
+			Scope:      "local",
+			Mountpoint: "/var/lib/docker/volumes/" + name + "/_data",
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		},
+		fs: newMemFS(),
+	}
+	f.volumes[name] = v
+	return v
+}
+
+// imageLocked 按名称或ID查找镜像
+func (f *FakeClient) imageLocked(ref string) (*image.InspectResponse, bool) {
+	if img, ok := f.images[normalizeImage(ref)]; ok {
+		return img, true
+	}
+	for _, img := range f.images {
+		if img.ID == ref {
+			return img, true
+		}
+	}
+	return nil, false
+}
+
+// addImageLocked 添加镜像（已存在时替换为新的镜像ID，模拟拉取到新版本）
+func (f *FakeClient) addImageLocked(ref string) {
+	ref = normalizeImage(ref)
+	f.images[ref] = &image.InspectResponse{
+		ID:       "sha256:" + f.newID(),
+		RepoTags: []string{ref},
+		Created:  time.Now().UTC().Format(time.RFC3339Nano),
+		Size:     1 << 20,
+		Os:       "linux",
+	}
+}
+
+// newID 生成64位十六进制的ID
+func (f *FakeClient) newID() string {
+	f.nextID++
+	return fmt.Sprintf("%064x", f.nextID)
+}
+
+// stop 停止容器并记录退出码
+func (c *fakeContainer) stop(exitCode int) {
+	if !c.state.Running {
+		return
+	}
+	c.state.Status = container.StateExited
+	c.state.Running = false
+	c.state.Pid = 0
+	c.state.ExitCode = exitCode
+	c.state.FinishedAt = time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// inspect 生成容器详细信息（返回副本，调用方修改不影响模拟状态）
+func (c *fakeContainer) inspect() container.InspectResponse {
+	state := c.state
+	hostConfig := c.hostConfig
+	config := c.config
+
+	mounts := make([]container.MountPoint, 0, len(c.mounts))
+	for _, m := range c.mounts {
+		point := container.MountPoint{Type: m.kind, Source: m.source, Destination: m.target, RW: true}
+		if m.kind == mount.TypeVolume {
+			point.Name = m.source
+			point.Source = "/var/lib/docker/volumes/" + m.source + "/_data"
+		}
+		mounts = append(mounts, point)
+	}
+
+	name := containerNetwork(c.hostConfig)
+	endpoint := network.EndpointSettings{}
+	if configured, ok := c.endpoints[name]; ok {
+		endpoint = *configured
+	}
+	if c.state.Running && name != "host" {
+		if endpoint.IPAMConfig != nil && endpoint.IPAMConfig.IPv4Address != "" {
+			endpoint.IPAddress = endpoint.IPAMConfig.IPv4Address
+		} else {
+			endpoint.IPAddress = fmt.Sprintf("172.17.0.%d", 2+c.state.Pid%250)
+		}
+	}
+	networks := map[string]*network.EndpointSettings{name: &endpoint}
+
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         c.id,
+			Created:    c.created.UTC().Format(time.RFC3339Nano),
+			Name:       "/" + c.name,
+			Image:      normalizeImage(c.config.Image),
+			State:      &state,
+			HostConfig: &hostConfig,
+		},
+		Mounts:          mounts,
+		Config:          &config,
+		NetworkSettings: &container.NetworkSettings{Networks: networks},
+	}
+}
+
+// summary 生成容器列表项
+func (c *fakeContainer) summary() container.Summary {
+	status := "Created"
+	switch c.state.Status {
+	case container.StateRunning:
+		status = "Up"
+	case container.StateExited:
+		status = fmt.Sprintf("Exited (%d)", c.state.ExitCode)
+	}
+	return container.Summary{
+		ID:      c.id,
+		Names:   []string{"/" + c.name},
+		Image:   c.config.Image,
+		Created: c.created.Unix(),
+		Labels:  c.config.Labels,
+		State:   c.state.Status,
+		Status:  status,
+	}
+}
+
+// matchFilters 检查容器是否满足过滤条件（支持 label 和 name）
+func matchFilters(args filters.Args, c *fakeContainer) bool {
+	if !matchLabels(args, c.config.Labels) {
+		return false
+	}
+	if names := args.Get("name"); len(names) > 0 {
+		matched := false
+		for _, name := range names {
+			if strings.Contains(c.name, strings.TrimPrefix(name, "/")) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLabels 检查标签是否满足 label 过滤条件（key 或 key=value）
+func matchLabels(args filters.Args, labels map[string]string) bool {
+	for _, label := range args.Get("label") {
+		key, value, hasValue := strings.Cut(label, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeImage 补全镜像标签（未指定标签或摘要时为 latest）
+func normalizeImage(ref string) string {
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	lastSegment := ref[strings.LastIndex(ref, "/")+1:]
+	if !strings.Contains(lastSegment, ":") {
+		return ref + ":latest"
+	}
+	return ref
+}
+
+// repositoryName 去掉标签或摘要的镜像仓库名称
+func repositoryName(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+// parentDir 父目录
+func parentDir(p string) string {
+	p = cleanPath(p)
+	if i := strings.LastIndex(p, "/"); i > 0 {
+		return p[:i]
+	}
+	return "/"
+}
+
+// notFound 资源不存在的错误（可用 errdefs.IsNotFound 判断）
+func notFound(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), errdefs.ErrNotFound)
+}
+
+// conflict 资源冲突的错误（可用 errdefs.IsConflict 判断）
+func conflict(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), errdefs.ErrConflict)
+}
+
+// invalidParameter 参数错误（可用 errdefs.IsInvalidArgument 判断）
+func invalidParameter(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), errdefs.ErrInvalidArgument)
+}
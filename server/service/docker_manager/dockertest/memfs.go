@@ -0,0 +1,122 @@
+package dockertest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memEntry 内存文件系统中的文件、目录或符号链接
+type memEntry struct {
+	dir     bool
+	data    []byte
+	link    string // 符号链接指向的路径（容器内的路径）
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// memFS 内存文件系统（卷和容器各自的根文件系统），路径均为以 / 开头的绝对路径
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+// newMemFS 创建只有根目录的文件系统
+func newMemFS() *memFS {
+	return &memFS{entries: map[string]*memEntry{
+		"/": {dir: true, mode: os.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+// cleanPath 规范化为绝对路径
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// get 获取路径对应的条目
+func (fs *memFS) get(p string) (*memEntry, bool) {
+	entry, ok := fs.entries[cleanPath(p)]
+	return entry, ok
+}
+
+// mkdirAll 创建目录及其父目录（路径上存在同名文件时返回错误）
+func (fs *memFS) mkdirAll(p string) error {
+	p = cleanPath(p)
+	if entry, ok := fs.entries[p]; ok {
+		if !entry.dir {
+			return fmt.Errorf("%s: Not a directory", p)
+		}
+		return nil
+	}
+	if err := fs.mkdirAll(path.Dir(p)); err != nil {
+		return err
+	}
+	fs.entries[p] = &memEntry{dir: true, mode: os.ModeDir | 0755, modTime: time.Now()}
+	return nil
+}
+
+// writeFile 写入文件（父目录必须存在）
+func (fs *memFS) writeFile(p string, data []byte, mode os.FileMode, modTime time.Time) error {
+	p = cleanPath(p)
+	parent, ok := fs.entries[path.Dir(p)]
+	if !ok || !parent.dir {
+		return fmt.Errorf("%s: No such file or directory", path.Dir(p))
+	}
+	if entry, ok := fs.entries[p]; ok && entry.dir {
+		return fmt.Errorf("%s: Is a directory", p)
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+	fs.entries[p] = &memEntry{data: append([]byte(nil), data...), mode: mode.Perm(), modTime: modTime}
+	return nil
+}
+
+// symlink 创建符号链接（覆盖已有的文件）
+func (fs *memFS) symlink(p, target string) {
+	fs.entries[cleanPath(p)] = &memEntry{link: target, mode: os.ModeSymlink | 0777, modTime: time.Now()}
+}
+
+// removeAll 删除路径及其下的所有内容（删除根目录时只清空内容）
+func (fs *memFS) removeAll(p string) {
+	p = cleanPath(p)
+	for name := range fs.entries {
+		if name != "/" && (name == p || isUnder(name, p)) {
+			delete(fs.entries, name)
+		}
+	}
+}
+
+// walk 按路径顺序遍历路径本身及其下的所有条目
+func (fs *memFS) walk(p string, fn func(name string, entry *memEntry)) {
+	p = cleanPath(p)
+	var names []string
+	for name := range fs.entries {
+		if name == p || isUnder(name, p) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn(name, fs.entries[name])
+	}
+}
+
+// isUnder name 是否在目录 dir 之下（不含 dir 本身）
+func isUnder(name, dir string) bool {
+	if dir == "/" {
+		return name != "/"
+	}
+	return strings.HasPrefix(name, dir+"/")
+}
+
+// depth name 相对于目录 dir 的层级（dir 本身为 0）
+func depth(name, dir string) int {
+	if name == dir {
+		return 0
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+	return strings.Count(rel, "/") + 1
+}
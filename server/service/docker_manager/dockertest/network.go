@@ -0,0 +1,69 @@
+package dockertest
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// AddNetwork 添加 Docker 网络
+// subnet: 网络的子网（如 192.168.1.0/24），为空时不设置
+func (f *FakeClient) AddNetwork(name, driver, subnet string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addNetworkLocked(name, driver, subnet)
+}
+
+// addNetworkLocked 添加 Docker 网络（调用方需持有 mu）
+func (f *FakeClient) addNetworkLocked(name, driver, subnet string) {
+	info := network.Inspect{
+		Name:    name,
+		ID:      f.newID(),
+		Created: time.Now(),
+		Scope:   "local",
+		Driver:  driver,
+	}
+	if subnet != "" {
+		info.IPAM.Config = []network.IPAMConfig{{Subnet: subnet}}
+	}
+	f.networks[name] = &info
+}
+
+// NetworkInspect 按名称或ID获取网络信息
+func (f *FakeClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.check("NetworkInspect", networkID); err != nil {
+		return network.Inspect{}, err
+	}
+	info, ok := f.networkLocked(networkID)
+	if !ok {
+		return network.Inspect{}, notFound("network %s not found", networkID)
+	}
+	return *info, nil
+}
+
+// networkLocked 按名称或ID查找网络
+func (f *FakeClient) networkLocked(ref string) (*network.Inspect, bool) {
+	if info, ok := f.networks[ref]; ok {
+		return info, true
+	}
+	for _, info := range f.networks {
+		if info.ID == ref {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+// containerNetwork 容器连接的网络名称（未指定网络模式时为 bridge）
+func containerNetwork(hostConfig container.HostConfig) string {
+	mode := string(hostConfig.NetworkMode)
+	if mode == "" || mode == "default" {
+		return "bridge"
+	}
+	return mode
+}
@@ -0,0 +1,239 @@
+package docker_manager
+
+import (
+	"ark-server-commander/models"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"golang.org/x/crypto/ssh"
+)
+
+// 远程节点连接参数
+const (
+	defaultTLSPort      = "2376"
+	defaultSSHPort      = "22"
+	defaultDockerSocket = "/var/run/docker.sock"
+	sshDialTimeout      = 10 * time.Second
+)
+
+// ValidateNode 校验节点的连接地址和证书配置（不实际连接）
+func ValidateNode(node models.Node) error {
+	if node.IsLocal() {
+		return nil
+	}
+	cli, closer, err := newNodeClient(node)
+	if err != nil {
+		return err
+	}
+	cli.Close()
+	if closer != nil {
+		closer.Close()
+	}
+	return nil
+}
+
+// NodeHost 节点供玩家和RCON连接的地址（未配置公开地址时使用连接地址中的主机名）
+// 面板所在主机返回空字符串
+func NodeHost(node models.Node) string {
+	if node.PublicHost != "" {
+		return node.PublicHost
+	}
+	if node.IsLocal() {
+		return ""
+	}
+	if endpoint, err := url.Parse(node.Endpoint); err == nil {
+		return endpoint.Hostname()
+	}
+	return ""
+}
+
+// newNodeClient 创建远程节点的Docker客户端
+// 返回: 客户端、需要在关闭客户端时一起关闭的连接（可能为 nil）和错误信息
+func newNodeClient(node models.Node) (*client.Client, io.Closer, error) {
+	endpoint, err := url.Parse(node.Endpoint)
+	if err != nil || endpoint.Hostname() == "" {
+		return nil, nil, fmt.Errorf("无效的节点地址: %s", node.Endpoint)
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:    6,
+		IdleConnTimeout: 30 * time.Second,
+	}
+
+	switch endpoint.Scheme {
+	case "tcp":
+		tlsConfig, err := nodeTLSConfig(node)
+		if err != nil {
+			return nil, nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+
+		port := endpoint.Port()
+		if port == "" {
+			port = defaultTLSPort
+		}
+		cli, err := client.NewClientWithOpts(
+			client.WithHTTPClient(&http.Client{Transport: transport}),
+			client.WithHost("tcp://"+net.JoinHostPort(endpoint.Hostname(), port)),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+		}
+		return cli, nil, nil
+
+	case "ssh":
+		dialer, err := newSSHDialer(endpoint, node)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 请求通过 SSH 转发到远程的 Docker 套接字，这里的主机名不会被实际解析
+		cli, err := client.NewClientWithOpts(
+			client.WithHTTPClient(&http.Client{Transport: transport}),
+			client.WithHost("http://docker.ssh"),
+			client.WithDialContext(dialer.DialContext),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建Docker客户端失败: %v", err)
+		}
+		return cli, dialer, nil
+
+	default:
+		return nil, nil, fmt.Errorf("节点地址必须以 tcp:// 或 ssh:// 开头")
+	}
+}
+
+// nodeTLSConfig 根据节点证书构建TLS配置（必须配置CA证书，客户端证书和私钥需同时配置）
+func nodeTLSConfig(node models.Node) (*tls.Config, error) {
+	if node.TLSCACert == "" {
+		return nil, fmt.Errorf("TCP节点必须配置TLS CA证书")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(node.TLSCACert)) {
+		return nil, fmt.Errorf("TLS CA证书格式错误")
+	}
+	tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	if node.TLSCert != "" || node.TLSKey != "" {
+		if node.TLSCert == "" || node.TLSKey == "" {
+			return nil, fmt.Errorf("TLS客户端证书和私钥需要同时配置")
+		}
+		pair, err := tls.X509KeyPair([]byte(node.TLSCert), []byte(node.TLSKey))
+		if err != nil {
+			return nil, fmt.Errorf("TLS客户端证书或私钥格式错误: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	return tlsConfig, nil
+}
+
+// sshDialer 通过 SSH 连接转发到远程 Docker 套接字（复用同一个 SSH 连接，断开后自动重连）
+type sshDialer struct {
+	address string // SSH 服务地址 host:port
+	socket  string // 远程 Docker 套接字路径
+	config  *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHDialer 根据节点配置创建 SSH 转发器（必须配置私钥和主机公钥）
+func newSSHDialer(endpoint *url.URL, node models.Node) (*sshDialer, error) {
+	user := endpoint.User.Username()
+	if user == "" {
+		return nil, fmt.Errorf("SSH节点地址需要包含用户名，如 ssh://user@host")
+	}
+	if node.SSHKey == "" {
+		return nil, fmt.Errorf("SSH节点必须配置私钥")
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(node.SSHKey))
+	if err != nil {
+		return nil, fmt.Errorf("SSH私钥格式错误: %v", err)
+	}
+	if node.SSHHostKey == "" {
+		return nil, fmt.Errorf("SSH节点必须配置主机公钥")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(node.SSHHostKey))
+	if err != nil {
+		return nil, fmt.Errorf("SSH主机公钥格式错误: %v", err)
+	}
+
+	port := endpoint.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+	socket := endpoint.Path
+	if socket == "" || socket == "/" {
+		socket = defaultDockerSocket
+	}
+
+	return &sshDialer{
+		address: net.JoinHostPort(endpoint.Hostname(), port),
+		socket:  socket,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         sshDialTimeout,
+		},
+	}, nil
+}
+
+// DialContext 打开一个到远程 Docker 套接字的连接
+func (d *sshDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		conn, err := d.client.Dial("unix", d.socket)
+		if err == nil {
+			return conn, nil
+		}
+		// SSH 连接可能已断开，重新建立连接后再试一次
+		d.client.Close()
+		d.client = nil
+	}
+
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	raw, err := dialer.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, fmt.Errorf("连接SSH失败: %v", err)
+	}
+	raw.SetDeadline(time.Now().Add(sshDialTimeout))
+	conn, channels, requests, err := ssh.NewClientConn(raw, d.address, d.config)
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("SSH握手失败: %v", err)
+	}
+	raw.SetDeadline(time.Time{})
+	d.client = ssh.NewClient(conn, channels, requests)
+
+	socketConn, err := d.client.Dial("unix", d.socket)
+	if err != nil {
+		return nil, fmt.Errorf("连接远程Docker套接字失败: %v", err)
+	}
+	return socketConn, nil
+}
+
+// Close 关闭 SSH 连接
+func (d *sshDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return err
+}
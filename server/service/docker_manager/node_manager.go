@@ -0,0 +1,150 @@
+package docker_manager
+
+import (
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 各节点的Docker管理器缓存（面板所在主机使用 instance 单例）
+var (
+	nodeManagers   = make(map[uint]*DockerManager)
+	nodeManagersMu sync.Mutex
+)
+
+// GetNodeManager 获取节点的Docker管理器（首次使用时创建连接并缓存）
+// nodeID: 节点ID，0 表示面板所在主机
+func GetNodeManager(nodeID uint) (*DockerManager, error) {
+	if nodeID == 0 {
+		return GetDockerManager()
+	}
+
+	nodeManagersMu.Lock()
+	defer nodeManagersMu.Unlock()
+
+	if dm, ok := nodeManagers[nodeID]; ok {
+		return dm, nil
+	}
+
+	var node models.Node
+	if err := database.DB.Where("id = ?", nodeID).First(&node).Error; err != nil {
+		return nil, fmt.Errorf("节点不存在: %d", nodeID)
+	}
+
+	var dm *DockerManager
+	if node.IsLocal() {
+		local, err := GetDockerManager()
+		if err != nil {
+			return nil, err
+		}
+		dm = local
+	} else {
+		cli, closer, err := newNodeClient(node)
+		if err != nil {
+			return nil, fmt.Errorf("连接节点 %s 失败: %v", node.Name, err)
+		}
+		dm = newDockerManager(cli, node.ID, NodeHost(node), closer)
+
+		// 接管节点上遗留的辅助容器（节点离线时不阻塞调用方）
+		go func() {
+			if err := dm.RecoverHelpers(); err != nil {
+				utils.Warn("回收节点遗留辅助容器失败", zap.String("node", node.Name), zap.Error(err))
+			}
+		}()
+	}
+
+	nodeManagers[nodeID] = dm
+	return dm, nil
+}
+
+// GetServerManager 获取服务器所在节点的Docker管理器（已删除的服务器也可以获取，用于清理资源）
+func GetServerManager(serverID uint) (*DockerManager, error) {
+	var server models.Server
+	if err := database.DB.Unscoped().Select("id", "node_id").Where("id = ?", serverID).First(&server).Error; err != nil {
+		return nil, fmt.Errorf("获取服务器信息失败: %v", err)
+	}
+	return GetNodeManager(server.NodeID)
+}
+
+// InvalidateNodeManager 关闭并移除节点的Docker管理器缓存（节点连接参数修改或节点删除后调用）
+func InvalidateNodeManager(nodeID uint) {
+	nodeManagersMu.Lock()
+	dm, ok := nodeManagers[nodeID]
+	delete(nodeManagers, nodeID)
+	nodeManagersMu.Unlock()
+
+	if !ok || dm == instance {
+		return
+	}
+	dm.removeAllHelpers()
+	if err := dm.close(); err != nil {
+		utils.Warn("关闭节点连接失败", zap.Uint("node_id", nodeID), zap.Error(err))
+	}
+}
+
+// closeNodeManagers 关闭所有远程节点的Docker管理器
+func closeNodeManagers() {
+	nodeManagersMu.Lock()
+	managers := nodeManagers
+	nodeManagers = make(map[uint]*DockerManager)
+	nodeManagersMu.Unlock()
+
+	for _, dm := range managers {
+		if dm != instance {
+			dm.close()
+		}
+	}
+}
+
+// forEachManager 对所有已创建的Docker管理器执行操作
+func forEachManager(fn func(dm *DockerManager)) {
+	nodeManagersMu.Lock()
+	managers := make([]*DockerManager, 0, len(nodeManagers)+1)
+	if instance != nil {
+		managers = append(managers, instance)
+	}
+	for _, dm := range nodeManagers {
+		if dm != instance {
+			managers = append(managers, dm)
+		}
+	}
+	nodeManagersMu.Unlock()
+
+	for _, dm := range managers {
+		fn(dm)
+	}
+}
+
+// HostInfo Docker主机信息
+type HostInfo struct {
+	DockerVersion string
+	CPUs          int
+	MemoryBytes   int64
+}
+
+// GetHostInfo 检查Docker服务是否可用并获取主机信息
+// timeout: 超时时间
+func (dm *DockerManager) GetHostInfo(timeout time.Duration) (*HostInfo, error) {
+	ctx, cancel := context.WithTimeout(dm.ctx, timeout)
+	defer cancel()
+
+	if _, err := dm.client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("docker服务未运行或无法连接: %v", err)
+	}
+	info, err := dm.client.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取Docker信息失败: %v", err)
+	}
+
+	return &HostInfo{
+		DockerVersion: info.ServerVersion,
+		CPUs:          info.NCPU,
+		MemoryBytes:   info.MemTotal,
+	}, nil
+}
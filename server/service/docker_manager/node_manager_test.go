@@ -0,0 +1,328 @@
+package docker_manager
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// setupNodeTestDB 创建独立的内存数据库，测试结束后关闭所有节点连接
+func setupNodeTestDB(t *testing.T) {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	dbtest.Open(t)
+	t.Cleanup(closeNodeManagers)
+}
+
+// fakeDockerHandler 模拟Docker守护进程的最小API（版本协商、主机信息、容器列表）
+func fakeDockerHandler(version string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.45")
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/v1.45/info", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ServerVersion": version,
+			"NCPU":          4,
+			"MemTotal":      8 << 30,
+		})
+	})
+	mux.HandleFunc("/v1.45/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	return mux
+}
+
+// newTLSNode 启动模拟的TLS Docker守护进程并返回连接它的节点
+func newTLSNode(t *testing.T, name, version string) models.Node {
+	t.Helper()
+
+	server := httptest.NewTLSServer(fakeDockerHandler(version))
+	t.Cleanup(server.Close)
+
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	node := models.Node{
+		Name:        name,
+		Endpoint:    "tcp://" + server.Listener.Addr().String(),
+		TLSCACert:   string(caCert),
+		Labels:      "{}",
+		Schedulable: true,
+	}
+	if err := database.DB.Create(&node).Error; err != nil {
+		t.Fatalf("创建节点失败: %v", err)
+	}
+	return node
+}
+
+func TestGetServerManagerRoutesToNode(t *testing.T) {
+	setupNodeTestDB(t)
+
+	first := newTLSNode(t, "first", "26.1.0")
+	second := newTLSNode(t, "second", "27.0.3")
+	database.DB.Model(&models.Node{}).Where("id = ?", second.ID).Update("public_host", "ark.example.com")
+
+	servers := []models.Server{
+		{Identifier: "island", NodeID: first.ID, ServerArgsJSON: "{}"},
+		{Identifier: "ragnarok", NodeID: second.ID, ServerArgsJSON: "{}"},
+	}
+	if err := database.DB.Create(&servers).Error; err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+
+	cases := []struct {
+		serverID uint
+		version  string
+		host     string
+	}{
+		{servers[0].ID, "26.1.0", "127.0.0.1"},
+		{servers[1].ID, "27.0.3", "ark.example.com"},
+	}
+	for _, item := range cases {
+		dm, err := GetServerManager(item.serverID)
+		if err != nil {
+			t.Fatalf("获取服务器 %d 的Docker管理器失败: %v", item.serverID, err)
+		}
+		info, err := dm.GetHostInfo(5 * time.Second)
+		if err != nil {
+			t.Fatalf("获取服务器 %d 所在节点信息失败: %v", item.serverID, err)
+		}
+		if info.DockerVersion != item.version || info.CPUs != 4 {
+			t.Fatalf("服务器 %d 应连接到版本 %s 的节点，实际为 %+v", item.serverID, item.version, info)
+		}
+		if dm.RemoteHost() != item.host {
+			t.Fatalf("服务器 %d 的连接地址应为 %s，实际为 %s", item.serverID, item.host, dm.RemoteHost())
+		}
+	}
+
+	// 同一节点复用同一个管理器，失效后重新创建
+	dm, _ := GetNodeManager(first.ID)
+	again, _ := GetNodeManager(first.ID)
+	if dm != again {
+		t.Fatal("同一节点应复用Docker管理器")
+	}
+	InvalidateNodeManager(first.ID)
+	if recreated, _ := GetNodeManager(first.ID); recreated == dm {
+		t.Fatal("失效后应重新创建Docker管理器")
+	}
+}
+
+func TestGetNodeManagerRejectsUntrustedCertificate(t *testing.T) {
+	setupNodeTestDB(t)
+
+	node := newTLSNode(t, "untrusted", "26.1.0")
+	// 使用另一个自签名证书作为CA，握手应失败
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("生成CA证书失败: %v", err)
+	}
+	otherCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	database.DB.Model(&models.Node{}).Where("id = ?", node.ID).Update("tls_ca_cert", string(otherCA))
+
+	dm, err := GetNodeManager(node.ID)
+	if err != nil {
+		t.Fatalf("创建Docker管理器失败: %v", err)
+	}
+	if _, err := dm.GetHostInfo(5 * time.Second); err == nil {
+		t.Fatal("CA不匹配时不应连接成功")
+	}
+}
+
+func TestValidateNode(t *testing.T) {
+	cases := []struct {
+		name string
+		node models.Node
+		err  string
+	}{
+		{"本机", models.Node{}, ""},
+		{"不支持的协议", models.Node{Endpoint: "http://docker:2375"}, "节点地址必须以 tcp:// 或 ssh:// 开头"},
+		{"TCP缺少CA", models.Node{Endpoint: "tcp://docker:2376"}, "TCP节点必须配置TLS CA证书"},
+		{"TCP证书格式错误", models.Node{Endpoint: "tcp://docker:2376", TLSCACert: "invalid"}, "TLS CA证书格式错误"},
+		{"SSH缺少用户名", models.Node{Endpoint: "ssh://docker"}, "SSH节点地址需要包含用户名"},
+		{"SSH缺少私钥", models.Node{Endpoint: "ssh://ark@docker"}, "SSH节点必须配置私钥"},
+	}
+
+	for _, item := range cases {
+		err := ValidateNode(item.node)
+		if item.err == "" {
+			if err != nil {
+				t.Errorf("%s: 不应返回错误: %v", item.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), item.err) {
+			t.Errorf("%s: 期望错误包含 %q，实际为 %v", item.name, item.err, err)
+		}
+	}
+}
+
+func TestSSHNodeForwardsDockerSocket(t *testing.T) {
+	setupNodeTestDB(t)
+
+	// 模拟远程主机上的Docker套接字
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("监听套接字失败: %v", err)
+	}
+	docker := &httptest.Server{Listener: listener, Config: &http.Server{Handler: fakeDockerHandler("25.0.5")}}
+	docker.Start()
+	defer docker.Close()
+
+	_, clientKey, _ := ed25519.GenerateKey(rand.Reader)
+	clientSigner, _ := ssh.NewSignerFromKey(clientKey)
+	clientPEM, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, _ := ssh.NewSignerFromKey(hostKey)
+
+	address := startSSHServer(t, hostSigner, clientSigner.PublicKey())
+
+	node := models.Node{
+		Name:        "ssh",
+		Endpoint:    "ssh://ark@" + address + socket,
+		SSHKey:      models.EncryptedString(pem.EncodeToMemory(clientPEM)),
+		SSHHostKey:  string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey())),
+		Labels:      "{}",
+		Schedulable: true,
+	}
+	if err := database.DB.Create(&node).Error; err != nil {
+		t.Fatalf("创建节点失败: %v", err)
+	}
+
+	dm, err := GetNodeManager(node.ID)
+	if err != nil {
+		t.Fatalf("创建Docker管理器失败: %v", err)
+	}
+	info, err := dm.GetHostInfo(5 * time.Second)
+	if err != nil {
+		t.Fatalf("通过SSH获取节点信息失败: %v", err)
+	}
+	if info.DockerVersion != "25.0.5" {
+		t.Fatalf("Docker版本应为 25.0.5，实际为 %s", info.DockerVersion)
+	}
+
+	// 主机公钥不匹配时拒绝连接
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(otherKey)
+	database.DB.Model(&models.Node{}).Where("id = ?", node.ID).
+		Update("ssh_host_key", string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey())))
+	InvalidateNodeManager(node.ID)
+
+	dm, err = GetNodeManager(node.ID)
+	if err != nil {
+		t.Fatalf("创建Docker管理器失败: %v", err)
+	}
+	if _, err := dm.GetHostInfo(5 * time.Second); err == nil {
+		t.Fatal("主机公钥不匹配时不应连接成功")
+	}
+}
+
+// startSSHServer 启动只允许转发Unix套接字的SSH服务，返回监听地址
+func startSSHServer(t *testing.T, hostSigner ssh.Signer, authorized ssh.PublicKey) string {
+	t.Helper()
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, fmt.Errorf("未授权的公钥")
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听SSH端口失败: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			raw, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(raw, serverConfig)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// serveSSHConn 处理一个SSH连接的 direct-streamlocal 转发请求
+func serveSSHConn(raw net.Conn, serverConfig *ssh.ServerConfig) {
+	conn, channels, requests, err := ssh.NewServerConn(raw, serverConfig)
+	if err != nil {
+		raw.Close()
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-streamlocal@openssh.com" {
+			newChannel.Reject(ssh.UnknownChannelType, "不支持的通道类型")
+			continue
+		}
+		var payload struct {
+			SocketPath string
+			Reserved0  string
+			Reserved1  uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		target, err := net.Dial("unix", payload.SocketPath)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go io.Copy(target, channel)
+			io.Copy(channel, target)
+		}()
+	}
+}
@@ -0,0 +1,332 @@
+package docker_manager
+
+import (
+	"ark-server-commander/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"go.uber.org/zap"
+)
+
+// 辅助容器标签，用于识别由面板管理的辅助容器（面板重启后可据此回收），所属服务器ID使用 ServerIDLabel
+const (
+	HelperLabel       = "ase-server-commander.helper" // 值固定为 "true"
+	HelperVolumeLabel = "ase-server-commander.volume" // 挂载的 Saved 卷名称
+)
+
+// HelperImage 辅助容器使用的镜像
+const HelperImage = "alpine:latest"
+
+// volumeHelper 服务器卷辅助容器的使用状态
+type volumeHelper struct {
+	mu          sync.Mutex // 串行化容器的检查和创建
+	containerID string
+	active      int // 正在进行的操作数量，大于0时不会被回收
+	lastUsed    time.Time
+}
+
+// helperPool 每个服务器一个常驻辅助容器，空闲超时后回收（每个节点的Docker管理器各有一个）
+type helperPool struct {
+	mu      sync.Mutex
+	helpers map[uint]*volumeHelper
+}
+
+// newHelperPool 创建辅助容器池
+func newHelperPool() *helperPool {
+	return &helperPool{helpers: make(map[uint]*volumeHelper)}
+}
+
+// acquireHelper 获取服务器卷辅助容器（不存在或已停止时自动创建/恢复）
+// 返回: 容器ID、释放函数和错误信息，操作完成后必须调用释放函数
+func (dm *DockerManager) acquireHelper(serverID uint) (string, func(), error) {
+	dm.helpers.mu.Lock()
+	helper, ok := dm.helpers.helpers[serverID]
+	if !ok {
+		helper = &volumeHelper{}
+		dm.helpers.helpers[serverID] = helper
+	}
+	helper.active++
+	dm.helpers.mu.Unlock()
+
+	release := func() {
+		dm.helpers.mu.Lock()
+		helper.active--
+		helper.lastUsed = time.Now()
+		dm.helpers.mu.Unlock()
+	}
+
+	helper.mu.Lock()
+	containerID, err := dm.ensureHelper(serverID, helper.containerID)
+	if err == nil {
+		helper.containerID = containerID
+	}
+	helper.mu.Unlock()
+
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+	return containerID, release, nil
+}
+
+// ensureHelper 确保辅助容器处于运行状态
+// knownID: 上次使用的容器ID，为空时按名称查找（面板重启后接管已有容器）
+func (dm *DockerManager) ensureHelper(serverID uint, knownID string) (string, error) {
+	ref := knownID
+	if ref == "" {
+		ref = utils.GetServerHelperContainerName(serverID)
+	}
+
+	inspect, err := dm.client.ContainerInspect(dm.ctx, ref)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return "", fmt.Errorf("检查辅助容器失败: %v", err)
+		}
+		if knownID != "" {
+			// 记录的容器已被删除，按名称重新查找
+			return dm.ensureHelper(serverID, "")
+		}
+		return dm.createHelper(serverID)
+	}
+
+	if inspect.State != nil && inspect.State.Running {
+		return inspect.ID, nil
+	}
+
+	// 容器已停止（例如Docker重启或容器崩溃），尝试重新启动
+	startErr := dm.client.ContainerStart(dm.ctx, inspect.ID, container.StartOptions{})
+	if startErr == nil {
+		utils.Info("辅助容器已恢复", zap.Uint("server_id", serverID))
+		return inspect.ID, nil
+	}
+	utils.Warn("重新启动辅助容器失败，将重新创建", zap.Uint("server_id", serverID), zap.Error(startErr))
+
+	if err := dm.client.ContainerRemove(dm.ctx, inspect.ID, container.RemoveOptions{Force: true}); err != nil && !errdefs.IsNotFound(err) {
+		return "", fmt.Errorf("删除异常的辅助容器失败: %v", err)
+	}
+	return dm.createHelper(serverID)
+}
+
+// createHelper 创建并启动挂载服务器卷的辅助容器
+func (dm *DockerManager) createHelper(serverID uint) (string, error) {
+	// 检查Alpine镜像是否存在
+	exists, err := dm.ImageExists(HelperImage)
+	if err != nil {
+		return "", fmt.Errorf("检查Alpine镜像失败: %v", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("Alpine镜像不存在，请确保后端启动时已成功拉取镜像")
+	}
+
+	volumeName := utils.GetServerVolumeName(serverID)
+	initProcess := true
+
+	labels := resourceLabels(serverID, RoleHelper)
+	labels[HelperLabel] = "true"
+	labels[HelperVolumeLabel] = volumeName
+	containerConfig := &container.Config{
+		Image:  HelperImage,
+		Cmd:    []string{"tail", "-f", "/dev/null"}, // 保持容器运行
+		Labels: labels,
+	}
+
+	hostConfig := &container.HostConfig{
+		Init: &initProcess, // 使 tail 能及时响应停止信号
+		Binds: []string{
+			fmt.Sprintf("%s:%s", volumeName, HelperSavedMount),
+			fmt.Sprintf("%s:%s", utils.GetServerPluginsVolumeName(serverID), HelperPluginsMount),
+		},
+	}
+
+	resp, err := dm.client.ContainerCreate(dm.ctx, containerConfig, hostConfig, nil, nil, utils.GetServerHelperContainerName(serverID))
+	if err != nil {
+		return "", fmt.Errorf("创建辅助容器失败: %v", err)
+	}
+
+	if err := dm.client.ContainerStart(dm.ctx, resp.ID, container.StartOptions{}); err != nil {
+		dm.client.ContainerRemove(dm.ctx, resp.ID, container.RemoveOptions{Force: true})
+		return "", fmt.Errorf("启动辅助容器失败: %v", err)
+	}
+
+	utils.Info("辅助容器已创建", zap.Uint("server_id", serverID), zap.String("container_id", resp.ID))
+	return resp.ID, nil
+}
+
+// RecoverHelpers 接管面板上次运行时遗留的辅助容器（通常在启动时调用）
+// 运行中的容器会被记录并在空闲超时后回收，已停止的容器会被删除
+func (dm *DockerManager) RecoverHelpers() error {
+	containers, err := dm.client.ContainerList(dm.ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", HelperLabel+"=true")),
+	})
+	if err != nil {
+		return fmt.Errorf("列出辅助容器失败: %v", err)
+	}
+
+	for _, c := range containers {
+		serverID, parseErr := strconv.ParseUint(c.Labels[ServerIDLabel], 10, 32)
+		if parseErr != nil || c.State != "running" {
+			if err := dm.client.ContainerRemove(dm.ctx, c.ID, container.RemoveOptions{Force: true}); err != nil {
+				utils.Warn("删除遗留辅助容器失败", zap.String("container_id", c.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		dm.helpers.mu.Lock()
+		if _, ok := dm.helpers.helpers[uint(serverID)]; !ok {
+			dm.helpers.helpers[uint(serverID)] = &volumeHelper{containerID: c.ID, lastUsed: time.Now()}
+		}
+		dm.helpers.mu.Unlock()
+	}
+
+	return nil
+}
+
+// StartHelperReaper 启动空闲辅助容器回收任务（回收所有节点上的辅助容器）
+// idleTimeout: 辅助容器空闲多久后删除
+// 返回: 停止函数
+func StartHelperReaper(idleTimeout time.Duration) func() {
+	interval := idleTimeout / 2
+	if interval < 30*time.Second {
+		interval = 30 * time.Second
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				forEachManager(func(dm *DockerManager) {
+					dm.reapIdleHelpers(idleTimeout)
+				})
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		wg.Wait()
+	}
+}
+
+// reapIdleHelpers 删除空闲超时的辅助容器
+func (dm *DockerManager) reapIdleHelpers(idleTimeout time.Duration) {
+	dm.helpers.mu.Lock()
+	serverIDs := dm.helpers.idle(time.Now(), idleTimeout)
+	dm.helpers.mu.Unlock()
+
+	for _, serverID := range serverIDs {
+		dm.RemoveHelper(serverID)
+	}
+}
+
+// RemoveHelper 删除服务器的辅助容器并移除记录（删除服务器或卷之前调用，避免卷被占用）
+// 正在使用中的辅助容器不会被删除；调用Docker时只持有该服务器的 helper.mu，
+// 远程节点响应慢时不会阻塞其他服务器获取和释放辅助容器，同一服务器的 acquireHelper 会等待删除完成后重新创建
+func (dm *DockerManager) RemoveHelper(serverID uint) {
+	dm.helpers.mu.Lock()
+	helper, ok := dm.helpers.helpers[serverID]
+	if ok && helper.active > 0 {
+		dm.helpers.mu.Unlock()
+		utils.Warn("辅助容器正在使用中，跳过删除", zap.Uint("server_id", serverID))
+		return
+	}
+	if !ok {
+		// 没有记录时仍按名称删除，占位使同一服务器的 acquireHelper 等待删除完成
+		helper = &volumeHelper{}
+		dm.helpers.helpers[serverID] = helper
+	}
+	helper.active++
+	dm.helpers.mu.Unlock()
+
+	helper.mu.Lock()
+	ref := helper.containerID
+	if ref == "" {
+		ref = utils.GetServerHelperContainerName(serverID)
+	}
+	err := dm.client.ContainerRemove(dm.ctx, ref, container.RemoveOptions{Force: true})
+	helper.containerID = ""
+	helper.mu.Unlock()
+
+	dm.helpers.mu.Lock()
+	helper.active--
+	if helper.active == 0 && dm.helpers.helpers[serverID] == helper {
+		delete(dm.helpers.helpers, serverID)
+	}
+	dm.helpers.mu.Unlock()
+
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			utils.Warn("删除辅助容器失败", zap.Uint("server_id", serverID), zap.Error(err))
+		}
+		return
+	}
+	utils.Debug("辅助容器已删除", zap.Uint("server_id", serverID))
+}
+
+// RemoveAllHelpers 删除所有节点上的辅助容器（通常在程序退出时调用）
+func RemoveAllHelpers() {
+	forEachManager(func(dm *DockerManager) {
+		dm.removeAllHelpers()
+	})
+}
+
+// removeAllHelpers 删除当前节点上的所有辅助容器
+func (dm *DockerManager) removeAllHelpers() {
+	dm.helpers.mu.Lock()
+	serverIDs := make([]uint, 0, len(dm.helpers.helpers))
+	for serverID := range dm.helpers.helpers {
+		serverIDs = append(serverIDs, serverID)
+	}
+	dm.helpers.mu.Unlock()
+
+	for _, serverID := range serverIDs {
+		dm.RemoveHelper(serverID)
+	}
+}
+
+// removeHelpersByVolume 删除挂载了指定 Saved 卷的辅助容器
+func (dm *DockerManager) removeHelpersByVolume(volumeName string) {
+	containers, err := dm.client.ContainerList(dm.ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", HelperVolumeLabel+"="+volumeName)),
+	})
+	if err != nil {
+		utils.Warn("列出辅助容器失败", zap.String("volume", volumeName), zap.Error(err))
+		return
+	}
+
+	for _, c := range containers {
+		if serverID, err := strconv.ParseUint(c.Labels[ServerIDLabel], 10, 32); err == nil {
+			dm.RemoveHelper(uint(serverID))
+			continue
+		}
+		dm.client.ContainerRemove(dm.ctx, c.ID, container.RemoveOptions{Force: true})
+	}
+}
+
+// idle 返回空闲超过指定时长且没有进行中操作的服务器ID（调用方需持有 mu）
+func (p *helperPool) idle(now time.Time, idleTimeout time.Duration) []uint {
+	var serverIDs []uint
+	for serverID, helper := range p.helpers {
+		if helper.active == 0 && now.Sub(helper.lastUsed) >= idleTimeout {
+			serverIDs = append(serverIDs, serverID)
+		}
+	}
+	sort.Slice(serverIDs, func(i, j int) bool { return serverIDs[i] < serverIDs[j] })
+	return serverIDs
+}
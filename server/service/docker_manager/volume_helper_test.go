@@ -0,0 +1,24 @@
+package docker_manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHelperPoolIdle(t *testing.T) {
+	now := time.Now()
+	pool := &helperPool{helpers: map[uint]*volumeHelper{
+		1: {lastUsed: now.Add(-20 * time.Minute)},            // 空闲超时
+		2: {lastUsed: now.Add(-time.Minute)},                 // 最近使用过
+		3: {lastUsed: now.Add(-time.Hour), active: 1},        // 正在使用中
+		4: {lastUsed: now.Add(-10 * time.Minute)},            // 刚好达到超时
+		5: {containerID: "recovered", lastUsed: time.Time{}}, // 从未使用
+	}}
+
+	got := pool.idle(now, 10*time.Minute)
+	expected := []uint{1, 4, 5}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("空闲辅助容器应为 %v，实际为 %v", expected, got)
+	}
+}
@@ -0,0 +1,432 @@
+package file_manager
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/permission"
+	"ark-server-commander/utils"
+
+	"github.com/containerd/errdefs"
+	"go.uber.org/zap"
+)
+
+// 可管理的服务器卷
+const (
+	VolumeSaved   = "saved"   // Saved 卷（存档、配置、日志、白名单等）
+	VolumePlugins = "plugins" // ArkApi 插件卷
+)
+
+// volumeRoots 卷名称与辅助容器中挂载点的对应关系
+var volumeRoots = map[string]string{
+	VolumeSaved:   docker_manager.HelperSavedMount,
+	VolumePlugins: docker_manager.HelperPluginsMount,
+}
+
+// FileService 服务器卷文件管理服务
+type FileService struct{}
+
+// NewFileService 创建文件管理服务实例
+func NewFileService() *FileService {
+	return &FileService{}
+}
+
+// ListDir 列出目录内容（目录在前，按名称排序）
+// volume: 卷名称（saved 或 plugins）
+// relPath: 相对于卷根目录的路径，空字符串表示根目录
+func (s *FileService) ListDir(userID uint, serverID, volume, relPath string) ([]models.FileInfo, error) {
+	var files []models.FileInfo
+	err := s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		target, rel, err := resolveExisting(session, root, relPath)
+		if err != nil {
+			return err
+		}
+
+		stat, err := session.Stat(target)
+		if err != nil {
+			return err
+		}
+		if !stat.Mode.IsDir() {
+			return fmt.Errorf("路径不是目录")
+		}
+
+		output, err := session.Exec("find", target, "-mindepth", "1", "-maxdepth", "1", "-exec", "stat", "-c", statFormat, "{}", "+")
+		if err != nil {
+			return fmt.Errorf("列出目录失败: %w", err)
+		}
+
+		files, err = parseStatOutput(output, rel)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Stat 获取文件或目录信息
+func (s *FileService) Stat(userID uint, serverID, volume, relPath string) (*models.FileInfo, error) {
+	var info models.FileInfo
+	err := s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		target, rel, err := resolveExisting(session, root, relPath)
+		if err != nil {
+			return err
+		}
+
+		stat, err := session.Stat(target)
+		if err != nil {
+			return err
+		}
+
+		info = models.FileInfo{
+			Name:      path.Base(rel),
+			Path:      rel,
+			IsDir:     stat.Mode.IsDir(),
+			IsSymlink: stat.LinkTarget != "",
+			Size:      stat.Size,
+			ModTime:   stat.Mtime.Format(time.RFC3339),
+		}
+		if rel == "" {
+			info.Name = "/"
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// DownloadFile 下载文件，write 在文件流有效期间被调用
+func (s *FileService) DownloadFile(userID uint, serverID, volume, relPath string, write func(name string, size int64, content io.Reader) error) error {
+	return s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		target, rel, err := resolveExisting(session, root, relPath)
+		if err != nil {
+			return err
+		}
+
+		stat, err := session.Stat(target)
+		if err != nil {
+			return err
+		}
+		if !stat.Mode.IsRegular() {
+			return fmt.Errorf("路径不是文件")
+		}
+
+		return session.OpenFile(target, func(size int64, content io.Reader) error {
+			return write(path.Base(rel), size, content)
+		})
+	})
+}
+
+// UploadFile 上传文件到指定目录
+// dirPath: 目标目录（不存在时自动创建）
+// fileName: 文件名
+// overwrite: 文件已存在时是否覆盖
+func (s *FileService) UploadFile(userID uint, serverID, volume, dirPath, fileName string, size int64, content io.Reader, overwrite bool) (*models.FileInfo, error) {
+	if err := utils.ValidateFileName(fileName); err != nil {
+		return nil, err
+	}
+
+	var info models.FileInfo
+	err := s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		rel, err := utils.SanitizeRelativePath(path.Join(dirPath, fileName))
+		if err != nil {
+			return err
+		}
+		target, err := resolveNew(session, root, rel)
+		if err != nil {
+			return err
+		}
+
+		if stat, err := session.Stat(target); err == nil {
+			if stat.Mode.IsDir() {
+				return fmt.Errorf("目标已存在")
+			}
+			if !overwrite {
+				return fmt.Errorf("目标已存在")
+			}
+		} else if !errdefs.IsNotFound(err) {
+			return err
+		}
+
+		if err := session.WriteStream(target, size, content); err != nil {
+			return fmt.Errorf("上传文件失败: %w", err)
+		}
+
+		info = models.FileInfo{
+			Name:    fileName,
+			Path:    rel,
+			Size:    size,
+			ModTime: time.Now().Format(time.RFC3339),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Rename 重命名或移动文件/目录（目标已存在时返回错误）
+func (s *FileService) Rename(userID uint, serverID, volume, from, to string) error {
+	return s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		source, sourceRel, err := resolveExisting(session, root, from)
+		if err != nil {
+			return err
+		}
+		if sourceRel == "" {
+			return fmt.Errorf("不能操作卷根目录")
+		}
+
+		targetRel, err := utils.SanitizeRelativePath(to)
+		if err != nil {
+			return err
+		}
+		if targetRel == "" {
+			return fmt.Errorf("不能操作卷根目录")
+		}
+		if targetRel == sourceRel || strings.HasPrefix(targetRel, sourceRel+"/") {
+			return fmt.Errorf("不能移动到自身或子目录中")
+		}
+		target, err := resolveNew(session, root, targetRel)
+		if err != nil {
+			return err
+		}
+
+		exists, err := session.Exists(target)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("目标已存在")
+		}
+
+		if _, err := session.Exec("mkdir", "-p", path.Dir(target)); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		if _, err := session.Exec("mv", source, target); err != nil {
+			return fmt.Errorf("重命名失败: %w", err)
+		}
+
+		utils.Info("文件已重命名", zap.String("server_id", serverID), zap.String("volume", volume),
+			zap.String("from", sourceRel), zap.String("to", targetRel))
+		return nil
+	})
+}
+
+// Delete 删除文件或目录（目录会递归删除）
+func (s *FileService) Delete(userID uint, serverID, volume, relPath string) error {
+	return s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		target, rel, err := resolveExisting(session, root, relPath)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			return fmt.Errorf("不能操作卷根目录")
+		}
+
+		if _, err := session.Exec("rm", "-rf", target); err != nil {
+			return fmt.Errorf("删除失败: %w", err)
+		}
+
+		utils.Info("文件已删除", zap.String("server_id", serverID), zap.String("volume", volume), zap.String("path", rel))
+		return nil
+	})
+}
+
+// Mkdir 创建目录（包括不存在的父目录）
+func (s *FileService) Mkdir(userID uint, serverID, volume, relPath string) error {
+	return s.withVolume(userID, serverID, volume, func(session *docker_manager.VolumeSession, root string) error {
+		rel, err := utils.SanitizeRelativePath(relPath)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			return fmt.Errorf("不能操作卷根目录")
+		}
+		target, err := resolveNew(session, root, rel)
+		if err != nil {
+			return err
+		}
+
+		if stat, err := session.Stat(target); err == nil && !stat.Mode.IsDir() {
+			return fmt.Errorf("目标已存在")
+		}
+
+		if _, err := session.Exec("mkdir", "-p", target); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// withVolume 校验服务器和卷名称后在辅助容器中执行文件操作
+func (s *FileService) withVolume(userID uint, serverID, volume string, fn func(session *docker_manager.VolumeSession, root string) error) error {
+	root, ok := volumeRoots[volume]
+	if !ok {
+		return fmt.Errorf("无效的卷: %s", volume)
+	}
+
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return err
+	}
+
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %v", err)
+	}
+
+	return dockerManager.WithVolumes(server.ID, func(session *docker_manager.VolumeSession) error {
+		return fn(session, root)
+	})
+}
+
+// resolveExisting 解析已存在的路径，并确认解析符号链接后仍在卷根目录内
+// 返回: 辅助容器中的绝对路径、清理后的相对路径和错误信息
+func resolveExisting(session *docker_manager.VolumeSession, root, relPath string) (string, string, error) {
+	rel, err := utils.SanitizeRelativePath(relPath)
+	if err != nil {
+		return "", "", err
+	}
+	target := path.Join(root, rel)
+
+	exists, err := session.Exists(target)
+	if err != nil {
+		return "", "", err
+	}
+	if !exists {
+		return "", "", fmt.Errorf("文件不存在")
+	}
+
+	if err := ensureWithinRoot(session, root, target); err != nil {
+		return "", "", err
+	}
+	return target, rel, nil
+}
+
+// resolveNew 解析待创建的路径，确认已存在的最近父目录在卷根目录内
+func resolveNew(session *docker_manager.VolumeSession, root, rel string) (string, error) {
+	target := path.Join(root, rel)
+
+	for dir := path.Dir(target); ; dir = path.Dir(dir) {
+		exists, err := session.Exists(dir)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			if err := ensureWithinRoot(session, root, dir); err != nil {
+				return "", err
+			}
+			break
+		}
+		if dir == root || dir == "/" {
+			break
+		}
+	}
+
+	// 目标本身已存在时（例如覆盖上传）同样需要检查
+	exists, err := session.Exists(target)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		if err := ensureWithinRoot(session, root, target); err != nil {
+			return "", err
+		}
+	}
+	return target, nil
+}
+
+// ensureWithinRoot 解析符号链接后检查路径是否仍在卷根目录内
+func ensureWithinRoot(session *docker_manager.VolumeSession, root, target string) error {
+	output, err := session.Exec("readlink", "-f", target)
+	if err != nil {
+		return fmt.Errorf("解析路径失败: %w", err)
+	}
+	if !isWithinRoot(root, strings.TrimSpace(output)) {
+		return fmt.Errorf("路径超出卷范围")
+	}
+	return nil
+}
+
+// isWithinRoot 判断绝对路径是否位于根目录内（包括根目录本身）
+func isWithinRoot(root, resolved string) bool {
+	return resolved == root || strings.HasPrefix(resolved, root+"/")
+}
+
+// statFormat stat 命令输出格式：十六进制文件模式、大小、修改时间戳、路径
+const statFormat = "%f %s %Y %n"
+
+// parseStatOutput 解析 stat 命令的输出（目录在前，按名称排序）
+// parentRel: 所列目录相对于卷根目录的路径
+func parseStatOutput(output, parentRel string) ([]models.FileInfo, error) {
+	files := []models.FileInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("无法解析文件信息: %s", line)
+		}
+		mode, err := strconv.ParseUint(fields[0], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析文件信息: %s", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析文件信息: %s", line)
+		}
+		modTime, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析文件信息: %s", line)
+		}
+
+		name := path.Base(fields[3])
+		fileType := mode & 0xF000
+		files = append(files, models.FileInfo{
+			Name:      name,
+			Path:      strings.TrimPrefix(path.Join(parentRel, name), "/"),
+			IsDir:     fileType == 0x4000,
+			IsSymlink: fileType == 0xA000,
+			Size:      size,
+			ModTime:   time.Unix(modTime, 0).Format(time.RFC3339),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].IsDir != files[j].IsDir {
+			return files[i].IsDir
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// findServer 查找当前用户的服务器
+func findServer(userID uint, serverID string) (*models.Server, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+
+	var server models.Server
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+	return &server, nil
+}
@@ -0,0 +1,259 @@
+package file_manager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/docker_manager/dockertest"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// setupFileTest 创建所有者、服务器和模拟Docker客户端，Saved 卷中写入配置和存档
+// 返回: 模拟客户端、所有者ID和服务器ID
+func setupFileTest(t *testing.T) (*dockertest.FakeClient, uint, string) {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	db := dbtest.Open(t)
+	owner := models.User{Username: "owner", Password: "x", Role: models.RoleOwner}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	server := models.Server{Identifier: "island", UserID: owner.ID, ServerArgsJSON: "{}"}
+	if err := db.Create(&server).Error; err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+
+	fake := dockertest.NewFakeClient("alpine:latest")
+	docker_manager.SetDockerClient(fake)
+	t.Cleanup(docker_manager.RemoveAllHelpers)
+
+	saved := utils.GetServerVolumeName(server.ID)
+	fake.WriteVolumeFile(saved, "Config/WindowsServer/Game.ini", []byte("[game]"))
+	fake.WriteVolumeFile(saved, "SavedArks/TheIsland.ark", []byte("map data"))
+	fake.WriteVolumeFile(utils.GetServerPluginsVolumeName(server.ID), "ArkShop/config.json", []byte("{}"))
+	return fake, owner.ID, fmt.Sprint(server.ID)
+}
+
+func TestParseStatOutput(t *testing.T) {
+	output := "81a4 120 1700000000 /data/saved/Config/WindowsServer/Game.ini\n" +
+		"41ed 4096 1700000100 /data/saved/Config/WindowsServer/Backup\n" +
+		"a1ff 9 1700000200 /data/saved/Config/WindowsServer/link to file\n"
+
+	files, err := parseStatOutput(output, "Config/WindowsServer")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("应有3个文件，实际为 %d", len(files))
+	}
+
+	// 目录排在最前面
+	if files[0].Name != "Backup" || !files[0].IsDir {
+		t.Errorf("第一个应为目录 Backup: %+v", files[0])
+	}
+	if files[1].Name != "Game.ini" || files[1].IsDir || files[1].Size != 120 || files[1].Path != "Config/WindowsServer/Game.ini" {
+		t.Errorf("Game.ini 信息错误: %+v", files[1])
+	}
+	if files[2].Name != "link to file" || !files[2].IsSymlink {
+		t.Errorf("符号链接信息错误: %+v", files[2])
+	}
+}
+
+func TestParseStatOutputRootDirectory(t *testing.T) {
+	files, err := parseStatOutput("81a4 10 1700000000 /data/saved/whitelist.txt\n", "")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "whitelist.txt" {
+		t.Fatalf("根目录文件路径错误: %+v", files)
+	}
+}
+
+func TestIsWithinRoot(t *testing.T) {
+	tests := []struct {
+		resolved string
+		expected bool
+	}{
+		{"/data/saved", true},
+		{"/data/saved/Config/Game.ini", true},
+		{"/data/savedother/file", false},
+		{"/data/plugins/ArkShop", false},
+		{"/etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		if got := isWithinRoot("/data/saved", tt.resolved); got != tt.expected {
+			t.Errorf("isWithinRoot(%q) = %v，期望 %v", tt.resolved, got, tt.expected)
+		}
+	}
+}
+
+func TestFileServiceRejectsTraversal(t *testing.T) {
+	fake, ownerID, serverID := setupFileTest(t)
+	service := NewFileService()
+
+	for _, p := range []string{"..", "../plugins", "Config/../../plugins/ArkShop", "..\\plugins"} {
+		if _, err := service.Stat(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("Stat(%q) 应拒绝: %v", p, err)
+		}
+		if _, err := service.ListDir(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("ListDir(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Delete(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("Delete(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Mkdir(ownerID, serverID, VolumeSaved, p+"/new"); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("Mkdir(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Rename(ownerID, serverID, VolumeSaved, "SavedArks/TheIsland.ark", p+"/TheIsland.ark"); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("Rename 到 %q 应拒绝: %v", p, err)
+		}
+		if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, p, "evil.dll", 4, strings.NewReader("evil"), true); err == nil || err.Error() != "路径不能包含 .." {
+			t.Errorf("UploadFile(%q) 应拒绝: %v", p, err)
+		}
+	}
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "", "..", 4, strings.NewReader("evil"), true); err == nil || err.Error() != "无效的名称: .." {
+		t.Errorf("文件名为 .. 时应拒绝: %v", err)
+	}
+	if _, err := service.Stat(ownerID, serverID, "../etc", ""); err == nil || err.Error() != "无效的卷: ../etc" {
+		t.Errorf("无效的卷应拒绝: %v", err)
+	}
+
+	// 没有权限的用户看不到服务器
+	if _, err := service.Stat(ownerID+1, serverID, VolumeSaved, ""); err == nil || err.Error() != "服务器不存在" {
+		t.Errorf("其他用户不应能访问服务器文件: %v", err)
+	}
+
+	if data, ok := fake.ReadVolumeFile(utils.GetServerVolumeName(1), "SavedArks/TheIsland.ark"); !ok || string(data) != "map data" {
+		t.Fatal("被拒绝的操作不应修改文件")
+	}
+}
+
+func TestFileServiceRejectsSymlinkEscape(t *testing.T) {
+	fake, ownerID, serverID := setupFileTest(t)
+	service := NewFileService()
+	saved, plugins := utils.GetServerVolumeName(1), utils.GetServerPluginsVolumeName(1)
+
+	// 指向卷外（插件卷和系统目录）的链接，以及指向卷内的相对链接
+	fake.SymlinkVolumeFile(saved, "escape", docker_manager.HelperPluginsMount)
+	fake.SymlinkVolumeFile(saved, "Config/etc", "/etc")
+	fake.SymlinkVolumeFile(saved, "Config/shop.json", "../../plugins/ArkShop/config.json")
+	fake.SymlinkVolumeFile(saved, "latest", "SavedArks")
+
+	for _, p := range []string{"escape", "escape/ArkShop", "escape/ArkShop/config.json", "Config/etc", "Config/shop.json"} {
+		if _, err := service.Stat(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "路径超出卷范围" {
+			t.Errorf("Stat(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Delete(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "路径超出卷范围" {
+			t.Errorf("Delete(%q) 应拒绝: %v", p, err)
+		}
+	}
+	err := service.DownloadFile(ownerID, serverID, VolumeSaved, "escape/ArkShop/config.json", func(name string, size int64, content io.Reader) error {
+		t.Error("不应读取卷外的文件")
+		return nil
+	})
+	if err == nil || err.Error() != "路径超出卷范围" {
+		t.Errorf("下载卷外文件应拒绝: %v", err)
+	}
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "escape/ArkShop", "evil.dll", 4, strings.NewReader("evil"), true); err == nil || err.Error() != "路径超出卷范围" {
+		t.Errorf("上传到卷外应拒绝: %v", err)
+	}
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "Config", "shop.json", 4, strings.NewReader("evil"), true); err == nil || err.Error() != "路径超出卷范围" {
+		t.Errorf("通过链接覆盖卷外文件应拒绝: %v", err)
+	}
+	if err := service.Mkdir(ownerID, serverID, VolumeSaved, "escape/new/dir"); err == nil || err.Error() != "路径超出卷范围" {
+		t.Errorf("在卷外创建目录应拒绝: %v", err)
+	}
+	if err := service.Rename(ownerID, serverID, VolumeSaved, "SavedArks/TheIsland.ark", "escape/TheIsland.ark"); err == nil || err.Error() != "路径超出卷范围" {
+		t.Errorf("移动到卷外应拒绝: %v", err)
+	}
+
+	// 插件卷不受影响
+	if data, ok := fake.ReadVolumeFile(plugins, "ArkShop/config.json"); !ok || string(data) != "{}" {
+		t.Fatalf("卷外的文件不应被修改: %q", data)
+	}
+	if _, ok := fake.ReadVolumeFile(plugins, "ArkShop/evil.dll"); ok {
+		t.Fatal("不应写入卷外的文件")
+	}
+	if _, ok := fake.ReadVolumeFile(plugins, "TheIsland.ark"); ok {
+		t.Fatal("不应移动到卷外")
+	}
+
+	// 指向卷内的链接可以正常访问
+	info, err := service.Stat(ownerID, serverID, VolumeSaved, "latest/TheIsland.ark")
+	if err != nil || info.Size != int64(len("map data")) {
+		t.Fatalf("卷内链接应可访问: %+v, %v", info, err)
+	}
+	if info, err := service.Stat(ownerID, serverID, VolumeSaved, "latest"); err != nil || !info.IsSymlink {
+		t.Fatalf("应识别符号链接: %+v, %v", info, err)
+	}
+}
+
+func TestFileServiceProtectsRoot(t *testing.T) {
+	fake, ownerID, serverID := setupFileTest(t)
+	service := NewFileService()
+
+	for _, p := range []string{"", "/", ".", "./"} {
+		if err := service.Delete(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "不能操作卷根目录" {
+			t.Errorf("Delete(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Delete(ownerID, serverID, VolumePlugins, p); err == nil || err.Error() != "不能操作卷根目录" {
+			t.Errorf("删除插件卷根目录 %q 应拒绝: %v", p, err)
+		}
+		if err := service.Rename(ownerID, serverID, VolumeSaved, p, "moved"); err == nil || err.Error() != "不能操作卷根目录" {
+			t.Errorf("Rename(%q) 应拒绝: %v", p, err)
+		}
+		if err := service.Rename(ownerID, serverID, VolumeSaved, "SavedArks", p); err == nil || err.Error() != "不能操作卷根目录" {
+			t.Errorf("Rename 到 %q 应拒绝: %v", p, err)
+		}
+		if err := service.Mkdir(ownerID, serverID, VolumeSaved, p); err == nil || err.Error() != "不能操作卷根目录" {
+			t.Errorf("Mkdir(%q) 应拒绝: %v", p, err)
+		}
+	}
+
+	// 上传不能覆盖目录，未指定覆盖时不能覆盖文件
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "", "Config", 4, strings.NewReader("evil"), true); err == nil || err.Error() != "目标已存在" {
+		t.Errorf("上传不应覆盖目录: %v", err)
+	}
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "SavedArks", "TheIsland.ark", 4, strings.NewReader("evil"), false); err == nil || err.Error() != "目标已存在" {
+		t.Errorf("未指定覆盖时不应覆盖文件: %v", err)
+	}
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "", "", 4, strings.NewReader("evil"), true); err == nil {
+		t.Error("文件名为空时应拒绝")
+	}
+
+	saved := utils.GetServerVolumeName(1)
+	if data, ok := fake.ReadVolumeFile(saved, "SavedArks/TheIsland.ark"); !ok || string(data) != "map data" {
+		t.Fatalf("卷中的文件不应被修改: %q", data)
+	}
+	if _, ok := fake.ReadVolumeFile(saved, "Config/WindowsServer/Game.ini"); !ok {
+		t.Fatal("卷中的目录不应被删除")
+	}
+
+	// 覆盖上传和删除子目录正常执行
+	if _, err := service.UploadFile(ownerID, serverID, VolumeSaved, "SavedArks", "TheIsland.ark", 7, bytes.NewReader([]byte("updated")), true); err != nil {
+		t.Fatalf("覆盖上传失败: %v", err)
+	}
+	if data, _ := fake.ReadVolumeFile(saved, "SavedArks/TheIsland.ark"); string(data) != "updated" {
+		t.Fatalf("覆盖上传后内容错误: %q", data)
+	}
+	if err := service.Delete(ownerID, serverID, VolumeSaved, "Config"); err != nil {
+		t.Fatalf("删除目录失败: %v", err)
+	}
+	if _, ok := fake.ReadVolumeFile(saved, "Config/WindowsServer/Game.ini"); ok {
+		t.Fatal("目录应被删除")
+	}
+}
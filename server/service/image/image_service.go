@@ -0,0 +1,270 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/utils"
+
+	"github.com/distribution/reference"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ImageService 游戏服务器镜像登记服务
+type ImageService struct {
+	httpClient *http.Client // 测试仓库凭据时使用
+}
+
+// NewImageService 创建镜像登记服务实例
+func NewImageService() *ImageService {
+	return &ImageService{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// NormalizeImage 规范化镜像引用（补全 latest 标签，去掉 docker.io/library 前缀），与 Docker 保存的容器镜像名称一致
+func NormalizeImage(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimSpace(ref))
+	if err != nil {
+		return "", fmt.Errorf("镜像名称格式错误: %s", ref)
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named)), nil
+}
+
+// ListImages 获取所有登记的镜像及使用的服务器数量
+func (s *ImageService) ListImages() ([]models.ServerImageResponse, error) {
+	var images []models.ServerImage
+	if err := database.DB.Order("id").Find(&images).Error; err != nil {
+		return nil, fmt.Errorf("获取镜像列表失败: %w", err)
+	}
+	counts, err := serverCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.ServerImageResponse, 0, len(images))
+	for _, image := range images {
+		responses = append(responses, toImageResponse(image, counts[image.Image]))
+	}
+	return responses, nil
+}
+
+// CreateImage 登记镜像
+func (s *ImageService) CreateImage(req models.ServerImageRequest) (*models.ServerImageResponse, error) {
+	name, err := NormalizeImage(req.Image)
+	if err != nil {
+		return nil, err
+	}
+	if name == docker_manager.HelperImage {
+		return nil, fmt.Errorf("不能登记辅助容器使用的镜像")
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.ServerImage{}).Where("image = ?", name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("检查镜像失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("镜像已登记")
+	}
+
+	image := models.ServerImage{Image: name, Description: strings.TrimSpace(req.Description), IsDefault: req.IsDefault}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if image.IsDefault {
+			if err := clearDefault(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&image).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("登记镜像失败: %w", err)
+	}
+	utils.Info("镜像已登记", zap.String("image", image.Image), zap.Bool("default", image.IsDefault))
+
+	response := toImageResponse(image, 0)
+	return &response, nil
+}
+
+// UpdateImage 修改镜像的说明或将其设为默认镜像
+func (s *ImageService) UpdateImage(imageID uint, req models.ServerImageUpdateRequest) (*models.ServerImageResponse, error) {
+	image, err := findImage(imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		image.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.IsDefault != nil && !*req.IsDefault && image.IsDefault {
+		return nil, fmt.Errorf("不能取消默认镜像，请将其他镜像设为默认")
+	}
+	setDefault := req.IsDefault != nil && *req.IsDefault && !image.IsDefault
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if setDefault {
+			if err := clearDefault(tx); err != nil {
+				return err
+			}
+			image.IsDefault = true
+		}
+		return tx.Save(image).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("修改镜像失败: %w", err)
+	}
+	if setDefault {
+		utils.Info("默认镜像已更换", zap.String("image", image.Image))
+	}
+
+	counts, err := serverCounts()
+	if err != nil {
+		return nil, err
+	}
+	response := toImageResponse(*image, counts[image.Image])
+	return &response, nil
+}
+
+// DeleteImage 删除登记的镜像（默认镜像和正在被服务器使用的镜像不能删除，不删除节点上已下载的镜像）
+func (s *ImageService) DeleteImage(imageID uint) error {
+	image, err := findImage(imageID)
+	if err != nil {
+		return err
+	}
+	if image.IsDefault {
+		return fmt.Errorf("不能删除默认镜像")
+	}
+
+	counts, err := serverCounts()
+	if err != nil {
+		return err
+	}
+	if counts[image.Image] > 0 {
+		return fmt.Errorf("镜像正在被服务器使用，不能删除")
+	}
+
+	if err := database.DB.Delete(image).Error; err != nil {
+		return fmt.Errorf("删除镜像失败: %w", err)
+	}
+	utils.Info("镜像已删除", zap.String("image", image.Image))
+	return nil
+}
+
+// DefaultImage 新建服务器默认使用的镜像（未设置默认镜像时为 models.DefaultServerImage）
+func (s *ImageService) DefaultImage() (string, error) {
+	var images []models.ServerImage
+	if err := database.DB.Where("is_default = ?", true).Limit(1).Find(&images).Error; err != nil {
+		return "", fmt.Errorf("获取默认镜像失败: %w", err)
+	}
+	if len(images) == 0 {
+		return models.DefaultServerImage, nil
+	}
+	return images[0].Image, nil
+}
+
+// ResolveImage 校验服务器请求的镜像，返回规范化的镜像引用（未指定时使用默认镜像）
+func (s *ImageService) ResolveImage(ref string) (string, error) {
+	defaultImage, err := s.DefaultImage()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(ref) == "" {
+		return defaultImage, nil
+	}
+
+	name, err := NormalizeImage(ref)
+	if err != nil {
+		return "", err
+	}
+	if name == defaultImage {
+		return name, nil
+	}
+	var count int64
+	if err := database.DB.Model(&models.ServerImage{}).Where("image = ?", name).Count(&count).Error; err != nil {
+		return "", fmt.Errorf("检查镜像失败: %w", err)
+	}
+	if count == 0 {
+		return "", fmt.Errorf("镜像未登记: %s", name)
+	}
+	return name, nil
+}
+
+// ServerImages 服务器可以使用的所有镜像（登记的镜像和默认镜像）
+func (s *ImageService) ServerImages() ([]string, error) {
+	defaultImage, err := s.DefaultImage()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := database.DB.Model(&models.ServerImage{}).Order("id").Pluck("image", &names).Error; err != nil {
+		return nil, fmt.Errorf("获取镜像列表失败: %w", err)
+	}
+	for _, name := range names {
+		if name == defaultImage {
+			return names, nil
+		}
+	}
+	return append([]string{defaultImage}, names...), nil
+}
+
+// ManagedImages 面板管理的所有镜像（服务器镜像和辅助容器使用的镜像），只允许拉取和更新这些镜像
+func (s *ImageService) ManagedImages() ([]string, error) {
+	names, err := s.ServerImages()
+	if err != nil {
+		return nil, err
+	}
+	return append(names, docker_manager.HelperImage), nil
+}
+
+// findImage 按ID查找登记的镜像
+func findImage(imageID uint) (*models.ServerImage, error) {
+	var image models.ServerImage
+	if err := database.DB.Where("id = ?", imageID).First(&image).Error; err != nil {
+		return nil, fmt.Errorf("镜像不存在")
+	}
+	return &image, nil
+}
+
+// clearDefault 取消当前的默认镜像
+func clearDefault(tx *gorm.DB) error {
+	return tx.Model(&models.ServerImage{}).Where("is_default = ?", true).Update("is_default", false).Error
+}
+
+// serverCounts 统计使用每个镜像的服务器数量
+func serverCounts() (map[string]int64, error) {
+	var rows []struct {
+		Image string
+		Count int64
+	}
+	if err := database.DB.Model(&models.Server{}).Select("image, COUNT(*) AS count").Group("image").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计使用镜像的服务器失败: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		image := row.Image
+		if image == "" {
+			image = models.DefaultServerImage
+		}
+		counts[image] += row.Count
+	}
+	return counts, nil
+}
+
+// toImageResponse 转换为镜像响应
+func toImageResponse(image models.ServerImage, serverCount int64) models.ServerImageResponse {
+	return models.ServerImageResponse{
+		ID:          image.ID,
+		Image:       image.Image,
+		Description: image.Description,
+		IsDefault:   image.IsDefault,
+		ServerCount: serverCount,
+		CreatedAt:   image.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,97 @@
+package image
+
+import (
+	"strings"
+	"testing"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+func TestNormalizeImage(t *testing.T) {
+	digest := "tbro98/ase-server@sha256:" + strings.Repeat("a", 64)
+	cases := map[string]string{
+		"tbro98/ase-server":             "tbro98/ase-server:latest",
+		" tbro98/ase-server:v2 ":        "tbro98/ase-server:v2",
+		"docker.io/tbro98/ase-server":   "tbro98/ase-server:latest",
+		"docker.io/library/alpine":      "alpine:latest",
+		"registry.example.com:5000/ark": "registry.example.com:5000/ark:latest",
+		digest:                          digest,
+	}
+	for input, want := range cases {
+		if got, err := NormalizeImage(input); err != nil || got != want {
+			t.Errorf("NormalizeImage(%q) = %q, %v，期望 %q", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "Tbro98/ASE", "a b"} {
+		if _, err := NormalizeImage(input); err == nil {
+			t.Errorf("NormalizeImage(%q) 应返回错误", input)
+		}
+	}
+}
+
+func TestImageRegistry(t *testing.T) {
+	dbtest.Open(t)
+	service := NewImageService()
+
+	// 未登记镜像时使用内置的默认镜像
+	if image, err := service.ResolveImage(""); err != nil || image != models.DefaultServerImage {
+		t.Fatalf("未登记镜像时应使用内置默认镜像: %s, %v", image, err)
+	}
+	if _, err := service.ResolveImage("tbro98/ase-server:v2"); err == nil || !strings.HasPrefix(err.Error(), "镜像未登记") {
+		t.Fatalf("未登记的镜像应返回错误: %v", err)
+	}
+
+	stable, err := service.CreateImage(models.ServerImageRequest{Image: "tbro98/ase-server", IsDefault: true})
+	if err != nil || stable.Image != models.DefaultServerImage {
+		t.Fatalf("登记镜像失败: %+v, %v", stable, err)
+	}
+	if _, err := service.CreateImage(models.ServerImageRequest{Image: models.DefaultServerImage}); err == nil || err.Error() != "镜像已登记" {
+		t.Fatalf("重复登记应返回错误: %v", err)
+	}
+	if _, err := service.CreateImage(models.ServerImageRequest{Image: "alpine"}); err == nil {
+		t.Fatal("不应允许登记辅助容器镜像")
+	}
+	beta, err := service.CreateImage(models.ServerImageRequest{Image: "tbro98/ase-server:v2", Description: "测试版"})
+	if err != nil {
+		t.Fatalf("登记镜像失败: %v", err)
+	}
+
+	// 更换默认镜像
+	isDefault := true
+	if _, err := service.UpdateImage(beta.ID, models.ServerImageUpdateRequest{IsDefault: &isDefault}); err != nil {
+		t.Fatalf("设置默认镜像失败: %v", err)
+	}
+	if image, _ := service.ResolveImage(""); image != "tbro98/ase-server:v2" {
+		t.Fatalf("应使用新的默认镜像: %s", image)
+	}
+	notDefault := false
+	if _, err := service.UpdateImage(beta.ID, models.ServerImageUpdateRequest{IsDefault: &notDefault}); err == nil {
+		t.Fatal("不应允许取消默认镜像")
+	}
+
+	// 删除规则：默认镜像和使用中的镜像不能删除
+	database.DB.Create(&models.Server{Identifier: "ark", UserID: 1, Image: stable.Image})
+	if err := service.DeleteImage(beta.ID); err == nil || err.Error() != "不能删除默认镜像" {
+		t.Fatalf("删除默认镜像应返回错误: %v", err)
+	}
+	if err := service.DeleteImage(stable.ID); err == nil || err.Error() != "镜像正在被服务器使用，不能删除" {
+		t.Fatalf("删除使用中的镜像应返回错误: %v", err)
+	}
+
+	images, err := service.ListImages()
+	if err != nil || len(images) != 2 || images[0].ServerCount != 1 || images[0].IsDefault || !images[1].IsDefault {
+		t.Fatalf("镜像列表错误: %+v, %v", images, err)
+	}
+	managed, _ := service.ManagedImages()
+	if strings.Join(managed, ",") != "tbro98/ase-server:latest,tbro98/ase-server:v2,alpine:latest" {
+		t.Fatalf("管理的镜像错误: %v", managed)
+	}
+}
@@ -0,0 +1,265 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// dockerHubAPIHost Docker Hub 的 Registry API 地址
+const dockerHubAPIHost = "registry-1.docker.io"
+
+// ListCredentials 获取所有登记的仓库凭据（不包含密码）
+func (s *ImageService) ListCredentials() ([]models.RegistryCredentialResponse, error) {
+	var credentials []models.RegistryCredential
+	if err := database.DB.Order("registry").Find(&credentials).Error; err != nil {
+		return nil, fmt.Errorf("获取仓库凭据失败: %w", err)
+	}
+
+	responses := make([]models.RegistryCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		responses = append(responses, toCredentialResponse(credential))
+	}
+	return responses, nil
+}
+
+// CreateCredential 登记仓库凭据（每个仓库只能登记一个）
+func (s *ImageService) CreateCredential(req models.RegistryCredentialRequest) (*models.RegistryCredentialResponse, error) {
+	registry := docker_manager.NormalizeRegistry(req.Registry)
+	if registry == "" || strings.ContainsAny(registry, " \t") {
+		return nil, fmt.Errorf("仓库地址格式错误")
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.RegistryCredential{}).Where("registry = ?", registry).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("检查仓库凭据失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("仓库凭据已存在")
+	}
+
+	credential := models.RegistryCredential{
+		Registry: registry,
+		Username: strings.TrimSpace(req.Username),
+		Password: models.EncryptedString(req.Password),
+	}
+	if err := database.DB.Create(&credential).Error; err != nil {
+		return nil, fmt.Errorf("登记仓库凭据失败: %w", err)
+	}
+	utils.Info("仓库凭据已登记", zap.String("registry", credential.Registry), zap.String("username", credential.Username))
+
+	response := toCredentialResponse(credential)
+	return &response, nil
+}
+
+// UpdateCredential 修改仓库凭据（密码为空时保留原值）
+func (s *ImageService) UpdateCredential(credentialID uint, req models.RegistryCredentialUpdateRequest) (*models.RegistryCredentialResponse, error) {
+	credential, err := findCredential(credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if username := strings.TrimSpace(req.Username); username != "" {
+		credential.Username = username
+	}
+	if req.Password != "" {
+		credential.Password = models.EncryptedString(req.Password)
+	}
+	if err := database.DB.Save(credential).Error; err != nil {
+		return nil, fmt.Errorf("修改仓库凭据失败: %w", err)
+	}
+
+	response := toCredentialResponse(*credential)
+	return &response, nil
+}
+
+// DeleteCredential 删除仓库凭据
+func (s *ImageService) DeleteCredential(credentialID uint) error {
+	credential, err := findCredential(credentialID)
+	if err != nil {
+		return err
+	}
+	if err := database.DB.Delete(credential).Error; err != nil {
+		return fmt.Errorf("删除仓库凭据失败: %w", err)
+	}
+	utils.Info("仓库凭据已删除", zap.String("registry", credential.Registry))
+	return nil
+}
+
+// TestCredential 使用登记的凭据登录仓库（Docker Registry HTTP API V2），检查凭据是否有效
+func (s *ImageService) TestCredential(credentialID uint) error {
+	credential, err := findCredential(credentialID)
+	if err != nil {
+		return err
+	}
+
+	host := credential.Registry
+	if host == docker_manager.DockerHubRegistry {
+		host = dockerHubAPIHost
+	}
+	endpoint := "https://" + host + "/v2/"
+
+	// 先匿名访问，根据仓库返回的认证方式登录
+	resp, err := s.httpClient.Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("无法连接仓库: %v", err)
+	}
+	drainBody(resp)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// 仓库允许匿名访问，不需要凭据
+		return nil
+	case resp.StatusCode != http.StatusUnauthorized:
+		return fmt.Errorf("无法连接仓库: 返回状态 %d", resp.StatusCode)
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	var authorization string
+	switch scheme {
+	case "basic":
+		request, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+		request.SetBasicAuth(credential.Username, string(credential.Password))
+		authorization = request.Header.Get("Authorization")
+	case "bearer":
+		token, err := s.fetchToken(params, credential)
+		if err != nil {
+			return err
+		}
+		authorization = "Bearer " + token
+	default:
+		return fmt.Errorf("无法连接仓库: 不支持的认证方式 %q", scheme)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("无法连接仓库: %v", err)
+	}
+	request.Header.Set("Authorization", authorization)
+	resp, err = s.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("无法连接仓库: %v", err)
+	}
+	drainBody(resp)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("仓库凭据无效")
+	default:
+		return fmt.Errorf("无法连接仓库: 返回状态 %d", resp.StatusCode)
+	}
+}
+
+// fetchToken 使用凭据从仓库的认证服务获取访问令牌
+func (s *ImageService) fetchToken(params map[string]string, credential *models.RegistryCredential) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" || realm.Host == "" {
+		return "", fmt.Errorf("无法连接仓库: 认证地址格式错误")
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("account", credential.Username)
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("无法连接仓库: %v", err)
+	}
+	request.SetBasicAuth(credential.Username, string(credential.Password))
+	resp, err := s.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("无法连接仓库认证服务: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("仓库凭据无效")
+	default:
+		return "", fmt.Errorf("无法连接仓库认证服务: 返回状态 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("无法连接仓库认证服务: 响应格式错误")
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("无法连接仓库认证服务: 未返回令牌")
+}
+
+// parseChallenge 解析 WWW-Authenticate 头，返回小写的认证方式和参数
+// 如 Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return strings.ToLower(scheme), params
+}
+
+// drainBody 读取并关闭响应内容，以便复用连接
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+}
+
+// findCredential 按ID查找仓库凭据
+func findCredential(credentialID uint) (*models.RegistryCredential, error) {
+	var credential models.RegistryCredential
+	if err := database.DB.Where("id = ?", credentialID).First(&credential).Error; err != nil {
+		return nil, fmt.Errorf("仓库凭据不存在")
+	}
+	return &credential, nil
+}
+
+// toCredentialResponse 转换为仓库凭据响应（不包含密码）
+func toCredentialResponse(credential models.RegistryCredential) models.RegistryCredentialResponse {
+	return models.RegistryCredentialResponse{
+		ID:          credential.ID,
+		Registry:    credential.Registry,
+		Username:    credential.Username,
+		PasswordSet: credential.Password != "",
+		CreatedAt:   credential.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   credential.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
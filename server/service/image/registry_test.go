@@ -0,0 +1,124 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+)
+
+// newTestRegistry 模拟 Docker Registry 的 /v2/ 登录流程，bearer 为 true 时使用令牌认证
+func newTestRegistry(t *testing.T, bearer bool) *httptest.Server {
+	t.Helper()
+
+	var registry *httptest.Server
+	registry = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, hasBasic := r.BasicAuth()
+		validBasic := hasBasic && username == "ark" && password == "secret"
+
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("service") != "test-registry" || !validBasic {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token":"test-token"}`)
+		case "/v2/":
+			if bearer {
+				if r.Header.Get("Authorization") == "Bearer test-token" {
+					return
+				}
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, registry.URL))
+			} else {
+				if validBasic {
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	dbtest.Open(t)
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	service := NewImageService()
+
+	hub, err := service.CreateCredential(models.RegistryCredentialRequest{Registry: "https://index.docker.io/v1/", Username: "ark", Password: "secret"})
+	if err != nil || hub.Registry != "docker.io" || !hub.PasswordSet {
+		t.Fatalf("登记仓库凭据失败: %+v, %v", hub, err)
+	}
+	if _, err := service.CreateCredential(models.RegistryCredentialRequest{Registry: "registry-1.docker.io", Username: "ark", Password: "secret"}); err == nil || err.Error() != "仓库凭据已存在" {
+		t.Fatalf("重复登记应返回错误: %v", err)
+	}
+
+	// 密码为空时保留原密码
+	if _, err := service.UpdateCredential(hub.ID, models.RegistryCredentialUpdateRequest{Username: "ark2"}); err != nil {
+		t.Fatalf("修改仓库凭据失败: %v", err)
+	}
+	stored, _ := findCredential(hub.ID)
+	if stored.Username != "ark2" || stored.Password != "secret" {
+		t.Fatalf("修改后的仓库凭据错误: %+v", stored)
+	}
+
+	if err := service.DeleteCredential(hub.ID); err != nil {
+		t.Fatalf("删除仓库凭据失败: %v", err)
+	}
+	if err := service.DeleteCredential(hub.ID); err == nil || err.Error() != "仓库凭据不存在" {
+		t.Fatalf("删除不存在的凭据应返回错误: %v", err)
+	}
+}
+
+func TestTestCredential(t *testing.T) {
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+
+	for _, bearer := range []bool{false, true} {
+		t.Run(fmt.Sprintf("bearer=%v", bearer), func(t *testing.T) {
+			dbtest.Open(t)
+			registry := newTestRegistry(t, bearer)
+			service := NewImageService()
+			service.httpClient = registry.Client()
+
+			valid, err := service.CreateCredential(models.RegistryCredentialRequest{Registry: registry.URL, Username: "ark", Password: "secret"})
+			if err != nil {
+				t.Fatalf("登记仓库凭据失败: %v", err)
+			}
+			if err := service.TestCredential(valid.ID); err != nil {
+				t.Fatalf("有效的凭据测试失败: %v", err)
+			}
+
+			if _, err := service.UpdateCredential(valid.ID, models.RegistryCredentialUpdateRequest{Password: "wrong"}); err != nil {
+				t.Fatalf("修改仓库凭据失败: %v", err)
+			}
+			if err := service.TestCredential(valid.ID); err == nil || err.Error() != "仓库凭据无效" {
+				t.Fatalf("无效的凭据应返回错误: %v", err)
+			}
+
+			// 仓库不可访问
+			registry.Close()
+			if err := service.TestCredential(valid.ID); err == nil {
+				t.Fatal("仓库不可访问时应返回错误")
+			}
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:tbro98/ase-server:pull,push"`)
+	if scheme != "bearer" || params["realm"] != "https://auth.docker.io/token" || params["service"] != "registry.docker.io" || params["scope"] != "repository:tbro98/ase-server:pull,push" {
+		t.Fatalf("解析认证头错误: %s %v", scheme, params)
+	}
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	if scheme != "basic" || params["realm"] != "registry" {
+		t.Fatalf("解析认证头错误: %s %v", scheme, params)
+	}
+}
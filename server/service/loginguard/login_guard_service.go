@@ -0,0 +1,254 @@
+package loginguard
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 登录记录查询数量限制
+const (
+	defaultAttemptLimit = 100
+	maxAttemptLimit     = 1000
+)
+
+// maxUserAgentLength 保存的设备信息最大长度
+const maxUserAgentLength = 255
+
+// maxUsernameLength 保存的用户名最大长度（与 LoginThrottle.Value 的列长度一致）
+const maxUsernameLength = 191
+
+// LoginGuardService 登录防暴力破解服务
+// 按用户名和IP分别统计连续失败次数：每次失败后需要等待的时间翻倍，达到上限后锁定一段时间
+type LoginGuardService struct {
+	now func() time.Time // 当前时间，测试中可替换为固定时钟
+}
+
+// NewLoginGuardService 创建登录防暴力破解服务实例
+func NewLoginGuardService() *LoginGuardService {
+	return &LoginGuardService{now: time.Now}
+}
+
+// Check 检查是否允许本次登录尝试（在验证密码之前调用）
+// 返回: 需要等待的时间和错误信息，被拒绝的尝试也会记录到登录记录中
+func (s *LoginGuardService) Check(username, clientIP, userAgent string) (time.Duration, error) {
+	now := s.now()
+
+	var throttles []models.LoginThrottle
+	if err := database.DB.
+		Where("(kind = ? AND value = ?) OR (kind = ? AND value = ?)",
+			models.LoginThrottleKindUser, normalizeUsername(username), models.LoginThrottleKindIP, clientIP).
+		Find(&throttles).Error; err != nil {
+		return 0, fmt.Errorf("检查登录限制失败: %w", err)
+	}
+
+	var lockedFor, delayFor time.Duration
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			lockedFor = maxDuration(lockedFor, throttle.LockedUntil.Sub(now))
+		}
+		if throttle.NextAttemptAt.After(now) {
+			delayFor = maxDuration(delayFor, throttle.NextAttemptAt.Sub(now))
+		}
+	}
+
+	if lockedFor > 0 {
+		s.recordAttempt(username, clientIP, userAgent, false, models.LoginFailureLocked)
+		return lockedFor, fmt.Errorf("登录尝试次数过多，请在 %d 分钟后重试", int(math.Ceil(lockedFor.Minutes())))
+	}
+	if delayFor > 0 {
+		s.recordAttempt(username, clientIP, userAgent, false, models.LoginFailureLocked)
+		return delayFor, fmt.Errorf("登录过于频繁，请在 %d 秒后重试", int(math.Ceil(delayFor.Seconds())))
+	}
+	return 0, nil
+}
+
+// RecordFailure 记录一次失败的登录，并更新用户名和IP的失败计数
+// 用户名不存在时只按IP统计，避免为任意用户名创建限制记录
+func (s *LoginGuardService) RecordFailure(username, clientIP, userAgent, reason string) {
+	s.recordAttempt(username, clientIP, userAgent, false, reason)
+
+	now := s.now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if username := normalizeUsername(username); username != "" && reason != models.LoginFailureUnknownUser {
+			if err := registerFailure(tx, models.LoginThrottleKindUser, username, config.LoginMaxFailuresPerUser, now); err != nil {
+				return err
+			}
+		}
+		if clientIP != "" {
+			return registerFailure(tx, models.LoginThrottleKindIP, clientIP, config.LoginMaxFailuresPerIP, now)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Error("更新登录失败计数失败", zap.String("username", username), zap.String("ip", clientIP), zap.Error(err))
+	}
+}
+
+// RecordSuccess 记录一次成功的登录，并清除该用户名的失败计数
+// IP的失败计数不清除，避免攻击者用自己的账号登录来重置IP限制
+func (s *LoginGuardService) RecordSuccess(username, clientIP, userAgent string) {
+	s.recordAttempt(username, clientIP, userAgent, true, "")
+
+	if err := database.DB.
+		Where("kind = ? AND value = ?", models.LoginThrottleKindUser, normalizeUsername(username)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		utils.Error("清除登录失败计数失败", zap.String("username", username), zap.Error(err))
+	}
+}
+
+// Unlock 解除用户名的登录锁定和失败计数
+func (s *LoginGuardService) Unlock(username string) error {
+	if err := database.DB.
+		Where("kind = ? AND value = ?", models.LoginThrottleKindUser, normalizeUsername(username)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		return fmt.Errorf("解除锁定失败: %w", err)
+	}
+	return nil
+}
+
+// Prune 删除已失效的失败计数和超过保留期的登录记录（保留期为0时不删除登录记录）
+// 返回: 删除的失败计数和登录记录数量
+func (s *LoginGuardService) Prune() (int64, int64, error) {
+	now := s.now()
+
+	// 锁定和等待都已结束且超过统计窗口的失败计数不再影响登录
+	result := database.DB.
+		Where("(locked_until IS NULL OR locked_until <= ?) AND next_attempt_at <= ? AND last_failure_at <= ?",
+			now, now, now.Add(-config.LoginFailureWindow)).
+		Delete(&models.LoginThrottle{})
+	if result.Error != nil {
+		return 0, 0, fmt.Errorf("清理登录失败计数失败: %w", result.Error)
+	}
+	throttles := result.RowsAffected
+
+	if config.LoginAttemptRetention <= 0 {
+		return throttles, 0, nil
+	}
+	result = database.DB.Where("created_at < ?", now.Add(-config.LoginAttemptRetention)).Delete(&models.LoginAttempt{})
+	if result.Error != nil {
+		return throttles, 0, fmt.Errorf("清理登录记录失败: %w", result.Error)
+	}
+	return throttles, result.RowsAffected, nil
+}
+
+// ListAttempts 查询登录记录（按时间倒序）
+func (s *LoginGuardService) ListAttempts(query models.LoginAttemptQuery) ([]models.LoginAttempt, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAttemptLimit
+	}
+	if limit > maxAttemptLimit {
+		limit = maxAttemptLimit
+	}
+
+	db := database.DB.Model(&models.LoginAttempt{})
+	if query.Username != "" {
+		db = db.Where("username = ?", normalizeUsername(query.Username))
+	}
+	if query.IP != "" {
+		db = db.Where("ip = ?", query.IP)
+	}
+	if query.Success != nil {
+		db = db.Where("success = ?", *query.Success)
+	}
+
+	var attempts []models.LoginAttempt
+	if err := db.Order("id DESC").Limit(limit).Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("获取登录记录失败: %w", err)
+	}
+	return attempts, nil
+}
+
+// recordAttempt 写入登录记录
+func (s *LoginGuardService) recordAttempt(username, clientIP, userAgent string, success bool, reason string) {
+	attempt := models.LoginAttempt{
+		Username:  normalizeUsername(username),
+		IP:        clientIP,
+		UserAgent: truncate(userAgent, maxUserAgentLength),
+		Success:   success,
+		Reason:    reason,
+		CreatedAt: s.now(),
+	}
+	if err := database.DB.Create(&attempt).Error; err != nil {
+		utils.Error("写入登录记录失败", zap.String("username", username), zap.Error(err))
+	}
+	if !success {
+		utils.Warn("登录失败", zap.String("username", attempt.Username), zap.String("ip", clientIP), zap.String("reason", reason))
+	}
+}
+
+// registerFailure 增加一个统计维度的失败计数，并计算下次允许尝试的时间和锁定状态
+func registerFailure(tx *gorm.DB, kind, value string, maxFailures int, now time.Time) error {
+	var throttle models.LoginThrottle
+	err := tx.Where("kind = ? AND value = ?", kind, value).First(&throttle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		throttle = models.LoginThrottle{Kind: kind, Value: value}
+	} else if err != nil {
+		return err
+	}
+
+	// 超过统计窗口的失败不再累计
+	if now.Sub(throttle.LastFailureAt) > config.LoginFailureWindow {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.NextAttemptAt = now.Add(failureDelay(throttle.Failures))
+
+	if throttle.Failures >= maxFailures {
+		lockedUntil := now.Add(config.LoginLockoutDuration)
+		throttle.LockedUntil = &lockedUntil
+		throttle.Failures = 0
+		utils.Warn("登录尝试次数过多，已临时锁定", zap.String("kind", kind), zap.String("value", value), zap.Time("locked_until", lockedUntil))
+	}
+
+	return tx.Save(&throttle).Error
+}
+
+// failureDelay 计算第 n 次失败后需要等待的时间（指数增长，不超过最大值）
+func failureDelay(failures int) time.Duration {
+	if config.LoginDelayBase <= 0 || failures <= 0 {
+		return 0
+	}
+	delay := config.LoginDelayBase
+	for i := 1; i < failures && delay < config.LoginDelayMax; i++ {
+		delay *= 2
+	}
+	if delay > config.LoginDelayMax {
+		delay = config.LoginDelayMax
+	}
+	return delay
+}
+
+// normalizeUsername 规范化用户名（去除首尾空白）
+func normalizeUsername(username string) string {
+	return truncate(strings.TrimSpace(username), maxUsernameLength)
+}
+
+// maxDuration 返回较大的时长
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// truncate 按字符截断字符串
+func truncate(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength])
+}
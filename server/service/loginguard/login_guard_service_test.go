@@ -0,0 +1,226 @@
+package loginguard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// newTestService 创建使用固定时钟的服务，返回推进时钟的函数
+func newTestService(start time.Time) (*LoginGuardService, func(time.Duration)) {
+	current := start
+	service := NewLoginGuardService()
+	service.now = func() time.Time { return current }
+	return service, func(d time.Duration) { current = current.Add(d) }
+}
+
+func TestFailureDelay(t *testing.T) {
+	config.LoginDelayBase = time.Second
+	config.LoginDelayMax = 10 * time.Second
+
+	expected := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for failures, want := range expected {
+		if got := failureDelay(failures); got != want {
+			t.Errorf("第 %d 次失败: 期望等待 %s，实际 %s", failures, want, got)
+		}
+	}
+}
+
+func TestProgressiveDelayAndLockout(t *testing.T) {
+	dbtest.Open(t)
+	config.LoginMaxFailuresPerUser = 3
+	config.LoginMaxFailuresPerIP = 100
+	config.LoginFailureWindow = 15 * time.Minute
+	config.LoginLockoutDuration = 10 * time.Minute
+	config.LoginDelayBase = time.Second
+	config.LoginDelayMax = 30 * time.Second
+	service, advance := newTestService(time.Unix(1700000000, 0))
+
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+
+	// 等待时间未到时直接拒绝
+	wait, err := service.Check("alice", "10.0.0.2", "")
+	if err == nil || wait != time.Second {
+		t.Fatalf("第一次失败后应等待1秒，实际为 %s, %v", wait, err)
+	}
+	advance(time.Second)
+	if _, err := service.Check("alice", "10.0.0.2", ""); err != nil {
+		t.Fatalf("等待结束后应允许登录: %v", err)
+	}
+
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	advance(2 * time.Second)
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+
+	// 达到失败上限后锁定，其他IP也无法登录该用户
+	wait, err = service.Check("alice", "10.0.0.3", "")
+	if err == nil || wait != 10*time.Minute {
+		t.Fatalf("应锁定10分钟，实际为 %s, %v", wait, err)
+	}
+	// 其他用户不受影响（IP未达到上限）
+	advance(time.Minute)
+	if _, err := service.Check("bob", "10.0.0.1", ""); err != nil {
+		t.Fatalf("其他用户不应被锁定: %v", err)
+	}
+
+	advance(9 * time.Minute)
+	if _, err := service.Check("alice", "10.0.0.1", ""); err != nil {
+		t.Fatalf("锁定结束后应允许登录: %v", err)
+	}
+
+	// 被拒绝的尝试也会记录
+	var blocked int64
+	database.DB.Model(&models.LoginAttempt{}).Where("reason = ?", models.LoginFailureLocked).Count(&blocked)
+	if blocked != 2 {
+		t.Fatalf("应记录2次被拒绝的尝试，实际为 %d", blocked)
+	}
+}
+
+func TestIPLockout(t *testing.T) {
+	dbtest.Open(t)
+	config.LoginMaxFailuresPerUser = 100
+	config.LoginMaxFailuresPerIP = 3
+	config.LoginFailureWindow = 15 * time.Minute
+	config.LoginLockoutDuration = 10 * time.Minute
+	config.LoginDelayBase = 0
+	service, _ := newTestService(time.Unix(1700000000, 0))
+
+	// 同一IP尝试不同用户名
+	for _, username := range []string{"a", "b", "c"} {
+		service.RecordFailure(username, "10.0.0.1", "", models.LoginFailureUnknownUser)
+	}
+
+	if _, err := service.Check("d", "10.0.0.1", ""); err == nil {
+		t.Fatal("IP达到失败上限后应被锁定")
+	}
+	// 不存在的用户名只按IP统计，不创建用户名的失败计数
+	var userThrottles int64
+	database.DB.Model(&models.LoginThrottle{}).Where("kind = ?", models.LoginThrottleKindUser).Count(&userThrottles)
+	if userThrottles != 0 {
+		t.Fatalf("不应为不存在的用户名创建失败计数，实际为 %d 条", userThrottles)
+	}
+	if _, err := service.Check("d", "10.0.0.2", ""); err != nil {
+		t.Fatalf("其他IP不应被锁定: %v", err)
+	}
+}
+
+func TestLongUsername(t *testing.T) {
+	dbtest.Open(t)
+	config.LoginMaxFailuresPerUser = 1
+	config.LoginMaxFailuresPerIP = 100
+	config.LoginLockoutDuration = time.Hour
+	config.LoginDelayBase = 0
+	service, _ := newTestService(time.Unix(1700000000, 0))
+
+	// 超长用户名截断到列长度，截断后仍能匹配到失败计数
+	username := strings.Repeat("a", 250)
+	service.RecordFailure(username, "10.0.0.1", "", models.LoginFailureBadPassword)
+	var throttle models.LoginThrottle
+	if err := database.DB.Where("kind = ?", models.LoginThrottleKindUser).First(&throttle).Error; err != nil || len(throttle.Value) != maxUsernameLength {
+		t.Fatalf("用户名应截断到 %d 个字符: %d, %v", maxUsernameLength, len(throttle.Value), err)
+	}
+	if _, err := service.Check(username, "10.0.0.2", ""); err == nil {
+		t.Fatal("超长用户名达到失败上限后应被锁定")
+	}
+}
+
+func TestFailureWindowAndUnlock(t *testing.T) {
+	dbtest.Open(t)
+	config.LoginMaxFailuresPerUser = 2
+	config.LoginMaxFailuresPerIP = 100
+	config.LoginFailureWindow = 5 * time.Minute
+	config.LoginLockoutDuration = time.Hour
+	config.LoginDelayBase = 0
+	service, advance := newTestService(time.Unix(1700000000, 0))
+
+	// 超过统计窗口的失败不累计
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	advance(6 * time.Minute)
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	if _, err := service.Check("alice", "10.0.0.1", ""); err != nil {
+		t.Fatalf("窗口外的失败不应累计: %v", err)
+	}
+
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	if _, err := service.Check("alice", "10.0.0.1", ""); err == nil {
+		t.Fatal("窗口内连续失败应被锁定")
+	}
+
+	if err := service.Unlock("alice"); err != nil {
+		t.Fatalf("解除锁定失败: %v", err)
+	}
+	if _, err := service.Check("alice", "10.0.0.1", ""); err != nil {
+		t.Fatalf("解除锁定后应允许登录: %v", err)
+	}
+
+	// 登录成功清除用户名的失败计数
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	service.RecordSuccess("alice", "10.0.0.1", "")
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	if _, err := service.Check("alice", "10.0.0.1", ""); err != nil {
+		t.Fatalf("登录成功后失败计数应重置: %v", err)
+	}
+
+	success := true
+	attempts, err := service.ListAttempts(models.LoginAttemptQuery{Username: "alice", Success: &success})
+	if err != nil || len(attempts) != 1 {
+		t.Fatalf("应有1条成功记录: %v, %v", attempts, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dbtest.Open(t)
+	config.LoginMaxFailuresPerUser = 2
+	config.LoginMaxFailuresPerIP = 100
+	config.LoginFailureWindow = 15 * time.Minute
+	config.LoginLockoutDuration = time.Hour
+	config.LoginDelayBase = 0
+	config.LoginAttemptRetention = 24 * time.Hour
+	service, advance := newTestService(time.Unix(1700000000, 0))
+
+	// alice 被锁定一小时，bob 只失败一次
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	service.RecordFailure("alice", "10.0.0.1", "", models.LoginFailureBadPassword)
+	service.RecordFailure("bob", "10.0.0.2", "", models.LoginFailureBadPassword)
+
+	// 统计窗口结束后只清理已不影响登录的计数，锁定中的保留
+	advance(20 * time.Minute)
+	throttles, attempts, err := service.Prune()
+	if err != nil || throttles != 3 || attempts != 0 {
+		t.Fatalf("应清理 bob 和两个IP的失败计数，实际为 %d, %d, %v", throttles, attempts, err)
+	}
+	if _, err := service.Check("alice", "10.0.0.3", ""); err == nil {
+		t.Fatal("锁定中的失败计数不应被清理")
+	}
+
+	// 超过保留期的登录记录被删除
+	advance(25 * time.Hour)
+	service.RecordSuccess("carol", "10.0.0.4", "")
+	throttles, attempts, err = service.Prune()
+	if err != nil || throttles != 1 || attempts != 4 {
+		t.Fatalf("应清理锁定结束的计数和过期的登录记录，实际为 %d, %d, %v", throttles, attempts, err)
+	}
+	var remaining []models.LoginAttempt
+	database.DB.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].Username != "carol" {
+		t.Fatalf("应保留保留期内的登录记录: %+v", remaining)
+	}
+
+	// 保留期为0时不删除登录记录
+	config.LoginAttemptRetention = 0
+	advance(48 * time.Hour)
+	if _, attempts, err := service.Prune(); err != nil || attempts != 0 {
+		t.Fatalf("保留期为0时不应删除登录记录，实际为 %d, %v", attempts, err)
+	}
+}
@@ -0,0 +1,69 @@
+package loginguard
+
+import (
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// pruneInterval 清理登录记录和失败计数的间隔
+const pruneInterval = time.Hour
+
+// Pruner 定时清理已失效的失败计数和超过保留期的登录记录
+type Pruner struct {
+	service *LoginGuardService
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewPruner 创建登录记录清理器
+func NewPruner(service *LoginGuardService) *Pruner {
+	return &Pruner{
+		service: service,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start 在后台立即清理一次并定时清理
+func (p *Pruner) Start() {
+	utils.Info("登录记录自动清理已启动", zap.Duration("retention", config.LoginAttemptRetention))
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.prune()
+
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.prune()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台清理
+func (p *Pruner) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// prune 清理一次
+func (p *Pruner) prune() {
+	throttles, attempts, err := p.service.Prune()
+	if err != nil {
+		utils.Error("清理登录记录失败", zap.Error(err))
+		return
+	}
+	if throttles > 0 || attempts > 0 {
+		utils.Info("登录记录自动清理完成", zap.Int64("throttles", throttles), zap.Int64("attempts", attempts))
+	}
+}
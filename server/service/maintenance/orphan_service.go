@@ -0,0 +1,310 @@
+package maintenance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/node"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// orphanMinAge 创建不久的资源不视为遗留资源（服务器可能正在创建，数据库记录尚未提交）
+var orphanMinAge = 10 * time.Minute
+
+// localNodeName 面板所在主机未登记为节点时显示的名称
+const localNodeName = "本机"
+
+// MaintenanceService 维护服务（遗留资源扫描和清理）
+type MaintenanceService struct {
+	nodeService *node.NodeService
+}
+
+// NewMaintenanceService 创建维护服务实例
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{nodeService: node.NewNodeService()}
+}
+
+// orphan 扫描到的遗留资源
+type orphan struct {
+	models.OrphanResource
+	resource      docker_manager.ManagedResource
+	dockerManager *docker_manager.DockerManager
+}
+
+// scanTarget 需要扫描的Docker主机
+type scanTarget struct {
+	nodeID uint
+	name   string
+}
+
+// FindOrphans 扫描所有节点上的遗留容器和卷
+func (s *MaintenanceService) FindOrphans() (*models.OrphanReport, error) {
+	orphans, scanErrors, err := s.scan(nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.OrphanReport{
+		ManagerInstance: config.ManagerInstance,
+		GracePeriod:     config.OrphanGracePeriod.String(),
+		Resources:       make([]models.OrphanResource, 0, len(orphans)),
+		Errors:          scanErrors,
+	}
+	for _, item := range orphans {
+		report.Resources = append(report.Resources, item.OrphanResource)
+	}
+	return report, nil
+}
+
+// CleanupOrphans 清理遗留资源（先删除容器再删除卷，卷可以先备份）
+// 清理前重新扫描，只删除此时仍是遗留资源的容器和卷
+func (s *MaintenanceService) CleanupOrphans(req models.OrphanCleanupRequest) (*models.OrphanCleanupResult, error) {
+	now := time.Now()
+	orphans, scanErrors, err := s.scan(req.NodeID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(req.Names))
+	for _, name := range req.Names {
+		names[name] = true
+	}
+
+	result := &models.OrphanCleanupResult{
+		DryRun: req.DryRun,
+		Items:  []models.OrphanCleanupItem{},
+		Errors: scanErrors,
+	}
+	for _, item := range orphans {
+		if len(names) > 0 && !names[item.Name] {
+			continue
+		}
+
+		cleanupItem := models.OrphanCleanupItem{OrphanResource: item.OrphanResource}
+		if !req.DryRun {
+			cleanupItem.BackupPath, err = s.cleanup(item, req.Backup, now)
+			if err != nil {
+				cleanupItem.Error = err.Error()
+				result.Failed++
+			} else {
+				cleanupItem.Removed = true
+				result.Removed++
+			}
+		}
+		result.Items = append(result.Items, cleanupItem)
+	}
+
+	if !req.DryRun {
+		utils.Info("遗留资源清理完成", zap.Int("removed", result.Removed), zap.Int("failed", result.Failed))
+	}
+	return result, nil
+}
+
+// cleanup 删除单个遗留资源，backup 为 true 时先备份卷
+// 返回: 备份文件路径和错误信息（备份失败时不删除卷）
+func (s *MaintenanceService) cleanup(item orphan, backup bool, now time.Time) (string, error) {
+	backupPath := ""
+	if backup && item.Kind == docker_manager.ResourceVolume {
+		path, err := backupVolume(item, now)
+		if err != nil {
+			return "", err
+		}
+		backupPath = path
+	}
+
+	if err := item.dockerManager.RemoveManagedResource(item.resource); err != nil {
+		return backupPath, err
+	}
+	return backupPath, nil
+}
+
+// backupDir 节点的卷备份目录
+func backupDir(nodeID uint) string {
+	return filepath.Join(config.OrphanBackupDir, fmt.Sprintf("node-%d", nodeID))
+}
+
+// VolumeBackups 清理遗留资源时为卷创建的备份文件
+// nodeID: 卷所在的Docker主机（Docker管理器的节点ID，0 表示面板所在主机）
+func VolumeBackups(nodeID uint, volumeName string) ([]string, error) {
+	return filepath.Glob(filepath.Join(backupDir(nodeID), volumeName+"-*.tar.gz"))
+}
+
+// backupVolume 将卷备份到 ORPHAN_BACKUP_DIR/node-<节点ID>/<卷名称>-<时间>.tar.gz
+func backupVolume(item orphan, now time.Time) (string, error) {
+	dir := backupDir(item.NodeID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.tar.gz", item.Name, now.Format("20060102-150405")))
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("创建备份文件失败: %v", err)
+	}
+	backupErr := item.dockerManager.BackupVolume(item.Name, file)
+	if closeErr := file.Close(); backupErr == nil && closeErr != nil {
+		backupErr = fmt.Errorf("写入卷备份失败: %v", closeErr)
+	}
+	if backupErr != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("备份卷失败，未删除: %v", backupErr)
+	}
+
+	utils.Info("遗留Docker卷已备份", zap.String("volume", item.Name), zap.String("path", path))
+	return path, nil
+}
+
+// scan 扫描节点上的遗留资源
+// nodeID: 只扫描该节点，为空时扫描所有节点
+// 返回: 遗留资源、无法扫描的节点和错误信息
+func (s *MaintenanceService) scan(nodeID *uint, now time.Time) ([]orphan, []models.OrphanScanError, error) {
+	targets, err := s.targets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orphans := []orphan{}
+	scanErrors := []models.OrphanScanError{}
+	for _, target := range targets {
+		if nodeID != nil && *nodeID != target.nodeID {
+			continue
+		}
+
+		found, err := scanNode(target, now)
+		if err != nil {
+			utils.Warn("扫描节点遗留资源失败", zap.String("node", target.name), zap.Error(err))
+			scanErrors = append(scanErrors, models.OrphanScanError{NodeID: target.nodeID, NodeName: target.name, Error: err.Error()})
+			continue
+		}
+		orphans = append(orphans, found...)
+	}
+	return orphans, scanErrors, nil
+}
+
+// scanNode 扫描单个节点上的遗留资源
+func scanNode(target scanTarget, now time.Time) ([]orphan, error) {
+	dockerManager, err := docker_manager.GetNodeManager(target.nodeID)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := dockerManager.ListManagedResources()
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询资源所属的服务器（包括已删除的服务器）
+	var serverIDs []uint
+	for _, resource := range resources {
+		if resource.ServerID != 0 {
+			serverIDs = append(serverIDs, resource.ServerID)
+		}
+	}
+	servers := make(map[uint]models.Server)
+	if len(serverIDs) > 0 {
+		var rows []models.Server
+		if err := database.DB.Unscoped().Select("id", "deleted_at").Where("id IN ?", serverIDs).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("获取服务器列表失败: %v", err)
+		}
+		for _, server := range rows {
+			servers[server.ID] = server
+		}
+	}
+
+	var orphans []orphan
+	for _, resource := range resources {
+		// 创建时间未知的资源按已超过最短时间处理
+		if !resource.CreatedAt.IsZero() && now.Sub(resource.CreatedAt) < orphanMinAge {
+			continue
+		}
+
+		reason := orphanReason(resource, servers, now)
+		if reason == "" {
+			continue
+		}
+
+		item := orphan{
+			OrphanResource: models.OrphanResource{
+				NodeID:   target.nodeID,
+				NodeName: target.name,
+				Kind:     resource.Kind,
+				Name:     resource.Name,
+				Role:     resource.Role,
+				ServerID: resource.ServerID,
+				Reason:   reason,
+				Legacy:   resource.Legacy,
+			},
+			resource:      resource,
+			dockerManager: dockerManager,
+		}
+		if !resource.CreatedAt.IsZero() {
+			item.CreatedAt = resource.CreatedAt.Format(time.RFC3339)
+		}
+		orphans = append(orphans, item)
+	}
+	return orphans, nil
+}
+
+// orphanReason 判断资源是否为遗留资源，返回原因（不是遗留资源时为空）
+func orphanReason(resource docker_manager.ManagedResource, servers map[uint]models.Server, now time.Time) string {
+	if resource.ServerID == 0 {
+		if resource.Role == docker_manager.RoleBackup || resource.Role == docker_manager.RoleProbe {
+			return "遗留的临时容器"
+		}
+		return "无法识别所属服务器"
+	}
+
+	server, ok := servers[resource.ServerID]
+	if !ok {
+		return "服务器不存在（可能是创建失败时遗留）"
+	}
+	if !server.DeletedAt.Valid {
+		return ""
+	}
+	// 回收站中仍可恢复的服务器（保留期为0时一直可恢复）需要保留容器和卷
+	deletedFor := now.Sub(server.DeletedAt.Time)
+	if config.TrashRetention <= 0 || deletedFor < config.TrashRetention {
+		return ""
+	}
+	if deletedFor >= config.OrphanGracePeriod {
+		return fmt.Sprintf("服务器已于 %s 删除，超过保留期", server.DeletedAt.Time.Format("2006-01-02 15:04:05"))
+	}
+	return ""
+}
+
+// targets 需要扫描的Docker主机：面板所在主机（有服务器使用时）和所有远程节点
+func (s *MaintenanceService) targets() ([]scanTarget, error) {
+	var nodes []models.Node
+	if err := database.DB.Order("id").Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	usesLocalHost, err := s.nodeService.UsesLocalHost()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []scanTarget
+	if usesLocalHost {
+		name := localNodeName
+		for _, n := range nodes {
+			if n.IsLocal() {
+				name = n.Name
+				break
+			}
+		}
+		targets = append(targets, scanTarget{nodeID: 0, name: name})
+	}
+	for _, n := range nodes {
+		if !n.IsLocal() {
+			targets = append(targets, scanTarget{nodeID: n.ID, name: n.Name})
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,340 @@
+package maintenance
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/docker_manager/dockertest"
+	"ark-server-commander/utils"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// setupTest 创建内存数据库和模拟Docker客户端
+// 服务器 1 正常，服务器 2 刚删除（保留期内），服务器 3 已删除超过保留期，服务器 4 不存在
+func setupTest(t *testing.T) *dockertest.FakeClient {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	db := dbtest.Open(t)
+
+	now := time.Now()
+	for id, deletedAt := range map[uint]*time.Time{1: nil, 2: timePtr(now.Add(-time.Hour)), 3: timePtr(now.Add(-30 * 24 * time.Hour))} {
+		server := models.Server{ID: id, Identifier: fmt.Sprintf("server-%d", id), SessionName: "test", Port: 7777 + int(id)}
+		if err := db.Create(&server).Error; err != nil {
+			t.Fatalf("创建服务器失败: %v", err)
+		}
+		if deletedAt != nil {
+			if err := db.Model(&server).Update("deleted_at", *deletedAt).Error; err != nil {
+				t.Fatalf("删除服务器失败: %v", err)
+			}
+		}
+	}
+
+	oldMinAge := orphanMinAge
+	orphanMinAge = 0
+	t.Cleanup(func() { orphanMinAge = oldMinAge })
+
+	fake := dockertest.NewFakeClient("alpine:latest", "tbro98/ase-server:latest")
+	docker_manager.SetDockerClient(fake)
+	t.Cleanup(docker_manager.RemoveAllHelpers)
+	return fake
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// labels 面板资源标签
+func labels(manager string, serverID uint, role string) map[string]string {
+	result := map[string]string{
+		docker_manager.ManagerLabel: manager,
+		docker_manager.RoleLabel:    role,
+	}
+	if serverID != 0 {
+		result[docker_manager.ServerIDLabel] = strconv.FormatUint(uint64(serverID), 10)
+	}
+	return result
+}
+
+// createVolume 创建带标签的卷（labels 为空时模拟添加标签之前创建的卷）
+func createVolume(t *testing.T, fake *dockertest.FakeClient, name string, labels map[string]string) {
+	t.Helper()
+	if _, err := fake.VolumeCreate(context.Background(), volume.CreateOptions{Name: name, Labels: labels}); err != nil {
+		t.Fatalf("创建卷失败: %v", err)
+	}
+}
+
+// createContainer 创建挂载指定卷的容器
+func createContainer(t *testing.T, fake *dockertest.FakeClient, name string, labels map[string]string, volumeName string) {
+	t.Helper()
+	hostConfig := &container.HostConfig{}
+	if volumeName != "" {
+		hostConfig.Binds = []string{volumeName + ":/data"}
+	}
+	if _, err := fake.ContainerCreate(context.Background(), &container.Config{Image: "tbro98/ase-server:latest", Labels: labels}, hostConfig, nil, nil, name); err != nil {
+		t.Fatalf("创建容器失败: %v", err)
+	}
+}
+
+// seedResources 为各服务器创建容器和卷
+func seedResources(t *testing.T, fake *dockertest.FakeClient) {
+	t.Helper()
+	instance := config.ManagerInstance
+	for _, id := range []uint{1, 2, 3, 4} {
+		volumeName := utils.GetServerVolumeName(id)
+		createVolume(t, fake, volumeName, labels(instance, id, docker_manager.RoleSaved))
+		createVolume(t, fake, utils.GetServerPluginsVolumeName(id), labels(instance, id, docker_manager.RolePlugins))
+		if id != 3 {
+			createContainer(t, fake, utils.GetServerContainerName(id), labels(instance, id, docker_manager.RoleServer), volumeName)
+		}
+	}
+	// 其他面板实例创建的资源
+	createVolume(t, fake, "ase-server-plugins-5", labels("other", 5, docker_manager.RolePlugins))
+	// 添加标签之前创建的卷
+	createVolume(t, fake, utils.GetServerVolumeName(6), nil)
+	// 与面板无关的卷
+	createVolume(t, fake, "ase-server-6x", nil)
+	// 备份卷时遗留的临时容器
+	createContainer(t, fake, "leaked-backup", labels(instance, 0, docker_manager.RoleBackup), "")
+	if err := fake.WriteVolumeFile(utils.GetServerVolumeName(4), "SavedArks/TheIsland.ark", []byte("map data")); err != nil {
+		t.Fatalf("写入卷文件失败: %v", err)
+	}
+}
+
+func resourceNames(resources []models.OrphanResource) []string {
+	names := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		names = append(names, resource.Kind+":"+resource.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFindOrphans(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+
+	report, err := NewMaintenanceService().FindOrphans()
+	if err != nil {
+		t.Fatalf("扫描遗留资源失败: %v", err)
+	}
+
+	want := []string{
+		"container:ase-server-4",
+		"container:leaked-backup",
+		"volume:ase-server-3",
+		"volume:ase-server-4",
+		"volume:ase-server-6",
+		"volume:ase-server-plugins-3",
+		"volume:ase-server-plugins-4",
+	}
+	got := resourceNames(report.Resources)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("遗留资源 = %v，期望 %v", got, want)
+	}
+	if report.ManagerInstance != config.ManagerInstance || len(report.Errors) != 0 {
+		t.Errorf("扫描结果 = %+v", report)
+	}
+	for _, resource := range report.Resources {
+		if resource.Name == "ase-server-6" && (!resource.Legacy || resource.ServerID != 6 || resource.Role != docker_manager.RoleSaved) {
+			t.Errorf("未按名称识别旧卷: %+v", resource)
+		}
+		if resource.NodeName != localNodeName {
+			t.Errorf("节点名称 = %q，期望 %q", resource.NodeName, localNodeName)
+		}
+	}
+
+	// 创建不久的资源不视为遗留资源
+	orphanMinAge = time.Hour
+	report, err = NewMaintenanceService().FindOrphans()
+	if err != nil {
+		t.Fatalf("扫描遗留资源失败: %v", err)
+	}
+	if len(report.Resources) != 0 {
+		t.Errorf("新创建的资源不应视为遗留资源: %v", resourceNames(report.Resources))
+	}
+}
+
+func TestFindOrphansSkipsTrash(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+	oldRetention := config.TrashRetention
+	t.Cleanup(func() { config.TrashRetention = oldRetention })
+
+	// 回收站保留期长于遗留资源宽限期或不自动清理时，服务器 3 仍可恢复，其卷不是遗留资源
+	for _, retention := range []time.Duration{60 * 24 * time.Hour, 0} {
+		config.TrashRetention = retention
+		report, err := NewMaintenanceService().FindOrphans()
+		if err != nil {
+			t.Fatalf("扫描遗留资源失败: %v", err)
+		}
+		for _, resource := range report.Resources {
+			if resource.ServerID == 3 {
+				t.Errorf("保留期 %s 内的服务器资源不应视为遗留资源: %+v", retention, resource)
+			}
+		}
+	}
+}
+
+func TestFindOrphansNodeError(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+	fake.FailOn("VolumeList", "", fmt.Errorf("connection refused"))
+
+	report, err := NewMaintenanceService().FindOrphans()
+	if err != nil {
+		t.Fatalf("扫描遗留资源失败: %v", err)
+	}
+	if len(report.Resources) != 0 || len(report.Errors) != 1 || report.Errors[0].NodeID != 0 {
+		t.Errorf("无法扫描的节点应记录错误: %+v", report)
+	}
+}
+
+func TestCleanupOrphansDryRun(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+	volumes, containers := len(fake.VolumeNames()), len(fake.ContainerNames())
+
+	result, err := NewMaintenanceService().CleanupOrphans(models.OrphanCleanupRequest{DryRun: true})
+	if err != nil {
+		t.Fatalf("预览清理失败: %v", err)
+	}
+	if len(result.Items) != 7 || result.Removed != 0 {
+		t.Errorf("预览结果 = %+v", result)
+	}
+	if len(fake.VolumeNames()) != volumes || len(fake.ContainerNames()) != containers {
+		t.Error("预览时不应删除任何资源")
+	}
+}
+
+func TestCleanupOrphans(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+	config.OrphanBackupDir = t.TempDir()
+	t.Cleanup(func() { config.OrphanBackupDir = "backups/orphans" })
+
+	nodeID := uint(0)
+	result, err := NewMaintenanceService().CleanupOrphans(models.OrphanCleanupRequest{
+		Backup: true,
+		NodeID: &nodeID,
+		Names:  []string{"ase-server-4", "ase-server-plugins-4", "leaked-backup"},
+	})
+	if err != nil {
+		t.Fatalf("清理遗留资源失败: %v", err)
+	}
+	if result.Removed != 4 || result.Failed != 0 {
+		t.Fatalf("清理结果 = %+v", result)
+	}
+
+	// 容器先于卷删除，挂载中的卷也能删除
+	want := "[ase-server-1 ase-server-2]"
+	if got := fmt.Sprint(fake.ContainerNames()); got != want {
+		t.Errorf("剩余容器 = %s，期望 %s", got, want)
+	}
+	for _, name := range fake.VolumeNames() {
+		if name == "ase-server-4" || name == "ase-server-plugins-4" {
+			t.Errorf("卷 %s 未被删除", name)
+		}
+	}
+
+	var backupPath string
+	for _, item := range result.Items {
+		if item.Kind == docker_manager.ResourceContainer && item.BackupPath != "" {
+			t.Errorf("容器不应备份: %+v", item)
+		}
+		if item.Name == "ase-server-4" && item.Kind == docker_manager.ResourceVolume {
+			backupPath = item.BackupPath
+		}
+	}
+	if backupPath == "" {
+		t.Fatal("删除卷之前未备份")
+	}
+	if files := readBackup(t, backupPath); files["backup/SavedArks/TheIsland.ark"] != "map data" {
+		t.Errorf("备份内容 = %v", files)
+	}
+	// 备份用的临时容器已删除
+	for _, name := range fake.ContainerNames() {
+		if name != "ase-server-1" && name != "ase-server-2" {
+			t.Errorf("遗留容器 %s", name)
+		}
+	}
+}
+
+func TestCleanupOrphansBackupFailure(t *testing.T) {
+	fake := setupTest(t)
+	seedResources(t, fake)
+	config.OrphanBackupDir = t.TempDir()
+	t.Cleanup(func() { config.OrphanBackupDir = "backups/orphans" })
+	fake.FailOn("CopyFromContainer", "", fmt.Errorf("disk error"))
+
+	result, err := NewMaintenanceService().CleanupOrphans(models.OrphanCleanupRequest{
+		Backup: true,
+		Names:  []string{"ase-server-3"},
+	})
+	if err != nil {
+		t.Fatalf("清理遗留资源失败: %v", err)
+	}
+	if result.Failed != 1 || result.Removed != 0 || result.Items[0].Error == "" {
+		t.Fatalf("清理结果 = %+v", result)
+	}
+	found := false
+	for _, name := range fake.VolumeNames() {
+		found = found || name == "ase-server-3"
+	}
+	if !found {
+		t.Error("备份失败时不应删除卷")
+	}
+	entries, _ := os.ReadDir(config.OrphanBackupDir + "/node-0")
+	if len(entries) != 0 {
+		t.Errorf("备份失败时应删除不完整的备份文件: %v", entries)
+	}
+}
+
+// readBackup 读取 tar.gz 备份中的文件
+func readBackup(t *testing.T, path string) map[string]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开备份失败: %v", err)
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("解压备份失败: %v", err)
+	}
+	files := make(map[string]string)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取备份失败: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			data, _ := io.ReadAll(tarReader)
+			files[header.Name] = string(data)
+		}
+	}
+	return files
+}
@@ -0,0 +1,267 @@
+package maintenance
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/permission"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// 归档文件类型
+const (
+	ArchiveVolumeBackup   = "volume_backup"   // 清理遗留卷时的备份
+	ArchiveDatabaseBackup = "database_backup" // 数据库迁移前的备份
+)
+
+// GetServerStorage 获取服务器卷的占用空间、卷备份和卷所在磁盘的空间
+// userID: 当前用户ID（只能查看有权限的服务器）
+func (s *MaintenanceService) GetServerStorage(userID uint, serverID string) (*models.ServerStorage, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+	var server models.Server
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+	volumes, disk, err := dockerManager.VolumeUsage(server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &models.ServerStorage{
+		ServerID:   server.ID,
+		Identifier: server.Identifier,
+		NodeID:     server.NodeID,
+		Volumes:    volumes,
+		Archives:   []models.ArchiveFile{},
+		Disk:       disk,
+		Warnings:   []string{},
+	}
+	var volumesSize int64
+	for _, volume := range volumes {
+		volumesSize += volume.Size
+		backups, err := VolumeBackups(dockerManager.NodeID(), volume.Name)
+		if err != nil {
+			continue
+		}
+		storage.Archives = append(storage.Archives, archiveFiles(backups, ArchiveVolumeBackup)...)
+	}
+	storage.Total = volumesSize
+	for _, archive := range storage.Archives {
+		storage.Total += archive.Size
+	}
+
+	if warning := diskWarning(server.Identifier+" 所在节点", disk); warning != "" {
+		storage.Warnings = append(storage.Warnings, warning)
+	}
+	if warning := volumeWarning(server.Identifier, volumesSize); warning != "" {
+		storage.Warnings = append(storage.Warnings, warning)
+	}
+	return storage, nil
+}
+
+// GetStorageReport 获取所有节点的磁盘空间、Docker占用、各服务器卷的大小和面板保存的归档文件
+// 无法访问的节点记录错误信息，不影响其他节点
+func (s *MaintenanceService) GetStorageReport() (*models.StorageReport, error) {
+	targets, err := s.targets()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.StorageReport{
+		Nodes:    []models.NodeStorage{},
+		Warnings: []string{},
+	}
+	for _, target := range targets {
+		nodeStorage, warnings := nodeStorage(target)
+		report.Nodes = append(report.Nodes, nodeStorage)
+		report.Warnings = append(report.Warnings, warnings...)
+	}
+
+	report.Archives = listArchives()
+	for _, archive := range report.Archives {
+		report.ArchivesSize += archive.Size
+	}
+	return report, nil
+}
+
+// nodeStorage 获取单个节点的磁盘占用
+// 返回: 节点的磁盘占用和告警信息
+func nodeStorage(target scanTarget) (models.NodeStorage, []string) {
+	result := models.NodeStorage{NodeID: target.nodeID, NodeName: target.name, Servers: []models.ServerVolumeSize{}}
+	fail := func(err error) (models.NodeStorage, []string) {
+		utils.Warn("获取节点磁盘占用失败", zap.String("node", target.name), zap.Error(err))
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	dockerManager, err := docker_manager.GetNodeManager(target.nodeID)
+	if err != nil {
+		return fail(err)
+	}
+	dockerUsage, volumeSizes, err := dockerManager.DiskUsage()
+	if err != nil {
+		return fail(err)
+	}
+	result.Docker = dockerUsage
+
+	var warnings []string
+	disk, err := dockerManager.HostDiskSpace()
+	if err != nil {
+		// Docker 占用已获取，磁盘空间缺失时仍返回其他数据
+		utils.Warn("获取节点磁盘空间失败", zap.String("node", target.name), zap.Error(err))
+		result.Error = err.Error()
+	} else {
+		result.Disk = disk
+		if warning := diskWarning("节点 "+target.name, disk); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// 按卷名称归属到服务器
+	servers := make(map[uint]*models.ServerVolumeSize)
+	for name, size := range volumeSizes {
+		role, serverID, ok := docker_manager.IdentifyVolume(name)
+		if !ok {
+			continue
+		}
+		item, exists := servers[serverID]
+		if !exists {
+			item = &models.ServerVolumeSize{ServerID: serverID}
+			servers[serverID] = item
+		}
+		switch role {
+		case docker_manager.RoleSaved:
+			item.Saved = size
+		case docker_manager.RolePlugins:
+			item.Plugins = size
+		}
+	}
+	if len(servers) > 0 {
+		ids := make([]uint, 0, len(servers))
+		for id := range servers {
+			ids = append(ids, id)
+		}
+		var rows []models.Server
+		if err := database.DB.Select("id", "identifier").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return fail(fmt.Errorf("获取服务器列表失败: %v", err))
+		}
+		for _, server := range rows {
+			servers[server.ID].Identifier = server.Identifier
+		}
+	}
+	for _, item := range servers {
+		result.Servers = append(result.Servers, *item)
+		if item.Identifier != "" {
+			if warning := volumeWarning(item.Identifier, volumeTotal(*item)); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	sort.Slice(result.Servers, func(i, j int) bool {
+		a, b := volumeTotal(result.Servers[i]), volumeTotal(result.Servers[j])
+		if a != b {
+			return a > b
+		}
+		return result.Servers[i].ServerID < result.Servers[j].ServerID
+	})
+	sort.Strings(warnings)
+	return result, warnings
+}
+
+// volumeTotal 服务器卷的总大小（忽略无法统计的卷）
+func volumeTotal(item models.ServerVolumeSize) int64 {
+	var total int64
+	for _, size := range []int64{item.Saved, item.Plugins} {
+		if size > 0 {
+			total += size
+		}
+	}
+	return total
+}
+
+// listArchives 列出面板保存的归档文件：ORPHAN_BACKUP_DIR 中的卷备份和 SQLite 数据库迁移前的备份
+func listArchives() []models.ArchiveFile {
+	var paths []string
+	err := filepath.WalkDir(config.OrphanBackupDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tar.gz") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		utils.Warn("读取卷备份目录失败", zap.String("dir", config.OrphanBackupDir), zap.Error(err))
+	}
+	archives := archiveFiles(paths, ArchiveVolumeBackup)
+
+	if config.DBDriver == "sqlite" {
+		backups, err := filepath.Glob(config.DBPath + ".pre-migrate-*.bak")
+		if err == nil {
+			archives = append(archives, archiveFiles(backups, ArchiveDatabaseBackup)...)
+		}
+	}
+	return archives
+}
+
+// archiveFiles 读取归档文件的大小和修改时间（文件已被删除时跳过）
+func archiveFiles(paths []string, kind string) []models.ArchiveFile {
+	archives := make([]models.ArchiveFile, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		archives = append(archives, models.ArchiveFile{
+			Path:       path,
+			Kind:       kind,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime().Format("2006-01-02 15:04:05"),
+		})
+	}
+	return archives
+}
+
+// diskWarning 磁盘可用空间低于 STORAGE_WARN_FREE_PERCENT 时返回告警信息并写入日志
+func diskWarning(subject string, disk *models.DiskSpace) string {
+	if config.StorageWarnFreePercent <= 0 || disk == nil || disk.Total <= 0 {
+		return ""
+	}
+	freePercent := float64(disk.Available) * 100 / float64(disk.Total)
+	if freePercent >= float64(config.StorageWarnFreePercent) {
+		return ""
+	}
+	warning := fmt.Sprintf("%s的磁盘可用空间不足 %d%%（剩余 %s / 共 %s）", subject, config.StorageWarnFreePercent, utils.FormatSize(disk.Available), utils.FormatSize(disk.Total))
+	utils.Warn("磁盘可用空间不足", zap.String("subject", subject), zap.Int64("available", disk.Available), zap.Int64("total", disk.Total))
+	return warning
+}
+
+// volumeWarning 服务器卷总大小超过 STORAGE_WARN_VOLUME_MB 时返回告警信息并写入日志
+func volumeWarning(identifier string, size int64) string {
+	if config.StorageWarnVolumeSize <= 0 || size <= config.StorageWarnVolumeSize {
+		return ""
+	}
+	warning := fmt.Sprintf("服务器 %s 的卷占用 %s，超过告警阈值 %s", identifier, utils.FormatSize(size), utils.FormatSize(config.StorageWarnVolumeSize))
+	utils.Warn("服务器卷占用超过告警阈值", zap.String("identifier", identifier), zap.Int64("size", size))
+	return warning
+}
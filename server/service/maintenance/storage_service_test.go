@@ -0,0 +1,133 @@
+package maintenance
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/utils"
+)
+
+// setupStorageTest 创建服务器 1 和 3 的卷、一个卷备份和一个数据库备份，并设置告警阈值
+func setupStorageTest(t *testing.T) {
+	t.Helper()
+	fake := setupTest(t)
+	fake.DiskTotal, fake.DiskFree = 100<<30, 5<<30
+
+	dockerManager, err := docker_manager.GetNodeManager(0)
+	if err != nil {
+		t.Fatalf("获取Docker管理器失败: %v", err)
+	}
+	for _, id := range []uint{1, 3} {
+		if _, err := dockerManager.CreateVolume(id); err != nil {
+			t.Fatalf("创建卷失败: %v", err)
+		}
+	}
+	fake.WriteVolumeFile(utils.GetServerVolumeName(1), "SavedArks/TheIsland.ark", bytes.Repeat([]byte("a"), 3<<20))
+	fake.WriteVolumeFile(utils.GetServerVolumeName(3), "SavedArks/TheIsland.ark", bytes.Repeat([]byte("a"), 4<<20))
+
+	dir := t.TempDir()
+	oldBackupDir, oldDBPath, oldDriver := config.OrphanBackupDir, config.DBPath, config.DBDriver
+	oldFreePercent, oldVolumeSize := config.StorageWarnFreePercent, config.StorageWarnVolumeSize
+	config.OrphanBackupDir = filepath.Join(dir, "orphans")
+	config.DBPath, config.DBDriver = filepath.Join(dir, "ark_server.db"), "sqlite"
+	config.StorageWarnFreePercent, config.StorageWarnVolumeSize = 10, 2<<20
+	t.Cleanup(func() {
+		config.OrphanBackupDir, config.DBPath, config.DBDriver = oldBackupDir, oldDBPath, oldDriver
+		config.StorageWarnFreePercent, config.StorageWarnVolumeSize = oldFreePercent, oldVolumeSize
+	})
+
+	files := map[string]int{
+		filepath.Join(config.OrphanBackupDir, "node-0", utils.GetServerVolumeName(1)+"-20260101-000000.tar.gz"): 100,
+		config.DBPath + ".pre-migrate-20260101-000000-upgrade.bak":                                              50,
+	}
+	for path, size := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+	}
+}
+
+func TestGetStorageReport(t *testing.T) {
+	setupStorageTest(t)
+
+	report, err := NewMaintenanceService().GetStorageReport()
+	if err != nil {
+		t.Fatalf("获取磁盘占用失败: %v", err)
+	}
+	if len(report.Nodes) != 1 || report.Nodes[0].Error != "" || report.Nodes[0].Disk == nil || report.Nodes[0].Docker == nil {
+		t.Fatalf("节点磁盘占用错误: %+v", report.Nodes)
+	}
+
+	// 按卷大小排序，已删除的服务器没有标识
+	servers := report.Nodes[0].Servers
+	if len(servers) != 2 || servers[0].ServerID != 3 || servers[0].Identifier != "" ||
+		servers[1].ServerID != 1 || servers[1].Identifier != "server-1" || servers[1].Saved != 3<<20 {
+		t.Fatalf("服务器卷大小错误: %+v", servers)
+	}
+
+	if len(report.Archives) != 2 || report.ArchivesSize != 150 ||
+		report.Archives[0].Kind != ArchiveVolumeBackup || report.Archives[1].Kind != ArchiveDatabaseBackup {
+		t.Fatalf("归档文件错误: %+v", report.Archives)
+	}
+
+	warnings := strings.Join(report.Warnings, "\n")
+	if len(report.Warnings) != 2 || !strings.Contains(warnings, "磁盘可用空间不足 10%") || !strings.Contains(warnings, "服务器 server-1 的卷占用 3.0 MB") {
+		t.Fatalf("告警信息错误: %v", report.Warnings)
+	}
+}
+
+func TestGetServerStorage(t *testing.T) {
+	setupStorageTest(t)
+	owner := models.User{Username: "owner", Role: models.RoleOwner}
+	viewer := models.User{Username: "viewer", Role: models.RoleViewer}
+	for _, user := range []*models.User{&owner, &viewer} {
+		if err := database.DB.Create(user).Error; err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	service := NewMaintenanceService()
+
+	storage, err := service.GetServerStorage(owner.ID, "1")
+	if err != nil {
+		t.Fatalf("获取服务器磁盘占用失败: %v", err)
+	}
+	if len(storage.Volumes) != 2 || storage.Volumes[0].Size != 3<<20 || storage.Disk.Available != 5<<30 {
+		t.Fatalf("服务器磁盘占用错误: %+v", storage)
+	}
+	if len(storage.Archives) != 1 || storage.Total != 3<<20+100 {
+		t.Fatalf("服务器卷备份错误: %+v", storage)
+	}
+	if len(storage.Warnings) != 2 {
+		t.Fatalf("告警信息错误: %v", storage.Warnings)
+	}
+
+	// 关闭告警
+	config.StorageWarnFreePercent, config.StorageWarnVolumeSize = 0, 0
+	if storage, err := service.GetServerStorage(owner.ID, "1"); err != nil || len(storage.Warnings) != 0 {
+		t.Fatalf("关闭告警后不应返回告警: %+v, %v", storage, err)
+	}
+
+	for _, tc := range []struct {
+		userID   uint
+		serverID string
+		want     string
+	}{
+		{owner.ID, "abc", "无效的服务器ID"},
+		{owner.ID, "3", "服务器不存在"},
+		{viewer.ID, "1", "服务器不存在"},
+	} {
+		if _, err := service.GetServerStorage(tc.userID, tc.serverID); err == nil || err.Error() != tc.want {
+			t.Fatalf("GetServerStorage(%d, %s) 错误 = %v，期望 %s", tc.userID, tc.serverID, err, tc.want)
+		}
+	}
+}
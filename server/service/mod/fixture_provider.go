@@ -0,0 +1,62 @@
+package mod
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FixtureProvider 基于本地数据的模组元数据来源（用于测试和离线环境）
+type FixtureProvider struct {
+	mu   sync.RWMutex
+	mods map[string]ModDetails
+}
+
+// NewFixtureProvider 使用给定的模组信息创建 fixture 来源
+func NewFixtureProvider(mods ...ModDetails) *FixtureProvider {
+	provider := &FixtureProvider{
+		mods: make(map[string]ModDetails),
+	}
+	for _, mod := range mods {
+		provider.mods[mod.WorkshopID] = mod
+	}
+	return provider
+}
+
+// LoadFixtureProvider 从JSON文件加载 fixture 来源
+// 文件内容为 ModDetails 数组
+func LoadFixtureProvider(path string) (*FixtureProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取模组fixture文件失败: %w", err)
+	}
+
+	var mods []ModDetails
+	if err := json.Unmarshal(data, &mods); err != nil {
+		return nil, fmt.Errorf("解析模组fixture文件失败: %w", err)
+	}
+
+	return NewFixtureProvider(mods...), nil
+}
+
+// Set 添加或替换一个模组的信息
+func (p *FixtureProvider) Set(mod ModDetails) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mods[mod.WorkshopID] = mod
+}
+
+// GetModDetails 批量查询模组信息
+func (p *FixtureProvider) GetModDetails(workshopIDs []string) ([]ModDetails, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	details := []ModDetails{}
+	for _, workshopID := range workshopIDs {
+		if mod, exists := p.mods[workshopID]; exists {
+			details = append(details, mod)
+		}
+	}
+	return details, nil
+}
@@ -0,0 +1,194 @@
+package mod
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/service/docker_manager"
+
+	"github.com/containerd/errdefs"
+)
+
+// InstalledModsManifestPath 已安装模组清单在 Saved 卷中的路径
+// 镜像的模组安装器使用 SteamCMD 下载模组，并将创意工坊清单保存在此处
+const InstalledModsManifestPath = "mods/appworkshop_346110.acf"
+
+// InstalledMod 服务器上已安装的模组版本信息
+type InstalledMod struct {
+	WorkshopID  string    `json:"workshop_id"`
+	Size        int64     `json:"size"`
+	TimeUpdated time.Time `json:"time_updated"` // 安装版本在创意工坊的更新时间
+}
+
+// InstalledModReader 已安装模组信息来源
+type InstalledModReader interface {
+	// GetInstalledMods 获取服务器已安装的模组，键为模组ID
+	GetInstalledMods(serverID uint) (map[string]InstalledMod, error)
+}
+
+// volumeInstalledModReader 从服务器 Saved 卷读取已安装模组清单
+type volumeInstalledModReader struct{}
+
+// GetInstalledMods 读取并解析卷中的创意工坊清单
+func (r volumeInstalledModReader) GetInstalledMods(serverID uint) (map[string]InstalledMod, error) {
+	dockerManager, err := docker_manager.GetServerManager(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+
+	content, err := dockerManager.ReadSavedFile(serverID, InstalledModsManifestPath)
+	if err != nil {
+		// 清单不存在说明还没有安装过任何模组
+		if errdefs.IsNotFound(err) {
+			return map[string]InstalledMod{}, nil
+		}
+		return nil, err
+	}
+
+	return ParseWorkshopManifest(content)
+}
+
+// ParseWorkshopManifest 解析 SteamCMD 的 appworkshop_*.acf 清单
+// 从 WorkshopItemsInstalled 节点中读取每个模组的大小和更新时间
+func ParseWorkshopManifest(content string) (map[string]InstalledMod, error) {
+	root, err := parseVDF(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析模组清单失败: %w", err)
+	}
+
+	installed := make(map[string]InstalledMod)
+
+	appWorkshop, ok := root["AppWorkshop"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("解析模组清单失败: 缺少 AppWorkshop 节点")
+	}
+	items, ok := appWorkshop["WorkshopItemsInstalled"].(map[string]interface{})
+	if !ok {
+		return installed, nil
+	}
+
+	for workshopID, value := range items {
+		item, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		mod := InstalledMod{WorkshopID: workshopID}
+		if size, ok := item["size"].(string); ok {
+			mod.Size, _ = strconv.ParseInt(size, 10, 64)
+		}
+		if timeUpdated, ok := item["timeupdated"].(string); ok {
+			if seconds, err := strconv.ParseInt(timeUpdated, 10, 64); err == nil {
+				mod.TimeUpdated = time.Unix(seconds, 0)
+			}
+		}
+		installed[workshopID] = mod
+	}
+
+	return installed, nil
+}
+
+// parseVDF 解析 Valve KeyValues (VDF) 文本格式
+// 值为字符串或嵌套的 map[string]interface{}
+func parseVDF(content string) (map[string]interface{}, error) {
+	tokens, err := tokenizeVDF(content)
+	if err != nil {
+		return nil, err
+	}
+
+	position := 0
+	result, err := parseVDFObject(tokens, &position, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseVDFObject 解析键值对直到遇到右括号（nested 为 true 时）或输入结束
+func parseVDFObject(tokens []string, position *int, nested bool) (map[string]interface{}, error) {
+	object := make(map[string]interface{})
+
+	for *position < len(tokens) {
+		key := tokens[*position]
+		*position++
+
+		if key == "}" {
+			if !nested {
+				return nil, fmt.Errorf("多余的右括号")
+			}
+			return object, nil
+		}
+		if key == "{" {
+			return nil, fmt.Errorf("缺少键名的左括号")
+		}
+		if *position >= len(tokens) {
+			return nil, fmt.Errorf("键 %s 缺少值", key)
+		}
+
+		value := tokens[*position]
+		*position++
+
+		if value == "{" {
+			child, err := parseVDFObject(tokens, position, true)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = child
+		} else if value == "}" {
+			return nil, fmt.Errorf("键 %s 缺少值", key)
+		} else {
+			object[key] = value
+		}
+	}
+
+	if nested {
+		return nil, fmt.Errorf("缺少右括号")
+	}
+	return object, nil
+}
+
+// tokenizeVDF 将 VDF 文本拆分为字符串和括号
+// 引号内的字符串会去掉引号并处理转义，括号单独作为标记
+func tokenizeVDF(content string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(content); i++ {
+		char := content[i]
+		switch {
+		case char == '{' || char == '}':
+			tokens = append(tokens, string(char))
+		case char == '"':
+			var builder strings.Builder
+			i++
+			for ; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' && i+1 < len(content) {
+					i++
+				}
+				builder.WriteByte(content[i])
+			}
+			if i >= len(content) {
+				return nil, fmt.Errorf("字符串缺少结束引号")
+			}
+			tokens = append(tokens, builder.String())
+		case char == '/' && i+1 < len(content) && content[i+1] == '/':
+			// 跳过注释直到行尾
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case char == ' ' || char == '\t' || char == '\r' || char == '\n':
+			continue
+		default:
+			// 不带引号的字符串
+			start := i
+			for i < len(content) && !strings.ContainsRune(" \t\r\n{}\"", rune(content[i])) {
+				i++
+			}
+			tokens = append(tokens, content[start:i])
+			i--
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,21 @@
+package mod
+
+import (
+	"time"
+)
+
+// ModDetails 从元数据来源获取的模组信息
+type ModDetails struct {
+	WorkshopID  string    `json:"workshop_id"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// MetadataProvider 模组元数据来源
+// 生产环境使用 Steam Web API，测试中使用本地 fixture
+type MetadataProvider interface {
+	// GetModDetails 批量查询模组信息
+	// 不存在的模组不会出现在返回结果中
+	GetModDetails(workshopIDs []string) ([]ModDetails, error)
+}
@@ -0,0 +1,424 @@
+package mod
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/permission"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModService 模组管理业务逻辑服务
+type ModService struct {
+	provider        MetadataProvider
+	providerOnce    sync.Once
+	installedReader InstalledModReader
+}
+
+// NewModService 创建模组服务实例
+// 元数据来源在首次使用时根据配置创建（配置在包初始化之后才加载）
+func NewModService() *ModService {
+	return &ModService{}
+}
+
+// NewModServiceWithProvider 使用指定的元数据来源创建模组服务实例
+func NewModServiceWithProvider(provider MetadataProvider) *ModService {
+	return &ModService{provider: provider}
+}
+
+// SetInstalledModReader 设置已安装模组信息来源（默认从服务器卷读取）
+func (s *ModService) SetInstalledModReader(reader InstalledModReader) {
+	s.installedReader = reader
+}
+
+// getInstalledModReader 获取已安装模组信息来源
+func (s *ModService) getInstalledModReader() InstalledModReader {
+	if s.installedReader == nil {
+		return volumeInstalledModReader{}
+	}
+	return s.installedReader
+}
+
+// getProvider 获取元数据来源
+func (s *ModService) getProvider() MetadataProvider {
+	s.providerOnce.Do(func() {
+		if s.provider != nil {
+			return
+		}
+
+		if config.ModMetadataProvider == "fixture" {
+			provider, err := LoadFixtureProvider(config.ModFixturePath)
+			if err != nil {
+				utils.Error("加载模组fixture失败，使用空数据", zap.String("path", config.ModFixturePath), zap.Error(err))
+				s.provider = NewFixtureProvider()
+				return
+			}
+			s.provider = provider
+			return
+		}
+
+		s.provider = NewSteamProvider(config.SteamAPIKey)
+	})
+	return s.provider
+}
+
+// GetModMetadata 获取模组元数据（优先使用数据库缓存）
+// refresh: 是否忽略缓存强制从元数据来源拉取
+// 返回: 以模组ID为键的元数据映射，不存在的模组不会出现在结果中
+func (s *ModService) GetModMetadata(workshopIDs []string, refresh bool) (map[string]models.Mod, error) {
+	result := make(map[string]models.Mod)
+	if len(workshopIDs) == 0 {
+		return result, nil
+	}
+
+	var cached []models.Mod
+	if err := database.DB.Where("workshop_id IN ?", workshopIDs).Find(&cached).Error; err != nil {
+		return nil, fmt.Errorf("读取模组缓存失败: %w", err)
+	}
+	for _, mod := range cached {
+		result[mod.WorkshopID] = mod
+	}
+
+	// 找出缓存缺失或过期的模组
+	var staleIDs []string
+	for _, workshopID := range workshopIDs {
+		mod, exists := result[workshopID]
+		if refresh || !exists || time.Since(mod.FetchedAt) > config.ModMetadataCacheTTL {
+			staleIDs = append(staleIDs, workshopID)
+		}
+	}
+	if len(staleIDs) == 0 {
+		return result, nil
+	}
+
+	details, err := s.getProvider().GetModDetails(staleIDs)
+	if err != nil {
+		// 元数据来源不可用时，如果缓存完整则继续使用旧数据
+		for _, workshopID := range workshopIDs {
+			if _, exists := result[workshopID]; !exists {
+				return nil, fmt.Errorf("获取模组信息失败: %w", err)
+			}
+		}
+		utils.Warn("获取模组信息失败，使用缓存数据", zap.Strings("workshop_ids", staleIDs), zap.Error(err))
+		return result, nil
+	}
+
+	now := time.Now()
+	for _, detail := range details {
+		mod := models.Mod{
+			WorkshopID:  detail.WorkshopID,
+			Name:        detail.Name,
+			Size:        detail.Size,
+			LastUpdated: detail.LastUpdated,
+			FetchedAt:   now,
+		}
+		if err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "workshop_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "size", "last_updated", "fetched_at", "updated_at"}),
+		}).Create(&mod).Error; err != nil {
+			return nil, fmt.Errorf("缓存模组信息失败: %w", err)
+		}
+		result[mod.WorkshopID] = mod
+	}
+
+	return result, nil
+}
+
+// ListMods 获取已缓存的模组目录
+func (s *ModService) ListMods() ([]models.ModResponse, error) {
+	var mods []models.Mod
+	if err := database.DB.Order("name").Find(&mods).Error; err != nil {
+		return nil, fmt.Errorf("获取模组列表失败: %w", err)
+	}
+
+	responses := []models.ModResponse{}
+	for _, mod := range mods {
+		responses = append(responses, toModResponse(mod))
+	}
+	return responses, nil
+}
+
+// GetMod 获取单个模组的元数据
+func (s *ModService) GetMod(workshopID string, refresh bool) (*models.ModResponse, error) {
+	if err := utils.ValidateWorkshopID(workshopID); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.GetModMetadata([]string{workshopID}, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	mod, exists := metadata[workshopID]
+	if !exists {
+		return nil, fmt.Errorf("模组不存在")
+	}
+
+	response := toModResponse(mod)
+	return &response, nil
+}
+
+// GetServerMods 获取服务器的有序模组列表
+func (s *ModService) GetServerMods(userID uint, serverID string) ([]models.ServerModResponse, error) {
+	server, err := s.findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverMods, err := s.ensureServerMods(server)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildServerModResponses(serverMods), nil
+}
+
+// AddServerMod 将模组添加到服务器模组列表末尾
+func (s *ModService) AddServerMod(userID uint, serverID string, workshopID string) ([]models.ServerModResponse, error) {
+	workshopID = strings.TrimSpace(workshopID)
+	if err := utils.ValidateWorkshopID(workshopID); err != nil {
+		return nil, err
+	}
+
+	server, err := s.findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverMods, err := s.ensureServerMods(server)
+	if err != nil {
+		return nil, err
+	}
+
+	workshopIDs := make([]string, 0, len(serverMods)+1)
+	for _, serverMod := range serverMods {
+		if serverMod.WorkshopID == workshopID {
+			return nil, fmt.Errorf("模组已存在")
+		}
+		workshopIDs = append(workshopIDs, serverMod.WorkshopID)
+	}
+
+	// 确认模组在创意工坊中存在
+	metadata, err := s.GetModMetadata([]string{workshopID}, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := metadata[workshopID]; !exists {
+		return nil, fmt.Errorf("模组不存在")
+	}
+
+	workshopIDs = append(workshopIDs, workshopID)
+	return s.saveServerMods(server, workshopIDs)
+}
+
+// RemoveServerMod 从服务器模组列表中移除模组
+func (s *ModService) RemoveServerMod(userID uint, serverID string, workshopID string) ([]models.ServerModResponse, error) {
+	server, err := s.findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverMods, err := s.ensureServerMods(server)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	workshopIDs := make([]string, 0, len(serverMods))
+	for _, serverMod := range serverMods {
+		if serverMod.WorkshopID == workshopID {
+			found = true
+			continue
+		}
+		workshopIDs = append(workshopIDs, serverMod.WorkshopID)
+	}
+	if !found {
+		return nil, fmt.Errorf("模组不在列表中")
+	}
+
+	return s.saveServerMods(server, workshopIDs)
+}
+
+// ReorderServerMods 调整服务器模组加载顺序
+// workshopIDs 必须恰好包含当前列表中的所有模组
+func (s *ModService) ReorderServerMods(userID uint, serverID string, workshopIDs []string) ([]models.ServerModResponse, error) {
+	server, err := s.findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverMods, err := s.ensureServerMods(server)
+	if err != nil {
+		return nil, err
+	}
+
+	orderedIDs, err := utils.ParseGameModIds(strings.Join(workshopIDs, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool)
+	for _, serverMod := range serverMods {
+		current[serverMod.WorkshopID] = true
+	}
+	if len(orderedIDs) != len(current) {
+		return nil, fmt.Errorf("排序列表与当前模组列表不一致")
+	}
+	for _, workshopID := range orderedIDs {
+		if !current[workshopID] {
+			return nil, fmt.Errorf("排序列表与当前模组列表不一致")
+		}
+	}
+
+	return s.saveServerMods(server, orderedIDs)
+}
+
+// SyncServerMods 用给定的有序模组ID列表替换服务器模组列表，并同步 GameModIds 字段
+// tx: 数据库连接（可以是事务）
+func SyncServerMods(tx *gorm.DB, serverID uint, workshopIDs []string) error {
+	if err := tx.Where("server_id = ?", serverID).Delete(&models.ServerMod{}).Error; err != nil {
+		return fmt.Errorf("清除服务器模组列表失败: %w", err)
+	}
+
+	for position, workshopID := range workshopIDs {
+		serverMod := models.ServerMod{
+			ServerID:   serverID,
+			WorkshopID: workshopID,
+			Position:   position,
+		}
+		if err := tx.Create(&serverMod).Error; err != nil {
+			return fmt.Errorf("保存服务器模组列表失败: %w", err)
+		}
+	}
+
+	if err := tx.Model(&models.Server{}).Where("id = ?", serverID).
+		Update("game_mod_ids", strings.Join(workshopIDs, ",")).Error; err != nil {
+		return fmt.Errorf("更新服务器模组ID失败: %w", err)
+	}
+
+	return nil
+}
+
+// saveServerMods 在事务中保存模组列表并返回最新结果
+func (s *ModService) saveServerMods(server *models.Server, workshopIDs []string) ([]models.ServerModResponse, error) {
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return SyncServerMods(tx, server.ID, workshopIDs)
+	}); err != nil {
+		return nil, err
+	}
+
+	utils.Info("服务器模组列表已更新",
+		zap.Uint("server_id", server.ID),
+		zap.Strings("workshop_ids", workshopIDs))
+
+	serverMods, err := s.loadServerMods(server.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildServerModResponses(serverMods), nil
+}
+
+// ensureServerMods 读取服务器模组列表
+// 对于只有 GameModIds 字段而没有模组列表记录的旧服务器，会先根据 GameModIds 生成列表
+func (s *ModService) ensureServerMods(server *models.Server) ([]models.ServerMod, error) {
+	serverMods, err := s.loadServerMods(server.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(serverMods) > 0 || server.GameModIds == "" {
+		return serverMods, nil
+	}
+
+	workshopIDs, err := utils.ParseGameModIds(server.GameModIds)
+	if err != nil {
+		return nil, fmt.Errorf("服务器现有模组ID无效: %w", err)
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return SyncServerMods(tx, server.ID, workshopIDs)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.loadServerMods(server.ID)
+}
+
+// loadServerMods 按加载顺序读取服务器模组列表
+func (s *ModService) loadServerMods(serverID uint) ([]models.ServerMod, error) {
+	var serverMods []models.ServerMod
+	if err := database.DB.Where("server_id = ?", serverID).Order("position").Find(&serverMods).Error; err != nil {
+		return nil, fmt.Errorf("获取服务器模组列表失败: %w", err)
+	}
+	return serverMods, nil
+}
+
+// buildServerModResponses 构建模组列表响应（元数据获取失败时只返回ID）
+func (s *ModService) buildServerModResponses(serverMods []models.ServerMod) []models.ServerModResponse {
+	workshopIDs := make([]string, 0, len(serverMods))
+	for _, serverMod := range serverMods {
+		workshopIDs = append(workshopIDs, serverMod.WorkshopID)
+	}
+
+	metadata, err := s.GetModMetadata(workshopIDs, false)
+	if err != nil {
+		utils.Warn("获取模组元数据失败", zap.Error(err))
+		metadata = make(map[string]models.Mod)
+	}
+
+	responses := []models.ServerModResponse{}
+	for _, serverMod := range serverMods {
+		response := models.ServerModResponse{
+			Position:   serverMod.Position,
+			WorkshopID: serverMod.WorkshopID,
+		}
+		if mod, exists := metadata[serverMod.WorkshopID]; exists {
+			response.Name = mod.Name
+			response.Size = mod.Size
+			response.LastUpdated = formatTime(mod.LastUpdated)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// findServer 查找用户的服务器
+func (s *ModService) findServer(userID uint, serverID string) (*models.Server, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+
+	var server models.Server
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+	return &server, nil
+}
+
+// toModResponse 构建模组元数据响应
+func toModResponse(mod models.Mod) models.ModResponse {
+	return models.ModResponse{
+		WorkshopID:  mod.WorkshopID,
+		Name:        mod.Name,
+		Size:        mod.Size,
+		LastUpdated: formatTime(mod.LastUpdated),
+		FetchedAt:   formatTime(mod.FetchedAt),
+	}
+}
+
+// formatTime 格式化时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,147 @@
+package mod
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// countingProvider 记录查询次数的 fixture 来源
+type countingProvider struct {
+	*FixtureProvider
+	calls int
+}
+
+func (p *countingProvider) GetModDetails(workshopIDs []string) ([]ModDetails, error) {
+	p.calls++
+	return p.FixtureProvider.GetModDetails(workshopIDs)
+}
+
+// createTestServer 创建属于指定用户的测试服务器
+func createTestServer(t *testing.T, userID uint, gameModIds string) string {
+	t.Helper()
+
+	user := models.User{ID: userID, Username: fmt.Sprintf("user%d", userID), Password: "x", Role: models.RoleOperator}
+	if err := database.DB.FirstOrCreate(&user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+
+	server := models.Server{Identifier: "test", UserID: userID, GameModIds: gameModIds}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatalf("创建测试服务器失败: %v", err)
+	}
+	return fmt.Sprintf("%d", server.ID)
+}
+
+func newTestProvider() *countingProvider {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &countingProvider{FixtureProvider: NewFixtureProvider(
+		ModDetails{WorkshopID: "731604991", Name: "Structures Plus (S+)", Size: 1024, LastUpdated: updated},
+		ModDetails{WorkshopID: "1404697612", Name: "Awesome Spyglass!", Size: 2048, LastUpdated: updated},
+		ModDetails{WorkshopID: "889745138", Name: "Awesome Teleporters!", Size: 4096, LastUpdated: updated},
+	)}
+}
+
+// TestServerModLifecycle 测试模组的添加、排序与移除
+func TestServerModLifecycle(t *testing.T) {
+	dbtest.Open(t)
+	service := NewModServiceWithProvider(newTestProvider())
+	serverID := createTestServer(t, 1, "")
+
+	for _, workshopID := range []string{"731604991", "1404697612", "889745138"} {
+		if _, err := service.AddServerMod(1, serverID, workshopID); err != nil {
+			t.Fatalf("添加模组 %s 失败: %v", workshopID, err)
+		}
+	}
+
+	mods, err := service.ReorderServerMods(1, serverID, []string{"889745138", "731604991", "1404697612"})
+	if err != nil {
+		t.Fatalf("调整模组顺序失败: %v", err)
+	}
+	if mods[0].WorkshopID != "889745138" || mods[0].Name != "Awesome Teleporters!" {
+		t.Errorf("排序后第一个模组错误: %+v", mods[0])
+	}
+
+	if _, err := service.RemoveServerMod(1, serverID, "731604991"); err != nil {
+		t.Fatalf("移除模组失败: %v", err)
+	}
+
+	var server models.Server
+	database.DB.First(&server, serverID)
+	if server.GameModIds != "889745138,1404697612" {
+		t.Errorf("GameModIds 未同步，实际为 %q", server.GameModIds)
+	}
+}
+
+// TestAddServerModValidation 测试模组ID验证与重复检测
+func TestAddServerModValidation(t *testing.T) {
+	dbtest.Open(t)
+	service := NewModServiceWithProvider(newTestProvider())
+	serverID := createTestServer(t, 1, "731604991")
+
+	cases := map[string]string{
+		"abc123":    "无效的模组ID: abc123",
+		"731604991": "模组已存在",
+		"12345":     "模组不存在",
+	}
+	for workshopID, expected := range cases {
+		if _, err := service.AddServerMod(1, serverID, workshopID); err == nil || err.Error() != expected {
+			t.Errorf("添加模组 %s 期望错误 %q，实际为 %v", workshopID, expected, err)
+		}
+	}
+
+	if _, err := service.AddServerMod(2, serverID, "1404697612"); err == nil || err.Error() != "服务器不存在" {
+		t.Errorf("其他用户的服务器应不可访问，实际为 %v", err)
+	}
+}
+
+// TestReorderServerModsRejectsMismatch 测试排序列表必须与当前列表一致
+func TestReorderServerModsRejectsMismatch(t *testing.T) {
+	dbtest.Open(t)
+	service := NewModServiceWithProvider(newTestProvider())
+	serverID := createTestServer(t, 1, "731604991,1404697612")
+
+	invalidOrders := [][]string{
+		{"731604991"},
+		{"731604991", "731604991"},
+		{"731604991", "889745138"},
+	}
+	for _, order := range invalidOrders {
+		if _, err := service.ReorderServerMods(1, serverID, order); err == nil {
+			t.Errorf("排序列表 %v 应被拒绝", order)
+		}
+	}
+}
+
+// TestModMetadataCache 测试元数据缓存
+func TestModMetadataCache(t *testing.T) {
+	dbtest.Open(t)
+	provider := newTestProvider()
+	service := NewModServiceWithProvider(provider)
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetMod("731604991", false); err != nil {
+			t.Fatalf("获取模组信息失败: %v", err)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("缓存有效时期望只查询1次，实际查询%d次", provider.calls)
+	}
+
+	if _, err := service.GetMod("731604991", true); err != nil {
+		t.Fatalf("刷新模组信息失败: %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("强制刷新后期望查询2次，实际查询%d次", provider.calls)
+	}
+}
@@ -0,0 +1,98 @@
+package mod
+
+import (
+	"time"
+
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// 模组更新状态
+const (
+	ModStatusUpToDate     = "up_to_date"
+	ModStatusOutdated     = "outdated"
+	ModStatusNotInstalled = "not_installed"
+	ModStatusUnknown      = "unknown"
+)
+
+// GetServerModStatus 获取服务器模组的更新状态
+// refresh: 是否忽略缓存从元数据来源获取最新版本信息
+func (s *ModService) GetServerModStatus(userID uint, serverID string, refresh bool) (*models.ServerModStatusResponse, error) {
+	server, err := s.findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CheckServerModStatus(server, refresh)
+}
+
+// CheckServerModStatus 比较服务器已安装的模组版本与创意工坊最新版本
+func (s *ModService) CheckServerModStatus(server *models.Server, refresh bool) (*models.ServerModStatusResponse, error) {
+	serverMods, err := s.ensureServerMods(server)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ServerModStatusResponse{
+		ServerID:  server.ID,
+		Mods:      []models.ModUpdateStatus{},
+		CheckedAt: formatTime(time.Now()),
+	}
+	if len(serverMods) == 0 {
+		return response, nil
+	}
+
+	installed, err := s.getInstalledModReader().GetInstalledMods(server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	workshopIDs := make([]string, 0, len(serverMods))
+	for _, serverMod := range serverMods {
+		workshopIDs = append(workshopIDs, serverMod.WorkshopID)
+	}
+
+	// 元数据不可用时仍返回已安装信息，状态标记为未知
+	metadata, err := s.GetModMetadata(workshopIDs, refresh)
+	if err != nil {
+		utils.Warn("获取模组最新版本信息失败", zap.Uint("server_id", server.ID), zap.Error(err))
+		metadata = make(map[string]models.Mod)
+	}
+
+	for _, serverMod := range serverMods {
+		status := models.ModUpdateStatus{
+			WorkshopID: serverMod.WorkshopID,
+			Status:     ModStatusUnknown,
+		}
+
+		latest, hasLatest := metadata[serverMod.WorkshopID]
+		if hasLatest {
+			status.Name = latest.Name
+			status.LatestUpdated = formatTime(latest.LastUpdated)
+		}
+
+		installedMod, isInstalled := installed[serverMod.WorkshopID]
+		if isInstalled {
+			status.InstalledUpdated = formatTime(installedMod.TimeUpdated)
+		}
+
+		switch {
+		case !isInstalled:
+			status.Status = ModStatusNotInstalled
+		case !hasLatest || latest.LastUpdated.IsZero():
+			status.Status = ModStatusUnknown
+		case installedMod.TimeUpdated.Before(latest.LastUpdated):
+			status.Status = ModStatusOutdated
+			response.OutdatedCount++
+		default:
+			status.Status = ModStatusUpToDate
+		}
+
+		response.Mods = append(response.Mods, status)
+	}
+
+	response.UpdateAvailable = response.OutdatedCount > 0
+	return response, nil
+}
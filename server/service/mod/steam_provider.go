@@ -0,0 +1,96 @@
+package mod
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// steamPublishedFileDetailsURL Steam 创意工坊文件详情接口
+const steamPublishedFileDetailsURL = "https://api.steampowered.com/ISteamRemoteStorage/GetPublishedFileDetails/v1/"
+
+// SteamProvider 通过 Steam Web API 获取模组元数据
+type SteamProvider struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewSteamProvider 创建 Steam 元数据来源
+// apiKey: Steam Web API 密钥（GetPublishedFileDetails 接口可不提供）
+func NewSteamProvider(apiKey string) *SteamProvider {
+	return &SteamProvider{
+		endpoint: steamPublishedFileDetailsURL,
+		apiKey:   apiKey,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// steamFileDetailsResponse GetPublishedFileDetails 响应结构
+type steamFileDetailsResponse struct {
+	Response struct {
+		Result               int `json:"result"`
+		PublishedFileDetails []struct {
+			PublishedFileID string      `json:"publishedfileid"`
+			Result          int         `json:"result"`
+			Title           string      `json:"title"`
+			FileSize        json.Number `json:"file_size"`
+			TimeUpdated     int64       `json:"time_updated"`
+		} `json:"publishedfiledetails"`
+	} `json:"response"`
+}
+
+// GetModDetails 批量查询模组信息
+func (p *SteamProvider) GetModDetails(workshopIDs []string) ([]ModDetails, error) {
+	if len(workshopIDs) == 0 {
+		return []ModDetails{}, nil
+	}
+
+	form := url.Values{}
+	form.Set("itemcount", strconv.Itoa(len(workshopIDs)))
+	for i, workshopID := range workshopIDs {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), workshopID)
+	}
+	if p.apiKey != "" {
+		form.Set("key", p.apiKey)
+	}
+
+	resp, err := p.httpClient.Post(p.endpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("请求Steam API失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Steam API返回错误状态: %d", resp.StatusCode)
+	}
+
+	var body steamFileDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析Steam API响应失败: %w", err)
+	}
+
+	var details []ModDetails
+	for _, item := range body.Response.PublishedFileDetails {
+		// result 为 1 表示查询成功，其余值表示模组不存在或不可见
+		if item.Result != 1 {
+			continue
+		}
+
+		size, _ := item.FileSize.Int64()
+		details = append(details, ModDetails{
+			WorkshopID:  item.PublishedFileID,
+			Name:        item.Title,
+			Size:        size,
+			LastUpdated: time.Unix(item.TimeUpdated, 0),
+		})
+	}
+
+	return details, nil
+}
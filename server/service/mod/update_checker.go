@@ -0,0 +1,174 @@
+package mod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// RestartFunc 重启服务器的回调（由服务器服务提供，负责提前提醒玩家）
+// ctx 在检查器停止时取消，回调需要中止提醒倒计时
+type RestartFunc func(ctx context.Context, server models.Server, reason string) error
+
+// ModUpdateChecker 定时检查运行中服务器的模组是否有更新
+// 开启自动重启时，会对有过期模组的服务器触发带提醒的重启，重启时镜像的模组安装器会拉取更新
+type ModUpdateChecker struct {
+	service     *ModService
+	interval    time.Duration
+	autoRestart bool
+	restart     RestartFunc
+
+	mu         sync.Mutex
+	handled    map[uint]string // 已成功重启的更新签名，避免同一更新重复重启
+	restarting map[uint]bool
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// NewModUpdateChecker 根据配置创建模组更新检查器
+// restart: 自动重启回调，为 nil 时只检测不重启
+func NewModUpdateChecker(service *ModService, restart RestartFunc) *ModUpdateChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ModUpdateChecker{
+		service:     service,
+		interval:    config.ModUpdateCheckInterval,
+		autoRestart: config.ModAutoRestart,
+		restart:     restart,
+		handled:     make(map[uint]string),
+		restarting:  make(map[uint]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start 启动后台定时检查（检查间隔为0时不启动）
+func (c *ModUpdateChecker) Start() {
+	if c.interval <= 0 {
+		utils.Info("模组更新定时检查已关闭")
+		return
+	}
+
+	utils.Info("模组更新定时检查已启动",
+		zap.Duration("interval", c.interval),
+		zap.Bool("auto_restart", c.autoRestart))
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.CheckAll()
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台检查，取消进行中的重启倒计时并等待其结束
+func (c *ModUpdateChecker) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// CheckAll 检查所有运行中服务器的模组更新
+func (c *ModUpdateChecker) CheckAll() {
+	var servers []models.Server
+	if err := database.DB.Where("status = ? AND game_mod_ids <> ?", "running", "").Find(&servers).Error; err != nil {
+		utils.Error("获取运行中服务器失败", zap.Error(err))
+		return
+	}
+
+	for _, server := range servers {
+		status, err := c.service.CheckServerModStatus(&server, true)
+		if err != nil {
+			utils.Warn("检查服务器模组更新失败", zap.Uint("server_id", server.ID), zap.Error(err))
+			continue
+		}
+		if !status.UpdateAvailable {
+			continue
+		}
+
+		outdated := outdatedMods(status)
+		utils.Warn("服务器存在过期模组",
+			zap.Uint("server_id", server.ID),
+			zap.String("identifier", server.Identifier),
+			zap.Strings("workshop_ids", outdated))
+
+		if c.autoRestart && c.restart != nil {
+			c.triggerRestart(server, status)
+		}
+	}
+}
+
+// triggerRestart 为存在过期模组的服务器触发异步重启
+// 重启成功后才记录更新签名，失败或取消的重启会在下次检查时重试
+func (c *ModUpdateChecker) triggerRestart(server models.Server, status *models.ServerModStatusResponse) {
+	signature := updateSignature(status)
+
+	c.mu.Lock()
+	if c.ctx.Err() != nil || c.restarting[server.ID] || c.handled[server.ID] == signature {
+		c.mu.Unlock()
+		return
+	}
+	c.restarting[server.ID] = true
+	c.mu.Unlock()
+
+	reason := fmt.Sprintf("updating %d mod(s)", status.OutdatedCount)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			c.mu.Lock()
+			delete(c.restarting, server.ID)
+			c.mu.Unlock()
+		}()
+
+		if err := c.restart(c.ctx, server, reason); err != nil {
+			utils.Error("模组更新重启失败", zap.Uint("server_id", server.ID), zap.Error(err))
+			return
+		}
+		c.mu.Lock()
+		c.handled[server.ID] = signature
+		c.mu.Unlock()
+	}()
+}
+
+// outdatedMods 获取过期模组ID列表
+func outdatedMods(status *models.ServerModStatusResponse) []string {
+	var workshopIDs []string
+	for _, mod := range status.Mods {
+		if mod.Status == ModStatusOutdated {
+			workshopIDs = append(workshopIDs, mod.WorkshopID)
+		}
+	}
+	return workshopIDs
+}
+
+// updateSignature 生成过期模组及其最新版本的签名
+func updateSignature(status *models.ServerModStatusResponse) string {
+	var parts []string
+	for _, mod := range status.Mods {
+		if mod.Status == ModStatusOutdated {
+			parts = append(parts, mod.WorkshopID+"@"+mod.LatestUpdated)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,185 @@
+package mod
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+)
+
+const testManifest = `"AppWorkshop"
+{
+	"appid"		"346110"
+	"SizeOnDisk"		"3072"
+	"WorkshopItemsInstalled"
+	{
+		"731604991"
+		{
+			"size"		"1024"
+			"timeupdated"		"1714564800"
+			"manifest"		"4125690012731120455"
+		}
+		"1404697612"
+		{
+			"size"		"2048"
+			"timeupdated"		"1700000000"
+			"manifest"		"8841293410053012111"
+		}
+	}
+}
+`
+
+// fakeInstalledModReader 返回固定清单内容的已安装模组来源
+type fakeInstalledModReader struct {
+	manifest string
+}
+
+func (r fakeInstalledModReader) GetInstalledMods(serverID uint) (map[string]InstalledMod, error) {
+	return ParseWorkshopManifest(r.manifest)
+}
+
+// TestParseWorkshopManifest 测试解析 SteamCMD 创意工坊清单
+func TestParseWorkshopManifest(t *testing.T) {
+	installed, err := ParseWorkshopManifest(testManifest)
+	if err != nil {
+		t.Fatalf("解析清单失败: %v", err)
+	}
+
+	if len(installed) != 2 {
+		t.Fatalf("期望2个已安装模组，实际为%d", len(installed))
+	}
+	mod := installed["731604991"]
+	if mod.Size != 1024 || mod.TimeUpdated.Unix() != 1714564800 {
+		t.Errorf("模组信息解析错误: %+v", mod)
+	}
+
+	if _, err := ParseWorkshopManifest(`"AppWorkshop" { "WorkshopItemsInstalled" {`); err == nil {
+		t.Error("不完整的清单应解析失败")
+	}
+}
+
+// TestCheckServerModStatus 测试已安装版本与最新版本的比较
+func TestCheckServerModStatus(t *testing.T) {
+	dbtest.Open(t)
+	service := NewModServiceWithProvider(NewFixtureProvider(
+		ModDetails{WorkshopID: "731604991", Name: "Structures Plus (S+)", LastUpdated: time.Unix(1714564800, 0)},
+		ModDetails{WorkshopID: "1404697612", Name: "Awesome Spyglass!", LastUpdated: time.Unix(1710000000, 0)},
+		ModDetails{WorkshopID: "889745138", Name: "Awesome Teleporters!", LastUpdated: time.Unix(1710000000, 0)},
+	))
+	service.SetInstalledModReader(fakeInstalledModReader{manifest: testManifest})
+	serverID := createTestServer(t, 1, "731604991,1404697612,889745138")
+
+	status, err := service.GetServerModStatus(1, serverID, true)
+	if err != nil {
+		t.Fatalf("获取模组状态失败: %v", err)
+	}
+
+	expected := map[string]string{
+		"731604991":  ModStatusUpToDate,
+		"1404697612": ModStatusOutdated,
+		"889745138":  ModStatusNotInstalled,
+	}
+	for _, mod := range status.Mods {
+		if mod.Status != expected[mod.WorkshopID] {
+			t.Errorf("模组 %s 期望状态 %s，实际为 %s", mod.WorkshopID, expected[mod.WorkshopID], mod.Status)
+		}
+	}
+	if status.OutdatedCount != 1 || !status.UpdateAvailable {
+		t.Errorf("期望1个过期模组，实际为%d", status.OutdatedCount)
+	}
+}
+
+// TestModUpdateCheckerRestartsOncePerUpdate 测试同一更新只触发一次重启
+func TestModUpdateCheckerRestartsOncePerUpdate(t *testing.T) {
+	dbtest.Open(t)
+	provider := NewFixtureProvider(
+		ModDetails{WorkshopID: "731604991", LastUpdated: time.Unix(1714564800, 0)},
+		ModDetails{WorkshopID: "1404697612", LastUpdated: time.Unix(1710000000, 0)},
+	)
+	service := NewModServiceWithProvider(provider)
+	service.SetInstalledModReader(fakeInstalledModReader{manifest: testManifest})
+
+	running := models.Server{Identifier: "running", UserID: 1, Status: "running", GameModIds: "731604991,1404697612"}
+	stopped := models.Server{Identifier: "stopped", UserID: 1, Status: "stopped", GameModIds: "1404697612"}
+	database.DB.Create(&running)
+	database.DB.Create(&stopped)
+
+	var mu sync.Mutex
+	var restarted []uint
+	checker := NewModUpdateChecker(service, func(ctx context.Context, server models.Server, reason string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		restarted = append(restarted, server.ID)
+		return nil
+	})
+	checker.autoRestart = true
+
+	checker.CheckAll()
+	checker.wg.Wait()
+	checker.CheckAll()
+	checker.wg.Wait()
+
+	if len(restarted) != 1 || restarted[0] != running.ID {
+		t.Fatalf("期望只重启运行中的服务器一次，实际为 %v", restarted)
+	}
+
+	// 模组再次更新后应重新触发
+	provider.Set(ModDetails{WorkshopID: "1404697612", LastUpdated: time.Unix(1720000000, 0)})
+	checker.CheckAll()
+	checker.wg.Wait()
+
+	if len(restarted) != 2 {
+		t.Errorf("新的模组更新应再次触发重启，实际重启次数为%d", len(restarted))
+	}
+}
+
+// TestModUpdateCheckerRetriesFailedRestart 测试重启失败后下次检查会重试，停止检查器时取消进行中的重启
+func TestModUpdateCheckerRetriesFailedRestart(t *testing.T) {
+	dbtest.Open(t)
+	service := NewModServiceWithProvider(NewFixtureProvider(
+		ModDetails{WorkshopID: "1404697612", LastUpdated: time.Unix(1710000000, 0)},
+	))
+	service.SetInstalledModReader(fakeInstalledModReader{manifest: testManifest})
+	database.DB.Create(&models.Server{Identifier: "running", UserID: 1, Status: "running", GameModIds: "1404697612"})
+
+	var attempts int
+	checker := NewModUpdateChecker(service, func(ctx context.Context, server models.Server, reason string) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("重启失败")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	checker.autoRestart = true
+
+	checker.CheckAll()
+	checker.wg.Wait()
+	checker.CheckAll()
+
+	// 第二次重启一直等待，停止检查器时应被取消
+	done := make(chan struct{})
+	go func() {
+		checker.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("停止检查器时应取消进行中的重启")
+	}
+	if attempts != 2 {
+		t.Fatalf("重启失败后应重试，实际重启次数为%d", attempts)
+	}
+
+	// 已停止的检查器不再触发重启
+	checker.CheckAll()
+	if attempts != 2 {
+		t.Errorf("停止后不应再触发重启，实际重启次数为%d", attempts)
+	}
+}
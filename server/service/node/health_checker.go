@@ -0,0 +1,87 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// HealthChecker 定时检查所有节点的Docker连接
+type HealthChecker struct {
+	service  *NodeService
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewHealthChecker 根据配置创建节点健康检查器
+func NewHealthChecker(service *NodeService) *HealthChecker {
+	return &HealthChecker{
+		service:  service,
+		interval: config.NodeHealthCheckInterval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 在后台立即检查一次并定时检查（检查间隔为0时只检查一次）
+func (c *HealthChecker) Start() {
+	if c.interval <= 0 {
+		utils.Info("节点定时健康检查已关闭")
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.CheckAll()
+		}()
+		return
+	}
+
+	utils.Info("节点定时健康检查已启动", zap.Duration("interval", c.interval))
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.CheckAll()
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.CheckAll()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台检查
+func (c *HealthChecker) Stop() {
+	close(c.stop)
+	c.wg.Wait()
+}
+
+// CheckAll 并发检查所有节点
+func (c *HealthChecker) CheckAll() {
+	var nodes []models.Node
+	if err := database.DB.Order("id").Find(&nodes).Error; err != nil {
+		utils.Error("获取节点列表失败", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(node *models.Node) {
+			defer wg.Done()
+			c.service.CheckNode(node)
+		}(&nodes[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,382 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout 单个节点健康检查的超时时间
+const healthCheckTimeout = 10 * time.Second
+
+// NodeService 节点管理服务
+type NodeService struct {
+	now func() time.Time
+}
+
+// NewNodeService 创建节点管理服务实例
+func NewNodeService() *NodeService {
+	return &NodeService{now: time.Now}
+}
+
+// ListNodes 获取所有节点
+func (s *NodeService) ListNodes() ([]models.NodeResponse, error) {
+	var nodes []models.Node
+	if err := database.DB.Order("id").Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	counts, err := serverCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.NodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		responses = append(responses, toNodeResponse(node, counts[node.ID]))
+	}
+	return responses, nil
+}
+
+// GetNode 获取节点信息
+func (s *NodeService) GetNode(nodeID uint) (*models.NodeResponse, error) {
+	node, err := findNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := serverCounts()
+	if err != nil {
+		return nil, err
+	}
+	response := toNodeResponse(*node, counts[node.ID])
+	return &response, nil
+}
+
+// CreateNode 添加节点，添加后立即检查一次连接
+func (s *NodeService) CreateNode(req models.NodeRequest) (*models.NodeResponse, error) {
+	node := models.Node{
+		Name:        strings.TrimSpace(req.Name),
+		Endpoint:    strings.TrimSpace(req.Endpoint),
+		PublicHost:  strings.TrimSpace(req.PublicHost),
+		TLSCACert:   req.TLSCACert,
+		TLSCert:     req.TLSCert,
+		TLSKey:      models.EncryptedString(req.TLSKey),
+		SSHKey:      models.EncryptedString(req.SSHKey),
+		SSHHostKey:  strings.TrimSpace(req.SSHHostKey),
+		MaxServers:  req.MaxServers,
+		Schedulable: req.Schedulable == nil || *req.Schedulable,
+		Status:      models.NodeStatusUnknown,
+	}
+	if err := setLabels(&node, req.Labels); err != nil {
+		return nil, err
+	}
+	if err := validateNode(node, 0); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Create(&node).Error; err != nil {
+		return nil, fmt.Errorf("添加节点失败: %w", err)
+	}
+	utils.Info("节点已添加", zap.Uint("node_id", node.ID), zap.String("name", node.Name), zap.String("endpoint", node.Endpoint))
+
+	// 添加节点前创建的服务器运行在面板所在主机上，归属到代表该主机的节点
+	var serverCount int64
+	if node.IsLocal() {
+		result := database.DB.Model(&models.Server{}).Unscoped().Where("node_id = ?", 0).UpdateColumn("node_id", node.ID)
+		if result.Error != nil {
+			return nil, fmt.Errorf("分配已有服务器到节点失败: %w", result.Error)
+		}
+		serverCount = result.RowsAffected
+	}
+
+	s.CheckNode(&node)
+	response := toNodeResponse(node, serverCount)
+	return &response, nil
+}
+
+// UpdateNode 修改节点，修改连接参数后会重新连接并检查
+func (s *NodeService) UpdateNode(nodeID uint, req models.NodeUpdateRequest) (*models.NodeResponse, error) {
+	node, err := findNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		node.Name = name
+	}
+	if req.Endpoint != nil {
+		node.Endpoint = strings.TrimSpace(*req.Endpoint)
+	}
+	if req.PublicHost != nil {
+		node.PublicHost = strings.TrimSpace(*req.PublicHost)
+	}
+	if req.TLSCACert != nil {
+		node.TLSCACert = *req.TLSCACert
+	}
+	if req.TLSCert != nil {
+		node.TLSCert = *req.TLSCert
+	}
+	if req.TLSKey != "" {
+		node.TLSKey = models.EncryptedString(req.TLSKey)
+	}
+	if req.SSHKey != "" {
+		node.SSHKey = models.EncryptedString(req.SSHKey)
+	}
+	if req.SSHHostKey != nil {
+		node.SSHHostKey = strings.TrimSpace(*req.SSHHostKey)
+	}
+	if req.Labels != nil {
+		if err := setLabels(node, *req.Labels); err != nil {
+			return nil, err
+		}
+	}
+	if req.MaxServers != nil {
+		node.MaxServers = *req.MaxServers
+	}
+	if req.Schedulable != nil {
+		node.Schedulable = *req.Schedulable
+	}
+	if err := validateNode(*node, node.ID); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Save(node).Error; err != nil {
+		return nil, fmt.Errorf("修改节点失败: %w", err)
+	}
+	docker_manager.InvalidateNodeManager(node.ID)
+	s.CheckNode(node)
+
+	counts, err := serverCounts()
+	if err != nil {
+		return nil, err
+	}
+	response := toNodeResponse(*node, counts[node.ID])
+	return &response, nil
+}
+
+// DeleteNode 删除节点（节点上还有服务器时不能删除）
+func (s *NodeService) DeleteNode(nodeID uint) error {
+	node, err := findNode(nodeID)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Server{}).Where("node_id = ?", node.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("统计节点上的服务器失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("节点上还有服务器，不能删除")
+	}
+
+	if err := database.DB.Delete(node).Error; err != nil {
+		return fmt.Errorf("删除节点失败: %w", err)
+	}
+	docker_manager.InvalidateNodeManager(node.ID)
+	utils.Info("节点已删除", zap.Uint("node_id", node.ID), zap.String("name", node.Name))
+	return nil
+}
+
+// CheckNodeByID 立即检查节点连接
+func (s *NodeService) CheckNodeByID(nodeID uint) (*models.NodeResponse, error) {
+	node, err := findNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	s.CheckNode(node)
+	return s.GetNode(nodeID)
+}
+
+// CheckNode 检查节点连接并保存状态和主机信息（状态变化时记录日志）
+func (s *NodeService) CheckNode(node *models.Node) {
+	previous := node.Status
+
+	info, err := checkNode(node.ID)
+	updates := map[string]interface{}{}
+	if err != nil {
+		node.Status = models.NodeStatusOffline
+		node.LastError = truncate(err.Error(), 1024)
+	} else {
+		now := s.now()
+		node.Status = models.NodeStatusOnline
+		node.LastError = ""
+		node.LastSeenAt = &now
+		node.DockerVersion = info.DockerVersion
+		node.CPUs = info.CPUs
+		node.MemoryBytes = info.MemoryBytes
+		updates["last_seen_at"] = node.LastSeenAt
+		updates["docker_version"] = node.DockerVersion
+		updates["cpus"] = node.CPUs
+		updates["memory_bytes"] = node.MemoryBytes
+	}
+	updates["status"] = node.Status
+	updates["last_error"] = node.LastError
+
+	if err := database.DB.Model(&models.Node{}).Where("id = ?", node.ID).UpdateColumns(updates).Error; err != nil {
+		utils.Error("保存节点状态失败", zap.Uint("node_id", node.ID), zap.Error(err))
+	}
+
+	if previous != node.Status {
+		if node.Status == models.NodeStatusOnline {
+			utils.Info("节点已上线", zap.String("node", node.Name), zap.String("docker_version", node.DockerVersion))
+		} else {
+			utils.Warn("节点已离线", zap.String("node", node.Name), zap.String("error", node.LastError))
+		}
+	}
+}
+
+// UsesLocalHost 是否有服务器运行在面板所在主机上（未配置节点、存在本机节点或存在未分配节点的服务器）
+func (s *NodeService) UsesLocalHost() (bool, error) {
+	var nodeCount, localCount, unassigned int64
+	if err := database.DB.Model(&models.Node{}).Count(&nodeCount).Error; err != nil {
+		return false, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	if err := database.DB.Model(&models.Node{}).Where("endpoint = ?", "").Count(&localCount).Error; err != nil {
+		return false, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	if err := database.DB.Model(&models.Server{}).Where("node_id = ?", 0).Count(&unassigned).Error; err != nil {
+		return false, fmt.Errorf("获取服务器列表失败: %w", err)
+	}
+	return nodeCount == 0 || localCount > 0 || unassigned > 0, nil
+}
+
+// checkNode 连接节点的Docker服务并获取主机信息
+func checkNode(nodeID uint) (*docker_manager.HostInfo, error) {
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return dockerManager.GetHostInfo(healthCheckTimeout)
+}
+
+// findNode 按ID查找节点
+func findNode(nodeID uint) (*models.Node, error) {
+	var node models.Node
+	if err := database.DB.Where("id = ?", nodeID).First(&node).Error; err != nil {
+		return nil, fmt.Errorf("节点不存在")
+	}
+	return &node, nil
+}
+
+// validateNode 校验节点名称和连接参数
+// selfID: 修改节点时为节点自身ID，用于排除名称重复检查
+func validateNode(node models.Node, selfID uint) error {
+	if node.Name == "" {
+		return fmt.Errorf("节点名称不能为空")
+	}
+	var count int64
+	if err := database.DB.Model(&models.Node{}).Where("name = ? AND id <> ?", node.Name, selfID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查节点名称失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("节点名称已存在")
+	}
+	if node.IsLocal() {
+		if err := database.DB.Model(&models.Node{}).Where("endpoint = ? AND id <> ?", "", selfID).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查节点地址失败: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("面板所在主机的节点已存在")
+		}
+	}
+	if node.MaxServers < 0 {
+		return fmt.Errorf("服务器数量上限不能为负数")
+	}
+	if err := docker_manager.ValidateNode(node); err != nil {
+		return fmt.Errorf("节点配置错误: %w", err)
+	}
+	return nil
+}
+
+// setLabels 校验并保存节点标签
+func setLabels(node *models.Node, labels map[string]string) error {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key := range labels {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("节点标签名称不能为空")
+		}
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("节点标签格式错误: %w", err)
+	}
+	if len(data) > 1024 {
+		return fmt.Errorf("节点标签过长")
+	}
+	node.Labels = string(data)
+	return nil
+}
+
+// parseLabels 解析节点标签（格式错误时返回空标签）
+func parseLabels(node models.Node) map[string]string {
+	labels := map[string]string{}
+	if node.Labels != "" {
+		_ = json.Unmarshal([]byte(node.Labels), &labels)
+	}
+	return labels
+}
+
+// serverCounts 统计每个节点上的服务器数量
+func serverCounts() (map[uint]int64, error) {
+	var rows []struct {
+		NodeID uint
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Server{}).Select("node_id, COUNT(*) AS count").Group("node_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计节点上的服务器失败: %w", err)
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.NodeID] = row.Count
+	}
+	return counts, nil
+}
+
+// toNodeResponse 转换为节点响应（不包含证书和私钥）
+func toNodeResponse(node models.Node, serverCount int64) models.NodeResponse {
+	response := models.NodeResponse{
+		ID:            node.ID,
+		Name:          node.Name,
+		Endpoint:      node.Endpoint,
+		PublicHost:    node.PublicHost,
+		Local:         node.IsLocal(),
+		TLSEnabled:    node.TLSCACert != "",
+		SSHEnabled:    node.SSHKey != "",
+		Labels:        parseLabels(node),
+		MaxServers:    node.MaxServers,
+		Schedulable:   node.Schedulable,
+		ServerCount:   serverCount,
+		Status:        node.Status,
+		LastError:     node.LastError,
+		DockerVersion: node.DockerVersion,
+		CPUs:          node.CPUs,
+		MemoryBytes:   node.MemoryBytes,
+		CreatedAt:     node.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if node.LastSeenAt != nil {
+		response.LastSeenAt = node.LastSeenAt.Format("2006-01-02 15:04:05")
+	}
+	return response
+}
+
+// truncate 截断过长的字符串（不截断多字节字符）
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
@@ -0,0 +1,238 @@
+package node
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// setupTestDB 创建独立的内存数据库，测试结束后关闭节点连接（节点ID在各测试间会重复）
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	db := dbtest.Open(t)
+	t.Cleanup(func() {
+		var ids []uint
+		db.Model(&models.Node{}).Pluck("id", &ids)
+		for _, id := range ids {
+			docker_manager.InvalidateNodeManager(id)
+		}
+	})
+}
+
+// createNode 直接写入节点记录
+func createNode(t *testing.T, node models.Node) models.Node {
+	t.Helper()
+	if node.Labels == "" {
+		node.Labels = "{}"
+	}
+	if err := database.DB.Create(&node).Error; err != nil {
+		t.Fatalf("创建节点失败: %v", err)
+	}
+	return node
+}
+
+// createServer 在节点上写入服务器记录
+func createServer(t *testing.T, identifier string, nodeID uint, port int) {
+	t.Helper()
+	server := models.Server{Identifier: identifier, NodeID: nodeID, Port: port, QueryPort: port + 100, RCONPort: port + 200, ServerArgsJSON: "{}"}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+}
+
+// fakeDockerNode 启动模拟的TLS Docker守护进程，返回连接地址和CA证书
+func fakeDockerNode(t *testing.T) (string, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.45")
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/v1.45/info", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ServerVersion": "26.1.0", "NCPU": 8, "MemTotal": 16 << 30})
+	})
+	mux.HandleFunc("/v1.45/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return "tcp://" + server.Listener.Addr().String(), string(caCert)
+}
+
+func TestSelectNodeWithoutNodesUsesLocalHost(t *testing.T) {
+	setupTestDB(t)
+	service := NewNodeService()
+
+	if nodeID, err := service.SelectNode(Placement{}); err != nil || nodeID != 0 {
+		t.Fatalf("未配置节点时应使用面板所在主机: %d, %v", nodeID, err)
+	}
+	requested := uint(3)
+	if _, err := service.SelectNode(Placement{NodeID: &requested}); err == nil || err.Error() != "节点不存在" {
+		t.Fatalf("指定不存在的节点应返回错误: %v", err)
+	}
+}
+
+func TestSelectNode(t *testing.T) {
+	setupTestDB(t)
+	service := NewNodeService()
+
+	busy := createNode(t, models.Node{Name: "busy", Endpoint: "tcp://a:2376", Status: models.NodeStatusOnline, Schedulable: true, Labels: `{"region":"eu"}`})
+	idle := createNode(t, models.Node{Name: "idle", Endpoint: "tcp://b:2376", Status: models.NodeStatusOnline, Schedulable: true, Labels: `{"region":"us"}`})
+	full := createNode(t, models.Node{Name: "full", Endpoint: "tcp://c:2376", Status: models.NodeStatusOnline, Schedulable: true, MaxServers: 1})
+	offline := createNode(t, models.Node{Name: "offline", Endpoint: "tcp://d:2376", Status: models.NodeStatusOffline, Schedulable: true})
+	drained := createNode(t, models.Node{Name: "drained", Endpoint: "tcp://e:2376", Status: models.NodeStatusOnline})
+
+	createServer(t, "island", busy.ID, 7777)
+	createServer(t, "ragnarok", busy.ID, 7787)
+	createServer(t, "center", full.ID, 7777)
+
+	// 自动选择服务器最少的可用节点（跳过离线、已满和不允许自动分配的节点）
+	if nodeID, err := service.SelectNode(Placement{Ports: []int{7777}}); err != nil || nodeID != idle.ID {
+		t.Fatalf("应选择服务器最少的节点 %d，实际为 %d, %v", idle.ID, nodeID, err)
+	}
+
+	// 按标签选择
+	if nodeID, err := service.SelectNode(Placement{Labels: map[string]string{"region": "eu"}, Ports: []int{7797}}); err != nil || nodeID != busy.ID {
+		t.Fatalf("应选择满足标签的节点 %d，实际为 %d, %v", busy.ID, nodeID, err)
+	}
+	_, err := service.SelectNode(Placement{Labels: map[string]string{"region": "eu"}, Ports: []int{7787}})
+	if err == nil || !strings.HasPrefix(err.Error(), "没有可用的节点") {
+		t.Fatalf("端口冲突时应返回没有可用的节点: %v", err)
+	}
+
+	// 指定节点时不检查是否允许自动分配，但仍检查状态和容量
+	cases := []struct {
+		nodeID uint
+		err    string
+	}{
+		{drained.ID, ""},
+		{offline.ID, "节点 offline 不在线"},
+		{full.ID, "节点 full 的服务器数量已达到上限 1"},
+		{busy.ID, "节点 busy 上的端口 7778 已被服务器 island 占用"},
+	}
+	for _, item := range cases {
+		nodeID, err := service.SelectNode(Placement{NodeID: &item.nodeID, Ports: []int{7778}})
+		if item.err == "" {
+			if err != nil || nodeID != item.nodeID {
+				t.Errorf("应选择指定节点 %d，实际为 %d, %v", item.nodeID, nodeID, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != item.err {
+			t.Errorf("节点 %d: 期望错误 %q，实际为 %v", item.nodeID, item.err, err)
+		}
+	}
+}
+
+func TestUsedPortConsidersBindIP(t *testing.T) {
+	setupTestDB(t)
+
+	servers := []models.Server{
+		{Identifier: "public-a", Port: 7777, QueryPort: 27015, RCONPort: 32330, Network: models.ServerNetwork{BindIP: "203.0.113.10"}},
+		{Identifier: "lan", Port: 7787, QueryPort: 27025, RCONPort: 32340, Network: models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan", BindIP: "192.168.1.50"}},
+	}
+	for i := range servers {
+		servers[i].ServerArgsJSON = "{}"
+		if err := database.DB.Create(&servers[i]).Error; err != nil {
+			t.Fatalf("创建服务器失败: %v", err)
+		}
+	}
+
+	cases := []struct {
+		hostIP    string
+		ports     []int
+		excludeID uint
+		want      string
+	}{
+		{"203.0.113.11", []int{7777}, 0, ""},         // 绑定到不同IP时可以使用相同端口
+		{"203.0.113.10", []int{7777}, 0, "public-a"}, // 相同IP
+		{"", []int{7777}, 0, "public-a"},             // 绑定所有地址时与任何IP冲突
+		{"", []int{7787}, 0, ""},                     // macvlan 模式不占用主机端口
+		{"203.0.113.10", []int{7777}, servers[0].ID, ""},
+	}
+	for _, item := range cases {
+		identifier, _, err := UsedPort(0, item.hostIP, item.ports, item.excludeID)
+		if err != nil || identifier != item.want {
+			t.Errorf("UsedPort(%q, %v) = %q, %v，期望 %q", item.hostIP, item.ports, identifier, err, item.want)
+		}
+	}
+}
+
+func TestCreateNodeValidatesAndChecks(t *testing.T) {
+	setupTestDB(t)
+	service := NewNodeService()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	service.now = func() time.Time { return now }
+
+	endpoint, caCert := fakeDockerNode(t)
+	node, err := service.CreateNode(models.NodeRequest{Name: "remote", Endpoint: endpoint, TLSCACert: caCert, Labels: map[string]string{"gpu": "no"}})
+	if err != nil {
+		t.Fatalf("添加节点失败: %v", err)
+	}
+	if node.Status != models.NodeStatusOnline || node.DockerVersion != "26.1.0" || node.CPUs != 8 || !node.Schedulable {
+		t.Fatalf("添加后应检查节点状态: %+v", node)
+	}
+	if node.LastSeenAt != "2026-01-02 03:04:05" || node.Labels["gpu"] != "no" {
+		t.Fatalf("节点信息错误: %+v", node)
+	}
+
+	if _, err := service.CreateNode(models.NodeRequest{Name: "remote", Endpoint: endpoint, TLSCACert: caCert}); err == nil || err.Error() != "节点名称已存在" {
+		t.Fatalf("重复的节点名称应返回错误: %v", err)
+	}
+	if _, err := service.CreateNode(models.NodeRequest{Name: "plain", Endpoint: "tcp://docker:2375"}); err == nil || !strings.HasPrefix(err.Error(), "节点配置错误") {
+		t.Fatalf("未配置TLS的TCP节点应返回错误: %v", err)
+	}
+
+	// 节点上有服务器时不能删除
+	createServer(t, "island", node.ID, 7777)
+	if err := service.DeleteNode(node.ID); err == nil || err.Error() != "节点上还有服务器，不能删除" {
+		t.Fatalf("节点上有服务器时应拒绝删除: %v", err)
+	}
+}
+
+func TestHealthCheckerMarksUnreachableNodeOffline(t *testing.T) {
+	setupTestDB(t)
+	service := NewNodeService()
+
+	endpoint, caCert := fakeDockerNode(t)
+	online := createNode(t, models.Node{Name: "online", Endpoint: endpoint, TLSCACert: caCert, Status: models.NodeStatusOffline})
+	// 没有进程监听的端口
+	unreachable := createNode(t, models.Node{Name: "unreachable", Endpoint: "tcp://127.0.0.1:1", TLSCACert: caCert, Status: models.NodeStatusOnline})
+
+	NewHealthChecker(service).CheckAll()
+
+	var nodes []models.Node
+	database.DB.Order("id").Find(&nodes)
+	if nodes[0].ID != online.ID || nodes[0].Status != models.NodeStatusOnline || nodes[0].LastError != "" || nodes[0].LastSeenAt == nil {
+		t.Fatalf("可连接的节点应标记为在线: %+v", nodes[0])
+	}
+	if nodes[1].ID != unreachable.ID || nodes[1].Status != models.NodeStatusOffline || nodes[1].LastError == "" {
+		t.Fatalf("无法连接的节点应标记为离线: %+v", nodes[1])
+	}
+
+	requested := unreachable.ID
+	if _, err := service.SelectNode(Placement{NodeID: &requested}); err == nil || err.Error() != "节点 unreachable 不在线" {
+		t.Fatalf("离线节点不能运行新服务器: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"fmt"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+)
+
+// Placement 新服务器的节点选择条件
+type Placement struct {
+	NodeID *uint             // 指定节点，为空时自动选择
+	Labels map[string]string // 节点需要包含的标签
+	Ports  []int             // 服务器需要占用的主机端口
+	HostIP string            // 端口绑定的主机IP，为空表示所有地址
+}
+
+// SelectNode 为新服务器选择节点
+// 指定节点时只检查该节点；否则在允许自动分配的在线节点中选择服务器最少的节点
+// 返回: 节点ID（未配置任何节点时返回 0，表示面板所在主机）和错误信息
+func (s *NodeService) SelectNode(placement Placement) (uint, error) {
+	var nodes []models.Node
+	if err := database.DB.Order("id").Find(&nodes).Error; err != nil {
+		return 0, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	if len(nodes) == 0 {
+		if placement.NodeID != nil && *placement.NodeID != 0 {
+			return 0, fmt.Errorf("节点不存在")
+		}
+		return 0, nil
+	}
+
+	counts, err := serverCounts()
+	if err != nil {
+		return 0, err
+	}
+
+	if placement.NodeID != nil {
+		for _, node := range nodes {
+			if node.ID == *placement.NodeID {
+				if err := checkPlacement(node, placement, counts[node.ID]); err != nil {
+					return 0, err
+				}
+				return node.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("节点不存在")
+	}
+
+	var selected *models.Node
+	var lastErr error
+	for i := range nodes {
+		node := nodes[i]
+		if !node.Schedulable {
+			continue
+		}
+		if err := checkPlacement(node, placement, counts[node.ID]); err != nil {
+			lastErr = err
+			continue
+		}
+		if selected == nil || counts[node.ID] < counts[selected.ID] {
+			selected = &nodes[i]
+		}
+	}
+
+	if selected == nil {
+		if lastErr != nil {
+			return 0, fmt.Errorf("没有可用的节点: %w", lastErr)
+		}
+		return 0, fmt.Errorf("没有可用的节点")
+	}
+	return selected.ID, nil
+}
+
+// checkPlacement 检查节点能否运行新服务器（在线、满足标签、未达到数量上限、端口未被占用）
+func checkPlacement(node models.Node, placement Placement, serverCount int64) error {
+	if node.Status != models.NodeStatusOnline {
+		return fmt.Errorf("节点 %s 不在线", node.Name)
+	}
+
+	labels := parseLabels(node)
+	for key, value := range placement.Labels {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return fmt.Errorf("节点 %s 不满足标签 %s=%s", node.Name, key, value)
+		}
+	}
+
+	if node.MaxServers > 0 && serverCount >= int64(node.MaxServers) {
+		return fmt.Errorf("节点 %s 的服务器数量已达到上限 %d", node.Name, node.MaxServers)
+	}
+
+	if len(placement.Ports) > 0 {
+		identifier, port, err := UsedPort(node.ID, placement.HostIP, placement.Ports, 0)
+		if err != nil {
+			return fmt.Errorf("获取节点 %s 上的服务器失败: %w", node.Name, err)
+		}
+		if identifier != "" {
+			return fmt.Errorf("节点 %s 上的端口 %d 已被服务器 %s 占用", node.Name, port, identifier)
+		}
+	}
+	return nil
+}
+
+// UsedPort 检查节点上的服务器是否已占用指定端口
+// hostIP: 端口绑定的主机IP，为空表示所有地址；绑定到不同IP的服务器可以使用相同的端口
+// excludeID: 不检查的服务器（修改服务器配置时为该服务器，0 表示检查所有服务器）
+// 返回: 占用端口的服务器标识和端口（未被占用时标识为空）和错误信息
+func UsedPort(nodeID uint, hostIP string, ports []int, excludeID uint) (string, int, error) {
+	var servers []models.Server
+	if err := database.DB.Select("id", "identifier", "port", "query_port", "rcon_port", "network_mode", "bind_ip").Where("node_id = ? AND id != ?", nodeID, excludeID).Find(&servers).Error; err != nil {
+		return "", 0, err
+	}
+	for _, server := range servers {
+		if hostIP != "" && server.Network.BindIP != "" && hostIP != server.Network.BindIP {
+			continue
+		}
+		if port, ok := conflictingPort(ports, server.HostPorts()); ok {
+			return server.Identifier, port, nil
+		}
+	}
+	return "", 0, nil
+}
+
+// conflictingPort 返回两组端口中第一个重复的端口
+func conflictingPort(ports, used []int) (int, bool) {
+	for _, port := range ports {
+		for _, other := range used {
+			if port == other {
+				return port, true
+			}
+		}
+	}
+	return 0, false
+}
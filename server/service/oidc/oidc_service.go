@@ -0,0 +1,470 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 登录流程参数
+const (
+	AuthRequestTTL  = 10 * time.Minute // 跳转到身份提供方后完成登录的时限，也是浏览器绑定 Cookie 的有效期
+	exchangeCodeTTL = time.Minute      // 回调后前端兑换令牌的时限
+	randomBytes     = 32
+)
+
+// rolePriority 角色优先级（匹配到多个映射时取权限最高的角色）
+var rolePriority = map[string]int{
+	models.RoleOwner:    4,
+	models.RoleAdmin:    3,
+	models.RoleOperator: 2,
+	models.RoleViewer:   1,
+}
+
+// CallbackResult 回调处理结果
+type CallbackResult struct {
+	ExchangeCode string // 登录时交给前端的一次性兑换码
+	Linked       bool   // 是否为绑定外部账号
+}
+
+// OIDCService OpenID Connect 单点登录服务（授权码 + PKCE）
+type OIDCService struct {
+	client *http.Client
+	now    func() time.Time // 当前时间，测试中可替换为固定时钟
+
+	mu       sync.Mutex
+	provider *provider
+}
+
+// NewOIDCService 创建 OIDC 单点登录服务实例
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		client: &http.Client{Timeout: providerRequestTimeout},
+		now:    time.Now,
+	}
+}
+
+// Enabled 是否已启用 OIDC 登录
+func (s *OIDCService) Enabled() bool {
+	return config.OIDCEnabled
+}
+
+// BeginLogin 创建登录请求，返回身份提供方的授权地址和浏览器绑定值
+// linkUserID: 绑定外部账号时为当前用户ID，登录时为 0
+// 绑定值需要保存在发起登录的浏览器的 Cookie 中，回调时校验，防止把登录请求交给其他浏览器完成（登录CSRF）
+func (s *OIDCService) BeginLogin(ctx context.Context, linkUserID uint) (authURL string, binding string, err error) {
+	if !config.OIDCEnabled {
+		return "", "", fmt.Errorf("未启用OIDC登录")
+	}
+	document, err := s.getProvider().discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.RandomToken("", randomBytes)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.RandomToken("", randomBytes)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.RandomToken("", randomBytes)
+	if err != nil {
+		return "", "", err
+	}
+	binding, err = utils.RandomToken("", randomBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.now()
+	// 顺便清理过期的登录请求
+	database.DB.Where("expires_at < ?", now).Delete(&models.OIDCAuthRequest{})
+
+	request := models.OIDCAuthRequest{
+		StateHash:    utils.SHA256Hex(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BindingHash:  utils.SHA256Hex(binding),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    now.Add(AuthRequestTTL),
+	}
+	if err := database.DB.Create(&request).Error; err != nil {
+		return "", "", fmt.Errorf("创建登录请求失败: %w", err)
+	}
+
+	endpoint, err := url.Parse(document.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("无效的授权端点: %w", err)
+	}
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.OIDCClientID)
+	query.Set("redirect_uri", config.OIDCRedirectURL)
+	query.Set("scope", strings.Join(config.OIDCScopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), binding, nil
+}
+
+// HandleCallback 处理身份提供方的回调：换取并校验 ID 令牌，确定本地用户
+// binding 为浏览器 Cookie 中保存的绑定值，与发起登录时不一致的回调会被拒绝
+func (s *OIDCService) HandleCallback(ctx context.Context, code, state, binding string) (*CallbackResult, error) {
+	if !config.OIDCEnabled {
+		return nil, fmt.Errorf("未启用OIDC登录")
+	}
+
+	// 登录请求只能使用一次：取出后立即作废 state
+	var request models.OIDCAuthRequest
+	if err := database.DB.Where("state_hash = ? AND user_id = 0", utils.SHA256Hex(state)).First(&request).Error; err != nil {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+	if s.now().After(request.ExpiresAt) {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+	// 先校验浏览器再作废 state，伪造的回调不能让正常的登录请求失效
+	if binding == "" || subtle.ConstantTimeCompare([]byte(utils.SHA256Hex(binding)), []byte(request.BindingHash)) != 1 {
+		return nil, fmt.Errorf("登录请求不是由当前浏览器发起的，请重新登录")
+	}
+	result := database.DB.Model(&models.OIDCAuthRequest{}).
+		Where("id = ? AND state_hash = ?", request.ID, request.StateHash).
+		Update("state_hash", "used:"+request.StateHash)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+
+	provider := s.getProvider()
+	idToken, err := provider.exchangeCode(ctx, code, request.CodeVerifier, config.OIDCClientID, config.OIDCClientSecret, config.OIDCRedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.verifyIDToken(ctx, idToken, config.OIDCClientID, request.Nonce, s.now)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.LinkUserID != 0 {
+		if err := s.linkIdentity(request.LinkUserID, claims); err != nil {
+			return nil, err
+		}
+		return &CallbackResult{Linked: true}, nil
+	}
+
+	user, err := s.resolveUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeCode, err := utils.RandomToken("", randomBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(&request).Updates(map[string]interface{}{
+		"user_id":       user.ID,
+		"exchange_hash": utils.SHA256Hex(exchangeCode),
+		"expires_at":    s.now().Add(exchangeCodeTTL),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("保存登录结果失败: %w", err)
+	}
+
+	return &CallbackResult{ExchangeCode: exchangeCode}, nil
+}
+
+// Exchange 使用回调后的一次性兑换码获取登录用户（兑换码只能使用一次）
+func (s *OIDCService) Exchange(rawCode string) (*models.User, error) {
+	var request models.OIDCAuthRequest
+	if err := database.DB.Where("exchange_hash = ? AND user_id != 0", utils.SHA256Hex(rawCode)).First(&request).Error; err != nil {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+	now := s.now()
+	if request.UsedAt != nil || now.After(request.ExpiresAt) {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+
+	result := database.DB.Model(&models.OIDCAuthRequest{}).
+		Where("id = ? AND used_at IS NULL", request.ID).
+		Update("used_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, fmt.Errorf("登录请求已失效，请重新登录")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, request.UserID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("账号已被禁用")
+	}
+	return &user, nil
+}
+
+// ListIdentities 获取用户绑定的外部账号
+func (s *OIDCService) ListIdentities(userID uint) ([]models.UserIdentityResponse, error) {
+	var identities []models.UserIdentity
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("获取外部账号失败: %w", err)
+	}
+
+	responses := make([]models.UserIdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		response := models.UserIdentityResponse{
+			ID:        identity.ID,
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt.Format(time.RFC3339),
+		}
+		if identity.LastLoginAt != nil {
+			response.LastLoginAt = identity.LastLoginAt.Format(time.RFC3339)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// Unlink 解除外部账号绑定
+func (s *OIDCService) Unlink(userID uint, identityID string) error {
+	id, err := strconv.ParseUint(identityID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的外部账号ID")
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return fmt.Errorf("解除绑定失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("外部账号不存在")
+	}
+
+	utils.Info("用户已解除外部账号绑定", zap.Uint("user_id", userID), zap.Uint64("identity_id", id))
+	return nil
+}
+
+// linkIdentity 将外部账号绑定到已登录的本地用户
+func (s *OIDCService) linkIdentity(userID uint, claims jwt.MapClaims) error {
+	subject, _ := claims["sub"].(string)
+
+	var existing models.UserIdentity
+	err := database.DB.Where("issuer = ? AND subject = ?", config.OIDCIssuer, subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		return fmt.Errorf("该外部账号已绑定其他用户")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("查询外部账号失败: %w", err)
+	}
+
+	identity := models.UserIdentity{
+		UserID:  userID,
+		Issuer:  config.OIDCIssuer,
+		Subject: subject,
+		Email:   claimString(claims, "email"),
+	}
+	if err := database.DB.Create(&identity).Error; err != nil {
+		return fmt.Errorf("绑定外部账号失败: %w", err)
+	}
+
+	utils.Info("用户已绑定外部账号", zap.Uint("user_id", userID), zap.String("subject", subject))
+	return nil
+}
+
+// resolveUser 根据 ID 令牌确定本地用户：已绑定的直接登录，未绑定时按配置自动创建
+// 配置了角色映射时，每次登录都会按映射同步角色
+func (s *OIDCService) resolveUser(claims jwt.MapClaims) (*models.User, error) {
+	subject, _ := claims["sub"].(string)
+	mappedRole := mapRole(claims)
+
+	var identity models.UserIdentity
+	err := database.DB.Where("issuer = ? AND subject = ?", config.OIDCIssuer, subject).First(&identity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询外部账号失败: %w", err)
+	}
+
+	var user models.User
+	if err == nil {
+		if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, fmt.Errorf("外部账号绑定的用户不存在")
+		}
+		if mappedRole == "" && config.OIDCDefaultRole == "" {
+			return nil, fmt.Errorf("没有访问权限")
+		}
+		if mappedRole != "" && mappedRole != user.Role {
+			s.syncRole(&user, mappedRole)
+		}
+	} else {
+		created, err := s.provisionUser(claims, mappedRole)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+		identity = models.UserIdentity{UserID: user.ID, Issuer: config.OIDCIssuer, Subject: subject}
+	}
+
+	if user.Disabled {
+		return nil, fmt.Errorf("账号已被禁用")
+	}
+
+	now := s.now()
+	identity.Email = claimString(claims, "email")
+	identity.LastLoginAt = &now
+	if err := database.DB.Save(&identity).Error; err != nil {
+		return nil, fmt.Errorf("保存外部账号失败: %w", err)
+	}
+	return &user, nil
+}
+
+// provisionUser 首次登录时自动创建本地用户
+// 不会按用户名自动关联已有的本地账号，避免同名账号被接管，已有账号需要登录后主动绑定
+func (s *OIDCService) provisionUser(claims jwt.MapClaims, mappedRole string) (*models.User, error) {
+	if !config.OIDCAutoProvision {
+		return nil, fmt.Errorf("外部账号未绑定本地用户")
+	}
+
+	role := mappedRole
+	if role == "" {
+		role = config.OIDCDefaultRole
+	}
+	if role == "" {
+		return nil, fmt.Errorf("没有访问权限")
+	}
+
+	username := claimString(claims, config.OIDCUsernameClaim)
+	if username == "" {
+		username = claimString(claims, "email")
+	}
+	if username == "" {
+		return nil, fmt.Errorf("ID令牌中缺少用户名声明: %s", config.OIDCUsernameClaim)
+	}
+
+	var count int64
+	database.DB.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("用户名已存在，请使用本地账号登录后绑定外部账号")
+	}
+
+	// 单点登录用户不使用本地密码，设置一个无法猜测的随机密码
+	randomPassword, err := utils.RandomToken("", randomBytes)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return nil, fmt.Errorf("密码加密失败")
+	}
+
+	user := models.User{Username: username, Password: hashedPassword, Role: role}
+	if err := database.DB.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户创建失败: %w", err)
+	}
+
+	utils.Info("已通过OIDC自动创建用户", zap.String("username", username), zap.String("role", role))
+	return &user, nil
+}
+
+// syncRole 按角色映射更新用户角色（不会降级最后一个可用的所有者）
+func (s *OIDCService) syncRole(user *models.User, role string) {
+	if user.Role == models.RoleOwner {
+		var owners int64
+		database.DB.Model(&models.User{}).
+			Where("role = ? AND disabled = ? AND id != ?", models.RoleOwner, false, user.ID).
+			Count(&owners)
+		if owners == 0 {
+			utils.Warn("角色映射会降级最后一个所有者，已跳过", zap.Uint("user_id", user.ID), zap.String("role", role))
+			return
+		}
+	}
+
+	if err := database.DB.Model(user).Update("role", role).Error; err != nil {
+		utils.Warn("同步用户角色失败", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	utils.Info("已按角色映射更新用户角色", zap.Uint("user_id", user.ID), zap.String("role", role))
+}
+
+// getProvider 获取身份提供方客户端（配置变化时重新创建）
+func (s *OIDCService) getProvider() *provider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider == nil || s.provider.issuer != config.OIDCIssuer {
+		s.provider = newProvider(config.OIDCIssuer, s.client)
+	}
+	return s.provider
+}
+
+// mapRole 根据角色声明和映射配置计算角色，匹配多个时取权限最高的角色
+func mapRole(claims jwt.MapClaims) string {
+	role := ""
+	for _, value := range claimValues(claims, config.OIDCRoleClaim) {
+		mapped, ok := config.OIDCRoleMapping[value]
+		if ok && rolePriority[mapped] > rolePriority[role] {
+			role = mapped
+		}
+	}
+	return role
+}
+
+// claimValues 读取声明值（支持字符串和字符串数组，支持 realm_access.roles 这样的嵌套路径）
+func claimValues(claims jwt.MapClaims, path string) []string {
+	var current interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+
+	switch value := current.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// claimString 读取字符串声明
+func claimString(claims jwt.MapClaims, path string) string {
+	values := claimValues(claims, path)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// codeChallenge 计算 PKCE S256 校验值
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
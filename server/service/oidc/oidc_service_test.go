@@ -0,0 +1,356 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// mockProvider 本地模拟的身份提供方
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+// mockGrant 已签发授权码对应的 PKCE 校验值和声明
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// newMockProvider 启动模拟身份提供方，提供发现文档、JWKS 和令牌端点
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	mock := &mockProvider{key: key, codes: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                mock.server.URL,
+			AuthorizationEndpoint: mock.server.URL + "/authorize",
+			TokenEndpoint:         mock.server.URL + "/token",
+			JWKSURI:               mock.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []jsonWebKey{{
+				KeyType: "RSA",
+				KeyID:   "test-key",
+				Use:     "sig",
+				N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mock.mu.Lock()
+		grant, ok := mock.codes[r.PostForm.Get("code")]
+		delete(mock.codes, r.PostForm.Get("code"))
+		mock.mu.Unlock()
+
+		if !ok || codeChallenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": mock.sign(t, grant.claims)})
+	})
+	mock.server = httptest.NewServer(mux)
+	t.Cleanup(mock.server.Close)
+	return mock
+}
+
+// authorize 模拟用户在身份提供方登录：解析授权地址并签发授权码
+// claims 中未设置的 iss/aud/nonce/exp/iat 会自动补全
+func (m *mockProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (code, state string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("解析授权地址失败: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("授权地址缺少 PKCE 参数: %s", authURL)
+	}
+
+	defaults := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   config.OIDCClientID,
+		"nonce": query.Get("nonce"),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for key, value := range defaults {
+		if _, ok := claims[key]; !ok {
+			claims[key] = value
+		}
+	}
+
+	code = fmt.Sprintf("code-%d", time.Now().UnixNano())
+	m.mu.Lock()
+	m.codes[code] = mockGrant{challenge: query.Get("code_challenge"), claims: claims}
+	m.mu.Unlock()
+	return code, query.Get("state")
+}
+
+// sign 使用模拟身份提供方的私钥签名 ID 令牌
+func (m *mockProvider) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("签名ID令牌失败: %v", err)
+	}
+	return signed
+}
+
+// setupOIDC 创建内存数据库、模拟身份提供方并设置 OIDC 配置
+func setupOIDC(t *testing.T) (*OIDCService, *mockProvider) {
+	t.Helper()
+
+	dbtest.Open(t)
+
+	mock := newMockProvider(t)
+	config.OIDCEnabled = true
+	config.OIDCIssuer = mock.server.URL
+	config.OIDCClientID = "panel"
+	config.OIDCClientSecret = ""
+	config.OIDCRedirectURL = "http://panel.test/api/auth/oidc/callback"
+	config.OIDCScopes = []string{"openid", "profile"}
+	config.OIDCUsernameClaim = "preferred_username"
+	config.OIDCRoleClaim = "groups"
+	config.OIDCRoleMapping = map[string]string{"ark-admins": models.RoleAdmin, "ark-operators": models.RoleOperator}
+	config.OIDCDefaultRole = models.RoleViewer
+	config.OIDCAutoProvision = true
+	t.Cleanup(func() { config.OIDCEnabled = false })
+
+	return NewOIDCService(), mock
+}
+
+// login 完成一次完整的登录流程，返回回调结果
+func login(t *testing.T, service *OIDCService, mock *mockProvider, linkUserID uint, claims jwt.MapClaims) (*CallbackResult, error) {
+	t.Helper()
+
+	authURL, binding, err := service.BeginLogin(context.Background(), linkUserID)
+	if err != nil {
+		t.Fatalf("创建登录请求失败: %v", err)
+	}
+	code, state := mock.authorize(t, authURL, claims)
+	return service.HandleCallback(context.Background(), code, state, binding)
+}
+
+func TestLoginProvisionsUserWithMappedRole(t *testing.T) {
+	service, mock := setupOIDC(t)
+
+	result, err := login(t, service, mock, 0, jwt.MapClaims{
+		"sub":                "sub-1",
+		"preferred_username": "bob",
+		"email":              "bob@example.com",
+		"groups":             []string{"ark-operators", "ark-admins", "other"},
+	})
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+
+	user, err := service.Exchange(result.ExchangeCode)
+	if err != nil {
+		t.Fatalf("兑换登录结果失败: %v", err)
+	}
+	if user.Username != "bob" || user.Role != models.RoleAdmin {
+		t.Fatalf("期望创建 admin 用户 bob，实际 %s/%s", user.Username, user.Role)
+	}
+
+	// 兑换码只能使用一次
+	if _, err := service.Exchange(result.ExchangeCode); err == nil {
+		t.Fatal("兑换码重复使用应失败")
+	}
+
+	// 再次登录复用同一用户，并按映射同步角色
+	result, err = login(t, service, mock, 0, jwt.MapClaims{
+		"sub":                "sub-1",
+		"preferred_username": "bob",
+		"groups":             []string{"ark-operators"},
+	})
+	if err != nil {
+		t.Fatalf("再次登录失败: %v", err)
+	}
+	again, err := service.Exchange(result.ExchangeCode)
+	if err != nil {
+		t.Fatalf("兑换登录结果失败: %v", err)
+	}
+	if again.ID != user.ID || again.Role != models.RoleOperator {
+		t.Fatalf("期望同一用户角色同步为 operator，实际 %d/%s", again.ID, again.Role)
+	}
+}
+
+func TestLoginDoesNotTakeOverLocalAccount(t *testing.T) {
+	service, mock := setupOIDC(t)
+	database.DB.Create(&models.User{Username: "alice", Password: "x", Role: models.RoleOwner})
+
+	_, err := login(t, service, mock, 0, jwt.MapClaims{"sub": "sub-2", "preferred_username": "alice"})
+	if err == nil || err.Error() != "用户名已存在，请使用本地账号登录后绑定外部账号" {
+		t.Fatalf("同名本地账号不应被自动关联，实际错误: %v", err)
+	}
+}
+
+func TestLinkIdentityToLocalAccount(t *testing.T) {
+	service, mock := setupOIDC(t)
+	local := models.User{Username: "alice", Password: "x", Role: models.RoleOwner}
+	database.DB.Create(&local)
+
+	result, err := login(t, service, mock, local.ID, jwt.MapClaims{"sub": "sub-3", "preferred_username": "alice-sso"})
+	if err != nil {
+		t.Fatalf("绑定外部账号失败: %v", err)
+	}
+	if !result.Linked {
+		t.Fatal("期望返回绑定结果")
+	}
+
+	// 绑定后使用外部账号登录到本地用户，未匹配映射时不改变角色
+	result, err = login(t, service, mock, 0, jwt.MapClaims{"sub": "sub-3"})
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	user, err := service.Exchange(result.ExchangeCode)
+	if err != nil {
+		t.Fatalf("兑换登录结果失败: %v", err)
+	}
+	if user.ID != local.ID || user.Role != models.RoleOwner {
+		t.Fatalf("期望登录到本地用户，实际 %d/%s", user.ID, user.Role)
+	}
+
+	identities, err := service.ListIdentities(local.ID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("期望 1 个外部账号，实际 %d (%v)", len(identities), err)
+	}
+
+	// 其他用户不能绑定同一个外部账号
+	other := models.User{Username: "carol", Password: "x", Role: models.RoleViewer}
+	database.DB.Create(&other)
+	if _, err := login(t, service, mock, other.ID, jwt.MapClaims{"sub": "sub-3"}); err == nil {
+		t.Fatal("外部账号已绑定其他用户时应失败")
+	}
+
+	if err := service.Unlink(local.ID, fmt.Sprint(identities[0].ID)); err != nil {
+		t.Fatalf("解除绑定失败: %v", err)
+	}
+}
+
+func TestCallbackRejectsInvalidRequests(t *testing.T) {
+	service, mock := setupOIDC(t)
+	claims := jwt.MapClaims{"sub": "sub-4", "preferred_username": "dave"}
+
+	// 未知的 state
+	if _, err := service.HandleCallback(context.Background(), "code", "unknown", "binding"); err == nil {
+		t.Fatal("未知 state 应失败")
+	}
+
+	// nonce 不匹配
+	authURL, binding, _ := service.BeginLogin(context.Background(), 0)
+	code, state := mock.authorize(t, authURL, jwt.MapClaims{"sub": "sub-4", "preferred_username": "dave", "nonce": "forged"})
+	if _, err := service.HandleCallback(context.Background(), code, state, binding); err == nil {
+		t.Fatal("nonce 不匹配应失败")
+	}
+
+	// audience 不匹配
+	authURL, binding, _ = service.BeginLogin(context.Background(), 0)
+	code, state = mock.authorize(t, authURL, jwt.MapClaims{"sub": "sub-4", "preferred_username": "dave", "aud": "other-client"})
+	if _, err := service.HandleCallback(context.Background(), code, state, binding); err == nil {
+		t.Fatal("audience 不匹配应失败")
+	}
+
+	// state 只能使用一次
+	authURL, binding, _ = service.BeginLogin(context.Background(), 0)
+	code, state = mock.authorize(t, authURL, claims)
+	if _, err := service.HandleCallback(context.Background(), code, state, binding); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if _, err := service.HandleCallback(context.Background(), code, state, binding); err == nil {
+		t.Fatal("state 重复使用应失败")
+	}
+
+	// 过期的登录请求
+	authURL, binding, _ = service.BeginLogin(context.Background(), 0)
+	code, state = mock.authorize(t, authURL, claims)
+	service.now = func() time.Time { return time.Now().Add(AuthRequestTTL + time.Minute) }
+	if _, err := service.HandleCallback(context.Background(), code, state, binding); err == nil {
+		t.Fatal("过期的登录请求应失败")
+	}
+}
+
+func TestCallbackRejectsOtherBrowser(t *testing.T) {
+	service, mock := setupOIDC(t)
+	local := models.User{Username: "alice", Password: "x", Role: models.RoleOwner}
+	database.DB.Create(&local)
+
+	// 攻击者发起绑定请求，诱导其他浏览器完成回调
+	authURL, binding, err := service.BeginLogin(context.Background(), local.ID)
+	if err != nil {
+		t.Fatalf("创建登录请求失败: %v", err)
+	}
+	code, state := mock.authorize(t, authURL, jwt.MapClaims{"sub": "sub-5"})
+	for _, other := range []string{"", "other-browser"} {
+		if _, err := service.HandleCallback(context.Background(), code, state, other); err == nil ||
+			err.Error() != "登录请求不是由当前浏览器发起的，请重新登录" {
+			t.Fatalf("浏览器绑定值 %q 不匹配时应拒绝回调，实际错误: %v", other, err)
+		}
+	}
+	var count int64
+	database.DB.Model(&models.UserIdentity{}).Count(&count)
+	if count != 0 {
+		t.Fatal("浏览器不匹配时不应绑定外部账号")
+	}
+
+	// 被拒绝的回调不会作废登录请求，发起登录的浏览器仍然可以完成
+	result, err := service.HandleCallback(context.Background(), code, state, binding)
+	if err != nil || !result.Linked {
+		t.Fatalf("发起登录的浏览器应能完成绑定: %v", err)
+	}
+}
+
+func TestLoginWithoutDefaultRoleRequiresMapping(t *testing.T) {
+	service, mock := setupOIDC(t)
+	config.OIDCDefaultRole = ""
+	t.Cleanup(func() { config.OIDCDefaultRole = models.RoleViewer })
+
+	_, err := login(t, service, mock, 0, jwt.MapClaims{"sub": "sub-5", "preferred_username": "eve", "groups": "unmapped"})
+	if err == nil || err.Error() != "没有访问权限" {
+		t.Fatalf("未匹配映射应拒绝登录，实际错误: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 身份提供方访问参数
+const (
+	providerRequestTimeout = 10 * time.Second
+	maxProviderResponse    = 1 << 20          // 身份提供方响应的最大字节数
+	jwksMinRefresh         = 30 * time.Second // 遇到未知密钥时重新获取 JWKS 的最小间隔
+	idTokenLeeway          = time.Minute      // 校验 ID 令牌时间时允许的时钟偏差
+)
+
+// discoveryDocument OpenID Connect 发现文档中用到的字段
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse 令牌端点的响应
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// jsonWebKey JWKS 中的 RSA 公钥
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// provider 身份提供方客户端（缓存发现文档和签名公钥）
+type provider struct {
+	issuer string
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// newProvider 创建身份提供方客户端
+func newProvider(issuer string, client *http.Client) *provider {
+	return &provider{issuer: issuer, client: client}
+}
+
+// discover 获取发现文档（成功后缓存）
+func (p *provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var document discoveryDocument
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &document); err != nil {
+		return nil, fmt.Errorf("获取OIDC发现文档失败: %w", err)
+	}
+	if strings.TrimRight(document.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("OIDC发现文档的 issuer 不匹配: %s", document.Issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC发现文档缺少必要的端点")
+	}
+
+	p.discovery = &document
+	return p.discovery, nil
+}
+
+// exchangeCode 使用授权码和 PKCE 校验码换取 ID 令牌
+func (p *provider) exchangeCode(ctx context.Context, code, verifier, clientID, clientSecret, redirectURL string) (string, error) {
+	document, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", clientID)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, document.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		// client_secret_basic：按规范先对ID和密钥进行表单编码
+		request.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("请求令牌端点失败: %w", err)
+	}
+	defer response.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxProviderResponse)).Decode(&token); err != nil {
+		return "", fmt.Errorf("解析令牌响应失败 (HTTP %d): %w", response.StatusCode, err)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("身份提供方返回错误: %s %s", token.Error, token.ErrorDescription)
+	}
+	if response.StatusCode != http.StatusOK || token.IDToken == "" {
+		return "", fmt.Errorf("令牌响应中没有 id_token (HTTP %d)", response.StatusCode)
+	}
+	return token.IDToken, nil
+}
+
+// verifyIDToken 校验 ID 令牌的签名、issuer、audience、有效期和 nonce
+// 返回: 令牌中的声明
+func (p *provider) verifyIDToken(ctx context.Context, rawToken, clientID, nonce string, now func() time.Time) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, keyID)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+		jwt.WithTimeFunc(now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID令牌验证失败: %w", err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("ID令牌验证失败: nonce 不匹配")
+	}
+	if subject, _ := claims["sub"].(string); subject == "" {
+		return nil, fmt.Errorf("ID令牌验证失败: 缺少 sub")
+	}
+	return claims, nil
+}
+
+// publicKey 按 kid 获取签名公钥，缓存中没有时重新获取 JWKS（处理身份提供方轮换密钥）
+func (p *provider) publicKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	document, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(keyID); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksMinRefresh && p.keys != nil {
+		return nil, fmt.Errorf("未知的签名密钥: %s", keyID)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, document.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("获取JWKS失败: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		publicKey, err := parseRSAKey(key)
+		if err != nil {
+			continue
+		}
+		keys[key.KeyID] = publicKey
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.lookupKey(keyID); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", keyID)
+}
+
+// lookupKey 在缓存中查找公钥，令牌没有 kid 且只有一个公钥时直接使用该公钥
+func (p *provider) lookupKey(keyID string) *rsa.PublicKey {
+	if key, ok := p.keys[keyID]; ok {
+		return key
+	}
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// getJSON 请求并解析 JSON
+func (p *provider) getJSON(ctx context.Context, target string, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", response.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, maxProviderResponse)).Decode(out)
+}
+
+// parseRSAKey 将 JWK 转换为 RSA 公钥
+func parseRSAKey(key jsonWebKey) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(modulus) == 0 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, fmt.Errorf("无效的RSA公钥")
+	}
+
+	e := 0
+	for _, b := range exponent {
+		e = e<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: e}, nil
+}
@@ -0,0 +1,263 @@
+package permission
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+
+	"gorm.io/gorm"
+)
+
+// PermissionService 服务器授权管理服务
+type PermissionService struct{}
+
+// NewPermissionService 创建服务器授权管理服务实例
+func NewPermissionService() *PermissionService {
+	return &PermissionService{}
+}
+
+// ServerScope 返回用户可访问的服务器查询范围
+// 所有者和管理员可访问全部服务器，其他用户只能访问自己创建的和被授权的服务器（查看者对自己创建的服务器也只有查看权限）
+func ServerScope(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		var user models.User
+		if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+			return db.Where("1 = 0")
+		}
+		if IsAdminRole(user.Role) {
+			return db
+		}
+
+		granted := database.DB.Model(&models.ServerPermission{}).Select("server_id").Where("user_id = ?", userID)
+		return db.Where("servers.user_id = ? OR servers.id IN (?)", userID, granted)
+	}
+}
+
+// IsAdminRole 判断角色是否可以管理全部服务器
+func IsAdminRole(role string) bool {
+	return role == models.RoleOwner || role == models.RoleAdmin
+}
+
+// CheckServerPermission 检查用户对服务器的操作权限
+// serverID: 服务器ID（字符串形式，来自请求路径）
+// permission: 所需权限（models.Permission*）
+// 返回: 无权限时返回错误（"无效的服务器ID"、"服务器不存在" 或 "权限不足"）
+func CheckServerPermission(userID uint, serverID string, permission string) error {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的服务器ID")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("权限不足")
+	}
+
+	var server models.Server
+	if err := database.DB.Scopes(ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return fmt.Errorf("服务器不存在")
+	}
+
+	// 管理员拥有全部权限
+	if IsAdminRole(user.Role) || permission == models.PermissionView {
+		return nil
+	}
+	// 查看者只有查看权限（包括被降级为查看者的服务器创建者）
+	if user.Role == models.RoleViewer {
+		return fmt.Errorf("权限不足")
+	}
+	// 服务器创建者拥有全部权限
+	if server.UserID == userID {
+		return nil
+	}
+	if permission == models.PermissionManage {
+		return fmt.Errorf("权限不足")
+	}
+
+	var grant models.ServerPermission
+	if err := database.DB.Where("server_id = ? AND user_id = ?", server.ID, userID).First(&grant).Error; err != nil {
+		return fmt.Errorf("权限不足")
+	}
+	for _, granted := range ParsePermissions(grant.Permissions) {
+		if granted == permission {
+			return nil
+		}
+	}
+	return fmt.Errorf("权限不足")
+}
+
+// ListServerPermissions 获取服务器的授权列表
+func (s *PermissionService) ListServerPermissions(serverID string) ([]models.ServerPermissionResponse, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+
+	var grants []models.ServerPermission
+	if err := database.DB.Where("server_id = ?", id).Order("user_id").Find(&grants).Error; err != nil {
+		return nil, fmt.Errorf("获取授权列表失败: %w", err)
+	}
+
+	responses := make([]models.ServerPermissionResponse, 0, len(grants))
+	for _, grant := range grants {
+		var user models.User
+		if err := database.DB.First(&user, grant.UserID).Error; err != nil {
+			continue
+		}
+		responses = append(responses, models.ServerPermissionResponse{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Role:        user.Role,
+			Permissions: ParsePermissions(grant.Permissions),
+		})
+	}
+	return responses, nil
+}
+
+// SetServerPermissions 设置用户对服务器的授权（覆盖原有授权）
+func (s *PermissionService) SetServerPermissions(serverID, targetUserID string, permissions []string) (*models.ServerPermissionResponse, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+	user, err := findUser(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := NormalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	// 查看者的操作授权不会生效，只允许授予只读访问
+	if user.Role == models.RoleViewer && len(normalized) > 0 {
+		return nil, fmt.Errorf("查看者只能授予查看权限")
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+	if server.UserID == user.ID {
+		return nil, fmt.Errorf("服务器创建者已拥有全部权限")
+	}
+
+	grant := models.ServerPermission{ServerID: server.ID, UserID: user.ID}
+	if err := database.DB.Where(&grant).FirstOrInit(&grant).Error; err != nil {
+		return nil, fmt.Errorf("保存授权失败: %w", err)
+	}
+	grant.Permissions = strings.Join(normalized, ",")
+	if err := database.DB.Save(&grant).Error; err != nil {
+		return nil, fmt.Errorf("保存授权失败: %w", err)
+	}
+
+	return &models.ServerPermissionResponse{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		Permissions: normalized,
+	}, nil
+}
+
+// RemoveServerPermissions 撤销用户对服务器的全部授权
+func (s *PermissionService) RemoveServerPermissions(serverID, targetUserID string) error {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的服务器ID")
+	}
+	userID, err := strconv.ParseUint(targetUserID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的用户ID")
+	}
+
+	result := database.DB.Where("server_id = ? AND user_id = ?", id, userID).Delete(&models.ServerPermission{})
+	if result.Error != nil {
+		return fmt.Errorf("撤销授权失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("授权不存在")
+	}
+	return nil
+}
+
+// NormalizePermissions 校验权限列表并去重排序
+func NormalizePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool, len(permissions))
+	normalized := []string{}
+	for _, permission := range permissions {
+		permission = strings.TrimSpace(permission)
+		if !isGrantable(permission) {
+			return nil, fmt.Errorf("无效的权限: %s", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			normalized = append(normalized, permission)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// ParsePermissions 解析逗号分隔的权限列表
+func ParsePermissions(value string) []string {
+	permissions := []string{}
+	for _, permission := range strings.Split(value, ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// isGrantable 判断权限是否可以授予他人
+func isGrantable(permission string) bool {
+	for _, grantable := range models.GrantablePermissions {
+		if permission == grantable {
+			return true
+		}
+	}
+	return false
+}
+
+// findUser 根据ID查找用户
+func findUser(userID string) (*models.User, error) {
+	id, err := strconv.ParseUint(userID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的用户ID")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return &user, nil
+}
+
+// ScopeAllows 判断API令牌的作用域是否允许指定的服务器操作
+// 任意作用域都允许查看，其他操作需要对应的作用域；删除、备份和授权管理不允许通过API令牌执行
+func ScopeAllows(scopes []string, permission string) bool {
+	required := ""
+	switch permission {
+	case models.PermissionView:
+		return len(scopes) > 0
+	case models.PermissionStartStop:
+		required = models.TokenScopeLifecycle
+	case models.PermissionEditConfig:
+		required = models.TokenScopeConfig
+	case models.PermissionRCON:
+		required = models.TokenScopeRCON
+	default:
+		return false
+	}
+
+	for _, scope := range scopes {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package permission
+
+import (
+	"fmt"
+	"testing"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// createUser 创建指定角色的测试用户
+func createUser(t *testing.T, username, role string) models.User {
+	t.Helper()
+
+	user := models.User{Username: username, Password: "x", Role: role}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	return user
+}
+
+// createServer 创建属于指定用户的测试服务器
+func createServer(t *testing.T, ownerID uint) string {
+	t.Helper()
+
+	server := models.Server{Identifier: fmt.Sprintf("server-%d", ownerID), UserID: ownerID}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatalf("创建测试服务器失败: %v", err)
+	}
+	return fmt.Sprintf("%d", server.ID)
+}
+
+func TestCheckServerPermission(t *testing.T) {
+	dbtest.Open(t)
+
+	owner := createUser(t, "owner", models.RoleOwner)
+	admin := createUser(t, "admin", models.RoleAdmin)
+	creator := createUser(t, "creator", models.RoleOperator)
+	moderator := createUser(t, "moderator", models.RoleOperator)
+	viewer := createUser(t, "viewer", models.RoleViewer)
+	stranger := createUser(t, "stranger", models.RoleOperator)
+
+	serverID := createServer(t, creator.ID)
+
+	service := NewPermissionService()
+	if _, err := service.SetServerPermissions(serverID, fmt.Sprint(moderator.ID), []string{models.PermissionStartStop, models.PermissionRCON}); err != nil {
+		t.Fatalf("授权失败: %v", err)
+	}
+	if _, err := service.SetServerPermissions(serverID, fmt.Sprint(viewer.ID), nil); err != nil {
+		t.Fatalf("授权失败: %v", err)
+	}
+	// 模拟降级前遗留的操作授权
+	database.DB.Model(&models.ServerPermission{}).Where("user_id = ?", viewer.ID).Update("permissions", models.PermissionStartStop)
+
+	tests := []struct {
+		name       string
+		userID     uint
+		permission string
+		expected   string // 期望的错误信息，空字符串表示允许
+	}{
+		{"所有者可删除", owner.ID, models.PermissionDelete, ""},
+		{"管理员可管理授权", admin.ID, models.PermissionManage, ""},
+		{"创建者可删除", creator.ID, models.PermissionDelete, ""},
+		{"创建者可管理授权", creator.ID, models.PermissionManage, ""},
+		{"版主可查看", moderator.ID, models.PermissionView, ""},
+		{"版主可重启", moderator.ID, models.PermissionStartStop, ""},
+		{"版主可使用RCON", moderator.ID, models.PermissionRCON, ""},
+		{"版主不能删除", moderator.ID, models.PermissionDelete, "权限不足"},
+		{"版主不能修改配置", moderator.ID, models.PermissionEditConfig, "权限不足"},
+		{"版主不能管理授权", moderator.ID, models.PermissionManage, "权限不足"},
+		{"观察者可查看", viewer.ID, models.PermissionView, ""},
+		{"观察者即使被授权也不能重启", viewer.ID, models.PermissionStartStop, "权限不足"},
+		{"未授权用户看不到服务器", stranger.ID, models.PermissionView, "服务器不存在"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckServerPermission(tt.userID, serverID, tt.permission)
+			if tt.expected == "" && err != nil {
+				t.Fatalf("应允许操作，实际错误: %v", err)
+			}
+			if tt.expected != "" && (err == nil || err.Error() != tt.expected) {
+				t.Fatalf("期望错误 %q，实际为 %v", tt.expected, err)
+			}
+		})
+	}
+
+	// 服务器创建者被降级为查看者后只保留查看权限
+	database.DB.Model(&models.User{}).Where("id = ?", creator.ID).Update("role", models.RoleViewer)
+	for _, permission := range []string{models.PermissionStartStop, models.PermissionDelete, models.PermissionManage} {
+		if err := CheckServerPermission(creator.ID, serverID, permission); err == nil || err.Error() != "权限不足" {
+			t.Fatalf("降级为查看者的创建者不应拥有 %s 权限，实际为 %v", permission, err)
+		}
+	}
+	if err := CheckServerPermission(creator.ID, serverID, models.PermissionView); err != nil {
+		t.Fatalf("降级为查看者的创建者应能查看服务器: %v", err)
+	}
+
+	if err := CheckServerPermission(owner.ID, "abc", models.PermissionView); err == nil || err.Error() != "无效的服务器ID" {
+		t.Fatalf("无效的服务器ID应返回错误，实际为 %v", err)
+	}
+}
+
+func TestServerScope(t *testing.T) {
+	dbtest.Open(t)
+
+	admin := createUser(t, "admin", models.RoleAdmin)
+	alice := createUser(t, "alice", models.RoleOperator)
+	bob := createUser(t, "bob", models.RoleOperator)
+
+	aliceServer := createServer(t, alice.ID)
+	createServer(t, bob.ID)
+
+	countVisible := func(userID uint) int {
+		var servers []models.Server
+		if err := database.DB.Scopes(ServerScope(userID)).Find(&servers).Error; err != nil {
+			t.Fatalf("查询服务器失败: %v", err)
+		}
+		return len(servers)
+	}
+
+	if got := countVisible(admin.ID); got != 2 {
+		t.Errorf("管理员应看到全部2台服务器，实际为 %d", got)
+	}
+	if got := countVisible(bob.ID); got != 1 {
+		t.Errorf("授权前 bob 应只看到自己的服务器，实际为 %d", got)
+	}
+
+	service := NewPermissionService()
+	if _, err := service.SetServerPermissions(aliceServer, fmt.Sprint(bob.ID), nil); err != nil {
+		t.Fatalf("授权失败: %v", err)
+	}
+	if got := countVisible(bob.ID); got != 2 {
+		t.Errorf("授权后 bob 应看到2台服务器，实际为 %d", got)
+	}
+
+	// 与其他条件组合时授权条件不能影响ID过滤
+	var server models.Server
+	if err := database.DB.Scopes(ServerScope(bob.ID)).Where("id = ?", aliceServer).First(&server).Error; err != nil {
+		t.Fatalf("bob 应能访问被授权的服务器: %v", err)
+	}
+	if fmt.Sprint(server.ID) != aliceServer {
+		t.Fatalf("查询到错误的服务器: %d", server.ID)
+	}
+
+	if err := service.RemoveServerPermissions(aliceServer, fmt.Sprint(bob.ID)); err != nil {
+		t.Fatalf("撤销授权失败: %v", err)
+	}
+	if got := countVisible(bob.ID); got != 1 {
+		t.Errorf("撤销授权后 bob 应只看到自己的服务器，实际为 %d", got)
+	}
+}
+
+func TestSetServerPermissionsValidation(t *testing.T) {
+	dbtest.Open(t)
+
+	creator := createUser(t, "creator", models.RoleOperator)
+	other := createUser(t, "other", models.RoleOperator)
+	serverID := createServer(t, creator.ID)
+
+	service := NewPermissionService()
+	if _, err := service.SetServerPermissions(serverID, fmt.Sprint(other.ID), []string{"fly"}); err == nil {
+		t.Fatalf("无效的权限应返回错误")
+	}
+	if _, err := service.SetServerPermissions(serverID, fmt.Sprint(creator.ID), []string{models.PermissionRCON}); err == nil {
+		t.Fatalf("不应给服务器创建者授权")
+	}
+	viewer := createUser(t, "viewer", models.RoleViewer)
+	if _, err := service.SetServerPermissions(serverID, fmt.Sprint(viewer.ID), []string{models.PermissionRCON}); err == nil || err.Error() != "查看者只能授予查看权限" {
+		t.Fatalf("不应给查看者授予操作权限，实际为 %v", err)
+	}
+	if response, err := service.SetServerPermissions(serverID, fmt.Sprint(viewer.ID), []string{}); err != nil || len(response.Permissions) != 0 {
+		t.Fatalf("应能授予查看者只读访问: %+v, %v", response, err)
+	}
+
+	response, err := service.SetServerPermissions(serverID, fmt.Sprint(other.ID), []string{"rcon", "start_stop", "rcon"})
+	if err != nil {
+		t.Fatalf("授权失败: %v", err)
+	}
+	if fmt.Sprint(response.Permissions) != "[rcon start_stop]" {
+		t.Fatalf("权限应去重排序，实际为 %v", response.Permissions)
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		scopes     []string
+		permission string
+		expected   bool
+	}{
+		{[]string{models.TokenScopeRead}, models.PermissionView, true},
+		{[]string{models.TokenScopeLifecycle}, models.PermissionView, true},
+		{[]string{models.TokenScopeRead}, models.PermissionStartStop, false},
+		{[]string{models.TokenScopeLifecycle}, models.PermissionStartStop, true},
+		{[]string{models.TokenScopeConfig}, models.PermissionEditConfig, true},
+		{[]string{models.TokenScopeLifecycle}, models.PermissionRCON, false},
+		{[]string{models.TokenScopeRCON}, models.PermissionRCON, true},
+		{models.TokenScopes, models.PermissionDelete, false},
+		{models.TokenScopes, models.PermissionManage, false},
+	}
+
+	for _, tt := range tests {
+		if got := ScopeAllows(tt.scopes, tt.permission); got != tt.expected {
+			t.Errorf("ScopeAllows(%v, %s) = %v，期望 %v", tt.scopes, tt.permission, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"ark-server-commander/utils"
+)
+
+// maxArchiveEntries 插件压缩包最大文件数量
+const maxArchiveEntries = 2000
+
+// pluginNamePattern 插件目录名称规则（不能以点开头，避免与禁用目录冲突）
+var pluginNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidatePluginName 验证插件名称
+func ValidatePluginName(name string) error {
+	if len(name) > 128 || !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的插件名称: %s", name)
+	}
+	return nil
+}
+
+// archiveEntry 插件压缩包中的文件
+type archiveEntry struct {
+	file         *zip.File
+	relativePath string
+}
+
+// buildPluginArchive 将插件zip压缩包转换为可直接解压到插件卷的tar归档
+// 如果压缩包中所有文件都位于同一个顶层目录下，则使用该目录名作为插件名称，否则使用 fallbackName
+// 所有路径都会经过清理，包含 ".."、绝对路径或符号链接的压缩包会被拒绝
+// maxExtractedSize: 解压后允许的最大总大小
+// 返回: 插件名称、tar归档和错误信息
+func buildPluginArchive(zipReader *zip.Reader, fallbackName string, maxExtractedSize int64) (string, *bytes.Buffer, error) {
+	var entries []archiveEntry
+	for _, file := range zipReader.File {
+		// 只允许普通文件和目录，拒绝符号链接等特殊文件
+		if file.Mode().Type()&^fs.ModeDir != 0 {
+			return "", nil, fmt.Errorf("压缩包中包含不支持的文件类型: %s", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		relativePath, err := utils.SanitizeRelativePath(file.Name)
+		if err != nil {
+			return "", nil, fmt.Errorf("压缩包中包含非法路径 %s: %w", file.Name, err)
+		}
+		if strings.HasPrefix(file.Name, "/") || strings.HasPrefix(file.Name, "\\") {
+			return "", nil, fmt.Errorf("压缩包中包含非法路径 %s: 不允许绝对路径", file.Name)
+		}
+		// 跳过 macOS 打包产生的元数据
+		if relativePath == "" || strings.HasPrefix(relativePath, "__MACOSX/") || path.Base(relativePath) == ".DS_Store" {
+			continue
+		}
+
+		entries = append(entries, archiveEntry{file: file, relativePath: relativePath})
+		if len(entries) > maxArchiveEntries {
+			return "", nil, fmt.Errorf("压缩包文件数量超过限制（%d）", maxArchiveEntries)
+		}
+	}
+
+	if len(entries) == 0 {
+		return "", nil, fmt.Errorf("压缩包中没有文件")
+	}
+
+	// 检查是否所有文件都在同一个顶层目录下
+	pluginName := fallbackName
+	topDir := ""
+	for i, entry := range entries {
+		parts := strings.SplitN(entry.relativePath, "/", 2)
+		if len(parts) < 2 {
+			topDir = ""
+			break
+		}
+		if i == 0 {
+			topDir = parts[0]
+		} else if parts[0] != topDir {
+			topDir = ""
+			break
+		}
+	}
+	if topDir != "" {
+		pluginName = topDir
+		for i := range entries {
+			entries[i].relativePath = strings.TrimPrefix(entries[i].relativePath, topDir+"/")
+		}
+	}
+
+	if err := ValidatePluginName(pluginName); err != nil {
+		return "", nil, err
+	}
+
+	// 构建tar归档，所有文件放在插件目录下
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+	var totalSize int64
+
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     pluginName + "/",
+		Mode:     0755,
+		ModTime:  time.Now(),
+	}); err != nil {
+		return "", nil, fmt.Errorf("构建插件归档失败: %v", err)
+	}
+
+	for _, entry := range entries {
+		content, err := readZipEntry(entry.file, maxExtractedSize-totalSize)
+		if err != nil {
+			return "", nil, err
+		}
+		totalSize += int64(len(content))
+
+		header := &tar.Header{
+			Name:    path.Join(pluginName, entry.relativePath),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: entry.file.Modified,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return "", nil, fmt.Errorf("构建插件归档失败: %v", err)
+		}
+		if _, err := tarWriter.Write(content); err != nil {
+			return "", nil, fmt.Errorf("构建插件归档失败: %v", err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return "", nil, fmt.Errorf("构建插件归档失败: %v", err)
+	}
+
+	return pluginName, &buffer, nil
+}
+
+// readZipEntry 读取压缩包中的单个文件，超过剩余可用大小时返回错误（防止压缩炸弹）
+func readZipEntry(file *zip.File, remaining int64) ([]byte, error) {
+	if remaining <= 0 || file.UncompressedSize64 > uint64(remaining) {
+		return nil, fmt.Errorf("插件解压后大小超过限制")
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取压缩包文件 %s 失败: %v", file.Name, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, remaining+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取压缩包文件 %s 失败: %v", file.Name, err)
+	}
+	if int64(len(content)) > remaining {
+		return nil, fmt.Errorf("插件解压后大小超过限制")
+	}
+
+	return content, nil
+}
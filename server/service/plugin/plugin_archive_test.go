@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// buildZip 构建测试用zip压缩包
+func buildZip(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		fileWriter, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("创建zip文件失败: %v", err)
+		}
+		if _, err := fileWriter.Write([]byte(content)); err != nil {
+			t.Fatalf("写入zip文件失败: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("关闭zip失败: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("读取zip失败: %v", err)
+	}
+	return reader
+}
+
+// tarNames 列出tar归档中的文件
+func tarNames(t *testing.T, archive io.Reader) map[string]bool {
+	t.Helper()
+
+	names := map[string]bool{}
+	tarReader := tar.NewReader(archive)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取tar失败: %v", err)
+		}
+		names[header.Name] = true
+	}
+	return names
+}
+
+func TestBuildPluginArchiveUsesTopLevelFolder(t *testing.T) {
+	reader := buildZip(t, map[string]string{
+		"ArkShop/ArkShop.dll":     "dll",
+		"ArkShop/PluginInfo.json": `{"FullName":"ArkShop","Version":1.6}`,
+		"ArkShop/config.json":     "{}",
+		"__MACOSX/ArkShop/._x":    "meta",
+	})
+
+	name, archive, err := buildPluginArchive(reader, "upload", 1<<20)
+	if err != nil {
+		t.Fatalf("构建归档失败: %v", err)
+	}
+	if name != "ArkShop" {
+		t.Fatalf("插件名称应为 ArkShop，实际为 %s", name)
+	}
+
+	names := tarNames(t, archive)
+	for _, expected := range []string{"ArkShop/ArkShop.dll", "ArkShop/PluginInfo.json", "ArkShop/config.json"} {
+		if !names[expected] {
+			t.Errorf("归档中缺少 %s", expected)
+		}
+	}
+	for name := range names {
+		if strings.Contains(name, "__MACOSX") {
+			t.Errorf("归档中不应包含 %s", name)
+		}
+	}
+}
+
+func TestBuildPluginArchiveFallsBackToUploadName(t *testing.T) {
+	reader := buildZip(t, map[string]string{
+		"Permissions.dll": "dll",
+		"config.json":     "{}",
+	})
+
+	name, archive, err := buildPluginArchive(reader, "Permissions", 1<<20)
+	if err != nil {
+		t.Fatalf("构建归档失败: %v", err)
+	}
+	if name != "Permissions" {
+		t.Fatalf("插件名称应为 Permissions，实际为 %s", name)
+	}
+	if names := tarNames(t, archive); !names["Permissions/Permissions.dll"] {
+		t.Errorf("归档中缺少 Permissions/Permissions.dll")
+	}
+}
+
+func TestBuildPluginArchiveRejectsUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		fallback string
+		limit    int64
+	}{
+		{"路径穿越", map[string]string{"Plugin/../../evil.dll": "x"}, "Plugin", 1 << 20},
+		{"反斜杠路径穿越", map[string]string{"..\\evil.dll": "x"}, "Plugin", 1 << 20},
+		{"绝对路径", map[string]string{"/etc/passwd": "x"}, "Plugin", 1 << 20},
+		{"非法插件名称", map[string]string{"a.dll": "x"}, ".disabled", 1 << 20},
+		{"超过解压大小", map[string]string{"Plugin/big.dll": strings.Repeat("x", 2048)}, "Plugin", 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := buildZip(t, tt.files)
+			if _, _, err := buildPluginArchive(reader, tt.fallback, tt.limit); err == nil {
+				t.Fatalf("应拒绝不安全的压缩包")
+			}
+		})
+	}
+}
+
+func TestParsePluginInfoNumericVersion(t *testing.T) {
+	info, err := ParsePluginInfo([]byte("\uFEFF" + `{"FullName":"ArkShop","Version":1.6,"MinApiVersion":3.5}`))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if info["Version"] != "1.6" || info["MinApiVersion"] != "3.5" || info["FullName"] != "ArkShop" {
+		t.Fatalf("解析结果错误: %v", info)
+	}
+}
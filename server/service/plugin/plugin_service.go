@@ -0,0 +1,381 @@
+package plugin
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/permission"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// 插件卷中的目录和文件名
+const (
+	disabledDirName      = ".disabled"       // 禁用插件存放目录（ArkApi 不会加载以点开头的目录）
+	pluginInfoFileName   = "PluginInfo.json" // 插件信息文件
+	pluginConfigFileName = "config.json"     // 插件配置文件
+	zipFileExtension     = ".zip"            // 插件压缩包扩展名
+)
+
+// PluginService ArkApi 插件管理服务
+type PluginService struct{}
+
+// NewPluginService 创建插件管理服务实例
+func NewPluginService() *PluginService {
+	return &PluginService{}
+}
+
+// ListPlugins 获取服务器已安装的插件列表（包括已禁用的插件）
+func (s *PluginService) ListPlugins(userID uint, serverID string) ([]models.PluginResponse, error) {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := []models.PluginResponse{}
+	err = withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		enabledNames, err := session.ListDirs(docker_manager.HelperPluginsMount)
+		if err != nil {
+			return err
+		}
+		disabledNames, err := session.ListDirs(pluginDir(disabledDirName, true))
+		if err != nil {
+			return err
+		}
+
+		for _, name := range enabledNames {
+			if ValidatePluginName(name) != nil {
+				continue
+			}
+			plugins = append(plugins, readPluginInfo(session, name, true))
+		}
+		for _, name := range disabledNames {
+			if ValidatePluginName(name) != nil {
+				continue
+			}
+			plugins = append(plugins, readPluginInfo(session, name, false))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plugins, nil
+}
+
+// InstallPlugin 上传并安装插件压缩包
+// fileName: 上传的文件名（压缩包中没有统一的顶层目录时用作插件名称）
+// file: 压缩包内容
+// size: 压缩包大小
+// overwrite: 插件已存在时是否覆盖
+// 返回: 安装后的插件信息和错误信息
+func (s *PluginService) InstallPlugin(userID uint, serverID, fileName string, file io.ReaderAt, size int64, overwrite bool) (*models.PluginResponse, error) {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(path.Ext(fileName), zipFileExtension) {
+		return nil, fmt.Errorf("插件必须是zip压缩包")
+	}
+	if size > config.PluginMaxUploadSize {
+		return nil, fmt.Errorf("插件压缩包大小超过限制")
+	}
+
+	zipReader, err := zip.NewReader(file, size)
+	if err != nil {
+		return nil, fmt.Errorf("无效的zip压缩包: %v", err)
+	}
+
+	fallbackName := strings.TrimSuffix(path.Base(strings.ReplaceAll(fileName, "\\", "/")), path.Ext(fileName))
+	pluginName, archive, err := buildPluginArchive(zipReader, fallbackName, config.PluginMaxExtractedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.PluginResponse
+	err = withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		dir, enabled, err := locatePlugin(session, pluginName)
+		if err != nil && err.Error() != "插件不存在" {
+			return err
+		}
+		if err == nil {
+			if !overwrite {
+				return fmt.Errorf("插件已存在")
+			}
+			if _, err := session.Exec("rm", "-rf", dir); err != nil {
+				return fmt.Errorf("删除旧版本插件失败: %w", err)
+			}
+		} else {
+			enabled = true
+		}
+
+		// 覆盖安装时保持原有的启用状态
+		targetRoot := docker_manager.HelperPluginsMount
+		if !enabled {
+			targetRoot = pluginDir(disabledDirName, true)
+		}
+		if err := session.Extract(targetRoot, archive); err != nil {
+			return fmt.Errorf("解压插件失败: %w", err)
+		}
+
+		response = readPluginInfo(session, pluginName, enabled)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Info("插件安装成功", zap.Uint("server_id", server.ID), zap.String("plugin", pluginName))
+	return &response, nil
+}
+
+// SetPluginEnabled 启用或禁用插件（在插件目录和禁用目录之间移动，需重启服务器生效）
+func (s *PluginService) SetPluginEnabled(userID uint, serverID, name string, enabled bool) (*models.PluginResponse, error) {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePluginName(name); err != nil {
+		return nil, err
+	}
+
+	var response models.PluginResponse
+	err = withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		dir, currentlyEnabled, err := locatePlugin(session, name)
+		if err != nil {
+			return err
+		}
+
+		if currentlyEnabled != enabled {
+			if enabled {
+				if _, err := session.Exec("mv", dir, docker_manager.HelperPluginsMount+"/"); err != nil {
+					return fmt.Errorf("启用插件失败: %w", err)
+				}
+			} else {
+				disabledRoot := pluginDir(disabledDirName, true)
+				if _, err := session.Exec("mkdir", "-p", disabledRoot); err != nil {
+					return fmt.Errorf("创建禁用目录失败: %w", err)
+				}
+				if _, err := session.Exec("mv", dir, disabledRoot+"/"); err != nil {
+					return fmt.Errorf("禁用插件失败: %w", err)
+				}
+			}
+		}
+
+		response = readPluginInfo(session, name, enabled)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// GetPluginConfig 获取插件的 config.json 内容
+func (s *PluginService) GetPluginConfig(userID uint, serverID, name string) (string, error) {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidatePluginName(name); err != nil {
+		return "", err
+	}
+
+	var content string
+	err = withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		dir, _, err := locatePlugin(session, name)
+		if err != nil {
+			return err
+		}
+
+		configPath := path.Join(dir, pluginConfigFileName)
+		exists, err := session.Exists(configPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("插件配置文件不存在")
+		}
+
+		data, err := session.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("读取插件配置失败: %w", err)
+		}
+		content = string(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
+// UpdatePluginConfig 更新插件的 config.json（内容必须是合法的JSON）
+func (s *PluginService) UpdatePluginConfig(userID uint, serverID, name, content string) error {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePluginName(name); err != nil {
+		return err
+	}
+	if !json.Valid([]byte(content)) {
+		return fmt.Errorf("插件配置不是有效的JSON")
+	}
+
+	return withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		dir, _, err := locatePlugin(session, name)
+		if err != nil {
+			return err
+		}
+
+		if err := session.WriteFile(path.Join(dir, pluginConfigFileName), []byte(content)); err != nil {
+			return fmt.Errorf("写入插件配置失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemovePlugin 删除插件
+func (s *PluginService) RemovePlugin(userID uint, serverID, name string) error {
+	server, err := findServer(userID, serverID)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePluginName(name); err != nil {
+		return err
+	}
+
+	err = withPluginVolume(server.ID, func(session *docker_manager.VolumeSession) error {
+		dir, _, err := locatePlugin(session, name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := session.Exec("rm", "-rf", dir); err != nil {
+			return fmt.Errorf("删除插件失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.Info("插件已删除", zap.Uint("server_id", server.ID), zap.String("plugin", name))
+	return nil
+}
+
+// withPluginVolume 在挂载了服务器卷的辅助容器中执行插件操作
+func withPluginVolume(serverID uint, fn func(session *docker_manager.VolumeSession) error) error {
+	dockerManager, err := docker_manager.GetServerManager(serverID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %v", err)
+	}
+	return dockerManager.WithVolumes(serverID, fn)
+}
+
+// pluginDir 获取插件在辅助容器中的目录
+func pluginDir(name string, enabled bool) string {
+	if enabled {
+		return path.Join(docker_manager.HelperPluginsMount, name)
+	}
+	return path.Join(docker_manager.HelperPluginsMount, disabledDirName, name)
+}
+
+// locatePlugin 查找插件所在目录
+// 返回: 插件目录、是否启用和错误信息（插件不存在时返回 "插件不存在"）
+func locatePlugin(session *docker_manager.VolumeSession, name string) (string, bool, error) {
+	for _, enabled := range []bool{true, false} {
+		dir := pluginDir(name, enabled)
+		exists, err := session.Exists(dir)
+		if err != nil {
+			return "", false, err
+		}
+		if exists {
+			return dir, enabled, nil
+		}
+	}
+	return "", false, fmt.Errorf("插件不存在")
+}
+
+// readPluginInfo 读取插件信息（PluginInfo.json 缺失或格式错误时只返回名称和状态）
+func readPluginInfo(session *docker_manager.VolumeSession, name string, enabled bool) models.PluginResponse {
+	response := models.PluginResponse{
+		Name:    name,
+		Enabled: enabled,
+	}
+	dir := pluginDir(name, enabled)
+
+	if hasConfig, err := session.Exists(path.Join(dir, pluginConfigFileName)); err == nil {
+		response.HasConfig = hasConfig
+	}
+
+	data, err := session.ReadFile(path.Join(dir, pluginInfoFileName))
+	if err != nil {
+		return response
+	}
+
+	info, err := ParsePluginInfo(data)
+	if err != nil {
+		utils.Warn("解析插件信息失败", zap.String("plugin", name), zap.Error(err))
+		return response
+	}
+	response.FullName = info["FullName"]
+	response.Version = info["Version"]
+	response.Description = info["Description"]
+	response.MinApiVersion = info["MinApiVersion"]
+	return response
+}
+
+// ParsePluginInfo 解析 PluginInfo.json，数字类型的字段（如 Version）会转换为字符串
+func ParsePluginInfo(data []byte) (map[string]string, error) {
+	// 部分插件的 PluginInfo.json 带有 UTF-8 BOM
+	data = []byte(strings.TrimPrefix(string(data), "\uFEFF"))
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	info := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			info[key] = v
+		case float64:
+			info[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+		default:
+			info[key] = fmt.Sprint(v)
+		}
+	}
+	return info, nil
+}
+
+// findServer 查找当前用户的服务器
+func findServer(userID uint, serverID string) (*models.Server, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的服务器ID")
+	}
+
+	var server models.Server
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+	return &server, nil
+}
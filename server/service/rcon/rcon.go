@@ -0,0 +1,176 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Source RCON 协议数据包类型
+const (
+	packetTypeResponseValue = 0
+	packetTypeExecCommand   = 2
+	packetTypeAuthResponse  = 2
+	packetTypeAuth          = 3
+)
+
+// maxPacketSize 单个数据包最大长度（协议规定为4096字节），超过该长度的响应会拆分为多个数据包
+const maxPacketSize = 4096
+
+// maxResponsePacketSize 接收的单个数据包最大长度（ARK 的长响应可能不拆分，直接发送超过4096字节的数据包）
+const maxResponsePacketSize = 1 << 20
+
+// Client Source RCON 客户端（ARK 服务器使用此协议）
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+	nextID  int32
+}
+
+// Dial 连接RCON服务器并完成认证
+// address: 服务器地址（host:port）
+// password: 管理员密码
+// timeout: 连接和读写超时时间
+func Dial(address, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接RCON失败: %w", err)
+	}
+
+	client := &Client{
+		conn:    conn,
+		timeout: timeout,
+		nextID:  1,
+	}
+
+	if err := client.auth(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Execute 执行RCON命令并返回输出
+// 长响应拆分为多个数据包时，数据包长度达到上限说明后面还有后续数据包，依次读取并拼接
+func (c *Client) Execute(command string) (string, error) {
+	id := c.newID()
+	if err := c.writePacket(id, packetTypeExecCommand, command); err != nil {
+		return "", err
+	}
+
+	var body strings.Builder
+	for {
+		responseID, _, chunk, length, err := c.readPacket()
+		if err != nil {
+			// 响应恰好是数据包长度的整数倍时没有更短的结束包，读取超时即视为响应结束
+			var netErr net.Error
+			if body.Len() > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				return body.String(), nil
+			}
+			return "", err
+		}
+		if responseID < id {
+			// 之前超时的命令迟到的响应
+			continue
+		}
+		if responseID != id {
+			return "", fmt.Errorf("RCON响应ID不匹配: 期望%d，实际%d", id, responseID)
+		}
+
+		body.WriteString(chunk)
+		if length != maxPacketSize {
+			return body.String(), nil
+		}
+	}
+}
+
+// auth 发送认证请求
+func (c *Client) auth(password string) error {
+	id := c.newID()
+	if err := c.writePacket(id, packetTypeAuth, password); err != nil {
+		return err
+	}
+
+	// 服务器可能先返回一个空的 RESPONSE_VALUE 包，再返回认证结果
+	for {
+		responseID, packetType, _, _, err := c.readPacket()
+		if err != nil {
+			return err
+		}
+		if packetType == packetTypeResponseValue {
+			continue
+		}
+		if packetType != packetTypeAuthResponse {
+			return fmt.Errorf("RCON认证响应类型错误: %d", packetType)
+		}
+		if responseID == -1 {
+			return fmt.Errorf("RCON认证失败: 密码错误")
+		}
+		return nil
+	}
+}
+
+// newID 生成请求ID
+func (c *Client) newID() int32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// writePacket 写入一个数据包
+func (c *Client) writePacket(id, packetType int32, body string) error {
+	// 数据包长度 = ID(4) + 类型(4) + 内容 + 两个空字节
+	length := int32(4 + 4 + len(body) + 2)
+	if length > maxPacketSize {
+		return fmt.Errorf("RCON命令过长")
+	}
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, length)
+	binary.Write(&buffer, binary.LittleEndian, id)
+	binary.Write(&buffer, binary.LittleEndian, packetType)
+	buffer.WriteString(body)
+	buffer.Write([]byte{0, 0})
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(buffer.Bytes()); err != nil {
+		return fmt.Errorf("发送RCON数据失败: %w", err)
+	}
+	return nil
+}
+
+// readPacket 读取一个数据包
+// 返回: 请求ID、类型、内容和数据包长度
+func (c *Client) readPacket() (int32, int32, string, int32, error) {
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+
+	var length int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &length); err != nil {
+		return 0, 0, "", 0, fmt.Errorf("读取RCON响应失败: %w", err)
+	}
+	if length < 10 || length > maxResponsePacketSize {
+		return 0, 0, "", 0, fmt.Errorf("RCON响应长度无效: %d", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, payload); err != nil {
+		return 0, 0, "", 0, fmt.Errorf("读取RCON响应失败: %w", err)
+	}
+
+	id := int32(binary.LittleEndian.Uint32(payload[0:4]))
+	packetType := int32(binary.LittleEndian.Uint32(payload[4:8]))
+	body := string(bytes.TrimRight(payload[8:], "\x00"))
+
+	return id, packetType, body, length, nil
+}
@@ -0,0 +1,157 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer 模拟的 Source RCON 服务器
+// 命令 "echo <内容>" 返回内容；"repeat <n>" 返回 n 个字母 a，超过单个数据包长度时按 split 决定拆分还是发送超长数据包
+type fakeServer struct {
+	listener net.Listener
+	password string
+	split    bool
+}
+
+// startFakeServer 启动模拟服务器，测试结束后关闭
+func startFakeServer(t *testing.T, password string, split bool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动模拟RCON服务器失败: %v", err)
+	}
+	server := &fakeServer{listener: listener, password: password, split: split}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var length int32
+		if err := binary.Read(conn, binary.LittleEndian, &length); err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		id := int32(binary.LittleEndian.Uint32(payload[0:4]))
+		packetType := int32(binary.LittleEndian.Uint32(payload[4:8]))
+		body := string(bytes.TrimRight(payload[8:], "\x00"))
+
+		switch packetType {
+		case packetTypeAuth:
+			// 与 Source 服务器一致，先返回一个空的 RESPONSE_VALUE 包
+			writeTestPacket(conn, id, packetTypeResponseValue, "")
+			if body != s.password {
+				id = -1
+			}
+			writeTestPacket(conn, id, packetTypeAuthResponse, "")
+		case packetTypeExecCommand:
+			s.respond(conn, id, body)
+		}
+	}
+}
+
+// respond 返回命令输出
+func (s *fakeServer) respond(conn net.Conn, id int32, command string) {
+	output := strings.TrimPrefix(command, "echo ")
+	if strings.HasPrefix(command, "repeat ") {
+		n, _ := strconv.Atoi(strings.TrimPrefix(command, "repeat "))
+		output = strings.Repeat("a", n)
+	}
+
+	chunkSize := maxPacketSize - 10
+	if !s.split || len(output) <= chunkSize {
+		writeTestPacket(conn, id, packetTypeResponseValue, output)
+		return
+	}
+	for len(output) > 0 {
+		n := min(chunkSize, len(output))
+		writeTestPacket(conn, id, packetTypeResponseValue, output[:n])
+		output = output[n:]
+	}
+}
+
+func writeTestPacket(conn net.Conn, id, packetType int32, body string) {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, int32(4+4+len(body)+2))
+	binary.Write(&buffer, binary.LittleEndian, id)
+	binary.Write(&buffer, binary.LittleEndian, packetType)
+	buffer.WriteString(body)
+	buffer.Write([]byte{0, 0})
+	conn.Write(buffer.Bytes())
+}
+
+func TestAuthFailure(t *testing.T) {
+	address := startFakeServer(t, "secret", true)
+
+	if _, err := Dial(address, "wrong", time.Second); err == nil || !strings.Contains(err.Error(), "密码错误") {
+		t.Fatalf("密码错误时应认证失败: %v", err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	address := startFakeServer(t, "secret", true)
+	client, err := Dial(address, "secret", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("连接RCON失败: %v", err)
+	}
+	defer client.Close()
+
+	for _, command := range []string{"echo Server received, But no response!!", "echo "} {
+		if output, err := client.Execute(command); err != nil || output != strings.TrimPrefix(command, "echo ") {
+			t.Fatalf("执行 %q 返回 %q, %v", command, output, err)
+		}
+	}
+
+	// 拆分为多个数据包的长响应，包括恰好是数据包长度整数倍的响应
+	for _, n := range []int{5000, 9000, maxPacketSize - 10} {
+		output, err := client.Execute("repeat " + strconv.Itoa(n))
+		if err != nil || len(output) != n {
+			t.Fatalf("长度 %d 的响应读取为 %d 字节, %v", n, len(output), err)
+		}
+	}
+
+	// 后续命令不受之前长响应的影响
+	if output, err := client.Execute("echo ListPlayers"); err != nil || output != "ListPlayers" {
+		t.Fatalf("执行命令返回 %q, %v", output, err)
+	}
+}
+
+func TestExecuteOversizedPacket(t *testing.T) {
+	address := startFakeServer(t, "secret", false)
+	client, err := Dial(address, "secret", time.Second)
+	if err != nil {
+		t.Fatalf("连接RCON失败: %v", err)
+	}
+	defer client.Close()
+
+	// ARK 的长响应不拆分，直接发送超过4096字节的数据包
+	output, err := client.Execute("repeat 20000")
+	if err != nil || len(output) != 20000 {
+		t.Fatalf("超长数据包读取为 %d 字节, %v", len(output), err)
+	}
+
+	if _, err := client.Execute("echo " + strings.Repeat("a", maxPacketSize)); err == nil {
+		t.Fatal("超过数据包长度的命令应返回错误")
+	}
+}
@@ -14,7 +14,7 @@ func (s *ServerService) createServerContinue(userID uint, server *models.Server,
 	var err error
 
 	// 步骤5: 获取 Docker 管理器
-	dockerManager, getErr := docker_manager.GetDockerManager()
+	dockerManager, getErr := docker_manager.GetNodeManager(server.NodeID)
 	if getErr != nil {
 		err = fmt.Errorf("获取Docker管理器失败: %w", getErr)
 		return nil, err
@@ -99,12 +99,16 @@ func (s *ServerService) createServerContinue(userID uint, server *models.Server,
 		Port:          server.Port,
 		QueryPort:     server.QueryPort,
 		RCONPort:      server.RCONPort,
-		AdminPassword: server.AdminPassword,
+		PasswordIsSet: server.AdminPassword != "",
 		Map:           server.Map,
 		GameModIds:    server.GameModIds,
 		Status:        server.Status,
 		AutoRestart:   server.AutoRestart,
 		UserID:        server.UserID,
+		NodeID:        server.NodeID,
+		Image:         server.ImageName(),
+		Resources:     server.Resources,
+		Network:       server.Network,
 		CreatedAt:     server.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:     server.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
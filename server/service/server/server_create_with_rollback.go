@@ -4,10 +4,13 @@ import (
 	"ark-server-commander/database"
 	"ark-server-commander/models"
 	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/image"
+	"ark-server-commander/service/mod"
 	"ark-server-commander/utils"
 	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
+	"strings"
 )
 
 // CreateServerWithRollback 创建服务器（带完整回滚机制）
@@ -38,6 +41,14 @@ func (s *ServerService) CreateServerWithRollback(userID uint, req models.ServerR
 		return nil, err
 	}
 
+	// 验证模组ID列表
+	modIDs, parseErr := utils.ParseGameModIds(req.GameModIds)
+	if parseErr != nil {
+		err = parseErr
+		return nil, err
+	}
+	req.GameModIds = strings.Join(modIDs, ",")
+
 	// 步骤2: 设置默认值
 	if req.Map == "" {
 		req.Map = "TheIsland"
@@ -50,6 +61,20 @@ func (s *ServerService) CreateServerWithRollback(userID uint, req models.ServerR
 		req.AutoRestart = &defaultVal
 	}
 
+	// 校验服务器使用的镜像（未指定时使用默认镜像）
+	serverImage, imageErr := image.NewImageService().ResolveImage(req.Image)
+	if imageErr != nil {
+		err = imageErr
+		return nil, err
+	}
+
+	// 选择运行服务器的节点
+	nodeID, selectErr := selectNode(req)
+	if selectErr != nil {
+		err = selectErr
+		return nil, err
+	}
+
 	// 步骤3: 开始数据库事务
 	tx := database.DB.Begin()
 	if tx.Error != nil {
@@ -71,13 +96,17 @@ func (s *ServerService) CreateServerWithRollback(userID uint, req models.ServerR
 		Port:          req.Port,
 		QueryPort:     req.QueryPort,
 		RCONPort:      req.RCONPort,
-		AdminPassword: req.AdminPassword,
+		AdminPassword: models.EncryptedString(req.AdminPassword),
 		Map:           req.Map,
 		MaxPlayers:    req.MaxPlayers,
 		GameModIds:    req.GameModIds,
 		Status:        "stopped",
 		AutoRestart:   *req.AutoRestart,
 		UserID:        userID,
+		NodeID:        nodeID,
+		Image:         serverImage,
+		Resources:     requestResources(req),
+		Network:       requestNetwork(req),
 	}
 
 	if req.ServerArgs != nil {
@@ -96,14 +125,17 @@ func (s *ServerService) CreateServerWithRollback(userID uint, req models.ServerR
 		return nil, err
 	}
 
+	if syncErr := mod.SyncServerMods(tx, server.ID, modIDs); syncErr != nil {
+		err = syncErr
+		return nil, err
+	}
+
 	utils.Info("服务器记录创建成功", zap.Uint("server_id", server.ID))
 
-	// 添加回滚操作：删除服务器记录
-	serverID := server.ID
-	rollback.AddAction("database", fmt.Sprintf("server_%d", serverID), "删除服务器记录", func() error {
-		return database.DB.Unscoped().Delete(&models.Server{}, serverID).Error
-	})
+	// 服务器记录在事务中创建，失败时由事务回滚撤销，无需单独的回滚操作
 
-	// 继续下一部分...
-	return s.createServerContinue(userID, &server, req, tx, rollback)
+	// 继续下一部分（错误需要赋值给 err，defer 才会执行回滚）
+	var response *models.ServerResponse
+	response, err = s.createServerContinue(userID, &server, req, tx, rollback)
+	return response, err
 }
@@ -4,11 +4,14 @@ import (
 	"ark-server-commander/database"
 	"ark-server-commander/models"
 	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/image"
+	"ark-server-commander/service/mod"
 	"ark-server-commander/utils"
 	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"strings"
 )
 
 // CreateServerWithTransaction 创建服务器（使用数据库事务 + Docker 回滚）
@@ -41,6 +44,14 @@ func (s *ServerService) CreateServerWithTransaction(userID uint, req models.Serv
 		return nil, err
 	}
 
+	// 验证模组ID列表
+	modIDs, parseErr := utils.ParseGameModIds(req.GameModIds)
+	if parseErr != nil {
+		err = parseErr
+		return nil, err
+	}
+	req.GameModIds = strings.Join(modIDs, ",")
+
 	// 步骤2: 设置默认值
 	if req.Map == "" {
 		req.Map = "TheIsland"
@@ -53,6 +64,20 @@ func (s *ServerService) CreateServerWithTransaction(userID uint, req models.Serv
 		req.AutoRestart = &defaultVal
 	}
 
+	// 校验服务器使用的镜像（未指定时使用默认镜像）
+	serverImage, imageErr := image.NewImageService().ResolveImage(req.Image)
+	if imageErr != nil {
+		err = imageErr
+		return nil, err
+	}
+
+	// 选择运行服务器的节点
+	nodeID, selectErr := selectNode(req)
+	if selectErr != nil {
+		err = selectErr
+		return nil, err
+	}
+
 	// 步骤3: 数据库事务 - 创建服务器记录
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		// 创建服务器记录
@@ -63,13 +88,17 @@ func (s *ServerService) CreateServerWithTransaction(userID uint, req models.Serv
 			Port:          req.Port,
 			QueryPort:     req.QueryPort,
 			RCONPort:      req.RCONPort,
-			AdminPassword: req.AdminPassword,
+			AdminPassword: models.EncryptedString(req.AdminPassword),
 			Map:           req.Map,
 			MaxPlayers:    req.MaxPlayers,
 			GameModIds:    req.GameModIds,
 			Status:        "stopped",
 			AutoRestart:   *req.AutoRestart,
 			UserID:        userID,
+			NodeID:        nodeID,
+			Image:         serverImage,
+			Resources:     requestResources(req),
+			Network:       requestNetwork(req),
 		}
 
 		if req.ServerArgs != nil {
@@ -87,6 +116,10 @@ func (s *ServerService) CreateServerWithTransaction(userID uint, req models.Serv
 			return fmt.Errorf("服务器创建失败: %w", createErr)
 		}
 
+		if syncErr := mod.SyncServerMods(tx, server.ID, modIDs); syncErr != nil {
+			return syncErr
+		}
+
 		utils.Info("服务器记录创建成功（事务中）", zap.Uint("server_id", server.ID))
 		return nil
 	})
@@ -96,6 +129,8 @@ func (s *ServerService) CreateServerWithTransaction(userID uint, req models.Serv
 		return nil, err
 	}
 
-	// 继续创建 Docker 资源...
-	return s.createDockerResources(userID, &server, req, dockerRollback)
+	// 继续创建 Docker 资源（错误需要赋值给 err，defer 才会清理 Docker 资源）
+	var response *models.ServerResponse
+	response, err = s.createDockerResources(userID, &server, req, dockerRollback)
+	return response, err
 }
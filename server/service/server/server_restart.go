@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/audit"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/rcon"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// rconTimeout RCON连接和读写超时时间
+const rconTimeout = 10 * time.Second
+
+// restartWarningMarks 重启前广播提醒的剩余时间节点
+var restartWarningMarks = []time.Duration{
+	30 * time.Minute,
+	15 * time.Minute,
+	10 * time.Minute,
+	5 * time.Minute,
+	time.Minute,
+	30 * time.Second,
+	10 * time.Second,
+}
+
+// ExecuteRCONCommand 通过RCON在服务器上执行命令，每条命令都会写入审计日志
+// server: 服务器信息
+// command: RCON命令
+// 返回: 命令输出和错误信息
+func (s *ServerService) ExecuteRCONCommand(server models.Server, command string) (string, error) {
+	output, err := s.executeRCONCommand(server, command)
+	audit.RecordSystem("rcon.command", server.ID, map[string]interface{}{"command": command}, err)
+	return output, err
+}
+
+// executeRCONCommand 连接RCON并执行命令
+func (s *ServerService) executeRCONCommand(server models.Server, command string) (string, error) {
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return "", fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+
+	// 指定了绑定IP时游戏和端口映射只监听该IP，通过该IP连接；macvlan 模式下不映射端口，通过容器IP连接
+	// 否则远程节点通过节点地址连接；面板所在主机默认通过容器IP连接，配置了 RCON_HOST 时使用配置的地址
+	host := server.Network.BindIP
+	if host == "" && server.Network.UsesHostPorts() {
+		host = dockerManager.RemoteHost()
+		if host == "" {
+			host = config.RCONHost
+		}
+	}
+	if host == "" {
+		containerIP, ipErr := dockerManager.GetContainerIP(utils.GetServerContainerName(server.ID))
+		if ipErr != nil || containerIP == "" {
+			host = "127.0.0.1"
+		} else {
+			host = containerIP
+		}
+	}
+
+	client, err := rcon.Dial(net.JoinHostPort(host, strconv.Itoa(server.RCONPort)), string(server.AdminPassword), rconTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	return client.Execute(command)
+}
+
+// RestartServerWithWarning 向玩家广播提醒后重启服务器
+// 倒计时期间定时广播剩余时间，重启前保存世界，重启时容器会按最新配置重建
+// 倒计时期间服务器被停止或删除时取消重启，ctx 取消时中止倒计时
+// server: 服务器信息（必须处于运行状态）
+// warning: 提前提醒的时长
+// reason: 重启原因（会包含在广播中）
+func (s *ServerService) RestartServerWithWarning(ctx context.Context, server models.Server, warning time.Duration, reason string) (err error) {
+	defer func() {
+		audit.RecordSystem("servers.restart", server.ID, map[string]interface{}{
+			"warning": warning.String(),
+			"reason":  reason,
+		}, err)
+	}()
+
+	if server.Status != "running" {
+		return fmt.Errorf("服务器未在运行")
+	}
+
+	utils.Info("准备重启服务器",
+		zap.Uint("server_id", server.ID),
+		zap.Duration("warning", warning),
+		zap.String("reason", reason))
+
+	// 倒计时广播，每次等待后重新读取服务器，确认仍在运行
+	deadline := time.Now().Add(warning)
+	s.broadcast(server, fmt.Sprintf("Server will restart in %s: %s", formatRemaining(warning), reason))
+	for _, mark := range restartWarningMarks {
+		if mark >= warning {
+			continue
+		}
+		if server, err = s.waitRestart(ctx, server, deadline.Add(-mark)); err != nil {
+			return err
+		}
+		s.broadcast(server, fmt.Sprintf("Server will restart in %s: %s", formatRemaining(mark), reason))
+	}
+	if server, err = s.waitRestart(ctx, server, deadline); err != nil {
+		return err
+	}
+
+	// 保存世界（失败不影响重启）
+	if _, err := s.ExecuteRCONCommand(server, "SaveWorld"); err != nil {
+		utils.Warn("重启前保存世界失败", zap.Uint("server_id", server.ID), zap.Error(err))
+	}
+
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+	containerName := utils.GetServerContainerName(server.ID)
+
+	// 停止服务器
+	if err := database.DB.Model(&server).Update("status", "stopping").Error; err != nil {
+		return fmt.Errorf("更新服务器状态失败: %w", err)
+	}
+	s.stopServerAsync(server, dockerManager, containerName)
+
+	// 重新读取服务器配置后启动
+	if err := database.DB.First(&server, server.ID).Error; err != nil {
+		return fmt.Errorf("服务器不存在")
+	}
+	if err := database.DB.Model(&server).Update("status", "starting").Error; err != nil {
+		return fmt.Errorf("更新服务器状态失败: %w", err)
+	}
+	if err := s.startServerAsync(server, dockerManager, containerName); err != nil {
+		database.DB.Model(&server).Update("status", "stopped")
+		return fmt.Errorf("重启服务器失败: %w", err)
+	}
+
+	utils.Info("服务器重启完成", zap.Uint("server_id", server.ID), zap.String("reason", reason))
+	return nil
+}
+
+// waitRestart 等待到指定时间后重新读取服务器
+// 返回: 最新的服务器信息；ctx 取消、服务器已删除或不在运行时返回错误
+func (s *ServerService) waitRestart(ctx context.Context, server models.Server, until time.Time) (models.Server, error) {
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		s.broadcast(server, "Server restart cancelled")
+		return server, fmt.Errorf("重启已取消")
+	}
+
+	var current models.Server
+	if err := database.DB.First(&current, server.ID).Error; err != nil {
+		return server, fmt.Errorf("服务器不存在，取消重启")
+	}
+	if current.Status != "running" {
+		return server, fmt.Errorf("服务器已不在运行，取消重启")
+	}
+	return current, nil
+}
+
+// broadcast 向服务器内所有玩家广播消息（失败只记录警告）
+func (s *ServerService) broadcast(server models.Server, message string) {
+	if _, err := s.ExecuteRCONCommand(server, "Broadcast "+message); err != nil {
+		utils.Warn("RCON广播失败", zap.Uint("server_id", server.ID), zap.Error(err))
+	}
+}
+
+// formatRemaining 格式化剩余时间用于广播
+func formatRemaining(d time.Duration) string {
+	if d >= time.Minute {
+		minutes := int(d.Round(time.Minute) / time.Minute)
+		if minutes == 1 {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return fmt.Sprintf("%d seconds", int(d.Round(time.Second)/time.Second))
+}
@@ -3,12 +3,18 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"ark-server-commander/database"
 	"ark-server-commander/models"
 	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/image"
+	"ark-server-commander/service/mod"
+	"ark-server-commander/service/node"
+	"ark-server-commander/service/permission"
 	"ark-server-commander/utils"
 
 	"go.uber.org/zap"
@@ -22,17 +28,64 @@ func NewServerService() *ServerService {
 	return &ServerService{}
 }
 
+// selectNode 按请求中的节点和标签为新服务器选择节点，并校验资源限制是否超过节点主机的容量、网络配置是否可用
+func selectNode(req models.ServerRequest) (uint, error) {
+	serverNetwork := requestNetwork(req)
+	placement := node.Placement{
+		NodeID: req.NodeID,
+		Labels: req.NodeLabels,
+		HostIP: serverNetwork.BindIP,
+	}
+	if serverNetwork.UsesHostPorts() {
+		placement.Ports = []int{req.Port, req.Port + 1, req.QueryPort, req.RCONPort}
+	}
+	nodeID, err := node.NewNodeService().SelectNode(placement)
+	if err != nil {
+		return 0, err
+	}
+
+	if req.Resources != nil || req.Network != nil {
+		dockerManager, err := docker_manager.GetNodeManager(nodeID)
+		if err != nil {
+			return 0, fmt.Errorf("获取Docker管理器失败: %w", err)
+		}
+		if req.Resources != nil {
+			if err := dockerManager.ValidateResources(*req.Resources); err != nil {
+				return 0, err
+			}
+		}
+		if err := dockerManager.ValidateNetwork(serverNetwork); err != nil {
+			return 0, err
+		}
+	}
+	return nodeID, nil
+}
+
+// requestResources 请求中的资源限制（未提供时不限制）
+func requestResources(req models.ServerRequest) models.ServerResources {
+	if req.Resources == nil {
+		return models.ServerResources{}
+	}
+	return *req.Resources
+}
+
+// requestNetwork 请求中的网络配置（未提供时使用默认的桥接网络）
+func requestNetwork(req models.ServerRequest) models.ServerNetwork {
+	if req.Network == nil {
+		return models.ServerNetwork{}
+	}
+	return *req.Network
+}
+
 // GetServers 获取用户的所有服务器
 func (s *ServerService) GetServers(userID uint) ([]models.ServerResponse, error) {
 	var servers []models.Server
-	if err := database.DB.Where("user_id = ?", userID).Find(&servers).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Find(&servers).Error; err != nil {
 		return nil, fmt.Errorf("获取服务器列表失败: %w", err)
 	}
 
-	dockerManager, err := docker_manager.GetDockerManager()
-	if err != nil {
-		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
-	}
+	// 每个节点的Docker管理器，节点不可用时使用数据库中的状态
+	managers := make(map[uint]*docker_manager.DockerManager)
 
 	var serverResponses []models.ServerResponse
 	for _, server := range servers {
@@ -40,25 +93,36 @@ func (s *ServerService) GetServers(userID uint) ([]models.ServerResponse, error)
 		containerName := utils.GetServerContainerName(server.ID)
 		realTimeStatus := server.Status
 
-		// 检查容器是否存在
-		containerExists, err := dockerManager.ContainerExists(containerName)
-		if err == nil && containerExists {
-			if dockerStatus, err := dockerManager.GetContainerStatus(containerName); err == nil {
-				realTimeStatus = dockerStatus
-
-				// 如果实时状态与数据库状态不同，更新数据库（异步）
-				if realTimeStatus != server.Status {
-					go func(s models.Server, status string) {
-						database.DB.Model(&s).Update("status", status)
-					}(server, realTimeStatus)
+		dockerManager, ok := managers[server.NodeID]
+		if !ok {
+			var managerErr error
+			if dockerManager, managerErr = docker_manager.GetNodeManager(server.NodeID); managerErr != nil {
+				utils.Warn("获取节点Docker管理器失败", zap.Uint("node_id", server.NodeID), zap.Error(managerErr))
+			}
+			managers[server.NodeID] = dockerManager
+		}
+
+		// 检查容器是否存在（节点不可用时使用数据库中的状态）
+		if dockerManager != nil {
+			containerExists, err := dockerManager.ContainerExists(containerName)
+			if err == nil && containerExists {
+				if dockerStatus, err := dockerManager.GetContainerStatus(containerName); err == nil {
+					realTimeStatus = dockerStatus
+
+					// 如果实时状态与数据库状态不同，更新数据库（异步）
+					if realTimeStatus != server.Status {
+						go func(s models.Server, status string) {
+							database.DB.Model(&s).Update("status", status)
+						}(server, realTimeStatus)
+					}
 				}
+			} else if err == nil && !containerExists && server.Status == "running" {
+				// 如果容器不存在但数据库状态是运行中，更新为停止状态
+				realTimeStatus = "stopped"
+				go func(s models.Server) {
+					database.DB.Model(&s).Update("status", "stopped")
+				}(server)
 			}
-		} else if err == nil && !containerExists && server.Status == "running" {
-			// 如果容器不存在但数据库状态是运行中，更新为停止状态
-			realTimeStatus = "stopped"
-			go func(s models.Server) {
-				database.DB.Model(&s).Update("status", "stopped")
-			}(server)
 		}
 
 		serverResponses = append(serverResponses, models.ServerResponse{
@@ -69,13 +133,17 @@ func (s *ServerService) GetServers(userID uint) ([]models.ServerResponse, error)
 			Port:          server.Port,
 			QueryPort:     server.QueryPort,
 			RCONPort:      server.RCONPort,
-			AdminPassword: server.AdminPassword,
+			PasswordIsSet: server.AdminPassword != "",
 			Map:           server.Map,
 			MaxPlayers:    server.MaxPlayers,
 			GameModIds:    server.GameModIds,
 			Status:        realTimeStatus,
 			AutoRestart:   server.AutoRestart,
 			UserID:        server.UserID,
+			NodeID:        server.NodeID,
+			Image:         server.ImageName(),
+			Resources:     server.Resources,
+			Network:       server.Network,
 			CreatedAt:     server.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:     server.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
@@ -92,6 +160,13 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		return nil, fmt.Errorf("服务器标识已存在")
 	}
 
+	// 验证模组ID列表
+	modIDs, err := utils.ParseGameModIds(req.GameModIds)
+	if err != nil {
+		return nil, err
+	}
+	req.GameModIds = strings.Join(modIDs, ",")
+
 	// 设置默认值
 	if req.Map == "" {
 		req.Map = "TheIsland"
@@ -104,6 +179,18 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		req.AutoRestart = &defaultVal
 	}
 
+	// 校验服务器使用的镜像（未指定时使用默认镜像）
+	serverImage, err := image.NewImageService().ResolveImage(req.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	// 选择运行服务器的节点
+	nodeID, err := selectNode(req)
+	if err != nil {
+		return nil, err
+	}
+
 	// 开始数据库事务
 	tx := database.DB.Begin()
 	if tx.Error != nil {
@@ -118,13 +205,17 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		Port:          req.Port,
 		QueryPort:     req.QueryPort,
 		RCONPort:      req.RCONPort,
-		AdminPassword: req.AdminPassword,
+		AdminPassword: models.EncryptedString(req.AdminPassword),
 		Map:           req.Map,
 		MaxPlayers:    req.MaxPlayers,
 		GameModIds:    req.GameModIds,
 		Status:        "stopped",
 		AutoRestart:   *req.AutoRestart,
 		UserID:        userID,
+		NodeID:        nodeID,
+		Image:         serverImage,
+		Resources:     requestResources(req),
+		Network:       requestNetwork(req),
 	}
 
 	if req.ServerArgs != nil {
@@ -143,20 +234,12 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		return nil, fmt.Errorf("服务器创建失败: %w", err)
 	}
 
-	// 创建Docker卷
-	dockerManager, err := docker_manager.GetDockerManager()
-	if err != nil {
+	if err := mod.SyncServerMods(tx, server.ID, modIDs); err != nil {
 		tx.Rollback()
-		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
+		return nil, err
 	}
 
-	volumeName, err := dockerManager.CreateVolume(server.ID)
-	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("创建Docker卷失败: %w", err)
-	}
-
-	// 处理配置文件
+	// 处理配置文件（在创建Docker卷之前校验，避免格式错误时遗留卷）
 	var gameUserSettings string
 	var gameIni string
 
@@ -180,14 +263,29 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		gameIni = utils.GetDefaultGameIni()
 	}
 
-	// 写入配置文件
+	// 创建Docker卷
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+
+	volumeName, err := dockerManager.CreateVolume(server.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("创建Docker卷失败: %w", err)
+	}
+
+	// 写入配置文件（失败时删除已创建的卷）
 	if err := dockerManager.WriteConfigFile(server.ID, utils.GameUserSettingsFileName, gameUserSettings); err != nil {
 		tx.Rollback()
+		dockerManager.RemoveVolume(volumeName)
 		return nil, fmt.Errorf("写入GameUserSettings.ini失败: %w", err)
 	}
 
 	if err := dockerManager.WriteConfigFile(server.ID, utils.GameIniFileName, gameIni); err != nil {
 		tx.Rollback()
+		dockerManager.RemoveVolume(volumeName)
 		return nil, fmt.Errorf("写入Game.ini失败: %w", err)
 	}
 
@@ -206,12 +304,16 @@ func (s *ServerService) CreateServer(userID uint, req models.ServerRequest) (*mo
 		Port:          server.Port,
 		QueryPort:     server.QueryPort,
 		RCONPort:      server.RCONPort,
-		AdminPassword: server.AdminPassword,
+		PasswordIsSet: server.AdminPassword != "",
 		Map:           server.Map,
 		GameModIds:    server.GameModIds,
 		Status:        server.Status,
 		AutoRestart:   server.AutoRestart,
 		UserID:        server.UserID,
+		NodeID:        server.NodeID,
+		Image:         server.ImageName(),
+		Resources:     server.Resources,
+		Network:       server.Network,
 		CreatedAt:     server.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:     server.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
@@ -235,7 +337,7 @@ func (s *ServerService) GetServer(userID uint, serverID string) (*models.ServerR
 	}
 
 	var server models.Server
-	if err = database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err = database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return nil, fmt.Errorf("服务器不存在")
 	}
 
@@ -258,13 +360,17 @@ func (s *ServerService) GetServer(userID uint, serverID string) (*models.ServerR
 		Port:          server.Port,
 		QueryPort:     server.QueryPort,
 		RCONPort:      server.RCONPort,
-		AdminPassword: server.AdminPassword,
+		PasswordIsSet: server.AdminPassword != "",
 		Map:           server.Map,
 		MaxPlayers:    server.MaxPlayers,
 		GameModIds:    server.GameModIds,
 		Status:        server.Status,
 		AutoRestart:   server.AutoRestart,
 		UserID:        server.UserID,
+		NodeID:        server.NodeID,
+		Image:         server.ImageName(),
+		Resources:     server.Resources,
+		Network:       server.Network,
 		CreatedAt:     server.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:     server.UpdatedAt.Format("2006-01-02 15:04:05"),
 		ServerArgs:    serverArgs,
@@ -272,7 +378,7 @@ func (s *ServerService) GetServer(userID uint, serverID string) (*models.ServerR
 	}
 
 	// 读取配置文件内容
-	dockerManager, err := docker_manager.GetDockerManager()
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
 	if err != nil {
 		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
@@ -295,7 +401,7 @@ func (s *ServerService) GetServerRCON(userID uint, serverID string) (map[string]
 	}
 
 	var server models.Server
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return nil, fmt.Errorf("服务器不存在")
 	}
 
@@ -303,10 +409,25 @@ func (s *ServerService) GetServerRCON(userID uint, serverID string) (map[string]
 		"server_id":         server.ID,
 		"server_identifier": server.Identifier,
 		"rcon_port":         server.RCONPort,
-		"admin_password":    server.AdminPassword,
+		"admin_password":    string(server.AdminPassword),
 	}, nil
 }
 
+// RevealAdminPassword 获取服务器管理员密码明文
+func (s *ServerService) RevealAdminPassword(userID uint, serverID string) (string, error) {
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("无效的服务器ID")
+	}
+
+	var server models.Server
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
+		return "", fmt.Errorf("服务器不存在")
+	}
+
+	return string(server.AdminPassword), nil
+}
+
 // UpdateServer 更新服务器配置
 func (s *ServerService) UpdateServer(userID uint, serverID string, req models.ServerUpdateRequest) (*models.ServerResponse, bool, error) {
 	id, err := strconv.ParseUint(serverID, 10, 32)
@@ -315,14 +436,14 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 	}
 
 	var server models.Server
-	if err = database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err = database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return nil, false, fmt.Errorf("服务器不存在")
 	}
 
 	// 检查标识是否冲突
 	if req.Identifier != "" && req.Identifier != server.Identifier {
 		var existingServer models.Server
-		if err = database.DB.Where("identifier = ? AND user_id = ? AND id != ?", req.Identifier, userID, id).First(&existingServer).Error; err == nil {
+		if err = database.DB.Where("identifier = ? AND user_id = ? AND id != ?", req.Identifier, server.UserID, id).First(&existingServer).Error; err == nil {
 			return nil, false, fmt.Errorf("服务器标识已存在")
 		}
 		server.Identifier = req.Identifier
@@ -335,17 +456,22 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 	if req.ClusterID != "" {
 		server.ClusterID = req.ClusterID
 	}
-	if req.Port > 0 {
+	// 主机端口变化时需要检查端口占用并重建容器
+	portsChanged := false
+	if req.Port > 0 && req.Port != server.Port {
 		server.Port = req.Port
+		portsChanged = true
 	}
-	if req.QueryPort > 0 {
+	if req.QueryPort > 0 && req.QueryPort != server.QueryPort {
 		server.QueryPort = req.QueryPort
+		portsChanged = true
 	}
-	if req.RCONPort > 0 {
+	if req.RCONPort > 0 && req.RCONPort != server.RCONPort {
 		server.RCONPort = req.RCONPort
+		portsChanged = true
 	}
 	if req.AdminPassword != "" {
-		server.AdminPassword = req.AdminPassword
+		server.AdminPassword = models.EncryptedString(req.AdminPassword)
 	}
 	if req.Map != "" {
 		server.Map = req.Map
@@ -353,12 +479,66 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 	if req.MaxPlayers > 0 {
 		server.MaxPlayers = req.MaxPlayers
 	}
+	var modIDs []string
 	if req.GameModIds != "" {
-		server.GameModIds = req.GameModIds
+		if modIDs, err = utils.ParseGameModIds(req.GameModIds); err != nil {
+			return nil, false, err
+		}
+		server.GameModIds = strings.Join(modIDs, ",")
+	}
+
+	// 端口、资源限制、镜像或网络发生变化时也需要重建容器
+	argsChanged := portsChanged
+	if req.Image != "" {
+		serverImage, err := image.NewImageService().ResolveImage(req.Image)
+		if err != nil {
+			return nil, false, err
+		}
+		if serverImage != server.ImageName() {
+			server.Image = serverImage
+			argsChanged = true
+		}
+	}
+	if req.Resources != nil && len(req.Resources.Ulimits) == 0 {
+		req.Resources.Ulimits = nil
+	}
+	if req.Resources != nil && !reflect.DeepEqual(*req.Resources, server.Resources) {
+		dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+		if err != nil {
+			return nil, false, fmt.Errorf("获取Docker管理器失败: %w", err)
+		}
+		if err := dockerManager.ValidateResources(*req.Resources); err != nil {
+			return nil, false, err
+		}
+		server.Resources = *req.Resources
+		argsChanged = true
+	}
+	networkChanged := false
+	if req.Network != nil && *req.Network != server.Network {
+		dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+		if err != nil {
+			return nil, false, fmt.Errorf("获取Docker管理器失败: %w", err)
+		}
+		if err := dockerManager.ValidateNetwork(*req.Network); err != nil {
+			return nil, false, err
+		}
+		server.Network = *req.Network
+		networkChanged = true
+		argsChanged = true
+	}
+
+	// 主机端口或网络变化后检查端口是否被同一节点上的其他服务器占用
+	if portsChanged || networkChanged {
+		identifier, port, err := node.UsedPort(server.NodeID, server.Network.BindIP, server.HostPorts(), server.ID)
+		if err != nil {
+			return nil, false, fmt.Errorf("检查端口占用失败: %w", err)
+		}
+		if identifier != "" {
+			return nil, false, fmt.Errorf("端口 %d 已被服务器 %s 占用", port, identifier)
+		}
 	}
 
 	// 检查启动参数是否发生变化
-	argsChanged := false
 	if req.ServerArgs != nil {
 		argsJson, err := json.Marshal(req.ServerArgs)
 		if err != nil {
@@ -375,9 +555,15 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 		return nil, false, fmt.Errorf("服务器更新失败: %w", err)
 	}
 
+	if modIDs != nil {
+		if err := mod.SyncServerMods(database.DB, server.ID, modIDs); err != nil {
+			return nil, false, err
+		}
+	}
+
 	// 处理配置文件更新
 	if req.GameUserSettings != "" || req.GameIni != "" {
-		dockerManager, err := docker_manager.GetDockerManager()
+		dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
 		if err != nil {
 			return nil, false, fmt.Errorf("获取Docker管理器失败: %w", err)
 		}
@@ -410,20 +596,24 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 		Port:          server.Port,
 		QueryPort:     server.QueryPort,
 		RCONPort:      server.RCONPort,
-		AdminPassword: server.AdminPassword,
+		PasswordIsSet: server.AdminPassword != "",
 		Map:           server.Map,
 		MaxPlayers:    server.MaxPlayers,
 		GameModIds:    server.GameModIds,
 		Status:        server.Status,
 		AutoRestart:   server.AutoRestart,
 		UserID:        server.UserID,
+		NodeID:        server.NodeID,
+		Image:         server.ImageName(),
+		Resources:     server.Resources,
+		Network:       server.Network,
 		CreatedAt:     server.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:     server.UpdatedAt.Format("2006-01-02 15:04:05"),
 		ServerArgs:    models.FromServer(server),
 	}
 
 	// 读取配置文件内容
-	dockerManager, err := docker_manager.GetDockerManager()
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
 	if err != nil {
 		return nil, false, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
@@ -438,7 +628,7 @@ func (s *ServerService) UpdateServer(userID uint, serverID string, req models.Se
 	return &response, argsChanged, nil
 }
 
-// DeleteServer 删除服务器
+// DeleteServer 删除服务器（移入回收站，保留期后自动彻底删除）
 func (s *ServerService) DeleteServer(userID uint, serverID string) error {
 	id, err := strconv.ParseUint(serverID, 10, 32)
 	if err != nil {
@@ -446,7 +636,7 @@ func (s *ServerService) DeleteServer(userID uint, serverID string) error {
 	}
 
 	var server models.Server
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return fmt.Errorf("服务器不存在")
 	}
 
@@ -460,7 +650,7 @@ func (s *ServerService) DeleteServer(userID uint, serverID string) error {
 	}
 
 	// 删除Docker容器
-	dockerManager, err := docker_manager.GetDockerManager()
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
 	if err != nil {
 		return fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
@@ -475,6 +665,9 @@ func (s *ServerService) DeleteServer(userID uint, serverID string) error {
 		}
 	}
 
+	// 删除卷辅助容器（数据卷、模组列表和授权保留到彻底删除，以便从回收站恢复）
+	dockerManager.RemoveHelper(server.ID)
+
 	return nil
 }
 
@@ -486,7 +679,7 @@ func (s *ServerService) StartServer(userID uint, serverID string) error {
 	}
 
 	var server models.Server
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return fmt.Errorf("服务器不存在")
 	}
 
@@ -498,18 +691,18 @@ func (s *ServerService) StartServer(userID uint, serverID string) error {
 		return fmt.Errorf("服务器正在启动中")
 	}
 
+	// 获取服务器所在节点的Docker管理器（节点不可用时不修改状态）
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+
 	// 更新服务器状态为启动中
 	server.Status = "starting"
 	if err := database.DB.Save(&server).Error; err != nil {
 		return fmt.Errorf("更新服务器状态失败: %w", err)
 	}
 
-	// 启动Docker容器
-	dockerManager, err := docker_manager.GetDockerManager()
-	if err != nil {
-		return fmt.Errorf("获取Docker管理器失败: %w", err)
-	}
-
 	containerName := utils.GetServerContainerName(server.ID)
 
 	go func() {
@@ -525,7 +718,7 @@ func (s *ServerService) StartServer(userID uint, serverID string) error {
 // startServerAsync 异步启动服务器
 func (s *ServerService) startServerAsync(server models.Server, dockerManager *docker_manager.DockerManager, containerName string) error {
 	// 严格验证必要镜像是否存在
-	missingImages, err := dockerManager.ValidateRequiredImages()
+	missingImages, err := dockerManager.ValidateRequiredImages(server.ImageName())
 	if err != nil {
 		return fmt.Errorf("验证镜像失败: %w", err)
 	}
@@ -574,6 +767,27 @@ func (s *ServerService) startServerAsync(server models.Server, dockerManager *do
 					needRecreateContainer = true
 				}
 			}
+
+			// 检查资源限制和重启策略
+			if !needRecreateContainer {
+				if changed, err := dockerManager.ContainerResourcesChanged(containerName, server); err != nil || changed {
+					needRecreateContainer = true
+				}
+			}
+
+			// 检查服务器使用的镜像
+			if !needRecreateContainer {
+				if changed, err := dockerManager.ContainerImageChanged(containerName, server); err != nil || changed {
+					needRecreateContainer = true
+				}
+			}
+
+			// 检查网络模式和绑定的IP
+			if !needRecreateContainer {
+				if changed, err := dockerManager.ContainerNetworkChanged(containerName, server); err != nil || changed {
+					needRecreateContainer = true
+				}
+			}
 		}
 
 		if needRecreateContainer {
@@ -585,7 +799,7 @@ func (s *ServerService) startServerAsync(server models.Server, dockerManager *do
 
 	// 创建容器
 	if !containerExists || needRecreateContainer {
-		_, err = dockerManager.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, server.AdminPassword, server.Map, server.GameModIds, server.AutoRestart)
+		_, err = dockerManager.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, string(server.AdminPassword), server.Map, server.GameModIds, server.AutoRestart)
 		if err != nil {
 			return fmt.Errorf("创建容器失败: %w", err)
 		}
@@ -623,7 +837,7 @@ func (s *ServerService) StopServer(userID uint, serverID string) error {
 	}
 
 	var server models.Server
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return fmt.Errorf("服务器不存在")
 	}
 
@@ -635,18 +849,18 @@ func (s *ServerService) StopServer(userID uint, serverID string) error {
 		return fmt.Errorf("服务器正在停止中")
 	}
 
+	// 获取服务器所在节点的Docker管理器（节点不可用时不修改状态）
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+
 	// 更新服务器状态为停止中
 	server.Status = "stopping"
 	if err := database.DB.Save(&server).Error; err != nil {
 		return fmt.Errorf("更新服务器状态失败: %w", err)
 	}
 
-	// 停止Docker容器
-	dockerManager, err := docker_manager.GetDockerManager()
-	if err != nil {
-		return fmt.Errorf("获取Docker管理器失败: %w", err)
-	}
-
 	containerName := utils.GetServerContainerName(server.ID)
 
 	go func() {
@@ -695,26 +909,27 @@ func (s *ServerService) stopServerAsync(server models.Server, dockerManager *doc
 	}
 }
 
-// ValidateRequiredImages 验证启动服务器所需的镜像是否存在
-func (s *ServerService) ValidateRequiredImages() (missing []string, err error) {
-	dockerManager, err := docker_manager.GetDockerManager()
+// ValidateRequiredImages 验证节点上启动服务器所需的镜像是否存在（nodeID 为 0 表示面板所在主机）
+// serverImages: 服务器使用的镜像
+func (s *ServerService) ValidateRequiredImages(nodeID uint, serverImages ...string) (missing []string, err error) {
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
 	if err != nil {
 		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
 
-	return dockerManager.ValidateRequiredImages()
+	return dockerManager.ValidateRequiredImages(serverImages...)
 }
 
-// CheckImageUpdates 检查所有管理的镜像更新
-func (s *ServerService) CheckImageUpdates() (map[string]bool, error) {
-	dockerManager, err := docker_manager.GetDockerManager()
+// CheckImageUpdates 检查节点上所有管理的镜像更新
+func (s *ServerService) CheckImageUpdates(nodeID uint) (map[string]bool, error) {
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
 	if err != nil {
 		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
 
-	requiredImages := []string{
-		"tbro98/ase-server:latest",
-		"alpine:latest",
+	requiredImages, err := image.NewImageService().ManagedImages()
+	if err != nil {
+		return nil, err
 	}
 
 	updateStatus := make(map[string]bool)
@@ -731,102 +946,129 @@ func (s *ServerService) CheckImageUpdates() (map[string]bool, error) {
 	return updateStatus, nil
 }
 
-// PullImage 手动拉取指定镜像
-func (s *ServerService) PullImage(imageName string) error {
-	dockerManager, err := docker_manager.GetDockerManager()
+// PullImage 在节点上手动拉取指定镜像（镜像已在拉取时返回正在进行的任务）
+func (s *ServerService) PullImage(imageName string, nodeID uint) (*docker_manager.PullJobInfo, error) {
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
 	if err != nil {
-		return fmt.Errorf("获取Docker管理器失败: %w", err)
+		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
 
 	// 验证镜像名称是否在允许的列表中
-	allowedImages := []string{
-		"tbro98/ase-server:latest",
-		"alpine:latest",
-	}
-
-	allowed := false
-	for _, allowedImage := range allowedImages {
-		if imageName == allowedImage {
-			allowed = true
-			break
-		}
+	name, allowed, err := managedImage(imageName)
+	if err != nil {
+		return nil, err
 	}
-
 	if !allowed {
-		return fmt.Errorf("不允许拉取镜像: %s", imageName)
+		return nil, fmt.Errorf("不允许拉取镜像: %s", imageName)
 	}
 
-	// 异步拉取镜像
-	go func() {
-		if err := dockerManager.PullImageWithProgress(imageName); err != nil {
-			utils.Error("拉取镜像失败", zap.String("image", imageName), zap.Error(err))
-		} else {
-			utils.Info("镜像拉取完成", zap.String("image", imageName))
-		}
-	}()
-
-	return nil
+	// 后台拉取镜像
+	job, err := dockerManager.StartPull(name)
+	if err != nil {
+		return nil, fmt.Errorf("创建拉取任务失败: %w", err)
+	}
+	info := job.Info()
+	return &info, nil
 }
 
-// UpdateImage 更新指定镜像及相关容器
-func (s *ServerService) UpdateImage(imageName string, userID uint) ([]models.ServerResponse, error) {
+// UpdateImage 更新节点上的指定镜像及相关容器
+// 返回: 受影响的服务器和拉取任务
+func (s *ServerService) UpdateImage(imageName string, userID uint, nodeID uint) ([]models.ServerResponse, *docker_manager.PullJobInfo, error) {
 	// 验证镜像名称
-	allowedImages := []string{
-		"tbro98/ase-server:latest",
-		"alpine:latest",
-	}
-
-	allowed := false
-	for _, allowedImage := range allowedImages {
-		if imageName == allowedImage {
-			allowed = true
-			break
-		}
+	name, allowed, err := managedImage(imageName)
+	if err != nil {
+		return nil, nil, err
 	}
-
 	if !allowed {
-		return nil, fmt.Errorf("不允许更新镜像: %s", imageName)
+		return nil, nil, fmt.Errorf("不允许更新镜像: %s", imageName)
 	}
+	imageName = name
 
 	// 获取受影响的服务器
 	affectedServers, err := s.GetAffectedServers(imageName, userID)
 	if err != nil {
-		return nil, fmt.Errorf("获取受影响服务器失败: %w", err)
+		return nil, nil, fmt.Errorf("获取受影响服务器失败: %w", err)
 	}
 
-	// 异步更新镜像
-	go func() {
-		dockerManager, err := docker_manager.GetDockerManager()
-		if err != nil {
-			utils.Error("获取Docker管理器失败", zap.Error(err))
-			return
+	// 只返回该节点上的服务器
+	if nodeID != 0 {
+		nodeServers := make([]models.ServerResponse, 0, len(affectedServers))
+		for _, server := range affectedServers {
+			if server.NodeID == nodeID {
+				nodeServers = append(nodeServers, server)
+			}
 		}
+		affectedServers = nodeServers
+	}
 
-		// 拉取新镜像
-		utils.Info("开始更新镜像", zap.String("image", imageName))
-		if err := dockerManager.PullImageWithProgress(imageName); err != nil {
-			utils.Error("更新镜像失败", zap.String("image", imageName), zap.Error(err))
-			return
-		}
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
 
-		utils.Info("镜像更新完成", zap.String("image", imageName))
+	// 后台拉取新镜像，用户可以在完成后选择重建受影响的容器
+	utils.Info("开始更新镜像", zap.String("image", imageName))
+	job, err := dockerManager.StartPull(imageName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建拉取任务失败: %w", err)
+	}
+	info := job.Info()
+	return affectedServers, &info, nil
+}
 
-		// 这里可以添加通知逻辑，告知用户镜像更新完成
-		// 用户可以选择重建受影响的容器
-	}()
+// GetPullJobs 获取正在进行和最近结束的镜像拉取任务
+func (s *ServerService) GetPullJobs() []docker_manager.PullJobInfo {
+	return docker_manager.ListPullJobs()
+}
 
-	return affectedServers, nil
+// GetPullJob 获取镜像拉取任务
+func (s *ServerService) GetPullJob(jobID string) (*docker_manager.PullJob, error) {
+	return docker_manager.GetPullJob(jobID)
+}
+
+// CancelPullJob 取消正在进行的镜像拉取任务
+func (s *ServerService) CancelPullJob(jobID string) error {
+	return docker_manager.CancelPullJob(jobID)
 }
 
 // GetAffectedServers 获取使用指定镜像的服务器列表
 func (s *ServerService) GetAffectedServers(imageName string, userID uint) ([]models.ServerResponse, error) {
-	// 目前所有ARK服务器都使用相同的镜像
-	if imageName == "tbro98/ase-server:latest" {
-		return s.GetServers(userID)
+	name, err := image.NormalizeImage(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := s.GetServers(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	affectedServers := make([]models.ServerResponse, 0, len(servers))
+	for _, server := range servers {
+		if server.Image == name {
+			affectedServers = append(affectedServers, server)
+		}
 	}
+	return affectedServers, nil
+}
 
-	// 对于其他镜像，返回空列表
-	return []models.ServerResponse{}, nil
+// managedImage 检查镜像是否为面板管理的镜像（登记的服务器镜像或辅助容器镜像）
+// 返回: 规范化的镜像名称、是否允许和错误信息
+func managedImage(imageName string) (string, bool, error) {
+	name, err := image.NormalizeImage(imageName)
+	if err != nil {
+		return "", false, nil
+	}
+	managedImages, err := image.NewImageService().ManagedImages()
+	if err != nil {
+		return "", false, err
+	}
+	for _, managed := range managedImages {
+		if name == managed {
+			return name, true, nil
+		}
+	}
+	return name, false, nil
 }
 
 // RecreateContainer 重建指定服务器的容器
@@ -837,7 +1079,7 @@ func (s *ServerService) RecreateContainer(userID uint, serverID string) error {
 	}
 
 	var server models.Server
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&server).Error; err != nil {
+	if err := database.DB.Scopes(permission.ServerScope(userID)).Where("id = ?", id).First(&server).Error; err != nil {
 		return fmt.Errorf("服务器不存在")
 	}
 
@@ -860,7 +1102,7 @@ func (s *ServerService) RecreateContainer(userID uint, serverID string) error {
 
 	// 异步重建容器
 	go func() {
-		dockerManager, err := docker_manager.GetDockerManager()
+		dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
 		if err != nil {
 			utils.Error("获取Docker管理器失败", zap.Error(err))
 			return
@@ -880,7 +1122,7 @@ func (s *ServerService) RecreateContainer(userID uint, serverID string) error {
 			server.Port,
 			server.QueryPort,
 			server.RCONPort,
-			server.AdminPassword,
+			string(server.AdminPassword),
 			server.Map,
 			server.GameModIds,
 			server.AutoRestart,
@@ -896,16 +1138,16 @@ func (s *ServerService) RecreateContainer(userID uint, serverID string) error {
 	return nil
 }
 
-// GetImageStatus 获取镜像状态
-func (s *ServerService) GetImageStatus() (map[string]interface{}, error) {
-	dockerManager, err := docker_manager.GetDockerManager()
+// GetImageStatus 获取节点上的镜像状态
+func (s *ServerService) GetImageStatus(nodeID uint) (map[string]interface{}, error) {
+	dockerManager, err := docker_manager.GetNodeManager(nodeID)
 	if err != nil {
 		return nil, fmt.Errorf("获取Docker管理器失败: %w", err)
 	}
 
-	requiredImages := []string{
-		"tbro98/ase-server:latest",
-		"alpine:latest",
+	requiredImages, err := image.NewImageService().ManagedImages()
+	if err != nil {
+		return nil, err
 	}
 
 	imageStatuses := make(map[string]*docker_manager.ImageStatus)
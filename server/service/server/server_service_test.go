@@ -0,0 +1,667 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/docker_manager/dockertest"
+	"ark-server-commander/utils"
+)
+
+const (
+	testServerImage = "tbro98/ase-server:latest"
+	testHelperImage = "alpine:latest"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// setupTest 创建内存数据库和模拟Docker客户端，返回模拟客户端和所有者用户ID
+func setupTest(t *testing.T, images ...string) (*dockertest.FakeClient, uint) {
+	t.Helper()
+
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+	db := dbtest.Open(t)
+
+	owner := models.User{Username: "owner", Password: "x", Role: models.RoleOwner}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	fake := dockertest.NewFakeClient(images...)
+	docker_manager.SetDockerClient(fake)
+	t.Cleanup(docker_manager.RemoveAllHelpers)
+	return fake, owner.ID
+}
+
+// serverRequest 创建服务器的请求
+func serverRequest(identifier string, port int) models.ServerRequest {
+	return models.ServerRequest{
+		Identifier:    identifier,
+		SessionName:   identifier + " PvE",
+		Port:          port,
+		QueryPort:     port + 100,
+		RCONPort:      port + 200,
+		AdminPassword: "secret",
+		GameModIds:    "111, 222",
+	}
+}
+
+// loadServer 从数据库读取服务器
+func loadServer(t *testing.T, id uint) models.Server {
+	t.Helper()
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		t.Fatalf("读取服务器失败: %v", err)
+	}
+	return server
+}
+
+// inspect 获取容器信息
+func inspect(t *testing.T, fake *dockertest.FakeClient, name string) (string, map[string]string, int64) {
+	t.Helper()
+	info, err := fake.ContainerInspect(context.Background(), name)
+	if err != nil {
+		t.Fatalf("获取容器信息失败: %v", err)
+	}
+	env := make(map[string]string)
+	for _, item := range info.Config.Env {
+		key, value, _ := strings.Cut(item, "=")
+		env[key] = value
+	}
+	return info.ID, env, info.HostConfig.Memory
+}
+
+// assertNoServer 检查创建失败后没有遗留数据库记录和Docker资源
+func assertNoServer(t *testing.T, fake *dockertest.FakeClient) {
+	t.Helper()
+	var count int64
+	database.DB.Unscoped().Model(&models.Server{}).Count(&count)
+	if count != 0 {
+		t.Errorf("不应遗留服务器记录，实际 %d 条", count)
+	}
+	if names := fake.VolumeNames(); len(names) != 0 {
+		t.Errorf("不应遗留Docker卷: %v", names)
+	}
+	if names := fake.ContainerNames(); len(names) != 0 {
+		t.Errorf("不应遗留容器: %v", names)
+	}
+}
+
+func TestCreateServer(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	req := serverRequest("island", 7777)
+	req.Resources = &models.ServerResources{MemoryLimitMB: 4096}
+	req.GameIni = "[/script/shootergame.shootergamemode]\nbDisableStructurePlacementCollision=True\n"
+	response, err := service.CreateServer(ownerID, req)
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if response.Status != "stopped" || response.GameModIds != "111,222" || response.Map != "TheIsland" || response.Resources.MemoryLimitMB != 4096 {
+		t.Fatalf("响应错误: %+v", response)
+	}
+
+	// 卷和配置文件
+	if names := fake.VolumeNames(); strings.Join(names, ",") != "ase-server-1,ase-server-plugins-1" {
+		t.Fatalf("应创建数据卷和插件卷: %v", names)
+	}
+	gameIni, ok := fake.ReadVolumeFile("ase-server-1", utils.ConfigDirectory+"/"+utils.GameIniFileName)
+	if !ok || string(gameIni) != req.GameIni || response.GameIni != req.GameIni {
+		t.Fatalf("Game.ini 应原样写入: %q", gameIni)
+	}
+	gameUserSettings, ok := fake.ReadVolumeFile("ase-server-1", utils.ConfigDirectory+"/"+utils.GameUserSettingsFileName)
+	if !ok || !strings.Contains(string(gameUserSettings), "island") || response.GameUserSettings != string(gameUserSettings) {
+		t.Fatalf("应写入默认的 GameUserSettings.ini: %q", gameUserSettings)
+	}
+
+	// 数据库记录
+	server := loadServer(t, response.ID)
+	if string(server.AdminPassword) != "secret" || server.NodeID != 0 || server.Resources.MemoryLimitMB != 4096 {
+		t.Fatalf("服务器记录错误: %+v", server)
+	}
+	var mods []models.ServerMod
+	database.DB.Order("position").Find(&mods)
+	if len(mods) != 2 || mods[0].WorkshopID != "111" || mods[1].WorkshopID != "222" {
+		t.Fatalf("模组列表错误: %+v", mods)
+	}
+
+	if _, err := service.CreateServer(ownerID, req); err == nil || err.Error() != "服务器标识已存在" {
+		t.Fatalf("重复的服务器标识应返回错误: %v", err)
+	}
+}
+
+func TestCreateServerCleansUpOnFailure(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	// 配置文件格式错误时不创建任何资源
+	req := serverRequest("island", 7777)
+	req.GameIni = "[broken"
+	if _, err := service.CreateServer(ownerID, req); err == nil || !strings.Contains(err.Error(), "格式错误") {
+		t.Fatalf("配置文件格式错误时应返回错误: %v", err)
+	}
+	assertNoServer(t, fake)
+
+	// 写入配置文件失败时删除已创建的卷和辅助容器
+	fake.FailOn("CopyToContainer", "", errors.New("no space left on device"))
+	if _, err := service.CreateServer(ownerID, serverRequest("island", 7777)); err == nil || !strings.Contains(err.Error(), "写入GameUserSettings.ini失败") {
+		t.Fatalf("写入配置文件失败时应返回错误: %v", err)
+	}
+	assertNoServer(t, fake)
+}
+
+func TestCreateServerWithRollback(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	fake.FailOn("CopyToContainer", "", errors.New("no space left on device"))
+	if _, err := service.CreateServerWithRollback(ownerID, serverRequest("island", 7777)); err == nil || !strings.Contains(err.Error(), "写入GameUserSettings.ini失败") {
+		t.Fatalf("写入配置文件失败时应返回错误: %v", err)
+	}
+	assertNoServer(t, fake)
+
+	fake.ClearFailures()
+	response, err := service.CreateServerWithRollback(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if names := fake.VolumeNames(); len(names) != 2 || response.GameUserSettings == "" {
+		t.Fatalf("应创建卷并写入配置文件: %v", names)
+	}
+	loadServer(t, response.ID)
+}
+
+func TestCreateServerWithTransaction(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	fake.FailOn("CopyToContainer", "", errors.New("no space left on device"))
+	if _, err := service.CreateServerWithTransaction(ownerID, serverRequest("island", 7777)); err == nil || !strings.Contains(err.Error(), "写入GameUserSettings.ini失败") {
+		t.Fatalf("写入配置文件失败时应返回错误: %v", err)
+	}
+	assertNoServer(t, fake)
+
+	fake.ClearFailures()
+	response, err := service.CreateServerWithTransaction(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if names := fake.VolumeNames(); len(names) != 2 || response.GameIni == "" {
+		t.Fatalf("应创建卷并写入配置文件: %v", names)
+	}
+}
+
+func TestStartServerAsync(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(response.ID)
+
+	// 首次启动时创建容器
+	if err := service.startServerAsync(loadServer(t, response.ID), dockerManager, containerName); err != nil {
+		t.Fatalf("启动服务器失败: %v", err)
+	}
+	server := loadServer(t, response.ID)
+	if server.Status != "running" {
+		t.Fatalf("启动后状态应为 running: %s", server.Status)
+	}
+	firstID, env, _ := inspect(t, fake, containerName)
+	if env["GameModIds"] != "111,222" || !strings.Contains(env["SERVER_ARGS"], "TheIsland") {
+		t.Fatalf("容器环境变量错误: %v", env)
+	}
+
+	// 配置未变化时复用已有容器
+	if err := service.startServerAsync(server, dockerManager, containerName); err != nil {
+		t.Fatalf("再次启动服务器失败: %v", err)
+	}
+	if id, _, _ := inspect(t, fake, containerName); id != firstID {
+		t.Fatal("配置未变化时不应重建容器")
+	}
+
+	// 资源限制修改后重建容器
+	database.DB.Model(&server).Update("memory_limit_mb", 2048)
+	if err := service.startServerAsync(loadServer(t, response.ID), dockerManager, containerName); err != nil {
+		t.Fatalf("修改资源限制后启动失败: %v", err)
+	}
+	id, _, memory := inspect(t, fake, containerName)
+	if id == firstID || memory != 2048<<20 {
+		t.Fatalf("资源限制修改后应重建容器: id=%s memory=%d", id, memory)
+	}
+}
+
+func TestStartServerAsyncRequiresImages(t *testing.T) {
+	fake, ownerID := setupTest(t, testHelperImage)
+	service := NewServerService()
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+
+	err = service.startServerAsync(loadServer(t, response.ID), dockerManager, utils.GetServerContainerName(response.ID))
+	if err == nil || !strings.Contains(err.Error(), testServerImage) {
+		t.Fatalf("缺少服务器镜像时应返回错误: %v", err)
+	}
+	if fake.CallCount("ContainerStart") != 1 {
+		t.Fatal("缺少镜像时不应创建服务器容器")
+	}
+}
+
+func TestStartServerAsyncWithRollback(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(response.ID)
+	database.DB.Model(&models.Server{}).Where("id = ?", response.ID).Update("status", "starting")
+
+	// 启动失败时删除刚创建的容器并恢复为停止状态
+	fake.FailOn("ContainerStart", containerName, errors.New("port is already allocated"))
+	err = service.startServerAsyncWithRollback(loadServer(t, response.ID), dockerManager, containerName)
+	if err == nil || !strings.Contains(err.Error(), "port is already allocated") {
+		t.Fatalf("容器启动失败时应返回错误: %v", err)
+	}
+	if exists, _ := dockerManager.ContainerExists(containerName); exists {
+		t.Fatal("启动失败后应删除刚创建的容器")
+	}
+	if server := loadServer(t, response.ID); server.Status != "stopped" {
+		t.Fatalf("启动失败后状态应为 stopped: %s", server.Status)
+	}
+}
+
+func TestRecreateContainer(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	server := loadServer(t, response.ID)
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(server.ID)
+	if _, err := dockerManager.CreateContainer(server.ID, server.Identifier, server.Port, server.QueryPort, server.RCONPort, "", server.Map, server.GameModIds, server.AutoRestart); err != nil {
+		t.Fatalf("创建容器失败: %v", err)
+	}
+	oldID, _, _ := inspect(t, fake, containerName)
+
+	if err := service.RecreateContainer(ownerID, "abc"); err == nil || err.Error() != "无效的服务器ID" {
+		t.Fatalf("无效的服务器ID应返回错误: %v", err)
+	}
+	if err := service.RecreateContainer(ownerID, "99"); err == nil || err.Error() != "服务器不存在" {
+		t.Fatalf("不存在的服务器应返回错误: %v", err)
+	}
+
+	// 修改模组后重建，新容器使用数据库中的配置
+	database.DB.Model(&server).Update("game_mod_ids", "333")
+	if err := service.RecreateContainer(ownerID, fmt.Sprint(server.ID)); err != nil {
+		t.Fatalf("重建容器失败: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if info, err := fake.ContainerInspect(context.Background(), containerName); err == nil && info.ID != oldID {
+			_, env, _ := inspect(t, fake, containerName)
+			if env["GameModIds"] != "333" {
+				t.Fatalf("重建的容器应使用新的模组列表: %v", env)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("等待容器重建超时")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerImages(t *testing.T) {
+	const pinnedImage = "tbro98/ase-server:v2"
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage, pinnedImage)
+	service := NewServerService()
+
+	req := serverRequest("beta", 7800)
+	req.Image = pinnedImage
+	if _, err := service.CreateServer(ownerID, req); err == nil || err.Error() != "镜像未登记: "+pinnedImage {
+		t.Fatalf("未登记的镜像应返回错误: %v", err)
+	}
+	if _, err := service.PullImage(pinnedImage, 0); err == nil || !strings.Contains(err.Error(), "不允许拉取镜像") {
+		t.Fatalf("不应允许拉取未登记的镜像: %v", err)
+	}
+	database.DB.Create(&models.ServerImage{Image: pinnedImage})
+
+	stable, err := service.CreateServer(ownerID, serverRequest("stable", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	beta, err := service.CreateServer(ownerID, req)
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if stable.Image != testServerImage || beta.Image != pinnedImage {
+		t.Fatalf("服务器镜像错误: %s, %s", stable.Image, beta.Image)
+	}
+
+	// 使用镜像的服务器（镜像名称规范化后比较）
+	for imageName, want := range map[string]uint{"tbro98/ase-server": stable.ID, pinnedImage: beta.ID} {
+		affected, err := service.GetAffectedServers(imageName, ownerID)
+		if err != nil || len(affected) != 1 || affected[0].ID != want {
+			t.Fatalf("%s 影响的服务器错误: %+v, %v", imageName, affected, err)
+		}
+	}
+
+	// 容器使用服务器的镜像，更换镜像后启动时重建容器
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(stable.ID)
+	if err := service.startServerAsync(loadServer(t, stable.ID), dockerManager, containerName); err != nil {
+		t.Fatalf("启动服务器失败: %v", err)
+	}
+	firstID, _, _ := inspect(t, fake, containerName)
+
+	_, changed, err := service.UpdateServer(ownerID, fmt.Sprint(stable.ID), models.ServerUpdateRequest{Image: pinnedImage})
+	if err != nil || !changed {
+		t.Fatalf("更换镜像应需要重建容器: %v, %v", changed, err)
+	}
+	if err := service.startServerAsync(loadServer(t, stable.ID), dockerManager, containerName); err != nil {
+		t.Fatalf("更换镜像后启动失败: %v", err)
+	}
+	info, _ := fake.ContainerInspect(context.Background(), containerName)
+	if info.ID == firstID || info.Config.Image != pinnedImage {
+		t.Fatalf("更换镜像后应使用新镜像重建容器: %s", info.Config.Image)
+	}
+	if affected, _ := service.GetAffectedServers(testServerImage, ownerID); len(affected) != 0 {
+		t.Fatalf("更换镜像后不应再影响该服务器: %+v", affected)
+	}
+}
+
+func TestUpdateServerPortConflict(t *testing.T) {
+	_, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	var ids []uint
+	for i, port := range []int{7777, 7800} {
+		response, err := service.CreateServer(ownerID, serverRequest(fmt.Sprintf("ports-%d", i), port))
+		if err != nil {
+			t.Fatalf("创建服务器失败: %v", err)
+		}
+		ids = append(ids, response.ID)
+	}
+
+	// 游戏端口、查询端口和RCON端口修改为其他服务器占用的端口时都应拒绝
+	for _, req := range []models.ServerUpdateRequest{{Port: 7777}, {QueryPort: 7778}, {RCONPort: 7977}} {
+		if _, _, err := service.UpdateServer(ownerID, fmt.Sprint(ids[1]), req); err == nil || !strings.Contains(err.Error(), "已被服务器 ports-0 占用") {
+			t.Fatalf("修改为已占用的端口 %+v 时应返回错误: %v", req, err)
+		}
+	}
+	if server := loadServer(t, ids[1]); server.Port != 7800 || server.QueryPort != 7900 || server.RCONPort != 8000 {
+		t.Fatalf("端口冲突时不应保存修改: %d/%d/%d", server.Port, server.QueryPort, server.RCONPort)
+	}
+
+	// 修改端口后需要重建容器
+	if _, changed, err := service.UpdateServer(ownerID, fmt.Sprint(ids[1]), models.ServerUpdateRequest{RCONPort: 8100}); err != nil || !changed {
+		t.Fatalf("修改端口应需要重建容器: %v, %v", changed, err)
+	}
+	if _, changed, err := service.UpdateServer(ownerID, fmt.Sprint(ids[1]), models.ServerUpdateRequest{RCONPort: 8100}); err != nil || changed {
+		t.Fatalf("端口未变化时不需要重建容器: %v, %v", changed, err)
+	}
+}
+
+func TestServerNetwork(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	req := serverRequest("macvlan", 7900)
+	req.Network = &models.ServerNetwork{NetworkMode: models.NetworkModeMacvlan, NetworkName: "lan"}
+	if _, err := service.CreateServer(ownerID, req); err == nil || err.Error() != "网络配置错误: Docker 网络 lan 不存在" {
+		t.Fatalf("网络不存在时应返回错误: %v", err)
+	}
+
+	// 绑定到不同IP的服务器可以使用相同的端口
+	var ids []uint
+	for i, ip := range []string{"203.0.113.10", "203.0.113.11"} {
+		req := serverRequest(fmt.Sprintf("public-%d", i), 7777)
+		req.Network = &models.ServerNetwork{BindIP: ip}
+		response, err := service.CreateServer(ownerID, req)
+		if err != nil {
+			t.Fatalf("创建服务器失败: %v", err)
+		}
+		if response.Network.BindIP != ip {
+			t.Fatalf("响应中的网络配置错误: %+v", response.Network)
+		}
+		ids = append(ids, response.ID)
+	}
+	_, _, err := service.UpdateServer(ownerID, fmt.Sprint(ids[1]), models.ServerUpdateRequest{Network: &models.ServerNetwork{BindIP: "203.0.113.10"}})
+	if err == nil || err.Error() != "端口 7777 已被服务器 public-0 占用" {
+		t.Fatalf("修改为已占用端口的IP时应返回错误: %v", err)
+	}
+
+	// 修改网络模式后启动时重建容器
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(ids[0])
+	if err := service.startServerAsync(loadServer(t, ids[0]), dockerManager, containerName); err != nil {
+		t.Fatalf("启动服务器失败: %v", err)
+	}
+	firstID, _, _ := inspect(t, fake, containerName)
+
+	hostNetwork := models.ServerNetwork{NetworkMode: models.NetworkModeHost, BindIP: "203.0.113.10"}
+	if _, changed, err := service.UpdateServer(ownerID, fmt.Sprint(ids[0]), models.ServerUpdateRequest{Network: &hostNetwork}); err != nil || !changed {
+		t.Fatalf("修改网络模式应需要重建容器: %v, %v", changed, err)
+	}
+	if _, changed, err := service.UpdateServer(ownerID, fmt.Sprint(ids[0]), models.ServerUpdateRequest{Network: &hostNetwork}); err != nil || changed {
+		t.Fatalf("网络配置未变化时不需要重建容器: %v, %v", changed, err)
+	}
+	if err := service.startServerAsync(loadServer(t, ids[0]), dockerManager, containerName); err != nil {
+		t.Fatalf("修改网络模式后启动失败: %v", err)
+	}
+	info, _ := fake.ContainerInspect(context.Background(), containerName)
+	_, env, _ := inspect(t, fake, containerName)
+	if info.ID == firstID || info.HostConfig.NetworkMode != "host" || !strings.Contains(env["SERVER_ARGS"], "?MultiHome=203.0.113.10") {
+		t.Fatalf("修改网络模式后应重建容器: %s %v", info.HostConfig.NetworkMode, env)
+	}
+}
+
+// loadDeletedAt 读取回收站中服务器的删除时间
+func loadDeletedAt(t *testing.T, id uint) time.Time {
+	t.Helper()
+	var server models.Server
+	if err := database.DB.Unscoped().First(&server, id).Error; err != nil || !server.DeletedAt.Valid {
+		t.Fatalf("服务器 %d 不在回收站中: %v", id, err)
+	}
+	return server.DeletedAt.Time
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+	config.OrphanBackupDir = t.TempDir()
+	t.Cleanup(func() { config.OrphanBackupDir = "backups/orphans" })
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	serverID := fmt.Sprint(response.ID)
+
+	// 删除后移入回收站，数据卷和模组列表保留
+	if err := service.DeleteServer(ownerID, serverID); err != nil {
+		t.Fatalf("删除服务器失败: %v", err)
+	}
+	trash, err := service.GetDeletedServers()
+	if err != nil || len(trash) != 1 || trash[0].ID != response.ID || trash[0].PurgeAt == "" {
+		t.Fatalf("回收站应包含删除的服务器: %+v, %v", trash, err)
+	}
+	if names := fake.VolumeNames(); len(names) != 2 {
+		t.Fatalf("删除后应保留数据卷: %v", names)
+	}
+	if err := service.RestoreServer("999"); err == nil || err.Error() != "服务器不存在" {
+		t.Fatalf("恢复不存在的服务器应返回错误: %v", err)
+	}
+
+	// 标识被占用时不能恢复
+	other, err := service.CreateServer(ownerID, serverRequest("island", 7877))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if err := service.RestoreServer(serverID); err == nil || err.Error() != "服务器标识已存在" {
+		t.Fatalf("标识被占用时应返回错误: %v", err)
+	}
+	if err := service.PurgeServer(fmt.Sprint(other.ID), "island"); err != nil {
+		t.Fatalf("彻底删除服务器失败: %v", err)
+	}
+
+	// 创建容器失败时保留原删除时间，回收站保留期不重新计算
+	deletedAt := loadDeletedAt(t, response.ID)
+	fake.FailOn("ContainerCreate", "", fmt.Errorf("no space left on device"))
+	if err := service.RestoreServer(serverID); err == nil {
+		t.Fatal("创建容器失败时应返回错误")
+	}
+	fake.ClearFailures()
+	if restored := loadDeletedAt(t, response.ID); !restored.Equal(deletedAt) {
+		t.Fatalf("恢复失败后删除时间 = %s，期望 %s", restored, deletedAt)
+	}
+
+	// 恢复后重新创建容器
+	if err := service.RestoreServer(serverID); err != nil {
+		t.Fatalf("恢复服务器失败: %v", err)
+	}
+	if server := loadServer(t, response.ID); server.Status != "stopped" {
+		t.Fatalf("恢复后的服务器应处于停止状态: %s", server.Status)
+	}
+	if _, env, _ := inspect(t, fake, utils.GetServerContainerName(response.ID)); env["GameModIds"] != "111,222" {
+		t.Fatalf("恢复后应重新创建容器: %v", env)
+	}
+	if err := service.RestoreServer(serverID); err == nil || err.Error() != "服务器未被删除" {
+		t.Fatalf("未删除的服务器不能恢复: %v", err)
+	}
+
+	// 彻底删除需要确认，删除容器、数据卷、卷备份、模组列表和授权
+	backup := fmt.Sprintf("%s/node-0/ase-server-%d-20260101-000000.tar.gz", config.OrphanBackupDir, response.ID)
+	if err := os.MkdirAll(config.OrphanBackupDir+"/node-0", 0700); err != nil {
+		t.Fatalf("创建备份目录失败: %v", err)
+	}
+	if err := os.WriteFile(backup, []byte("backup"), 0600); err != nil {
+		t.Fatalf("写入备份失败: %v", err)
+	}
+	if err := service.DeleteServer(ownerID, serverID); err != nil {
+		t.Fatalf("删除服务器失败: %v", err)
+	}
+	if err := service.PurgeServer(serverID, "wrong"); err == nil || err.Error() != "确认信息与服务器标识不一致" {
+		t.Fatalf("确认信息错误时应返回错误: %v", err)
+	}
+	if err := service.PurgeServer(serverID, "island"); err != nil {
+		t.Fatalf("彻底删除服务器失败: %v", err)
+	}
+	assertNoServer(t, fake)
+	var mods int64
+	database.DB.Model(&models.ServerMod{}).Count(&mods)
+	if mods != 0 {
+		t.Fatalf("应删除模组列表，剩余 %d 条", mods)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatalf("应删除卷备份: %v", err)
+	}
+}
+
+func TestPurgeExpiredServers(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	var ids []string
+	for i, identifier := range []string{"old", "recent"} {
+		response, err := service.CreateServer(ownerID, serverRequest(identifier, 7777+i*100))
+		if err != nil {
+			t.Fatalf("创建服务器失败: %v", err)
+		}
+		ids = append(ids, fmt.Sprint(response.ID))
+		if err := service.DeleteServer(ownerID, ids[i]); err != nil {
+			t.Fatalf("删除服务器失败: %v", err)
+		}
+	}
+	database.DB.Unscoped().Model(&models.Server{}).Where("id = ?", ids[0]).Update("deleted_at", time.Now().Add(-config.TrashRetention-time.Hour))
+
+	if purged := service.PurgeExpiredServers(time.Now()); purged != 1 {
+		t.Fatalf("应彻底删除 1 个服务器，实际 %d 个", purged)
+	}
+	trash, _ := service.GetDeletedServers()
+	if len(trash) != 1 || trash[0].Identifier != "recent" {
+		t.Fatalf("回收站应只剩未过期的服务器: %+v", trash)
+	}
+	if names := fake.VolumeNames(); strings.Join(names, ",") != "ase-server-2,ase-server-plugins-2" {
+		t.Fatalf("应只删除过期服务器的卷: %v", names)
+	}
+
+	// 保留期为0时不自动删除
+	retention := config.TrashRetention
+	config.TrashRetention = 0
+	t.Cleanup(func() { config.TrashRetention = retention })
+	if purged := service.PurgeExpiredServers(time.Now().Add(365 * 24 * time.Hour)); purged != 0 {
+		t.Fatalf("保留期为0时不应自动删除: %d", purged)
+	}
+}
+
+func TestRestartServerWithWarningAborts(t *testing.T) {
+	fake, ownerID := setupTest(t, testServerImage, testHelperImage)
+	service := NewServerService()
+
+	response, err := service.CreateServer(ownerID, serverRequest("island", 7777))
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	dockerManager, _ := docker_manager.GetNodeManager(0)
+	containerName := utils.GetServerContainerName(response.ID)
+	if err := service.startServerAsync(loadServer(t, response.ID), dockerManager, containerName); err != nil {
+		t.Fatalf("启动服务器失败: %v", err)
+	}
+	running := loadServer(t, response.ID)
+	containerID, _, _ := inspect(t, fake, containerName)
+	// 广播连接本机未监听的端口，立即失败
+	config.RCONHost = "127.0.0.1"
+	t.Cleanup(func() { config.RCONHost = "" })
+
+	// 倒计时中被取消
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := service.RestartServerWithWarning(ctx, running, time.Hour, "test"); err == nil || err.Error() != "重启已取消" {
+		t.Fatalf("取消后应中止重启: %v", err)
+	}
+
+	// 倒计时期间服务器被停止
+	database.DB.Model(&models.Server{}).Where("id = ?", running.ID).Update("status", "stopped")
+	if err := service.RestartServerWithWarning(context.Background(), running, 0, "test"); err == nil || err.Error() != "服务器已不在运行，取消重启" {
+		t.Fatalf("服务器已停止时应取消重启: %v", err)
+	}
+	if id, _, _ := inspect(t, fake, containerName); id != containerID {
+		t.Fatal("取消重启时不应重建容器")
+	}
+
+	// 倒计时期间服务器被删除
+	if err := service.DeleteServer(ownerID, fmt.Sprint(response.ID)); err != nil {
+		t.Fatalf("删除服务器失败: %v", err)
+	}
+	if err := service.RestartServerWithWarning(context.Background(), running, 0, "test"); err == nil || err.Error() != "服务器不存在，取消重启" {
+		t.Fatalf("服务器已删除时应取消重启: %v", err)
+	}
+}
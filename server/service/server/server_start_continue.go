@@ -25,7 +25,7 @@ func (s *ServerService) startServerAsyncContinue(server models.Server, dockerMan
 			server.Port,
 			server.QueryPort,
 			server.RCONPort,
-			server.AdminPassword,
+			string(server.AdminPassword),
 			server.Map,
 			server.GameModIds,
 			server.AutoRestart,
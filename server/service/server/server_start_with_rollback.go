@@ -36,7 +36,7 @@ func (s *ServerService) startServerAsyncWithRollback(server models.Server, docke
 		zap.Uint("server_id", server.ID))
 
 	// 步骤1: 验证必要镜像是否存在
-	missingImages, validateErr := dockerManager.ValidateRequiredImages()
+	missingImages, validateErr := dockerManager.ValidateRequiredImages(server.ImageName())
 	if validateErr != nil {
 		err = fmt.Errorf("验证镜像失败: %w", validateErr)
 		return err
@@ -91,6 +91,30 @@ func (s *ServerService) startServerAsyncWithRollback(server models.Server, docke
 					utils.Info("Mod列表已变更，需要重建容器")
 				}
 			}
+
+			// 检查资源限制和重启策略
+			if !needRecreateContainer {
+				if changed, resErr := dockerManager.ContainerResourcesChanged(containerName, server); resErr != nil || changed {
+					needRecreateContainer = true
+					utils.Info("资源限制已变更，需要重建容器")
+				}
+			}
+
+			// 检查服务器使用的镜像
+			if !needRecreateContainer {
+				if changed, imageErr := dockerManager.ContainerImageChanged(containerName, server); imageErr != nil || changed {
+					needRecreateContainer = true
+					utils.Info("服务器镜像已变更，需要重建容器")
+				}
+			}
+
+			// 检查网络模式和绑定的IP
+			if !needRecreateContainer {
+				if changed, networkErr := dockerManager.ContainerNetworkChanged(containerName, server); networkErr != nil || changed {
+					needRecreateContainer = true
+					utils.Info("网络配置已变更，需要重建容器")
+				}
+			}
 		}
 
 		// 如果需要重建，删除现有容器
@@ -103,6 +127,7 @@ func (s *ServerService) startServerAsyncWithRollback(server models.Server, docke
 		}
 	}
 
-	// 继续下一部分...
-	return s.startServerAsyncContinue(server, dockerManager, containerName, containerExists, needRecreateContainer, rollback)
+	// 继续下一部分（错误需要赋值给 err，defer 才会执行回滚）
+	err = s.startServerAsyncContinue(server, dockerManager, containerName, containerExists, needRecreateContainer, rollback)
+	return err
 }
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/docker_manager"
+	"ark-server-commander/service/maintenance"
+	"ark-server-commander/service/node"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetDeletedServers 获取回收站中的服务器（按删除时间倒序）
+func (s *ServerService) GetDeletedServers() ([]models.DeletedServerResponse, error) {
+	var servers []models.Server
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&servers).Error; err != nil {
+		return nil, fmt.Errorf("获取回收站失败: %w", err)
+	}
+
+	responses := make([]models.DeletedServerResponse, 0, len(servers))
+	for _, server := range servers {
+		response := models.DeletedServerResponse{
+			ID:          server.ID,
+			Identifier:  server.Identifier,
+			SessionName: server.SessionName,
+			Map:         server.Map,
+			Port:        server.Port,
+			UserID:      server.UserID,
+			NodeID:      server.NodeID,
+			Image:       server.ImageName(),
+			CreatedAt:   server.CreatedAt.Format("2006-01-02 15:04:05"),
+			DeletedAt:   server.DeletedAt.Time.Format("2006-01-02 15:04:05"),
+		}
+		if config.TrashRetention > 0 {
+			response.PurgeAt = server.DeletedAt.Time.Add(config.TrashRetention).Format("2006-01-02 15:04:05")
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// RestoreServer 从回收站恢复服务器并重新创建容器（需要数据卷仍然存在）
+func (s *ServerService) RestoreServer(serverID string) error {
+	server, err := findDeletedServer(serverID)
+	if err != nil {
+		return err
+	}
+	if !server.DeletedAt.Valid {
+		return fmt.Errorf("服务器未被删除")
+	}
+
+	// 删除期间可能已创建了同名服务器或占用了端口
+	var count int64
+	if err := database.DB.Model(&models.Server{}).Where("identifier = ? AND user_id = ?", server.Identifier, server.UserID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查服务器标识失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("服务器标识已存在")
+	}
+	identifier, port, err := node.UsedPort(server.NodeID, server.Network.BindIP, server.HostPorts(), server.ID)
+	if err != nil {
+		return fmt.Errorf("检查端口占用失败: %w", err)
+	}
+	if identifier != "" {
+		return fmt.Errorf("端口 %d 已被服务器 %s 占用", port, identifier)
+	}
+
+	dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+	if err != nil {
+		return fmt.Errorf("获取Docker管理器失败: %w", err)
+	}
+	exists, err := dockerManager.VolumeExists(utils.GetServerVolumeName(server.ID))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("服务器数据卷已被删除，无法恢复")
+	}
+
+	// 先恢复记录再创建容器（创建容器时读取服务器配置），失败时还原删除时间和状态（保留期仍从原删除时间算起）
+	deletedAt, status := server.DeletedAt, server.Status
+	if err := database.DB.Unscoped().Model(&server).Updates(map[string]interface{}{"deleted_at": nil, "status": "stopped"}).Error; err != nil {
+		return fmt.Errorf("恢复服务器失败: %w", err)
+	}
+	_, err = dockerManager.CreateContainer(
+		server.ID,
+		server.Identifier,
+		server.Port,
+		server.QueryPort,
+		server.RCONPort,
+		string(server.AdminPassword),
+		server.Map,
+		server.GameModIds,
+		server.AutoRestart,
+	)
+	if err != nil {
+		rollback := map[string]interface{}{"deleted_at": deletedAt, "status": status}
+		if rollbackErr := database.DB.Unscoped().Model(&models.Server{}).Where("id = ?", server.ID).Updates(rollback).Error; rollbackErr != nil {
+			utils.Error("恢复失败后还原服务器删除状态失败", zap.Uint("server_id", server.ID), zap.Error(rollbackErr))
+		}
+		return fmt.Errorf("创建容器失败: %w", err)
+	}
+
+	utils.Info("服务器已从回收站恢复", zap.Uint("server_id", server.ID), zap.String("identifier", server.Identifier))
+	return nil
+}
+
+// PurgeServer 彻底删除服务器（回收站中的或未删除的），包括容器、数据卷、卷备份、模组列表和授权
+// confirm: 需要与服务器标识一致，防止误删
+// 审计日志保留，不随服务器删除
+func (s *ServerService) PurgeServer(serverID string, confirm string) error {
+	server, err := findDeletedServer(serverID)
+	if err != nil {
+		return err
+	}
+	if confirm != server.Identifier {
+		return fmt.Errorf("确认信息与服务器标识不一致")
+	}
+	if !server.DeletedAt.Valid && server.Status == "running" {
+		return fmt.Errorf("无法删除正在运行的服务器，请先停止服务器")
+	}
+	return purgeServer(server)
+}
+
+// PurgeExpiredServers 彻底删除回收站中超过保留期的服务器（保留期为0时不删除）
+// 返回: 删除的服务器数量
+func (s *ServerService) PurgeExpiredServers(now time.Time) int {
+	if config.TrashRetention <= 0 {
+		return 0
+	}
+
+	var servers []models.Server
+	if err := database.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", now.Add(-config.TrashRetention)).Order("id").Find(&servers).Error; err != nil {
+		utils.Error("获取回收站失败", zap.Error(err))
+		return 0
+	}
+
+	purged := 0
+	for _, server := range servers {
+		if err := purgeServer(server); err != nil {
+			utils.Warn("自动彻底删除服务器失败", zap.Uint("server_id", server.ID), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// findDeletedServer 按ID查找服务器（包括回收站中的服务器）
+func findDeletedServer(serverID string) (models.Server, error) {
+	var server models.Server
+	id, err := strconv.ParseUint(serverID, 10, 32)
+	if err != nil {
+		return server, fmt.Errorf("无效的服务器ID")
+	}
+	if err := database.DB.Unscoped().Where("id = ?", id).First(&server).Error; err != nil {
+		return server, fmt.Errorf("服务器不存在")
+	}
+	return server, nil
+}
+
+// purgeServer 删除服务器的Docker资源和卷备份，再删除数据库记录
+// Docker资源删除失败时保留记录，以便重试；服务器所在节点已删除时跳过Docker资源
+func purgeServer(server models.Server) error {
+	if nodeExists(server.NodeID) {
+		dockerManager, err := docker_manager.GetNodeManager(server.NodeID)
+		if err != nil {
+			return fmt.Errorf("获取Docker管理器失败: %w", err)
+		}
+
+		containerName := utils.GetServerContainerName(server.ID)
+		exists, err := dockerManager.ContainerExists(containerName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := dockerManager.RemoveContainer(containerName); err != nil {
+				return err
+			}
+		}
+		dockerManager.RemoveHelper(server.ID)
+		if err := dockerManager.RemoveVolume(utils.GetServerVolumeName(server.ID)); err != nil {
+			return err
+		}
+		removeVolumeBackups(dockerManager.NodeID(), server.ID)
+	} else {
+		utils.Warn("服务器所在节点已删除，跳过Docker资源", zap.Uint("server_id", server.ID), zap.Uint("node_id", server.NodeID))
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("server_id = ?", server.ID).Delete(&models.ServerMod{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("server_id = ?", server.ID).Delete(&models.ServerPermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&server).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除服务器记录失败: %w", err)
+	}
+
+	utils.Info("服务器已彻底删除", zap.Uint("server_id", server.ID), zap.String("identifier", server.Identifier))
+	return nil
+}
+
+// nodeExists 服务器所在节点是否存在（0 表示面板所在主机）
+func nodeExists(nodeID uint) bool {
+	if nodeID == 0 {
+		return true
+	}
+	err := database.DB.Select("id").Where("id = ?", nodeID).First(&models.Node{}).Error
+	return !errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// removeVolumeBackups 删除清理遗留资源时为服务器的卷创建的备份
+func removeVolumeBackups(nodeID uint, serverID uint) {
+	for _, volumeName := range []string{utils.GetServerVolumeName(serverID), utils.GetServerPluginsVolumeName(serverID)} {
+		backups, err := maintenance.VolumeBackups(nodeID, volumeName)
+		if err != nil {
+			continue
+		}
+		for _, backup := range backups {
+			if err := os.Remove(backup); err != nil {
+				utils.Warn("删除卷备份失败", zap.String("path", backup), zap.Error(err))
+			}
+		}
+	}
+}
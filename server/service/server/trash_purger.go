@@ -0,0 +1,69 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// trashPurgeInterval 检查回收站的间隔
+const trashPurgeInterval = time.Hour
+
+// TrashPurger 定时彻底删除回收站中超过保留期的服务器
+type TrashPurger struct {
+	service *ServerService
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewTrashPurger 创建回收站自动清理器
+func NewTrashPurger(service *ServerService) *TrashPurger {
+	return &TrashPurger{
+		service: service,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start 在后台立即清理一次并定时清理（保留期为0时不启动）
+func (p *TrashPurger) Start() {
+	if config.TrashRetention <= 0 {
+		utils.Info("回收站自动清理已关闭")
+		return
+	}
+
+	utils.Info("回收站自动清理已启动", zap.Duration("retention", config.TrashRetention))
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.purge()
+
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.purge()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台清理
+func (p *TrashPurger) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// purge 清理一次回收站
+func (p *TrashPurger) purge() {
+	if purged := p.service.PurgeExpiredServers(time.Now()); purged > 0 {
+		utils.Info("回收站自动清理完成", zap.Int("purged", purged))
+	}
+}
@@ -0,0 +1,258 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 刷新令牌格式
+const (
+	refreshTokenPrefix = "ascr_"
+	refreshTokenBytes  = 32
+)
+
+// lastSeenUpdateInterval 会话最后活动时间的更新间隔，避免每个请求都写数据库
+const lastSeenUpdateInterval = time.Minute
+
+// maxUserAgentLength 保存的设备信息最大长度
+const maxUserAgentLength = 255
+
+// SessionService 登录会话管理服务
+type SessionService struct{}
+
+// NewSessionService 创建登录会话管理服务实例
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// CreateSession 为用户创建登录会话
+// 返回: 访问令牌和刷新令牌
+func (s *SessionService) CreateSession(user *models.User, userAgent, clientIP string) (*models.TokenPair, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		UserAgent:  truncate(userAgent, maxUserAgentLength),
+		IP:         clientIP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(config.RefreshTokenTTL),
+	}
+
+	var pair *models.TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return fmt.Errorf("创建会话失败: %w", err)
+		}
+
+		var err error
+		pair, err = issueTokens(tx, user, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌（刷新令牌轮换）
+// 已使用过的刷新令牌再次出现时视为泄露，整个会话（令牌族）会被撤销
+func (s *SessionService) Refresh(rawRefreshToken, userAgent, clientIP string) (*models.TokenPair, error) {
+	var refreshToken models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.SHA256Hex(rawRefreshToken)).First(&refreshToken).Error; err != nil {
+		return nil, fmt.Errorf("无效的刷新令牌")
+	}
+
+	var session models.Session
+	if err := database.DB.First(&session, refreshToken.SessionID).Error; err != nil {
+		return nil, fmt.Errorf("无效的刷新令牌")
+	}
+	if refreshToken.UsedAt != nil {
+		s.revokeReused(&session)
+		return nil, fmt.Errorf("刷新令牌已被使用，会话已撤销")
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) || now.After(refreshToken.ExpiresAt) {
+		return nil, fmt.Errorf("会话已失效，请重新登录")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, session.UserID).Error; err != nil || user.Disabled {
+		return nil, fmt.Errorf("会话已失效，请重新登录")
+	}
+
+	var pair *models.TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证同一刷新令牌只能被使用一次（并发请求中只有一个成功）
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", refreshToken.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("刷新令牌失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errTokenReused
+		}
+
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip":           clientIP,
+			"user_agent":   truncate(userAgent, maxUserAgentLength),
+		}).Error; err != nil {
+			return fmt.Errorf("更新会话失败: %w", err)
+		}
+
+		var err error
+		pair, err = issueTokens(tx, &user, &session)
+		return err
+	})
+	if err == errTokenReused {
+		s.revokeReused(&session)
+		return nil, fmt.Errorf("刷新令牌已被使用，会话已撤销")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// errTokenReused 刷新令牌在并发请求中已被使用
+var errTokenReused = fmt.Errorf("refresh token reused")
+
+// ValidateSession 检查访问令牌所属的会话是否有效，并记录最后活动时间
+func (s *SessionService) ValidateSession(sessionID, userID uint, clientIP string) error {
+	var session models.Session
+	if err := database.DB.First(&session, sessionID).Error; err != nil {
+		return fmt.Errorf("会话不存在")
+	}
+
+	now := time.Now()
+	if session.UserID != userID || session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return fmt.Errorf("会话已失效，请重新登录")
+	}
+
+	if now.Sub(session.LastSeenAt) >= lastSeenUpdateInterval || session.IP != clientIP {
+		if err := database.DB.Model(&session).Updates(map[string]interface{}{
+			"last_seen_at": now,
+			"ip":           clientIP,
+		}).Error; err != nil {
+			utils.Warn("更新会话活动时间失败", zap.Uint("session_id", sessionID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// ListSessions 获取用户的有效会话
+// currentSessionID: 当前请求所在的会话ID
+func (s *SessionService) ListSessions(userID, currentSessionID uint) ([]models.SessionResponse, error) {
+	var sessions []models.Session
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("获取会话列表失败: %w", err)
+	}
+
+	responses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return responses, nil
+}
+
+// RevokeSession 撤销用户的指定会话
+func (s *SessionService) RevokeSession(userID uint, sessionID string, reason string) error {
+	id, err := strconv.ParseUint(sessionID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的会话ID")
+	}
+
+	result := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason})
+	if result.Error != nil {
+		return fmt.Errorf("撤销会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("会话不存在")
+	}
+	return nil
+}
+
+// RevokeUserSessions 撤销用户的所有会话（修改密码、禁用用户时调用）
+func (s *SessionService) RevokeUserSessions(userID uint, reason string) error {
+	result := database.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason})
+	if result.Error != nil {
+		return fmt.Errorf("撤销会话失败: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		utils.Info("已撤销用户的所有会话", zap.Uint("user_id", userID), zap.String("reason", reason), zap.Int64("count", result.RowsAffected))
+	}
+	return nil
+}
+
+// revokeReused 刷新令牌被重复使用时撤销整个会话
+func (s *SessionService) revokeReused(session *models.Session) {
+	utils.Warn("检测到刷新令牌被重复使用，撤销会话", zap.Uint("session_id", session.ID), zap.Uint("user_id", session.UserID))
+	if err := database.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", session.ID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": models.SessionRevokeTokenReuse}).Error; err != nil {
+		utils.Error("撤销会话失败", zap.Uint("session_id", session.ID), zap.Error(err))
+	}
+}
+
+// issueTokens 为会话签发新的刷新令牌和访问令牌
+func issueTokens(tx *gorm.DB, user *models.User, session *models.Session) (*models.TokenPair, error) {
+	rawRefreshToken, err := utils.RandomToken(refreshTokenPrefix, refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: utils.SHA256Hex(rawRefreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return nil, fmt.Errorf("保存刷新令牌失败: %w", err)
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("令牌生成失败")
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    int64(config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// truncate 截断过长的字符串
+func truncate(value string, maxLength int) string {
+	if len(value) > maxLength {
+		return strings.ToValidUTF8(value[:maxLength], "")
+	}
+	return value
+}
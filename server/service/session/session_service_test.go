@@ -0,0 +1,133 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+	config.JWTSecret = []byte("test-secret-for-session-service-tests")
+}
+
+// setupTestDB 创建独立的内存数据库和测试用户
+func setupTestDB(t *testing.T) *models.User {
+	t.Helper()
+
+	db := dbtest.Open(t)
+
+	user := &models.User{Username: "alice", Password: "x", Role: models.RoleOperator}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	return user
+}
+
+func TestRefreshRotation(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewSessionService()
+
+	pair, err := service.CreateSession(user, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	claims, err := utils.ParseToken(pair.AccessToken)
+	if err != nil || claims.SessionID == 0 {
+		t.Fatalf("访问令牌应包含会话ID: %+v, %v", claims, err)
+	}
+	if err := service.ValidateSession(claims.SessionID, user.ID, "127.0.0.1"); err != nil {
+		t.Fatalf("新会话应有效: %v", err)
+	}
+
+	rotated, err := service.Refresh(pair.RefreshToken, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+	if rotated.RefreshToken == pair.RefreshToken {
+		t.Fatal("刷新后应返回新的刷新令牌")
+	}
+	if _, err := service.Refresh(rotated.RefreshToken, "test-agent", "127.0.0.1"); err != nil {
+		t.Fatalf("新的刷新令牌应可用: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewSessionService()
+
+	pair, err := service.CreateSession(user, "", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	rotated, err := service.Refresh(pair.RefreshToken, "", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+
+	// 旧刷新令牌被重放，整个令牌族失效
+	if _, err := service.Refresh(pair.RefreshToken, "", "10.0.0.1"); err == nil || err.Error() != "刷新令牌已被使用，会话已撤销" {
+		t.Fatalf("重复使用刷新令牌应被拒绝，实际为 %v", err)
+	}
+	if _, err := service.Refresh(rotated.RefreshToken, "", "127.0.0.1"); err == nil {
+		t.Fatal("会话撤销后最新的刷新令牌也应失效")
+	}
+
+	claims, _ := utils.ParseToken(rotated.AccessToken)
+	if err := service.ValidateSession(claims.SessionID, user.ID, "127.0.0.1"); err == nil {
+		t.Fatal("会话撤销后访问令牌应失效")
+	}
+
+	var session models.Session
+	database.DB.First(&session, claims.SessionID)
+	if session.RevokeReason != models.SessionRevokeTokenReuse {
+		t.Fatalf("撤销原因应为 %s，实际为 %s", models.SessionRevokeTokenReuse, session.RevokeReason)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewSessionService()
+
+	first, _ := service.CreateSession(user, "laptop", "127.0.0.1")
+	second, _ := service.CreateSession(user, "phone", "127.0.0.2")
+	firstClaims, _ := utils.ParseToken(first.AccessToken)
+	secondClaims, _ := utils.ParseToken(second.AccessToken)
+
+	sessions, err := service.ListSessions(user.ID, firstClaims.SessionID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("应有两个会话: %v, %v", sessions, err)
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == firstClaims.SessionID) {
+			t.Fatalf("当前会话标记错误: %+v", session)
+		}
+	}
+
+	// 其他用户不能撤销该会话
+	if err := service.RevokeSession(user.ID+1, fmt.Sprint(secondClaims.SessionID), models.SessionRevokeManual); err == nil || err.Error() != "会话不存在" {
+		t.Fatalf("撤销他人会话应失败，实际为 %v", err)
+	}
+	if err := service.RevokeSession(user.ID, fmt.Sprint(secondClaims.SessionID), models.SessionRevokeManual); err != nil {
+		t.Fatalf("撤销会话失败: %v", err)
+	}
+	if err := service.ValidateSession(secondClaims.SessionID, user.ID, "127.0.0.2"); err == nil {
+		t.Fatal("已撤销会话的访问令牌应失效")
+	}
+	if _, err := service.Refresh(second.RefreshToken, "phone", "127.0.0.2"); err == nil {
+		t.Fatal("已撤销会话的刷新令牌应失效")
+	}
+
+	if err := service.RevokeUserSessions(user.ID, models.SessionRevokePasswordChange); err != nil {
+		t.Fatalf("撤销全部会话失败: %v", err)
+	}
+	if sessions, _ := service.ListSessions(user.ID, 0); len(sessions) != 0 {
+		t.Fatalf("撤销全部会话后不应再有有效会话: %v", sessions)
+	}
+}
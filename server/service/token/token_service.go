@@ -0,0 +1,206 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+)
+
+// 令牌格式
+const (
+	TokenPrefix       = "asc_" // 令牌前缀，用于区分API令牌和JWT
+	tokenRandomBytes  = 32     // 令牌随机部分的字节数
+	displayPrefixSize = 12     // 保存用于展示的令牌前缀长度
+)
+
+// lastUsedUpdateInterval 最后使用时间的更新间隔，避免每个请求都写数据库
+const lastUsedUpdateInterval = time.Minute
+
+// TokenService API令牌管理服务
+type TokenService struct{}
+
+// NewTokenService 创建API令牌管理服务实例
+func NewTokenService() *TokenService {
+	return &TokenService{}
+}
+
+// IsAPIToken 判断授权令牌是否为API令牌
+func IsAPIToken(raw string) bool {
+	return strings.HasPrefix(raw, TokenPrefix)
+}
+
+// CreateToken 创建API令牌
+// 返回: 令牌信息（包含只返回一次的令牌明文）和错误信息
+func (s *TokenService) CreateToken(userID uint, req models.APITokenCreateRequest) (*models.APITokenResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("令牌名称不能为空")
+	}
+	scopes, err := NormalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := utils.RandomToken(TokenPrefix, tokenRandomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	apiToken := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:displayPrefixSize],
+		TokenHash: HashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.Create(&apiToken).Error; err != nil {
+		return nil, fmt.Errorf("创建令牌失败: %w", err)
+	}
+
+	utils.Info("API令牌已创建", zap.Uint("user_id", userID), zap.Uint("token_id", apiToken.ID), zap.Strings("scopes", scopes))
+	response := toTokenResponse(apiToken)
+	response.Token = raw
+	return &response, nil
+}
+
+// ListTokens 获取用户未撤销的API令牌
+func (s *TokenService) ListTokens(userID uint) ([]models.APITokenResponse, error) {
+	var tokens []models.APIToken
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("id").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("获取令牌列表失败: %w", err)
+	}
+
+	responses := make([]models.APITokenResponse, 0, len(tokens))
+	for _, apiToken := range tokens {
+		responses = append(responses, toTokenResponse(apiToken))
+	}
+	return responses, nil
+}
+
+// RevokeToken 撤销用户的API令牌
+func (s *TokenService) RevokeToken(userID uint, tokenID string) error {
+	id, err := strconv.ParseUint(tokenID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的令牌ID")
+	}
+
+	result := database.DB.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("撤销令牌失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("令牌不存在")
+	}
+
+	utils.Info("API令牌已撤销", zap.Uint("user_id", userID), zap.Uint64("token_id", id))
+	return nil
+}
+
+// Authenticate 验证API令牌并记录使用情况
+// clientIP: 请求来源IP
+// 返回: 令牌记录和错误信息
+func (s *TokenService) Authenticate(raw, clientIP string) (*models.APIToken, error) {
+	var apiToken models.APIToken
+	if err := database.DB.Where("token_hash = ? AND revoked_at IS NULL", HashToken(raw)).First(&apiToken).Error; err != nil {
+		return nil, fmt.Errorf("无效的授权令牌")
+	}
+
+	now := time.Now()
+	if apiToken.ExpiresAt != nil && now.After(*apiToken.ExpiresAt) {
+		return nil, fmt.Errorf("授权令牌已过期")
+	}
+
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= lastUsedUpdateInterval || apiToken.LastUsedIP != clientIP {
+		apiToken.LastUsedAt = &now
+		apiToken.LastUsedIP = clientIP
+		if err := database.DB.Model(&apiToken).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}).Error; err != nil {
+			utils.Warn("更新令牌使用记录失败", zap.Uint("token_id", apiToken.ID), zap.Error(err))
+		}
+	}
+
+	return &apiToken, nil
+}
+
+// HashToken 计算令牌的 SHA-256 哈希（十六进制）
+func HashToken(raw string) string {
+	return utils.SHA256Hex(raw)
+}
+
+// NormalizeScopes 校验作用域列表并去重排序
+func NormalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !isValidScope(scope) {
+			return nil, fmt.Errorf("无效的作用域: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("至少需要一个作用域")
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// ParseScopes 解析逗号分隔的作用域列表
+func ParseScopes(value string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// isValidScope 判断作用域是否有效
+func isValidScope(scope string) bool {
+	for _, valid := range models.TokenScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// toTokenResponse 构建令牌信息响应
+func toTokenResponse(apiToken models.APIToken) models.APITokenResponse {
+	response := models.APITokenResponse{
+		ID:         apiToken.ID,
+		Name:       apiToken.Name,
+		Prefix:     apiToken.Prefix,
+		Scopes:     ParseScopes(apiToken.Scopes),
+		LastUsedIP: apiToken.LastUsedIP,
+		CreatedAt:  apiToken.CreatedAt.Format(time.RFC3339),
+	}
+	if apiToken.LastUsedAt != nil {
+		response.LastUsedAt = apiToken.LastUsedAt.Format(time.RFC3339)
+	}
+	if apiToken.ExpiresAt != nil {
+		response.ExpiresAt = apiToken.ExpiresAt.Format(time.RFC3339)
+	}
+	return response
+}
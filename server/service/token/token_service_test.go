@@ -0,0 +1,102 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	dbtest.Open(t)
+	service := NewTokenService()
+
+	created, err := service.CreateToken(1, models.APITokenCreateRequest{
+		Name:   "restart script",
+		Scopes: []string{"lifecycle", "read", "lifecycle"},
+	})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+	if !IsAPIToken(created.Token) || !strings.HasPrefix(created.Token, created.Prefix) {
+		t.Fatalf("令牌格式错误: %+v", created)
+	}
+	if fmt.Sprint(created.Scopes) != "[lifecycle read]" {
+		t.Fatalf("作用域应去重排序，实际为 %v", created.Scopes)
+	}
+
+	// 数据库中只保存哈希
+	var stored models.APIToken
+	database.DB.First(&stored, created.ID)
+	if stored.TokenHash == created.Token || stored.TokenHash != HashToken(created.Token) {
+		t.Fatalf("令牌应以哈希形式保存")
+	}
+
+	// 列表中不包含明文
+	tokens, err := service.ListTokens(1)
+	if err != nil || len(tokens) != 1 || tokens[0].Token != "" {
+		t.Fatalf("令牌列表错误: %+v, %v", tokens, err)
+	}
+
+	authenticated, err := service.Authenticate(created.Token, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("验证令牌失败: %v", err)
+	}
+	if authenticated.UserID != 1 {
+		t.Fatalf("令牌所属用户错误: %d", authenticated.UserID)
+	}
+	database.DB.First(&stored, created.ID)
+	if stored.LastUsedAt == nil || stored.LastUsedIP != "10.0.0.1" {
+		t.Fatalf("未记录最后使用信息: %+v", stored)
+	}
+
+	if _, err := service.Authenticate(created.Token+"x", "10.0.0.1"); err == nil {
+		t.Fatalf("错误的令牌不应通过验证")
+	}
+
+	// 其他用户不能撤销
+	if err := service.RevokeToken(2, fmt.Sprint(created.ID)); err == nil || err.Error() != "令牌不存在" {
+		t.Fatalf("其他用户撤销应返回令牌不存在，实际为 %v", err)
+	}
+	if err := service.RevokeToken(1, fmt.Sprint(created.ID)); err != nil {
+		t.Fatalf("撤销令牌失败: %v", err)
+	}
+	if _, err := service.Authenticate(created.Token, "10.0.0.1"); err == nil {
+		t.Fatalf("撤销后的令牌不应通过验证")
+	}
+	if tokens, _ := service.ListTokens(1); len(tokens) != 0 {
+		t.Fatalf("撤销后的令牌不应出现在列表中")
+	}
+}
+
+func TestTokenExpiryAndValidation(t *testing.T) {
+	dbtest.Open(t)
+	service := NewTokenService()
+
+	if _, err := service.CreateToken(1, models.APITokenCreateRequest{Name: "bad", Scopes: []string{"admin"}}); err == nil {
+		t.Fatalf("无效的作用域应返回错误")
+	}
+
+	created, err := service.CreateToken(1, models.APITokenCreateRequest{Name: "short", Scopes: []string{"read"}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("创建令牌失败: %v", err)
+	}
+	if created.ExpiresAt == "" {
+		t.Fatalf("应设置过期时间")
+	}
+
+	database.DB.Model(&models.APIToken{}).Where("id = ?", created.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	if _, err := service.Authenticate(created.Token, "10.0.0.1"); err == nil || err.Error() != "授权令牌已过期" {
+		t.Fatalf("过期令牌应返回错误，实际为 %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 登录两步验证参数
+const (
+	challengeTokenPrefix = "ascc_"
+	challengeTTL         = 5 * time.Minute // 密码验证后完成两步验证的时限
+	maxChallengeAttempts = 5               // 每次登录最多尝试的验证码次数
+	totpSkew             = 1               // 允许前后各一个时间步的时钟偏差
+)
+
+// 恢复码参数
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10 // 不含分隔符的字符数（Base32，50位熵）
+)
+
+// recoveryEncoding 恢复码使用的小写 Base32 字母表（无易混淆的 0/1/l/o）
+var recoveryEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// TwoFactorService 两步验证（TOTP）服务
+type TwoFactorService struct {
+	now func() time.Time // 当前时间，测试中可替换为固定时钟
+}
+
+// NewTwoFactorService 创建两步验证服务实例
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{now: time.Now}
+}
+
+// Status 获取用户的两步验证状态
+func (s *TwoFactorService) Status(userID uint) (*models.TwoFactorStatusResponse, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining int64
+	database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+
+	return &models.TwoFactorStatusResponse{
+		Enabled:                user.TOTPEnabled,
+		Required:               user.TOTPRequired,
+		Pending:                user.TOTPPendingSecret != "",
+		RecoveryCodesRemaining: int(remaining),
+	}, nil
+}
+
+// BeginSetup 生成新的 TOTP 密钥，需要调用 ConfirmSetup 确认后才生效
+func (s *TwoFactorService) BeginSetup(userID uint, password string) (*models.TwoFactorSetupResponse, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证已启用")
+	}
+	if !utils.CheckPassword(password, user.Password) {
+		return nil, fmt.Errorf("密码错误")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(user).Update("totp_pending_secret", models.EncryptedString(secret)).Error; err != nil {
+		return nil, fmt.Errorf("保存两步验证密钥失败: %w", err)
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.TOTPIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmSetup 使用验证器生成的验证码确认绑定并启用两步验证
+// 返回: 恢复码明文（只返回这一次）
+func (s *TwoFactorService) ConfirmSetup(userID uint, code string) ([]string, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证已启用")
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, fmt.Errorf("请先生成两步验证密钥")
+	}
+
+	step, ok := utils.ValidateTOTP(string(user.TOTPPendingSecret), code, s.now(), totpSkew)
+	if !ok {
+		return nil, fmt.Errorf("验证码错误")
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":         user.TOTPPendingSecret,
+			"totp_pending_secret": "",
+			"totp_enabled":        true,
+			"totp_last_step":      step,
+		}).Error; err != nil {
+			return fmt.Errorf("启用两步验证失败: %w", err)
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Info("用户已启用两步验证", zap.Uint("user_id", user.ID))
+	return codes, nil
+}
+
+// Disable 关闭两步验证（需要密码和当前验证码）
+func (s *TwoFactorService) Disable(userID uint, password, code string) error {
+	user, err := getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("两步验证未启用")
+	}
+	if user.TOTPRequired {
+		return fmt.Errorf("管理员要求启用两步验证，不能关闭")
+	}
+	if !utils.CheckPassword(password, user.Password) {
+		return fmt.Errorf("密码错误")
+	}
+	if !s.verifyTOTP(user, code) {
+		return fmt.Errorf("验证码错误")
+	}
+
+	if err := ClearTwoFactor(user.ID); err != nil {
+		return err
+	}
+
+	utils.Info("用户已关闭两步验证", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部失效
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证未启用")
+	}
+	if !s.verifyTOTP(user, code) {
+		return nil, fmt.Errorf("验证码错误")
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Info("用户已重新生成恢复码", zap.Uint("user_id", user.ID))
+	return codes, nil
+}
+
+// CreateChallenge 密码验证通过后创建登录挑战，客户端需要提交验证码完成登录
+func (s *TwoFactorService) CreateChallenge(userID uint) (*models.TwoFactorChallengeResponse, error) {
+	rawToken, err := utils.RandomToken(challengeTokenPrefix, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	// 顺便清理过期的登录挑战
+	database.DB.Where("expires_at < ?", now).Delete(&models.LoginChallenge{})
+
+	challenge := models.LoginChallenge{
+		UserID:    userID,
+		TokenHash: utils.SHA256Hex(rawToken),
+		ExpiresAt: now.Add(challengeTTL),
+	}
+	if err := database.DB.Create(&challenge).Error; err != nil {
+		return nil, fmt.Errorf("创建登录验证失败: %w", err)
+	}
+
+	return &models.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    rawToken,
+		ExpiresIn:         int64(challengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyChallenge 校验登录挑战的验证码或恢复码
+// 返回: 完成验证的用户
+func (s *TwoFactorService) VerifyChallenge(rawToken, code, recoveryCode string) (*models.User, error) {
+	challenge, user, err := s.loadChallenge(rawToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("账号已被禁用")
+	}
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("登录验证已失效，请重新登录")
+	}
+
+	var verified bool
+	switch {
+	case strings.TrimSpace(code) != "":
+		verified = s.verifyTOTP(user, code)
+	case strings.TrimSpace(recoveryCode) != "":
+		verified = s.useRecoveryCode(user.ID, recoveryCode)
+		if verified {
+			utils.Warn("用户使用恢复码登录", zap.Uint("user_id", user.ID))
+		}
+	default:
+		return nil, fmt.Errorf("请输入验证码或恢复码")
+	}
+
+	if !verified {
+		database.DB.Model(&challenge).UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		return nil, fmt.Errorf("验证码错误")
+	}
+
+	// 条件更新保证同一登录挑战只能完成一次
+	result := database.DB.Model(&models.LoginChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", s.now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, fmt.Errorf("登录验证已失效，请重新登录")
+	}
+
+	return user, nil
+}
+
+// ChallengeUser 获取有效登录挑战所属的用户（用于在校验验证码前检查登录限制）
+func (s *TwoFactorService) ChallengeUser(rawToken string) (*models.User, error) {
+	_, user, err := s.loadChallenge(rawToken)
+	return user, err
+}
+
+// loadChallenge 加载未使用、未过期且未超过尝试次数的登录挑战
+func (s *TwoFactorService) loadChallenge(rawToken string) (*models.LoginChallenge, *models.User, error) {
+	var challenge models.LoginChallenge
+	if err := database.DB.Where("token_hash = ?", utils.SHA256Hex(rawToken)).First(&challenge).Error; err != nil {
+		return nil, nil, fmt.Errorf("登录验证已失效，请重新登录")
+	}
+	if challenge.UsedAt != nil || s.now().After(challenge.ExpiresAt) || challenge.Attempts >= maxChallengeAttempts {
+		return nil, nil, fmt.Errorf("登录验证已失效，请重新登录")
+	}
+
+	user, err := getUser(challenge.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("登录验证已失效，请重新登录")
+	}
+	return &challenge, user, nil
+}
+
+// ClearTwoFactor 清除用户的两步验证配置和恢复码（关闭或管理员重置时使用）
+func ClearTwoFactor(userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_enabled":        false,
+			"totp_last_step":      0,
+		}).Error; err != nil {
+			return fmt.Errorf("关闭两步验证失败: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("删除恢复码失败: %w", err)
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.LoginChallenge{}).Error
+	})
+}
+
+// verifyTOTP 校验验证码，同一时间步的验证码只能使用一次
+func (s *TwoFactorService) verifyTOTP(user *models.User, code string) bool {
+	step, ok := utils.ValidateTOTP(string(user.TOTPSecret), code, s.now(), totpSkew)
+	if !ok || step <= user.TOTPLastStep {
+		return false
+	}
+
+	// 条件更新防止并发请求重复使用同一验证码
+	result := database.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	user.TOTPLastStep = step
+	return true
+}
+
+// useRecoveryCode 使用一个恢复码（使用后立即失效）
+func (s *TwoFactorService) useRecoveryCode(userID uint, code string) bool {
+	result := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", s.now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的一组
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("删除旧恢复码失败: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		record := models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+		if err := tx.Create(&record).Error; err != nil {
+			return nil, fmt.Errorf("保存恢复码失败: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode 生成 xxxxx-xxxxx 格式的恢复码
+func generateRecoveryCode() (string, error) {
+	buffer := make([]byte, recoveryCodeLength*5/8)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("生成恢复码失败: %v", err)
+	}
+	code := recoveryEncoding.EncodeToString(buffer)
+	return code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:], nil
+}
+
+// hashRecoveryCode 计算恢复码哈希（忽略大小写、空格和分隔符）
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(code)
+	normalized = strings.NewReplacer("-", "", " ", "").Replace(normalized)
+	return utils.SHA256Hex(normalized)
+}
+
+// getUser 根据ID查找用户
+func getUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return &user, nil
+}
@@ -0,0 +1,221 @@
+package twofactor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ark-server-commander/config"
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+	config.EncryptionKeys = []config.EncryptionKey{{ID: "test", Key: make([]byte, 32)}}
+}
+
+// setupTestDB 创建独立的内存数据库和测试用户
+func setupTestDB(t *testing.T) *models.User {
+	t.Helper()
+
+	db := dbtest.Open(t)
+
+	hashed, err := utils.HashPassword("password")
+	if err != nil {
+		t.Fatalf("密码加密失败: %v", err)
+	}
+	user := &models.User{Username: "alice", Password: hashed, Role: models.RoleOperator}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	return user
+}
+
+// fixedClock 返回可手动推进的固定时钟
+func fixedClock(start time.Time) (func() time.Time, func(time.Duration)) {
+	current := start
+	return func() time.Time { return current }, func(d time.Duration) { current = current.Add(d) }
+}
+
+// enroll 为用户完成两步验证绑定，返回密钥和恢复码
+func enroll(t *testing.T, service *TwoFactorService, userID uint) (string, []string) {
+	t.Helper()
+
+	setup, err := service.BeginSetup(userID, "password")
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	code, _ := utils.TOTPCode(setup.Secret, service.now())
+	codes, err := service.ConfirmSetup(userID, code)
+	if err != nil {
+		t.Fatalf("确认绑定失败: %v", err)
+	}
+	return setup.Secret, codes
+}
+
+func TestEnrollment(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewTwoFactorService()
+	now, _ := fixedClock(time.Unix(1700000000, 0))
+	service.now = now
+
+	if _, err := service.BeginSetup(user.ID, "wrong"); err == nil || err.Error() != "密码错误" {
+		t.Fatalf("密码错误时不应生成密钥，实际为 %v", err)
+	}
+	setup, err := service.BeginSetup(user.ID, "password")
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	if setup.ProvisioningURI == "" || setup.Secret == "" {
+		t.Fatalf("绑定信息不完整: %+v", setup)
+	}
+
+	// 未确认前不启用
+	status, _ := service.Status(user.ID)
+	if status.Enabled || !status.Pending {
+		t.Fatalf("确认前状态错误: %+v", status)
+	}
+	if _, err := service.ConfirmSetup(user.ID, "000000"); err == nil || err.Error() != "验证码错误" {
+		t.Fatalf("错误的验证码应被拒绝，实际为 %v", err)
+	}
+
+	code, _ := utils.TOTPCode(setup.Secret, now())
+	codes, err := service.ConfirmSetup(user.ID, code)
+	if err != nil {
+		t.Fatalf("确认绑定失败: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("应生成 %d 个恢复码，实际为 %d", recoveryCodeCount, len(codes))
+	}
+	for _, code := range codes {
+		if strings.ContainsAny(code, "01lo") {
+			t.Fatalf("恢复码不应包含易混淆的字符: %s", code)
+		}
+	}
+
+	status, _ = service.Status(user.ID)
+	if !status.Enabled || status.Pending || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Fatalf("确认后状态错误: %+v", status)
+	}
+
+	// 密钥在数据库中加密存储
+	var stored string
+	database.DB.Table("users").Select("totp_secret").Where("id = ?", user.ID).Scan(&stored)
+	if !strings.HasPrefix(stored, "enc:v1:test:") || strings.Contains(stored, setup.Secret) {
+		t.Fatalf("两步验证密钥应加密存储，实际为 %q", stored)
+	}
+}
+
+func TestLoginChallenge(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewTwoFactorService()
+	now, advance := fixedClock(time.Unix(1700000000, 0))
+	service.now = now
+	secret, _ := enroll(t, service, user.ID)
+
+	// 绑定时使用的验证码不能再用于登录
+	challenge, err := service.CreateChallenge(user.ID)
+	if err != nil {
+		t.Fatalf("创建登录挑战失败: %v", err)
+	}
+	code, _ := utils.TOTPCode(secret, now())
+	if _, err := service.VerifyChallenge(challenge.ChallengeToken, code, ""); err == nil {
+		t.Fatal("同一时间步的验证码不能重复使用")
+	}
+
+	advance(utils.TOTPPeriod * time.Second)
+	code, _ = utils.TOTPCode(secret, now())
+	verified, err := service.VerifyChallenge(challenge.ChallengeToken, code, "")
+	if err != nil || verified.ID != user.ID {
+		t.Fatalf("验证码应有效: %v", err)
+	}
+
+	// 登录挑战只能完成一次
+	advance(utils.TOTPPeriod * time.Second)
+	code, _ = utils.TOTPCode(secret, now())
+	if _, err := service.VerifyChallenge(challenge.ChallengeToken, code, ""); err == nil || err.Error() != "登录验证已失效，请重新登录" {
+		t.Fatalf("已完成的登录挑战不能再次使用，实际为 %v", err)
+	}
+
+	// 过期的登录挑战失效
+	expired, _ := service.CreateChallenge(user.ID)
+	advance(challengeTTL + time.Second)
+	code, _ = utils.TOTPCode(secret, now())
+	if _, err := service.VerifyChallenge(expired.ChallengeToken, code, ""); err == nil || err.Error() != "登录验证已失效，请重新登录" {
+		t.Fatalf("过期的登录挑战应失效，实际为 %v", err)
+	}
+}
+
+func TestChallengeAttemptLimit(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewTwoFactorService()
+	now, advance := fixedClock(time.Unix(1700000000, 0))
+	service.now = now
+	secret, _ := enroll(t, service, user.ID)
+
+	challenge, _ := service.CreateChallenge(user.ID)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		if _, err := service.VerifyChallenge(challenge.ChallengeToken, "000000", ""); err == nil || err.Error() != "验证码错误" {
+			t.Fatalf("第 %d 次错误验证码应被拒绝，实际为 %v", i+1, err)
+		}
+	}
+
+	advance(utils.TOTPPeriod * time.Second)
+	code, _ := utils.TOTPCode(secret, now())
+	if _, err := service.VerifyChallenge(challenge.ChallengeToken, code, ""); err == nil || err.Error() != "登录验证已失效，请重新登录" {
+		t.Fatalf("超过尝试次数后登录挑战应失效，实际为 %v", err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewTwoFactorService()
+	now, _ := fixedClock(time.Unix(1700000000, 0))
+	service.now = now
+	_, codes := enroll(t, service, user.ID)
+
+	// 恢复码忽略大小写和分隔符，且只能使用一次
+	challenge, _ := service.CreateChallenge(user.ID)
+	if _, err := service.VerifyChallenge(challenge.ChallengeToken, "", " "+codes[0][:5]+codes[0][6:]+" "); err != nil {
+		t.Fatalf("恢复码应有效: %v", err)
+	}
+	challenge, _ = service.CreateChallenge(user.ID)
+	if _, err := service.VerifyChallenge(challenge.ChallengeToken, "", codes[0]); err == nil {
+		t.Fatal("恢复码只能使用一次")
+	}
+
+	status, _ := service.Status(user.ID)
+	if status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Fatalf("剩余恢复码数量错误: %d", status.RecoveryCodesRemaining)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	user := setupTestDB(t)
+	service := NewTwoFactorService()
+	now, advance := fixedClock(time.Unix(1700000000, 0))
+	service.now = now
+	secret, _ := enroll(t, service, user.ID)
+	advance(utils.TOTPPeriod * time.Second)
+	code, _ := utils.TOTPCode(secret, now())
+
+	// 管理员强制启用时不能关闭
+	database.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_required", true)
+	if err := service.Disable(user.ID, "password", code); err == nil || err.Error() != "管理员要求启用两步验证，不能关闭" {
+		t.Fatalf("强制启用时不应允许关闭，实际为 %v", err)
+	}
+
+	database.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_required", false)
+	if err := service.Disable(user.ID, "password", code); err != nil {
+		t.Fatalf("关闭两步验证失败: %v", err)
+	}
+
+	status, _ := service.Status(user.ID)
+	if status.Enabled || status.RecoveryCodesRemaining != 0 {
+		t.Fatalf("关闭后状态错误: %+v", status)
+	}
+}
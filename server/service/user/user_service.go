@@ -0,0 +1,361 @@
+package user
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark-server-commander/database"
+	"ark-server-commander/models"
+	"ark-server-commander/service/loginguard"
+	"ark-server-commander/service/session"
+	"ark-server-commander/service/twofactor"
+	"ark-server-commander/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	loginGuard     = loginguard.NewLoginGuardService()
+	sessionService = session.NewSessionService()
+)
+
+// UserService 用户管理服务
+type UserService struct{}
+
+// NewUserService 创建用户管理服务实例
+func NewUserService() *UserService {
+	return &UserService{}
+}
+
+// ListUsers 获取所有用户
+func (s *UserService) ListUsers() ([]models.UserResponse, error) {
+	var users []models.User
+	if err := database.DB.Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, ToUserResponse(user))
+	}
+	return responses, nil
+}
+
+// CreateUser 创建用户
+// actorID: 执行操作的用户ID
+func (s *UserService) CreateUser(actorID uint, req models.UserCreateRequest) (*models.UserResponse, error) {
+	actor, err := getUser(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.IsValidRole(req.Role) {
+		return nil, fmt.Errorf("无效的角色: %s", req.Role)
+	}
+	if !canAssignRole(actor, req.Role) {
+		return nil, fmt.Errorf("权限不足")
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("用户名不能为空")
+	}
+
+	var count int64
+	database.DB.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("用户名已存在")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("密码加密失败")
+	}
+
+	user := models.User{
+		Username: username,
+		Password: hashedPassword,
+		Role:     req.Role,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户创建失败: %w", err)
+	}
+
+	utils.Info("用户已创建", zap.Uint("actor_id", actorID), zap.String("username", user.Username), zap.String("role", user.Role))
+	response := ToUserResponse(user)
+	return &response, nil
+}
+
+// SetUserDisabled 禁用或启用用户
+func (s *UserService) SetUserDisabled(actorID uint, targetID string, disabled bool) (*models.UserResponse, error) {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID == target.ID {
+		return nil, fmt.Errorf("不能禁用或启用自己")
+	}
+	if !canManage(actor, target) {
+		return nil, fmt.Errorf("权限不足")
+	}
+	if disabled && target.Role == models.RoleOwner {
+		if err := ensureAnotherOwner(target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	target.Disabled = disabled
+	if err := database.DB.Model(target).Update("disabled", disabled).Error; err != nil {
+		return nil, fmt.Errorf("更新用户状态失败: %w", err)
+	}
+
+	// 禁用用户时撤销其所有会话
+	if disabled {
+		if err := sessionService.RevokeUserSessions(target.ID, models.SessionRevokeUserDisabled); err != nil {
+			utils.Warn("撤销用户会话失败", zap.Uint("user_id", target.ID), zap.Error(err))
+		}
+	}
+
+	utils.Info("用户状态已更新", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID), zap.Bool("disabled", disabled))
+	response := ToUserResponse(*target)
+	return &response, nil
+}
+
+// UpdateUserRole 修改用户角色
+func (s *UserService) UpdateUserRole(actorID uint, targetID, role string) (*models.UserResponse, error) {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID == target.ID {
+		return nil, fmt.Errorf("不能修改自己的角色")
+	}
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("无效的角色: %s", role)
+	}
+	if !canManage(actor, target) || !canAssignRole(actor, role) {
+		return nil, fmt.Errorf("权限不足")
+	}
+	if target.Role == models.RoleOwner && role != models.RoleOwner {
+		if err := ensureAnotherOwner(target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(target).Update("role", role).Error; err != nil {
+			return err
+		}
+		// 查看者的操作授权不会生效，降级时只保留只读访问
+		if role == models.RoleViewer {
+			return tx.Model(&models.ServerPermission{}).Where("user_id = ?", target.ID).Update("permissions", "").Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新用户角色失败: %w", err)
+	}
+	target.Role = role
+
+	utils.Info("用户角色已更新", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID), zap.String("role", role))
+	response := ToUserResponse(*target)
+	return &response, nil
+}
+
+// ResetPassword 重置用户密码
+func (s *UserService) ResetPassword(actorID uint, targetID, password string) error {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if actor.ID != target.ID && !canManage(actor, target) {
+		return fmt.Errorf("权限不足")
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("密码加密失败")
+	}
+	if err := database.DB.Model(target).Update("password", hashedPassword).Error; err != nil {
+		return fmt.Errorf("重置密码失败: %w", err)
+	}
+
+	// 密码修改后所有会话失效
+	if err := sessionService.RevokeUserSessions(target.ID, models.SessionRevokePasswordChange); err != nil {
+		return err
+	}
+
+	utils.Info("用户密码已重置", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID))
+	return nil
+}
+
+// ChangePassword 用户修改自己的密码，验证旧密码后撤销所有会话
+// 返回: 更新后的用户和错误信息
+func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword string) (*models.User, error) {
+	user, err := getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.CheckPassword(oldPassword, user.Password) {
+		return nil, fmt.Errorf("旧密码错误")
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return nil, fmt.Errorf("密码加密失败")
+	}
+	if err := database.DB.Model(user).Update("password", hashedPassword).Error; err != nil {
+		return nil, fmt.Errorf("修改密码失败: %w", err)
+	}
+
+	if err := sessionService.RevokeUserSessions(user.ID, models.SessionRevokePasswordChange); err != nil {
+		return nil, err
+	}
+
+	utils.Info("用户已修改密码", zap.Uint("user_id", user.ID))
+	return user, nil
+}
+
+// SetTwoFactorRequired 设置是否强制用户启用两步验证
+// 被强制的用户在启用两步验证前只能访问个人账号相关接口
+func (s *UserService) SetTwoFactorRequired(actorID uint, targetID string, required bool) (*models.UserResponse, error) {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID != target.ID && !canManage(actor, target) {
+		return nil, fmt.Errorf("权限不足")
+	}
+
+	target.TOTPRequired = required
+	if err := database.DB.Model(target).Update("totp_required", required).Error; err != nil {
+		return nil, fmt.Errorf("更新两步验证要求失败: %w", err)
+	}
+
+	utils.Info("用户两步验证要求已更新", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID), zap.Bool("required", required))
+	response := ToUserResponse(*target)
+	return &response, nil
+}
+
+// ResetTwoFactor 重置用户的两步验证（用户丢失验证器时使用），并撤销其所有会话
+func (s *UserService) ResetTwoFactor(actorID uint, targetID string) (*models.UserResponse, error) {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID == target.ID {
+		return nil, fmt.Errorf("不能重置自己的两步验证")
+	}
+	if !canManage(actor, target) {
+		return nil, fmt.Errorf("权限不足")
+	}
+
+	if err := twofactor.ClearTwoFactor(target.ID); err != nil {
+		return nil, err
+	}
+	if err := sessionService.RevokeUserSessions(target.ID, models.SessionRevokeTwoFactorReset); err != nil {
+		return nil, err
+	}
+
+	target.TOTPEnabled = false
+	utils.Warn("用户两步验证已被重置", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID))
+	response := ToUserResponse(*target)
+	return &response, nil
+}
+
+// UnlockUser 解除用户因登录失败过多导致的锁定
+func (s *UserService) UnlockUser(actorID uint, targetID string) (*models.UserResponse, error) {
+	actor, target, err := loadActorAndTarget(actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage(actor, target) {
+		return nil, fmt.Errorf("权限不足")
+	}
+
+	if err := loginGuard.Unlock(target.Username); err != nil {
+		return nil, err
+	}
+
+	utils.Info("用户登录锁定已解除", zap.Uint("actor_id", actorID), zap.Uint("user_id", target.ID))
+	response := ToUserResponse(*target)
+	return &response, nil
+}
+
+// ToUserResponse 构建用户信息响应
+func ToUserResponse(user models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+
+		TwoFactorEnabled:  user.TOTPEnabled,
+		TwoFactorRequired: user.TOTPRequired,
+	}
+}
+
+// canManage 判断操作者是否可以管理目标用户
+// 所有者可以管理所有用户，管理员只能管理操作员和观察者
+func canManage(actor, target *models.User) bool {
+	switch actor.Role {
+	case models.RoleOwner:
+		return true
+	case models.RoleAdmin:
+		return target.Role == models.RoleOperator || target.Role == models.RoleViewer
+	}
+	return false
+}
+
+// canAssignRole 判断操作者是否可以授予指定角色
+func canAssignRole(actor *models.User, role string) bool {
+	switch actor.Role {
+	case models.RoleOwner:
+		return true
+	case models.RoleAdmin:
+		return role == models.RoleOperator || role == models.RoleViewer
+	}
+	return false
+}
+
+// ensureAnotherOwner 确保除指定用户外还有其他可用的所有者
+func ensureAnotherOwner(excludeID uint) error {
+	var count int64
+	database.DB.Model(&models.User{}).
+		Where("role = ? AND disabled = ? AND id != ?", models.RoleOwner, false, excludeID).
+		Count(&count)
+	if count == 0 {
+		return fmt.Errorf("至少需要保留一个可用的所有者")
+	}
+	return nil
+}
+
+// loadActorAndTarget 加载操作者和目标用户
+func loadActorAndTarget(actorID uint, targetID string) (*models.User, *models.User, error) {
+	actor, err := getUser(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := strconv.ParseUint(targetID, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的用户ID")
+	}
+	target, err := getUser(uint(id))
+	if err != nil {
+		return nil, nil, err
+	}
+	return actor, target, nil
+}
+
+// getUser 根据ID查找用户
+func getUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return &user, nil
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+
+	"ark-server-commander/database"
+	"ark-server-commander/database/dbtest"
+	"ark-server-commander/models"
+	"ark-server-commander/utils"
+)
+
+func init() {
+	// 初始化测试环境的 logger
+	utils.InitLogger()
+}
+
+// createUser 创建指定角色的测试用户
+func createUser(t *testing.T, username, role string) models.User {
+	t.Helper()
+
+	user := models.User{Username: username, Password: "x", Role: role}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	return user
+}
+
+func TestUserManagementRules(t *testing.T) {
+	dbtest.Open(t)
+	service := NewUserService()
+
+	owner := createUser(t, "owner", models.RoleOwner)
+	admin := createUser(t, "admin", models.RoleAdmin)
+
+	// 管理员只能创建操作员和观察者
+	if _, err := service.CreateUser(admin.ID, models.UserCreateRequest{Username: "a2", Password: "secret1", Role: models.RoleAdmin}); err == nil || err.Error() != "权限不足" {
+		t.Fatalf("管理员不应能创建管理员，实际为 %v", err)
+	}
+	moderator, err := service.CreateUser(admin.ID, models.UserCreateRequest{Username: "moderator", Password: "secret1", Role: models.RoleOperator})
+	if err != nil {
+		t.Fatalf("创建操作员失败: %v", err)
+	}
+	if _, err := service.CreateUser(owner.ID, models.UserCreateRequest{Username: "moderator", Password: "secret1", Role: models.RoleViewer}); err == nil || err.Error() != "用户名已存在" {
+		t.Fatalf("重复用户名应返回错误，实际为 %v", err)
+	}
+
+	// 管理员不能管理所有者
+	if _, err := service.SetUserDisabled(admin.ID, fmt.Sprint(owner.ID), true); err == nil || err.Error() != "权限不足" {
+		t.Fatalf("管理员不应能禁用所有者，实际为 %v", err)
+	}
+
+	// 不能禁用唯一的所有者，也不能禁用自己
+	if _, err := service.SetUserDisabled(owner.ID, fmt.Sprint(owner.ID), true); err == nil {
+		t.Fatalf("不应能禁用自己")
+	}
+	second := createUser(t, "second-owner", models.RoleOwner)
+	if _, err := service.UpdateUserRole(second.ID, fmt.Sprint(owner.ID), models.RoleAdmin); err != nil {
+		t.Fatalf("存在其他所有者时应能降级: %v", err)
+	}
+	if _, err := service.UpdateUserRole(owner.ID, fmt.Sprint(second.ID), models.RoleViewer); err == nil {
+		t.Fatalf("降级后的用户不应能修改所有者")
+	}
+
+	// 降级为查看者时清除操作授权，保留只读访问
+	database.DB.Create(&models.ServerPermission{ServerID: 1, UserID: moderator.ID, Permissions: "rcon,start_stop"})
+	if _, err := service.UpdateUserRole(admin.ID, fmt.Sprint(moderator.ID), models.RoleViewer); err != nil {
+		t.Fatalf("降级操作员失败: %v", err)
+	}
+	var grant models.ServerPermission
+	if err := database.DB.Where("user_id = ?", moderator.ID).First(&grant).Error; err != nil || grant.Permissions != "" {
+		t.Fatalf("降级为查看者后应只保留只读授权: %+v, %v", grant, err)
+	}
+
+	// 禁用与重置密码
+	disabled, err := service.SetUserDisabled(admin.ID, fmt.Sprint(moderator.ID), true)
+	if err != nil || !disabled.Disabled {
+		t.Fatalf("禁用操作员失败: %v", err)
+	}
+	if err := service.ResetPassword(admin.ID, fmt.Sprint(moderator.ID), "newpassword"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	var stored models.User
+	database.DB.First(&stored, moderator.ID)
+	if !utils.CheckPassword("newpassword", stored.Password) {
+		t.Fatalf("密码未更新")
+	}
+}
@@ -22,3 +22,10 @@ func GetServerVolumeName(serverID uint) string {
 func GetServerPluginsVolumeName(serverID uint) string {
 	return fmt.Sprintf("ase-server-plugins-%d", serverID)
 }
+
+// GetServerHelperContainerName 获取服务器卷辅助容器名称
+// serverID: 服务器ID
+// 返回: 辅助容器名称
+func GetServerHelperContainerName(serverID uint) string {
+	return fmt.Sprintf("ase-helper-%d", serverID)
+}
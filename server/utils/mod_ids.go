@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateWorkshopID 验证创意工坊模组ID（必须为纯数字）
+func ValidateWorkshopID(workshopID string) error {
+	if workshopID == "" {
+		return fmt.Errorf("模组ID不能为空")
+	}
+	if len(workshopID) > 20 {
+		return fmt.Errorf("无效的模组ID: %s", workshopID)
+	}
+	for _, char := range workshopID {
+		if char < '0' || char > '9' {
+			return fmt.Errorf("无效的模组ID: %s", workshopID)
+		}
+	}
+	return nil
+}
+
+// ParseGameModIds 解析逗号分隔的模组ID列表
+// 会去除空白、验证每个ID的格式并检测重复
+// 返回: 按原始顺序排列的模组ID列表和错误信息
+func ParseGameModIds(gameModIds string) ([]string, error) {
+	var modIDs []string
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(gameModIds, ",") {
+		workshopID := strings.TrimSpace(part)
+		if workshopID == "" {
+			continue
+		}
+		if err := ValidateWorkshopID(workshopID); err != nil {
+			return nil, err
+		}
+		if seen[workshopID] {
+			return nil, fmt.Errorf("模组ID重复: %s", workshopID)
+		}
+		seen[workshopID] = true
+		modIDs = append(modIDs, workshopID)
+	}
+
+	return modIDs, nil
+}
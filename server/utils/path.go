@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SanitizeRelativePath 清理用户提供的相对路径，禁止逃逸出根目录
+// 反斜杠会转换为斜杠，开头的斜杠视为根目录；包含 ".." 或空字节的路径会被拒绝
+// 返回: 清理后的相对路径（根目录本身返回空字符串）和错误信息
+func SanitizeRelativePath(p string) (string, error) {
+	if strings.ContainsRune(p, 0) {
+		return "", fmt.Errorf("路径包含非法字符")
+	}
+
+	p = strings.ReplaceAll(p, "\\", "/")
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("路径不能包含 ..")
+		}
+	}
+
+	cleaned := strings.TrimPrefix(path.Clean("/"+p), "/")
+	return cleaned, nil
+}
+
+// ValidateFileName 验证单个文件或目录名称（不能包含路径分隔符，不能是 . 或 ..）
+func ValidateFileName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("无效的名称: %s", name)
+	}
+	if strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("无效的名称: %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// RandomToken 生成带前缀的随机令牌（URL安全的Base64编码）
+// prefix: 令牌前缀
+// size: 随机部分的字节数
+func RandomToken(prefix string, size int) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("生成随机令牌失败: %v", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// SHA256Hex 计算字符串的 SHA-256 哈希（十六进制）
+func SHA256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"ark-server-commander/config"
+)
+
+// secretPrefix 加密值的前缀，格式: enc:v1:<密钥标识>:<Base64(nonce+密文)>
+const secretPrefix = "enc:v1:"
+
+// EncryptSecret 使用当前密钥（AES-256-GCM）加密敏感字段，空字符串不加密
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if len(config.EncryptionKeys) == 0 {
+		return "", fmt.Errorf("未配置加密密钥")
+	}
+
+	key := config.EncryptionKeys[0]
+	aead, err := newSecretCipher(key.Key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+
+	// 将前缀和密钥标识作为附加数据，防止密文被改到其他密钥下
+	header := secretPrefix + key.ID + ":"
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(header))
+	return header + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密敏感字段；没有加密前缀的值视为升级前的明文，原样返回
+func DecryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, secretPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("无效的加密数据")
+	}
+	var keyBytes []byte
+	for _, key := range config.EncryptionKeys {
+		if key.ID == keyID {
+			keyBytes = key.Key
+			break
+		}
+	}
+	if keyBytes == nil {
+		return "", fmt.Errorf("未找到加密密钥: %s", keyID)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("无效的加密数据")
+	}
+	aead, err := newSecretCipher(keyBytes)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("无效的加密数据")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(secretPrefix+keyID+":"))
+	if err != nil {
+		return "", fmt.Errorf("解密失败，密钥 %s 不正确或数据已损坏", keyID)
+	}
+	return string(plaintext), nil
+}
+
+// SecretNeedsRotation 判断存储的值是否需要用当前密钥重新加密（明文或使用了旧密钥）
+func SecretNeedsRotation(value string) bool {
+	if value == "" || len(config.EncryptionKeys) == 0 {
+		return false
+	}
+	return !strings.HasPrefix(value, secretPrefix+config.EncryptionKeys[0].ID+":")
+}
+
+// newSecretCipher 创建 AES-GCM 加密器
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("无效的加密密钥: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"ark-server-commander/config"
+)
+
+// setKeys 设置测试用的加密密钥（密钥内容由标识决定），第一个为当前密钥
+func setKeys(t *testing.T, ids ...string) {
+	t.Helper()
+
+	previous := config.EncryptionKeys
+	t.Cleanup(func() { config.EncryptionKeys = previous })
+
+	config.EncryptionKeys = nil
+	for _, id := range ids {
+		key := make([]byte, 32)
+		copy(key, id)
+		config.EncryptionKeys = append(config.EncryptionKeys, config.EncryptionKey{ID: id, Key: key})
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	setKeys(t, "k1")
+
+	encrypted, err := EncryptSecret("hunter2")
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, "enc:v1:k1:") || strings.Contains(encrypted, "hunter2") {
+		t.Fatalf("密文格式错误: %s", encrypted)
+	}
+
+	again, _ := EncryptSecret("hunter2")
+	if again == encrypted {
+		t.Fatal("相同明文每次加密的结果应不同")
+	}
+
+	plaintext, err := DecryptSecret(encrypted)
+	if err != nil || plaintext != "hunter2" {
+		t.Fatalf("解密结果错误: %q (%v)", plaintext, err)
+	}
+
+	// 空字符串和升级前的明文原样返回
+	if value, _ := EncryptSecret(""); value != "" {
+		t.Fatalf("空字符串不应加密，实际为 %q", value)
+	}
+	if value, err := DecryptSecret("legacy"); err != nil || value != "legacy" {
+		t.Fatalf("明文应原样返回，实际为 %q (%v)", value, err)
+	}
+}
+
+func TestDecryptSecretRejectsTampering(t *testing.T) {
+	setKeys(t, "k1", "k2")
+
+	encrypted, _ := EncryptSecret("hunter2")
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	if _, err := DecryptSecret(tampered); err == nil {
+		t.Fatal("被篡改的密文应解密失败")
+	}
+
+	// 把密文改到另一个密钥下也无法解密
+	moved := strings.Replace(encrypted, "enc:v1:k1:", "enc:v1:k2:", 1)
+	if _, err := DecryptSecret(moved); err == nil {
+		t.Fatal("密钥标识被修改的密文应解密失败")
+	}
+
+	if _, err := DecryptSecret("enc:v1:missing:AAAA"); err == nil {
+		t.Fatal("未知密钥应解密失败")
+	}
+}
+
+func TestSecretRotation(t *testing.T) {
+	setKeys(t, "old")
+	encrypted, _ := EncryptSecret("hunter2")
+	if SecretNeedsRotation(encrypted) {
+		t.Fatal("使用当前密钥加密的值不需要轮换")
+	}
+	if !SecretNeedsRotation("plaintext") {
+		t.Fatal("明文需要加密")
+	}
+
+	// 新密钥放在最前面，旧密钥仍可解密
+	setKeys(t, "new", "old")
+	if !SecretNeedsRotation(encrypted) {
+		t.Fatal("使用旧密钥加密的值需要轮换")
+	}
+	plaintext, err := DecryptSecret(encrypted)
+	if err != nil || plaintext != "hunter2" {
+		t.Fatalf("旧密钥应能解密: %q (%v)", plaintext, err)
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP 参数（RFC 6238 默认值，兼容主流验证器应用）
+const (
+	TOTPPeriod     = 30 // 时间步长（秒）
+	TOTPDigits     = 6  // 验证码位数
+	totpSecretSize = 20 // 密钥字节数（160位，与 SHA-1 输出长度一致）
+)
+
+// totpEncoding 无填充的 Base32 编码（验证器应用使用的密钥格式）
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret 生成随机的 TOTP 密钥（Base32 编码）
+func GenerateTOTPSecret() (string, error) {
+	buffer := make([]byte, totpSecretSize)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("生成TOTP密钥失败: %v", err)
+	}
+	return totpEncoding.EncodeToString(buffer), nil
+}
+
+// TOTPProvisioningURI 生成验证器应用使用的 otpauth:// 地址（可转为二维码）
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(TOTPPeriod))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPStep 返回指定时间对应的时间步
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// TOTPCode 计算指定时间的 TOTP 验证码
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(TOTPStep(t)), TOTPDigits), nil
+}
+
+// ValidateTOTP 校验 TOTP 验证码，允许前后 skew 个时间步的时钟偏差
+// 返回: 匹配的时间步（用于防止同一验证码重复使用）和是否有效
+func ValidateTOTP(secret, code string, t time.Time, skew int) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	step := TOTPStep(t)
+	for offset := -int64(skew); offset <= int64(skew); offset++ {
+		candidate := step + offset
+		if candidate < 0 {
+			continue
+		}
+		expected := hotp(key, uint64(candidate), TOTPDigits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+// decodeTOTPSecret 解码 Base32 密钥（忽略大小写、空格和填充）
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+	key, err := totpEncoding.DecodeString(normalized)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("无效的TOTP密钥")
+	}
+	return key, nil
+}
+
+// hotp 计算 RFC 4226 HOTP 值（HMAC-SHA1 动态截断）
+func hotp(key []byte, counter uint64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus)
+}
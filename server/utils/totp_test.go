@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B 的 SHA-1 测试向量（密钥为 ASCII "12345678901234567890"）
+func TestHOTPRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, vector := range vectors {
+		step := TOTPStep(time.Unix(vector.unix, 0))
+		if got := hotp(key, uint64(step), 8); got != vector.code {
+			t.Errorf("时间 %d: 期望 %s，实际 %s", vector.unix, vector.code, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	// 6位验证码取8位向量的后6位
+	code, err := TOTPCode(secret, now)
+	if err != nil || code != "050471" {
+		t.Fatalf("验证码错误: %s, %v", code, err)
+	}
+
+	step, ok := ValidateTOTP(secret, code, now, 1)
+	if !ok || step != TOTPStep(now) {
+		t.Fatalf("当前时间步的验证码应有效")
+	}
+	// 允许一个时间步的时钟偏差
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), 1); !ok {
+		t.Fatal("相邻时间步的验证码应有效")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(3*TOTPPeriod*time.Second), 1); ok {
+		t.Fatal("超出偏差范围的验证码应无效")
+	}
+	if _, ok := ValidateTOTP(strings.ToLower(secret), " "+code+" ", now, 0); !ok {
+		t.Fatal("密钥大小写和验证码空白不应影响校验")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 1); ok {
+		t.Fatal("位数错误的验证码应无效")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("ARK Server Commander", "alice", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/ARK%20Server%20Commander:alice?") {
+		t.Fatalf("标签格式错误: %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=ARK+Server+Commander", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("地址缺少 %s: %s", part, uri)
+		}
+	}
+}
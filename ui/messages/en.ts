@@ -240,6 +240,8 @@ export default {
       serverIdentifier: 'Server Identifier',
       rconPort: 'RCON Port',
       adminPassword: 'Admin Password',
+      adminPasswordNotSet: 'Not set',
+      adminPasswordUnavailable: 'Not permitted',
       editServer: 'Edit Server',
       deleteServer: 'Delete Server',
       confirmDelete: 'Confirm Delete',
@@ -240,6 +240,8 @@ export default {
       serverIdentifier: '服务器标识',
       rconPort: 'RCON端口',
       adminPassword: '管理员密码',
+      adminPasswordNotSet: '未设置',
+      adminPasswordUnavailable: '无权查看',
       editServer: '编辑服务器',
       deleteServer: '删除服务器',
       confirmDelete: '确认删除',
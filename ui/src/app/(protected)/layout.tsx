@@ -5,6 +5,8 @@ import { useTranslations } from 'next-intl';
 import { Link, usePathname, useRouter } from '@/navigation';
 import { useIsAuthenticated, useAuthActions, useAuthIsInitialized } from '@/stores/auth';
 import { LanguageSwitcher } from '@/components/LanguageSwitcher';
+// 安装访问令牌过期后自动刷新的拦截器
+import '@/lib/axios';
 
 export default function ProtectedLayout({
   children,
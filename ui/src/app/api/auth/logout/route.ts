@@ -0,0 +1,24 @@
+import { NextResponse } from 'next/server';
+import axios from 'axios';
+import { headers } from 'next/headers';
+
+export async function POST() {
+  const headersList = await headers();
+  const authorization = headersList.get('authorization');
+
+  if (!authorization) {
+    return NextResponse.json({ error: '未授权' }, { status: 401 });
+  }
+
+  try {
+    const response = await axios.post(`${process.env.NEXT_PUBLIC_API_BASE}/auth/logout`, {}, {
+      headers: { Authorization: authorization, 'Content-Type': 'application/json' },
+    });
+    return NextResponse.json(response.data);
+  } catch (error: unknown) {
+    const axiosError = error as { response?: { data?: { error?: string }, status?: number } };
+    return NextResponse.json({
+      error: axiosError.response?.data?.error || '退出登录失败'
+    }, { status: axiosError.response?.status || 500 });
+  }
+}
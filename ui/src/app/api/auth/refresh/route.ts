@@ -0,0 +1,15 @@
+import { NextResponse } from 'next/server';
+import axios from 'axios';
+
+export async function POST(request: Request) {
+  try {
+    const body = await request.json();
+    const response = await axios.post(`${process.env.NEXT_PUBLIC_API_BASE}/auth/refresh`, body);
+    return NextResponse.json(response.data);
+  } catch (error: unknown) {
+    const axiosError = error as { response?: { data?: { error?: string }, status?: number } };
+    return NextResponse.json({
+      error: axiosError.response?.data?.error || '刷新令牌失败'
+    }, { status: axiosError.response?.status || 500 });
+  }
+}
@@ -2,7 +2,7 @@
 
 import { useState } from 'react';
 import { useTranslations } from 'next-intl';
-import { Server } from '@/stores/servers';
+import { Server, serversActions } from '@/stores/servers';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
 import { Button } from '@/components/ui/button';
 import { Badge } from '@/components/ui/badge';
@@ -46,6 +46,9 @@ export function ServerCard({
 }: ServerCardProps) {
   const t = useTranslations('servers');
   const [showPassword, setShowPassword] = useState(false);
+  // 管理员密码不随服务器列表返回，首次查看或复制时单独获取
+  const [adminPassword, setAdminPassword] = useState<string | null>(null);
+  const [passwordError, setPasswordError] = useState(false);
 
   // 获取地图的显示名称（翻译名称或原始名称）
   const getMapDisplayName = (mapName: string) => {
@@ -74,6 +77,29 @@ export function ServerCard({
     // You might want to add a toast notification here
   };
 
+  const loadAdminPassword = async () => {
+    if (adminPassword !== null) return adminPassword;
+    try {
+      const password = await serversActions.getAdminPassword(server.id);
+      setAdminPassword(password);
+      setPasswordError(false);
+      return password;
+    } catch {
+      setPasswordError(true);
+      return null;
+    }
+  };
+
+  const togglePassword = async () => {
+    if (!showPassword && (await loadAdminPassword()) === null) return;
+    setShowPassword(!showPassword);
+  };
+
+  const copyAdminPassword = async () => {
+    const password = await loadAdminPassword();
+    if (password !== null) copyToClipboard(password);
+  };
+
   const StartStopButton = () => {
     switch (server.status) {
       case 'running':
@@ -217,25 +243,31 @@ export function ServerCard({
               <span className="text-xs font-medium text-gray-700">{t('card.adminPassword')}</span>
             </div>
             <div className="flex items-center gap-1">
-              <span className="font-mono text-xs">
-                {showPassword ? server.admin_password : '••••••••'}
-              </span>
-              <Button
-                variant="ghost"
-                size="sm"
-                className="h-6 w-6 p-0"
-                onClick={() => setShowPassword(!showPassword)}
-              >
-                {showPassword ? <EyeOff className="h-3 w-3" /> : <Eye className="h-3 w-3" />}
-              </Button>
-              <Button
-                variant="ghost"
-                size="sm"
-                className="h-6 w-6 p-0"
-                onClick={() => copyToClipboard(server.admin_password)}
-              >
-                <Copy className="h-3 w-3" />
-              </Button>
+              {!server.admin_password_set ? (
+                <span className="text-xs text-gray-500">{t('card.adminPasswordNotSet')}</span>
+              ) : (
+                <>
+                  <span className="font-mono text-xs">
+                    {passwordError ? t('card.adminPasswordUnavailable') : showPassword ? adminPassword : '••••••••'}
+                  </span>
+                  <Button
+                    variant="ghost"
+                    size="sm"
+                    className="h-6 w-6 p-0"
+                    onClick={togglePassword}
+                  >
+                    {showPassword ? <EyeOff className="h-3 w-3" /> : <Eye className="h-3 w-3" />}
+                  </Button>
+                  <Button
+                    variant="ghost"
+                    size="sm"
+                    className="h-6 w-6 p-0"
+                    onClick={copyAdminPassword}
+                  >
+                    <Copy className="h-3 w-3" />
+                  </Button>
+                </>
+              )}
             </div>
           </div>
         </div>
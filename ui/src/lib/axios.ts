@@ -1,7 +1,42 @@
-import axios from 'axios';
+import axios, { AxiosError, AxiosInstance, InternalAxiosRequestConfig } from 'axios';
+import useAuthStore from '@/stores/auth';
 
 const instance = axios.create({
   baseURL: process.env.NEXT_PUBLIC_API_BASE || '/',
 });
 
-export default instance;
\ No newline at end of file
+// 同一时间只发起一次刷新，并发的 401 请求等待同一个结果
+let refreshing: Promise<string | null> | null = null;
+
+const refreshToken = () => {
+  if (!refreshing) {
+    refreshing = useAuthStore.getState().actions.refresh().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// 访问令牌过期（401）时用刷新令牌换取新令牌，并用新令牌重试原请求一次
+const installAuthInterceptor = (client: AxiosInstance) => {
+  client.interceptors.response.use(undefined, async (error: AxiosError) => {
+    const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+    if (error.response?.status !== 401 || !config || config._retried || config.url?.startsWith('/api/auth/')) {
+      return Promise.reject(error);
+    }
+    config._retried = true;
+
+    const token = await refreshToken();
+    if (!token) {
+      return Promise.reject(error);
+    }
+    config.headers.Authorization = `Bearer ${token}`;
+    return client(config);
+  });
+};
+
+// 各个 store 直接使用默认的 axios 实例，两者都需要拦截
+installAuthInterceptor(axios);
+installAuthInterceptor(instance);
+
+export default instance;
@@ -27,6 +27,7 @@ interface AuthActions {
   login: (credentials: Credentials) => Promise<{ success: boolean; message: string }>;
   getProfile: () => Promise<void>;
   logout: () => void;
+  refresh: () => Promise<string | null>;
   initFromStorage: () => void;
 }
 
@@ -36,13 +37,34 @@ interface Credentials {
   password?: string;
 }
 
-// 定义API响应类型
-interface AuthResponse {
+// 定义令牌类型（访问令牌短期有效，过期后用刷新令牌换取新的令牌）
+interface TokenPair {
   token: string;
+  refresh_token: string;
+  expires_in: number;
+}
+
+// 定义API响应类型
+interface AuthResponse extends TokenPair {
   user: User;
   message: string;
 }
 
+const TOKEN_COOKIE = 'auth-token';
+const REFRESH_TOKEN_COOKIE = 'refresh-token';
+// 与后端刷新令牌默认有效期（30天）一致
+const SESSION_COOKIE_DAYS = 30;
+
+const saveTokens = ({ token, refresh_token }: TokenPair) => {
+  Cookies.set(TOKEN_COOKIE, token, { expires: SESSION_COOKIE_DAYS });
+  Cookies.set(REFRESH_TOKEN_COOKIE, refresh_token, { expires: SESSION_COOKIE_DAYS });
+};
+
+const clearTokens = () => {
+  Cookies.remove(TOKEN_COOKIE);
+  Cookies.remove(REFRESH_TOKEN_COOKIE);
+};
+
 const useAuthStore = create<AuthState>((set, get) => ({
   token: null,
   user: null,
@@ -65,7 +87,7 @@ const useAuthStore = create<AuthState>((set, get) => ({
         const response = await axios.post<AuthResponse>('/api/auth/init', credentials);
         const { token, user, message } = response.data;
         set({ token, user, isAuthenticated: true });
-        Cookies.set('auth-token', token, { expires: 7 });
+        saveTokens(response.data);
         return { success: true, message };
       } catch (error) {
         if (axios.isAxiosError(error) && error.response) {
@@ -82,7 +104,7 @@ const useAuthStore = create<AuthState>((set, get) => ({
         const response = await axios.post<AuthResponse>('/api/auth/login', credentials);
         const { token, user, message } = response.data;
         set({ token, user, isAuthenticated: true });
-        Cookies.set('auth-token', token, { expires: 7 });
+        saveTokens(response.data);
         return { success: true, message };
       } catch (error) {
         if (axios.isAxiosError(error) && error.response) {
@@ -107,11 +129,37 @@ const useAuthStore = create<AuthState>((set, get) => ({
       }
     },
     logout: () => {
+      // 撤销服务端会话，失败时（如令牌已过期）仍清除本地登录状态
+      const token = get().token;
+      if (token) {
+        axios.post('/api/auth/logout', {}, { headers: { Authorization: `Bearer ${token}` } }).catch(() => {});
+      }
       set({ token: null, user: null, isAuthenticated: false });
-      Cookies.remove('auth-token');
+      clearTokens();
+    },
+    refresh: async () => {
+      const refreshToken = Cookies.get(REFRESH_TOKEN_COOKIE);
+      if (!refreshToken) {
+        set({ token: null, user: null, isAuthenticated: false });
+        clearTokens();
+        return null;
+      }
+      try {
+        const response = await axios.post<TokenPair>('/api/auth/refresh', { refresh_token: refreshToken });
+        saveTokens(response.data);
+        set({ token: response.data.token });
+        return response.data.token;
+      } catch (error) {
+        // 刷新令牌无效或会话已撤销时需要重新登录，网络错误时保留登录状态
+        if (axios.isAxiosError(error) && error.response && error.response.status < 500) {
+          set({ token: null, user: null, isAuthenticated: false });
+          clearTokens();
+        }
+        return null;
+      }
     },
     initFromStorage: () => {
-      const token = Cookies.get('auth-token');
+      const token = Cookies.get(TOKEN_COOKIE);
       if (token) {
         const currentState = get();
         set({ token, isAuthenticated: true, isInitialized: true });
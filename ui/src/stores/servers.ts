@@ -10,7 +10,8 @@ export interface Server {
     port: number;
     query_port: number;
     rcon_port: number;
-    admin_password: string;
+    admin_password?: string; // 仅在创建和更新时提交，列表和详情不返回
+    admin_password_set?: boolean;
     map: string;
     max_players: number;
     game_user_settings?: string;
@@ -61,6 +62,7 @@ interface ServersActions {
     updateServer: (serverId: string, updateData: Partial<Server>) => Promise<Server>;
     deleteServer: (serverId: string) => Promise<void>;
     getServer: (serverId: string) => Promise<Server>;
+    getAdminPassword: (serverId: string) => Promise<string>;
     getImageStatus: () => Promise<void>;
     startServer: (serverId: string) => Promise<void>;
     stopServer: (serverId: string) => Promise<void>;
@@ -141,6 +143,10 @@ const useServersStore = create<ServersState>((set, get) => ({
                 throw error;
             }
         },
+        getAdminPassword: async (serverId) => {
+            const response = await axios.get(`/api/servers/${serverId}/admin-password`, { headers: getAuthHeaders() });
+            return response.data.data.admin_password;
+        },
         getImageStatus: async () => {
             if (get().isLoading) return; // 如果正在加载，则不执行任何操作
 